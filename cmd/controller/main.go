@@ -62,6 +62,11 @@ const (
 var (
 	masterURL  = flag.String("master", "", "The address of the Kubernetes API server. Overrides any value in kubeconfig. Only required if out-of-cluster.")
 	kubeconfig = flag.String("kubeconfig", "", "Path to a kubeconfig. Only required if out-of-cluster.")
+
+	propagatedMetadataPrefix = flag.String("propagated-metadata-prefix", "",
+		"Prefix of the Route labels and annotations copied onto the resources generated for it, e.g. telemetry.knative.dev/. Nothing is copied if empty.")
+	childNaming = flag.String("child-naming", "sameName",
+		"Strategy naming the placeholder Services of Routes: sameName uses the Route's name, hashSuffix truncates it and appends a hash, avoiding collisions.")
 )
 
 func main() {
@@ -130,6 +135,9 @@ func main() {
 		Logger:           logger,
 		ResyncPeriod:     10 * time.Hour, // Based on controller-runtime default.
 		StopChannel:      stopCh,
+
+		PropagatedMetadataPrefix: *propagatedMetadataPrefix,
+		ChildNaming:              *childNaming,
 	}
 
 	kubeInformerFactory := kubeinformers.NewSharedInformerFactory(kubeClient, opt.ResyncPeriod)
@@ -149,8 +157,22 @@ func main() {
 	endpointsInformer := kubeInformerFactory.Core().V1().Endpoints()
 	configMapInformer := kubeInformerFactory.Core().V1().ConfigMaps()
 	virtualServiceInformer := sharedInformerFactory.Networking().V1alpha3().VirtualServices()
+	destinationRuleInformer := sharedInformerFactory.Networking().V1alpha3().DestinationRules()
+	gatewayInformer := sharedInformerFactory.Networking().V1alpha3().Gateways()
 	imageInformer := cachingInformerFactory.Caching().V1alpha1().Images()
 
+	routeController, err := route.NewController(
+		opt,
+		routeInformer,
+		configurationInformer,
+		revisionInformer,
+		coreServiceInformer,
+		clusterIngressInformer,
+	)
+	if err != nil {
+		logger.Fatalw("Error building route controller", zap.Error(err))
+	}
+
 	// Build all of our controllers, with the clients constructed above.
 	// Add new controllers to this array.
 	controllers := []*controller.Impl{
@@ -170,14 +192,7 @@ func main() {
 			configMapInformer,
 			buildInformerFactory,
 		),
-		route.NewController(
-			opt,
-			routeInformer,
-			configurationInformer,
-			revisionInformer,
-			coreServiceInformer,
-			clusterIngressInformer,
-		),
+		routeController,
 		labeler.NewRouteToConfigurationController(
 			opt,
 			routeInformer,
@@ -194,6 +209,8 @@ func main() {
 			opt,
 			clusterIngressInformer,
 			virtualServiceInformer,
+			destinationRuleInformer,
+			gatewayInformer,
 		),
 	}
 
@@ -226,6 +243,8 @@ func main() {
 		endpointsInformer.Informer().HasSynced,
 		configMapInformer.Informer().HasSynced,
 		virtualServiceInformer.Informer().HasSynced,
+		destinationRuleInformer.Informer().HasSynced,
+		gatewayInformer.Informer().HasSynced,
 	} {
 		if ok := cache.WaitForCacheSync(stopCh, synced); !ok {
 			logger.Fatalf("Failed to wait for cache at index %d to sync", i)
@@ -71,6 +71,9 @@ func (p *HTTPClusterIngressPath) SetDefaults() {
 	if len(p.Splits) == 1 && p.Splits[0].Percent == 0 {
 		p.Splits[0].Percent = 100
 	}
+	if len(p.MeshSplits) == 1 && p.MeshSplits[0].Percent == 0 {
+		p.MeshSplits[0].Percent = 100
+	}
 
 	if p.Timeout == nil {
 		p.Timeout = &metav1.Duration{Duration: DefaultTimeout}
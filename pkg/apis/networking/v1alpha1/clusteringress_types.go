@@ -101,8 +101,23 @@ type IngressSpec struct {
 
 	// Visibility setting.
 	Visibility IngressVisibility `json:"visibility,omitempty"`
+
+	// HTTPOption selects how plain HTTP requests are answered for the
+	// hosts of the TLS settings.  It defaults to HTTPOptionEnabled.
+	// +optional
+	HTTPOption HTTPOption `json:"httpOption,omitempty"`
 }
 
+// HTTPOption describes how a ClusterIngress answers plain HTTP requests.
+type HTTPOption string
+
+const (
+	// HTTPOptionEnabled serves plain HTTP alongside HTTPS.
+	HTTPOptionEnabled HTTPOption = "Enabled"
+	// HTTPOptionRedirected redirects plain HTTP requests to HTTPS.
+	HTTPOptionRedirected HTTPOption = "Redirected"
+)
+
 // IngressVisibility describes whether the Ingress should be exposed to
 // public gateways or not.
 type IngressVisibility string
@@ -195,10 +210,34 @@ type HTTPClusterIngressPath struct {
 	// +optional
 	Path string `json:"path,omitempty"`
 
+	// PathPrefix, if set, matches the requests whose path starts with it,
+	// instead of matching the path against the Path regex.  It must begin
+	// with a '/'.
+	//
+	// NOTE: This differs from K8s Ingress which only matches path regexes.
+	// +optional
+	PathPrefix string `json:"pathPrefix,omitempty"`
+
+	// Headers defines conditions on request headers, keyed by header name,
+	// that all need to hold for a request to match this path.
+	//
+	// NOTE: This differs from K8s Ingress which doesn't allow header matching.
+	// +optional
+	Headers map[string]HeaderMatch `json:"headers,omitempty"`
+
 	// Splits defines the referenced service endpoints to which the traffic
 	// will be forwarded to.
 	Splits []ClusterIngressBackendSplit `json:"splits"`
 
+	// MeshSplits, when set, defines the service endpoints to which the
+	// traffic coming from within the mesh is forwarded to, while Splits
+	// only apply to the traffic coming through the ingress gateways.
+	//
+	// NOTE: This differs from K8s Ingress which doesn't tell in-mesh
+	// traffic apart.
+	// +optional
+	MeshSplits []ClusterIngressBackendSplit `json:"meshSplits,omitempty"`
+
 	// AppendHeaders allow specifying additional HTTP headers to add
 	// before forwarding a request to the destination service.
 	//
@@ -217,6 +256,40 @@ type HTTPClusterIngressPath struct {
 	// NOTE: This differs from K8s Ingress which doesn't allow retry settings.
 	// +optional
 	Retries *HTTPRetry `json:"retries,omitempty"`
+
+	// Mirror optionally specifies a backend to send a copy of the
+	// requests to, in addition to the Splits.  Responses from the
+	// mirror are discarded.
+	//
+	// NOTE: This differs from K8s Ingress which doesn't allow mirroring.
+	// +optional
+	Mirror *ClusterIngressBackend `json:"mirror,omitempty"`
+
+	// Fault optionally injects delays or aborts into a share of the
+	// requests matching this path.
+	//
+	// NOTE: This differs from K8s Ingress which doesn't allow fault injection.
+	// +optional
+	Fault *HTTPFault `json:"fault,omitempty"`
+
+	// Rewrite optionally rewrites the host or path of the requests
+	// matching this path before they are forwarded.
+	//
+	// NOTE: This differs from K8s Ingress which doesn't allow rewrites.
+	// +optional
+	Rewrite *HTTPRewrite `json:"rewrite,omitempty"`
+}
+
+// HeaderMatch describes how to match the value of a request header.
+// Exactly one of Exact and Prefix must be set.
+type HeaderMatch struct {
+	// Exact matches a header value equal to the given string.
+	// +optional
+	Exact string `json:"exact,omitempty"`
+
+	// Prefix matches a header value starting with the given string.
+	// +optional
+	Prefix string `json:"prefix,omitempty"`
 }
 
 // ClusterIngressBackend describes all endpoints for a given service and port.
@@ -243,6 +316,11 @@ type ClusterIngressBackend struct {
 
 	// Specifies the port of the referenced service.
 	ServicePort intstr.IntOrString `json:"servicePort"`
+
+	// Subset optionally names a subset of the referenced service, defined
+	// by an Istio DestinationRule, to send the traffic to.
+	// +optional
+	Subset string `json:"subset,omitempty"`
 }
 
 // HTTPRetry describes the retry policy to use when a HTTP request fails.
@@ -254,6 +332,36 @@ type HTTPRetry struct {
 	PerTryTimeout *metav1.Duration `json:"perTryTimeout"`
 }
 
+// HTTPFault describes the faults injected into a share of the HTTP requests.
+type HTTPFault struct {
+	// DelayPercent is the percentage of requests delayed by FixedDelay.
+	// +optional
+	DelayPercent int `json:"delayPercent,omitempty"`
+
+	// FixedDelay before forwarding delayed requests. MUST BE >=1ms.
+	// +optional
+	FixedDelay *metav1.Duration `json:"fixedDelay,omitempty"`
+
+	// AbortPercent is the percentage of requests aborted with HTTPStatus.
+	// +optional
+	AbortPercent int `json:"abortPercent,omitempty"`
+
+	// HTTPStatus to answer aborted requests with.
+	// +optional
+	HTTPStatus int `json:"httpStatus,omitempty"`
+}
+
+// HTTPRewrite describes how to rewrite an HTTP request before forwarding it.
+type HTTPRewrite struct {
+	// Authority to replace the Host header of the request with.
+	// +optional
+	Authority string `json:"authority,omitempty"`
+
+	// URI to replace the path, or the matched path prefix, with.
+	// +optional
+	URI string `json:"uri,omitempty"`
+}
+
 // IngressStatus describe the current state of the ClusterIngress.
 type IngressStatus struct {
 	// +optional
@@ -312,6 +420,18 @@ const (
 	// ClusterIngressConditionLoadBalancerReady is set when the ClusterIngress has
 	// a ready LoadBalancer.
 	ClusterIngressConditionLoadBalancerReady duckv1alpha1.ConditionType = "LoadBalancerReady"
+
+	// ClusterIngressConditionSubsetsKnown is set to False when a backend
+	// refers to a DestinationRule subset that isn't defined.  It is only
+	// informational, and doesn't affect the readiness of the ClusterIngress.
+	ClusterIngressConditionSubsetsKnown duckv1alpha1.ConditionType = "SubsetsKnown"
+
+	// ClusterIngressConditionTrafficPolicyApplied is set to False when the
+	// traffic policy requested for a backend, e.g. session affinity, can't
+	// be applied because another DestinationRule already configures its
+	// host.  It is only informational, and doesn't affect the readiness of
+	// the ClusterIngress.
+	ClusterIngressConditionTrafficPolicyApplied duckv1alpha1.ConditionType = "TrafficPolicyApplied"
 )
 
 var clusterIngressCondSet = duckv1alpha1.NewLivingConditionSet(
@@ -361,6 +481,40 @@ func (cis *IngressStatus) MarkResourceNotOwned(kind, name string) {
 		fmt.Sprintf("There is an existing %s %q that we do not own.", kind, name))
 }
 
+// MarkUnknownGateway changes the "NetworkConfigured" condition to false to reflect that the
+// ClusterIngress asked for a Gateway that isn't configured for its visibility.
+func (cis *IngressStatus) MarkUnknownGateway(name string) {
+	clusterIngressCondSet.Manage(cis).MarkFalse(ClusterIngressConditionNetworkConfigured, "UnknownGateway",
+		"Gateway %q is not configured for this ClusterIngress.", name)
+}
+
+// MarkSubsetsKnown marks the DestinationRule subsets that the backends of
+// the ClusterIngress refer to as defined.
+func (cis *IngressStatus) MarkSubsetsKnown() {
+	clusterIngressCondSet.Manage(cis).MarkTrue(ClusterIngressConditionSubsetsKnown)
+}
+
+// MarkUnknownSubset changes the "SubsetsKnown" condition to false to reflect
+// that no DestinationRule for the given host defines the given subset.
+func (cis *IngressStatus) MarkUnknownSubset(host, subset string) {
+	clusterIngressCondSet.Manage(cis).MarkFalse(ClusterIngressConditionSubsetsKnown, "UnknownSubset",
+		"No DestinationRule for %q defines the subset %q.", host, subset)
+}
+
+// MarkTrafficPolicyApplied marks the traffic policy requested for the
+// backends of the ClusterIngress as applied.
+func (cis *IngressStatus) MarkTrafficPolicyApplied() {
+	clusterIngressCondSet.Manage(cis).MarkTrue(ClusterIngressConditionTrafficPolicyApplied)
+}
+
+// MarkDestinationRuleConflict changes the "TrafficPolicyApplied" condition to
+// false to reflect that the DestinationRule of the given namespace and name
+// already configures the given host.
+func (cis *IngressStatus) MarkDestinationRuleConflict(namespace, name, host string) {
+	clusterIngressCondSet.Manage(cis).MarkFalse(ClusterIngressConditionTrafficPolicyApplied, "DestinationRuleConflict",
+		"DestinationRule %q/%q already configures the traffic of %q.", namespace, name, host)
+}
+
 // MarkLoadBalancerReady marks the Ingress with ClusterIngressConditionLoadBalancerReady,
 // and also populate the address of the load balancer.
 func (cis *IngressStatus) MarkLoadBalancerReady(lbs []LoadBalancerIngressStatus) {
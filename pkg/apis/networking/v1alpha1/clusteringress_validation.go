@@ -18,6 +18,8 @@ package v1alpha1
 
 import (
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/knative/pkg/apis"
 	"k8s.io/apimachinery/pkg/api/equality"
@@ -48,6 +50,11 @@ func (spec *IngressSpec) Validate() *apis.FieldError {
 	for idx, tls := range spec.TLS {
 		all = all.Also(tls.Validate().ViaFieldIndex("tls", idx))
 	}
+	switch spec.HTTPOption {
+	case "", HTTPOptionEnabled, HTTPOptionRedirected:
+	default:
+		all = all.Also(apis.ErrInvalidValue(string(spec.HTTPOption), "httpOption"))
+	}
 	return all
 }
 
@@ -89,32 +96,72 @@ func (h HTTPClusterIngressPath) Validate() *apis.FieldError {
 	if len(h.Splits) == 0 {
 		all = all.Also(apis.ErrMissingField("splits"))
 	} else {
-		totalPct := 0
-		for idx, split := range h.Splits {
-			if err := split.Validate(); err != nil {
-				return err.ViaFieldIndex("splits", idx)
-			}
-			totalPct += split.Percent
-		}
-		// If a single split is provided we allow missing Percent, and
-		// interpret as 100%.
-		if len(h.Splits) == 1 && totalPct == 0 {
-			totalPct = 100
+		all = all.Also(validateSplits(h.Splits).ViaField("splits"))
+	}
+	if len(h.MeshSplits) != 0 {
+		all = all.Also(validateSplits(h.MeshSplits).ViaField("meshSplits"))
+	}
+	if h.PathPrefix != "" {
+		if h.Path != "" {
+			all = all.Also(apis.ErrMultipleOneOf("path", "pathPrefix"))
 		}
-		// Total traffic split percentage must sum up to 100%.
-		if totalPct != 100 {
-			all = all.Also(&apis.FieldError{
-				Message: "Traffic split percentage must total to 100, but was " + strconv.Itoa(totalPct),
-				Paths:   []string{"splits"},
-			})
+		if !strings.HasPrefix(h.PathPrefix, "/") {
+			all = all.Also(apis.ErrInvalidValue(h.PathPrefix, "pathPrefix"))
 		}
 	}
+	for name, match := range h.Headers {
+		all = all.Also(match.Validate().ViaFieldKey("headers", name))
+	}
 	if h.Retries != nil {
 		all = all.Also(h.Retries.Validate().ViaField("retries"))
 	}
+	if h.Mirror != nil {
+		all = all.Also(h.Mirror.Validate().ViaField("mirror"))
+	}
+	if h.Fault != nil {
+		all = all.Also(h.Fault.Validate().ViaField("fault"))
+	}
+	if h.Rewrite != nil {
+		all = all.Also(h.Rewrite.Validate().ViaField("rewrite"))
+	}
 	return all
 }
 
+// validateSplits inspects and validates a non-empty list of splits.
+func validateSplits(splits []ClusterIngressBackendSplit) *apis.FieldError {
+	totalPct := 0
+	for idx, split := range splits {
+		if err := split.Validate(); err != nil {
+			return err.ViaIndex(idx)
+		}
+		totalPct += split.Percent
+	}
+	// If a single split is provided we allow missing Percent, and
+	// interpret as 100%.
+	if len(splits) == 1 && totalPct == 0 {
+		totalPct = 100
+	}
+	// Total traffic split percentage must sum up to 100%.
+	if totalPct != 100 {
+		return &apis.FieldError{
+			Message: "Traffic split percentage must total to 100, but was " + strconv.Itoa(totalPct),
+			Paths:   []string{apis.CurrentField},
+		}
+	}
+	return nil
+}
+
+// Validate inspects and validates HeaderMatch object.
+func (m HeaderMatch) Validate() *apis.FieldError {
+	switch {
+	case m.Exact != "" && m.Prefix != "":
+		return apis.ErrMultipleOneOf("exact", "prefix")
+	case m.Exact == "" && m.Prefix == "":
+		return apis.ErrMissingOneOf("exact", "prefix")
+	}
+	return nil
+}
+
 // Validate inspects and validates HTTPClusterIngressPath object.
 func (s ClusterIngressBackendSplit) Validate() *apis.FieldError {
 	// Must not be empty.
@@ -158,6 +205,38 @@ func (r *HTTPRetry) Validate() *apis.FieldError {
 	return nil
 }
 
+// Validate inspects and validates HTTPFault object.
+func (f *HTTPFault) Validate() *apis.FieldError {
+	var all *apis.FieldError
+	if f.FixedDelay == nil && f.HTTPStatus == 0 {
+		all = apis.ErrMissingOneOf("fixedDelay", "httpStatus")
+	}
+	if f.DelayPercent < 0 || f.DelayPercent > 100 {
+		all = all.Also(apis.ErrInvalidValue(strconv.Itoa(f.DelayPercent), "delayPercent"))
+	}
+	if f.FixedDelay != nil && f.FixedDelay.Duration < time.Millisecond {
+		all = all.Also(apis.ErrInvalidValue(f.FixedDelay.Duration.String(), "fixedDelay"))
+	}
+	if f.AbortPercent < 0 || f.AbortPercent > 100 {
+		all = all.Also(apis.ErrInvalidValue(strconv.Itoa(f.AbortPercent), "abortPercent"))
+	}
+	if f.HTTPStatus != 0 && (f.HTTPStatus < 100 || f.HTTPStatus > 599) {
+		all = all.Also(apis.ErrInvalidValue(strconv.Itoa(f.HTTPStatus), "httpStatus"))
+	}
+	return all
+}
+
+// Validate inspects and validates HTTPRewrite object.
+func (r *HTTPRewrite) Validate() *apis.FieldError {
+	if r.Authority == "" && r.URI == "" {
+		return apis.ErrMissingOneOf("authority", "uri")
+	}
+	if r.URI != "" && !strings.HasPrefix(r.URI, "/") {
+		return apis.ErrInvalidValue(r.URI, "uri")
+	}
+	return nil
+}
+
 // Validate inspects and validates ClusterIngressTLS object.
 func (t *ClusterIngressTLS) Validate() *apis.FieldError {
 	// Provided TLS setting must not be empty.
@@ -55,6 +55,120 @@ func TestIngressSpecValidation(t *testing.T) {
 			}},
 		},
 		want: nil,
+	}, {
+		name: "valid-header-match",
+		cis: &IngressSpec{
+			Rules: []ClusterIngressRule{{
+				Hosts: []string{"example.com"},
+				HTTP: &HTTPClusterIngressRuleValue{
+					Paths: []HTTPClusterIngressPath{{
+						Headers: map[string]HeaderMatch{
+							"x-canary": {Exact: "true"},
+						},
+						Splits: []ClusterIngressBackendSplit{{
+							ClusterIngressBackend: ClusterIngressBackend{
+								ServiceName:      "revision-000",
+								ServiceNamespace: "default",
+								ServicePort:      intstr.FromInt(8080),
+							},
+						}},
+					}},
+				},
+			}},
+		},
+		want: nil,
+	}, {
+		name: "invalid-header-match",
+		cis: &IngressSpec{
+			Rules: []ClusterIngressRule{{
+				Hosts: []string{"example.com"},
+				HTTP: &HTTPClusterIngressRuleValue{
+					Paths: []HTTPClusterIngressPath{{
+						Headers: map[string]HeaderMatch{
+							"x-canary": {},
+						},
+						Splits: []ClusterIngressBackendSplit{{
+							ClusterIngressBackend: ClusterIngressBackend{
+								ServiceName:      "revision-000",
+								ServiceNamespace: "default",
+								ServicePort:      intstr.FromInt(8080),
+							},
+						}},
+					}},
+				},
+			}},
+		},
+		want: apis.ErrMissingOneOf(
+			"rules[0].http.paths[0].headers[x-canary].exact",
+			"rules[0].http.paths[0].headers[x-canary].prefix"),
+	}, {
+		name: "invalid-path-prefix",
+		cis: &IngressSpec{
+			Rules: []ClusterIngressRule{{
+				Hosts: []string{"example.com"},
+				HTTP: &HTTPClusterIngressRuleValue{
+					Paths: []HTTPClusterIngressPath{{
+						Path:       "/v2/.*",
+						PathPrefix: "v2",
+						Splits: []ClusterIngressBackendSplit{{
+							ClusterIngressBackend: ClusterIngressBackend{
+								ServiceName:      "revision-000",
+								ServiceNamespace: "default",
+								ServicePort:      intstr.FromInt(8080),
+							},
+						}},
+					}},
+				},
+			}},
+		},
+		want: apis.ErrMultipleOneOf("rules[0].http.paths[0].path", "rules[0].http.paths[0].pathPrefix").Also(
+			apis.ErrInvalidValue("v2", "rules[0].http.paths[0].pathPrefix")),
+	}, {
+		name: "invalid-fault",
+		cis: &IngressSpec{
+			Rules: []ClusterIngressRule{{
+				Hosts: []string{"example.com"},
+				HTTP: &HTTPClusterIngressRuleValue{
+					Paths: []HTTPClusterIngressPath{{
+						Splits: []ClusterIngressBackendSplit{{
+							ClusterIngressBackend: ClusterIngressBackend{
+								ServiceName:      "revision-000",
+								ServiceNamespace: "default",
+								ServicePort:      intstr.FromInt(8080),
+							},
+						}},
+						Fault: &HTTPFault{
+							AbortPercent: 120,
+							HTTPStatus:   99,
+						},
+					}},
+				},
+			}},
+		},
+		want: apis.ErrInvalidValue("120", "rules[0].http.paths[0].fault.abortPercent").Also(
+			apis.ErrInvalidValue("99", "rules[0].http.paths[0].fault.httpStatus")),
+	}, {
+		name: "invalid-rewrite",
+		cis: &IngressSpec{
+			Rules: []ClusterIngressRule{{
+				Hosts: []string{"example.com"},
+				HTTP: &HTTPClusterIngressRuleValue{
+					Paths: []HTTPClusterIngressPath{{
+						Splits: []ClusterIngressBackendSplit{{
+							ClusterIngressBackend: ClusterIngressBackend{
+								ServiceName:      "revision-000",
+								ServiceNamespace: "default",
+								ServicePort:      intstr.FromInt(8080),
+							},
+						}},
+						PathPrefix: "/legacy",
+						Rewrite:    &HTTPRewrite{},
+					}},
+				},
+			}},
+		},
+		want: apis.ErrMissingOneOf("rules[0].http.paths[0].rewrite.authority",
+			"rules[0].http.paths[0].rewrite.uri"),
 	}, {
 		name: "empty",
 		cis:  &IngressSpec{},
@@ -247,6 +361,71 @@ func TestIngressSpecValidation(t *testing.T) {
 			Message: "Traffic split percentage must total to 100, but was 30",
 			Paths:   []string{"rules[0].http.paths[0].splits"},
 		},
+	}, {
+		name: "invalid-mesh-split",
+		cis: &IngressSpec{
+			Rules: []ClusterIngressRule{{
+				Hosts: []string{"example.com"},
+				HTTP: &HTTPClusterIngressRuleValue{
+					Paths: []HTTPClusterIngressPath{{
+						Splits: []ClusterIngressBackendSplit{{
+							ClusterIngressBackend: ClusterIngressBackend{
+								ServiceName:      "activator-service",
+								ServiceNamespace: "knative-serving",
+								ServicePort:      intstr.FromInt(80),
+							},
+						}},
+						MeshSplits: []ClusterIngressBackendSplit{{
+							ClusterIngressBackend: ClusterIngressBackend{
+								ServiceName:      "revision-000",
+								ServiceNamespace: "default",
+								ServicePort:      intstr.FromInt(8080),
+							},
+							Percent: 30,
+						}, {
+							ClusterIngressBackend: ClusterIngressBackend{
+								ServiceName: "revision-001",
+							},
+							Percent: 30,
+						}},
+					}},
+				},
+			}},
+		},
+		want: apis.ErrMissingField(
+			"rules[0].http.paths[0].meshSplits[1].serviceNamespace",
+			"rules[0].http.paths[0].meshSplits[1].servicePort",
+		),
+	}, {
+		name: "mesh-split-percent-sum-not-100",
+		cis: &IngressSpec{
+			Rules: []ClusterIngressRule{{
+				Hosts: []string{"example.com"},
+				HTTP: &HTTPClusterIngressRuleValue{
+					Paths: []HTTPClusterIngressPath{{
+						Splits: []ClusterIngressBackendSplit{{
+							ClusterIngressBackend: ClusterIngressBackend{
+								ServiceName:      "activator-service",
+								ServiceNamespace: "knative-serving",
+								ServicePort:      intstr.FromInt(80),
+							},
+						}},
+						MeshSplits: []ClusterIngressBackendSplit{{
+							ClusterIngressBackend: ClusterIngressBackend{
+								ServiceName:      "revision-000",
+								ServiceNamespace: "default",
+								ServicePort:      intstr.FromInt(8080),
+							},
+							Percent: 30,
+						}},
+					}},
+				},
+			}},
+		},
+		want: &apis.FieldError{
+			Message: "Traffic split percentage must total to 100, but was 30",
+			Paths:   []string{"rules[0].http.paths[0].meshSplits"},
+		},
 	}, {
 		name: "wrong-retry-attempts",
 		cis: &IngressSpec{
@@ -333,6 +512,26 @@ func TestIngressSpecValidation(t *testing.T) {
 			}},
 		},
 		want: apis.ErrMissingField("tls[0].secretName"),
+	}, {
+		name: "invalid-http-option",
+		cis: &IngressSpec{
+			HTTPOption: "Disabled",
+			Rules: []ClusterIngressRule{{
+				Hosts: []string{"example.com"},
+				HTTP: &HTTPClusterIngressRuleValue{
+					Paths: []HTTPClusterIngressPath{{
+						Splits: []ClusterIngressBackendSplit{{
+							ClusterIngressBackend: ClusterIngressBackend{
+								ServiceName:      "revision-000",
+								ServiceNamespace: "default",
+								ServicePort:      intstr.FromInt(8080),
+							},
+						}},
+					}},
+				},
+			}},
+		},
+		want: apis.ErrInvalidValue("Disabled", "httpOption"),
 	}}
 
 	for _, test := range tests {
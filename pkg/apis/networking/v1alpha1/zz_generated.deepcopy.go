@@ -177,11 +177,23 @@ func (in *ClusterIngressTLS) DeepCopy() *ClusterIngressTLS {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *HTTPClusterIngressPath) DeepCopyInto(out *HTTPClusterIngressPath) {
 	*out = *in
+	if in.Headers != nil {
+		in, out := &in.Headers, &out.Headers
+		*out = make(map[string]HeaderMatch, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
 	if in.Splits != nil {
 		in, out := &in.Splits, &out.Splits
 		*out = make([]ClusterIngressBackendSplit, len(*in))
 		copy(*out, *in)
 	}
+	if in.MeshSplits != nil {
+		in, out := &in.MeshSplits, &out.MeshSplits
+		*out = make([]ClusterIngressBackendSplit, len(*in))
+		copy(*out, *in)
+	}
 	if in.AppendHeaders != nil {
 		in, out := &in.AppendHeaders, &out.AppendHeaders
 		*out = make(map[string]string, len(*in))
@@ -207,6 +219,33 @@ func (in *HTTPClusterIngressPath) DeepCopyInto(out *HTTPClusterIngressPath) {
 			(*in).DeepCopyInto(*out)
 		}
 	}
+	if in.Mirror != nil {
+		in, out := &in.Mirror, &out.Mirror
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(ClusterIngressBackend)
+			**out = **in
+		}
+	}
+	if in.Fault != nil {
+		in, out := &in.Fault, &out.Fault
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(HTTPFault)
+			(*in).DeepCopyInto(*out)
+		}
+	}
+	if in.Rewrite != nil {
+		in, out := &in.Rewrite, &out.Rewrite
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(HTTPRewrite)
+			**out = **in
+		}
+	}
 	return
 }
 
@@ -243,6 +282,31 @@ func (in *HTTPClusterIngressRuleValue) DeepCopy() *HTTPClusterIngressRuleValue {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HTTPFault) DeepCopyInto(out *HTTPFault) {
+	*out = *in
+	if in.FixedDelay != nil {
+		in, out := &in.FixedDelay, &out.FixedDelay
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(v1.Duration)
+			**out = **in
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HTTPFault.
+func (in *HTTPFault) DeepCopy() *HTTPFault {
+	if in == nil {
+		return nil
+	}
+	out := new(HTTPFault)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *HTTPRetry) DeepCopyInto(out *HTTPRetry) {
 	*out = *in
@@ -268,6 +332,38 @@ func (in *HTTPRetry) DeepCopy() *HTTPRetry {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HTTPRewrite) DeepCopyInto(out *HTTPRewrite) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HTTPRewrite.
+func (in *HTTPRewrite) DeepCopy() *HTTPRewrite {
+	if in == nil {
+		return nil
+	}
+	out := new(HTTPRewrite)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HeaderMatch) DeepCopyInto(out *HeaderMatch) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HeaderMatch.
+func (in *HeaderMatch) DeepCopy() *HeaderMatch {
+	if in == nil {
+		return nil
+	}
+	out := new(HeaderMatch)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *IngressSpec) DeepCopyInto(out *IngressSpec) {
 	*out = *in
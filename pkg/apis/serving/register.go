@@ -64,4 +64,66 @@ const (
 	// BuildHashLabelKey is the label key attached to a Build indicating the
 	// hash of the spec from which they were created.
 	BuildHashLabelKey = GroupName + "/buildHash"
+
+	// DisableRevisionHeadersAnnotationKey is the annotation key attached to a
+	// Route to opt out of appending headers identifying the Revision that
+	// serves a request.  Setting it to "true" disables the headers.
+	DisableRevisionHeadersAnnotationKey = GroupName + "/disableRevisionHeaders"
+
+	// ServiceTypeAnnotationKey is the annotation key attached to a Route to
+	// choose the flavor of the placeholder K8s Service created for it.
+	ServiceTypeAnnotationKey = GroupName + "/serviceType"
+
+	// ServiceTypeHeadless is the ServiceTypeAnnotationKey value requesting a
+	// headless (ClusterIP: None) placeholder K8s Service.
+	ServiceTypeHeadless = "headless"
+
+	// CreateServiceAnnotationKey is the annotation key attached to a Route
+	// to opt out of its placeholder K8s Service, e.g. when it's fronted by
+	// Istio alone.  Setting it to "false" skips, and removes, the Service.
+	CreateServiceAnnotationKey = GroupName + "/createService"
+
+	// ReadinessPathAnnotationKey is the annotation key attached to the
+	// placeholder K8s Service of a Route to advertise the path of the HTTP
+	// readiness probe of the Revisions serving its traffic, for tools
+	// probing the Service directly.
+	ReadinessPathAnnotationKey = GroupName + "/readinessPath"
+
+	// SessionAffinityAnnotationKey is the annotation key attached to a Route
+	// to pin clients to a single pod of each Revision.  The only supported
+	// value is "cookie=<name>", which hashes on the named HTTP cookie.
+	SessionAffinityAnnotationKey = GroupName + "/sessionAffinity"
+
+	// OutlierDetectionAnnotationKey is the annotation key attached to a Route
+	// to eject failing pods of its Revisions from load balancing.  Its value
+	// is a comma separated list of "consecutiveErrors=<n>", "interval=<d>"
+	// and "baseEjectionTime=<d>", e.g. "consecutiveErrors=5,interval=10s".
+	OutlierDetectionAnnotationKey = GroupName + "/outlierDetection"
+
+	// GatewayAnnotationKey is the annotation key attached to a Route to
+	// expose it through a single one of the Istio Gateways configured in
+	// config-istio for its visibility, instead of all of them.
+	GatewayAnnotationKey = GroupName + "/gateway"
+
+	// MeshRoutingAnnotationKey is the annotation key attached to a Route to
+	// route the requests from within the mesh apart from the ones coming
+	// through the ingress gateways.  The only supported value is "direct",
+	// which gives in-mesh requests a VirtualService of their own, bound to
+	// the mesh only.  They still reach scaled to zero Revisions through the
+	// activator.
+	MeshRoutingAnnotationKey = GroupName + "/meshRouting"
+
+	// MeshRoutingDirect is the MeshRoutingAnnotationKey value that routes
+	// in-mesh requests with a VirtualService of their own.
+	MeshRoutingDirect = "direct"
+
+	// TrafficHashAnnotationKey is the annotation key attached to a
+	// ClusterIngress indicating the hash of the Route traffic, domain and
+	// metadata from which it was created.
+	TrafficHashAnnotationKey = GroupName + "/trafficHash"
+
+	// SpecHashAnnotationKey is the annotation key attached to a
+	// ClusterIngress indicating the hash of the spec the Route controller
+	// last wrote, so that later edits of the spec are noticed.
+	SpecHashAnnotationKey = GroupName + "/specHash"
 )
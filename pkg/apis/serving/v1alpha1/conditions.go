@@ -0,0 +1,41 @@
+/*
+Copyright 2019 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	duckv1alpha1 "github.com/knative/pkg/apis/duck/v1alpha1"
+)
+
+// stampTransitions returns the updated conditions with LastTransitionTime
+// only moving for those whose Status differs from the old conditions.  A
+// condition re-marked with the same Status, e.g. with a new reason or
+// message, keeps the time of its last transition; the others keep the time
+// the condition manager stamped them with.
+func stampTransitions(old, updated duckv1alpha1.Conditions) duckv1alpha1.Conditions {
+	prior := make(map[duckv1alpha1.ConditionType]duckv1alpha1.Condition, len(old))
+	for _, c := range old {
+		prior[c.Type] = c
+	}
+	stamped := make(duckv1alpha1.Conditions, 0, len(updated))
+	for _, c := range updated {
+		if p, ok := prior[c.Type]; ok && p.Status == c.Status && !p.LastTransitionTime.Inner.IsZero() {
+			c.LastTransitionTime = p.LastTransitionTime
+		}
+		stamped = append(stamped, c)
+	}
+	return stamped
+}
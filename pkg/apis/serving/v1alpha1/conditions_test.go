@@ -0,0 +1,104 @@
+/*
+Copyright 2019 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"testing"
+	"time"
+
+	"github.com/knative/pkg/apis"
+	duckv1alpha1 "github.com/knative/pkg/apis/duck/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// longAgo is when backdate pretends the conditions last transitioned.
+var longAgo = time.Unix(1000, 0)
+
+// backdate moves the LastTransitionTime of all the conditions to longAgo.
+func backdate(conditions duckv1alpha1.Conditions) {
+	for i := range conditions {
+		conditions[i].LastTransitionTime = apis.VolatileTime{Inner: metav1.NewTime(longAgo)}
+	}
+}
+
+func TestRouteConditionTransitionTimes(t *testing.T) {
+	r := &RouteStatus{}
+	r.InitializeConditions()
+	backdate(r.Conditions)
+
+	// Unknown -> False is a transition.
+	r.MarkIngressNotReady("first")
+	checkTransitioned(t, r, RouteConditionIngressReady)
+	checkTransitioned(t, r, RouteConditionReady)
+	backdate(r.Conditions)
+
+	// False -> False with another message isn't.
+	r.MarkIngressNotReady("second")
+	checkTransitionTime(t, r, RouteConditionIngressReady, longAgo)
+	checkTransitionTime(t, r, RouteConditionReady, longAgo)
+	if got, want := r.GetCondition(RouteConditionIngressReady).Message, "Failed to reconcile the ClusterIngress: second"; got != want {
+		t.Errorf("Message = %q, wanted %q", got, want)
+	}
+
+	// Other conditions don't move when one flips.
+	r.MarkTrafficAssigned()
+	checkTransitioned(t, r, RouteConditionAllTrafficAssigned)
+	checkTransitionTime(t, r, RouteConditionIngressReady, longAgo)
+}
+
+func TestConfigurationConditionTransitionTimes(t *testing.T) {
+	c := &ConfigurationStatus{}
+	c.InitializeConditions()
+	backdate(c.Conditions)
+
+	c.MarkRevisionCreationFailed("quota exceeded")
+	checkTransitioned(t, c, ConfigurationConditionReady)
+	backdate(c.Conditions)
+
+	c.MarkLatestCreatedFailed("foo-00001", "crashed")
+	checkTransitionTime(t, c, ConfigurationConditionReady, longAgo)
+
+	c.SetLatestReadyRevisionName("foo-00002")
+	checkTransitioned(t, c, ConfigurationConditionReady)
+}
+
+type conditionGetter interface {
+	GetCondition(duckv1alpha1.ConditionType) *duckv1alpha1.Condition
+}
+
+func checkTransitionTime(t *testing.T, s conditionGetter, ct duckv1alpha1.ConditionType, want time.Time) {
+	t.Helper()
+	c := s.GetCondition(ct)
+	if c == nil {
+		t.Fatalf("GetCondition(%v) = nil", ct)
+	}
+	if got := c.LastTransitionTime.Inner.Time; !got.Equal(want) {
+		t.Errorf("%v LastTransitionTime = %v, wanted %v", ct, got, want)
+	}
+}
+
+// checkTransitioned checks that the condition was stamped after longAgo.
+func checkTransitioned(t *testing.T, s conditionGetter, ct duckv1alpha1.ConditionType) {
+	t.Helper()
+	c := s.GetCondition(ct)
+	if c == nil {
+		t.Fatalf("GetCondition(%v) = nil", ct)
+	}
+	if got := c.LastTransitionTime.Inner.Time; !got.After(longAgo) {
+		t.Errorf("%v LastTransitionTime = %v, wanted a transition after %v", ct, got, longAgo)
+	}
+}
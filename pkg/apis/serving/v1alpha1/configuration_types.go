@@ -193,8 +193,9 @@ func (cs *ConfigurationStatus) GetConditions() duckv1alpha1.Conditions {
 	return cs.Conditions
 }
 
-// SetConditions sets the Conditions array. This enables generic handling of
+// SetConditions sets the Conditions array, only moving the LastTransitionTime
+// of the conditions whose Status changed. This enables generic handling of
 // conditions by implementing the duckv1alpha1.Conditions interface.
 func (cs *ConfigurationStatus) SetConditions(conditions duckv1alpha1.Conditions) {
-	cs.Conditions = conditions
+	cs.Conditions = stampTransitions(cs.Conditions, conditions)
 }
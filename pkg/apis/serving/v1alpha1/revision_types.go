@@ -93,6 +93,17 @@ const (
 	DeprecatedRevisionServingStateRetired DeprecatedRevisionServingStateType = "Retired"
 )
 
+// RevisionProtocolType is an enumeration of the protocols a Revision's
+// container may serve requests with.
+type RevisionProtocolType string
+
+const (
+	// RevisionProtocolHTTP1 is HTTP/1.1, which Revisions serve by default.
+	RevisionProtocolHTTP1 RevisionProtocolType = "http1"
+	// RevisionProtocolH2C is HTTP/2 without TLS, as used by gRPC.
+	RevisionProtocolH2C RevisionProtocolType = "h2c"
+)
+
 // RevisionRequestConcurrencyModelType is an enumeration of the
 // concurrency models supported by a Revision.
 // Deprecated in favor of RevisionContainerConcurrencyType.
@@ -295,6 +306,16 @@ func (r *Revision) GetGroupVersionKind() schema.GroupVersionKind {
 	return SchemeGroupVersion.WithKind("Revision")
 }
 
+// GetProtocol returns the protocol the Revision's container serves requests
+// with, as named by its container port.
+func (r *Revision) GetProtocol() RevisionProtocolType {
+	ports := r.Spec.Container.Ports
+	if len(ports) > 0 && ports[0].Name == string(RevisionProtocolH2C) {
+		return RevisionProtocolH2C
+	}
+	return RevisionProtocolHTTP1
+}
+
 func (r *Revision) BuildRef() *corev1.ObjectReference {
 	if r.Spec.BuildRef != nil {
 		buildRef := r.Spec.BuildRef.DeepCopy()
@@ -17,8 +17,22 @@ limitations under the License.
 package v1alpha1
 
 func (r *Route) SetDefaults() {
+	if len(r.Spec.Traffic) == 0 && r.Name != "" {
+		// By convention a Route serves the Configuration of the same
+		// name, as the pair created for a Service does.
+		r.Spec.Traffic = []TrafficTarget{{
+			ConfigurationName: r.Name,
+		}}
+	}
 	r.Spec.SetDefaults()
 }
 
 func (rs *RouteSpec) SetDefaults() {
+	if len(rs.Traffic) == 1 && rs.Traffic[0].Percent == 0 && !rs.Traffic[0].Mirror {
+		// A lone target gets all of the traffic.
+		rs.Traffic[0].Percent = 100
+	}
+	if rs.RolloutDuration != nil && rs.RolloutStepPercent == 0 {
+		rs.RolloutStepPercent = DefaultRolloutStepPercent
+	}
 }
@@ -18,8 +18,10 @@ package v1alpha1
 
 import (
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 func TestRouteDefaulting(t *testing.T) {
@@ -30,8 +32,91 @@ func TestRouteDefaulting(t *testing.T) {
 	}{{
 		name: "empty",
 		in:   &Route{},
-		// At present, Route doesn't initialize any defaults.
 		want: &Route{},
+	}, {
+		name: "empty traffic",
+		in: &Route{
+			ObjectMeta: metav1.ObjectMeta{Name: "foo"},
+		},
+		want: &Route{
+			ObjectMeta: metav1.ObjectMeta{Name: "foo"},
+			Spec: RouteSpec{
+				Traffic: []TrafficTarget{{
+					ConfigurationName: "foo",
+					Percent:           100,
+				}},
+			},
+		},
+	}, {
+		name: "single target percent",
+		in: &Route{
+			ObjectMeta: metav1.ObjectMeta{Name: "foo"},
+			Spec: RouteSpec{
+				Traffic: []TrafficTarget{{
+					RevisionName: "bar-00001",
+				}},
+			},
+		},
+		want: &Route{
+			ObjectMeta: metav1.ObjectMeta{Name: "foo"},
+			Spec: RouteSpec{
+				Traffic: []TrafficTarget{{
+					RevisionName: "bar-00001",
+					Percent:      100,
+				}},
+			},
+		},
+	}, {
+		name: "several targets keep their percent",
+		in: &Route{
+			ObjectMeta: metav1.ObjectMeta{Name: "foo"},
+			Spec: RouteSpec{
+				Traffic: []TrafficTarget{{
+					RevisionName: "bar-00001",
+				}, {
+					RevisionName: "bar-00002",
+					Percent:      100,
+				}},
+			},
+		},
+		want: &Route{
+			ObjectMeta: metav1.ObjectMeta{Name: "foo"},
+			Spec: RouteSpec{
+				Traffic: []TrafficTarget{{
+					RevisionName: "bar-00001",
+				}, {
+					RevisionName: "bar-00002",
+					Percent:      100,
+				}},
+			},
+		},
+	}, {
+		name: "rollout step",
+		in: &Route{
+			Spec: RouteSpec{
+				RolloutDuration: &metav1.Duration{Duration: time.Minute},
+			},
+		},
+		want: &Route{
+			Spec: RouteSpec{
+				RolloutDuration:    &metav1.Duration{Duration: time.Minute},
+				RolloutStepPercent: DefaultRolloutStepPercent,
+			},
+		},
+	}, {
+		name: "rollout step specified",
+		in: &Route{
+			Spec: RouteSpec{
+				RolloutDuration:    &metav1.Duration{Duration: time.Minute},
+				RolloutStepPercent: 50,
+			},
+		},
+		want: &Route{
+			Spec: RouteSpec{
+				RolloutDuration:    &metav1.Duration{Duration: time.Minute},
+				RolloutStepPercent: 50,
+			},
+		},
 	}}
 
 	for _, test := range tests {
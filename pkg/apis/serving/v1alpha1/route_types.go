@@ -18,8 +18,14 @@ package v1alpha1
 
 import (
 	"fmt"
+	"regexp/syntax"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 
@@ -52,9 +58,10 @@ type Route struct {
 	Status RouteStatus `json:"status,omitempty"`
 }
 
-// Check that Route may be validated and defaulted.
+// Check that Route may be validated, defaulted, and has immutable fields.
 var _ apis.Validatable = (*Route)(nil)
 var _ apis.Defaultable = (*Route)(nil)
+var _ apis.Immutable = (*Route)(nil)
 
 // Check that we can create OwnerReferences to a Route.
 var _ kmeta.OwnerRefable = (*Route)(nil)
@@ -70,7 +77,7 @@ type TrafficTarget struct {
 	Name string `json:"name,omitempty"`
 
 	// RevisionName of a specific revision to which to send this portion of traffic.
-	// This is mutually exclusive with ConfigurationName.
+	// This is mutually exclusive with ConfigurationName and ConfigurationSelector.
 	// +optional
 	RevisionName string `json:"revisionName,omitempty"`
 
@@ -78,14 +85,148 @@ type TrafficTarget struct {
 	// this portion of traffic. When the "status.latestReadyRevisionName" of the
 	// referenced configuration changes, we will automatically migrate traffic
 	// from the prior "latest ready" revision to the new one.
-	// This field is never set in Route's status, only its spec.
-	// This is mutually exclusive with RevisionName.
+	// This is mutually exclusive with RevisionName and ConfigurationSelector.
+	// In Route's status, it's the Configuration that created RevisionName,
+	// however the target referred to it.
 	// +optional
 	ConfigurationName string `json:"configurationName,omitempty"`
 
+	// ServiceName is the Service that owns ConfigurationName, if any.
+	// This field is never set in Route's spec, only its status.
+	// +optional
+	ServiceName string `json:"serviceName,omitempty"`
+
+	// ConfigurationSelector picks the Configuration to send this portion
+	// of traffic to by its labels, instead of by name.  Exactly one
+	// Configuration of the Route's namespace must match it, unless
+	// SplitEvenly is set.
+	// This field is never set in Route's status, only its spec.
+	// This is mutually exclusive with RevisionName and ConfigurationName.
+	// +optional
+	ConfigurationSelector *metav1.LabelSelector `json:"configurationSelector,omitempty"`
+
+	// SplitEvenly spreads this portion of traffic evenly over all of the
+	// Configurations matching ConfigurationSelector, following them as they
+	// come and go.
+	// This field is never set in Route's status, only its spec.
+	// +optional
+	SplitEvenly bool `json:"splitEvenly,omitempty"`
+
+	// ConfigurationGeneration optionally freezes a ConfigurationName target
+	// on the Revision created for that generation of the Configuration,
+	// instead of following its latest ready Revision.
+	// This field is never set in Route's status, only its spec.
+	// +optional
+	ConfigurationGeneration *int64 `json:"configurationGeneration,omitempty"`
+
+	// Namespace of the Revision or Configuration this target refers to,
+	// when it isn't the Route's, e.g. a shared namespace of stable
+	// Revisions.  The controller only honors it when cross-namespace
+	// traffic is enabled in the config-route ConfigMap.
+	// This may not be combined with ConfigurationSelector.
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+
 	// Percent specifies percent of the traffic to this Revision or Configuration.
 	// This defaults to zero if unspecified.
 	Percent int `json:"percent"`
+
+	// Mirror sends a copy of the Route's traffic to this target, whose
+	// responses are discarded, e.g. to try out a Revision on live traffic
+	// before promoting it.  At most one target may be a mirror, and it must
+	// not receive a percentage of the traffic.
+	// +optional
+	Mirror bool `json:"mirror,omitempty"`
+
+	// Match optionally lists request headers that route a request to this
+	// target exclusively, ahead of the percentage split.  All headers must
+	// match.  This may only be set on named targets.
+	// +optional
+	Match []HeaderMatch `json:"match,omitempty"`
+
+	// PathPrefix optionally routes requests whose path starts with it to
+	// this target exclusively, ahead of the percentage split.  Longer
+	// prefixes take precedence over shorter ones.  This may only be set on
+	// named targets.
+	// +optional
+	PathPrefix string `json:"pathPrefix,omitempty"`
+
+	// Fault optionally injects delays or aborts into the requests routed
+	// to this target exclusively, i.e. those sent to its dedicated hostname
+	// or matching its headers or path prefix, e.g. for resilience testing.
+	// This may only be set on named targets.
+	// +optional
+	Fault *FaultSpec `json:"fault,omitempty"`
+
+	// Rewrite optionally rewrites the host or path of the requests
+	// matching PathPrefix before they are forwarded to this target, e.g.
+	// to serve a legacy path layout.  This requires a PathPrefix.
+	// +optional
+	Rewrite *RewriteSpec `json:"rewrite,omitempty"`
+
+	// Subset optionally names a subset, of an Istio DestinationRule set up
+	// outside of Knative for the Revision's Service, to send this portion
+	// of traffic to instead of to all of the Revision's pods.  An unknown
+	// subset is reported by the SubsetsKnown condition.
+	// +optional
+	Subset string `json:"subset,omitempty"`
+
+	// ImageDigest is the resolved digest of the container image of the
+	// Revision serving this portion of traffic, for auditing which image
+	// is live.  It's empty until the Revision has resolved it.
+	// This field is never set in Route's spec, only its status.
+	// +optional
+	ImageDigest string `json:"imageDigest,omitempty"`
+}
+
+// HeaderMatch describes a condition on the value of a request header.
+// Exactly one of Exact and Prefix must be set.
+type HeaderMatch struct {
+	// Name of the request header.
+	Name string `json:"name"`
+
+	// Exact is the value the header must be equal to.
+	// +optional
+	Exact string `json:"exact,omitempty"`
+
+	// Prefix is the value the header must start with.
+	// +optional
+	Prefix string `json:"prefix,omitempty"`
+}
+
+// FaultSpec describes the faults injected into a share of the requests.
+// At least one of FixedDelay and HTTPStatus must be set.
+type FaultSpec struct {
+	// DelayPercent is the percentage of the requests held for FixedDelay
+	// before being forwarded.
+	// +optional
+	DelayPercent int `json:"delayPercent,omitempty"`
+
+	// FixedDelay is how long delayed requests are held.  It must be at
+	// least a millisecond.
+	// +optional
+	FixedDelay *metav1.Duration `json:"fixedDelay,omitempty"`
+
+	// AbortPercent is the percentage of the requests answered with
+	// HTTPStatus instead of being forwarded.
+	// +optional
+	AbortPercent int `json:"abortPercent,omitempty"`
+
+	// HTTPStatus is the status code aborted requests are answered with.
+	// +optional
+	HTTPStatus int `json:"httpStatus,omitempty"`
+}
+
+// RewriteSpec describes how a request is rewritten before being forwarded.
+// At least one of Authority and URI must be set.
+type RewriteSpec struct {
+	// Authority replaces the Host (Authority) header of the request.
+	// +optional
+	Authority string `json:"authority,omitempty"`
+
+	// URI replaces the matched path prefix of the request.
+	// +optional
+	URI string `json:"uri,omitempty"`
 }
 
 // RouteSpec holds the desired state of the Route (from the client).
@@ -104,8 +245,65 @@ type RouteSpec struct {
 	// Traffic specifies how to distribute traffic over a collection of Knative Serving Revisions and Configurations.
 	// +optional
 	Traffic []TrafficTarget `json:"traffic,omitempty"`
+
+	// RolloutDuration, when set, makes the traffic sent to a Configuration
+	// move to its new LatestReadyRevisionName gradually over this period,
+	// instead of all at once.
+	// +optional
+	RolloutDuration *metav1.Duration `json:"rolloutDuration,omitempty"`
+
+	// RolloutStepPercent is the share of a Configuration's traffic moved to
+	// its new Revision at each step of a gradual rollout.  It defaults to
+	// DefaultRolloutStepPercent when RolloutDuration is set.
+	// +optional
+	RolloutStepPercent int `json:"rolloutStepPercent,omitempty"`
+
+	// Domains lists hosts, outside of the domain Knative picks for the
+	// Route, at which its traffic is also served.
+	// +optional
+	Domains []CustomDomain `json:"domains,omitempty"`
+
+	// HTTPOption selects how plain HTTP requests are answered for the
+	// hosts of the Route that TLS is terminated for.  It defaults to
+	// HTTPOptionEnabled.
+	// +optional
+	HTTPOption HTTPOption `json:"httpOption,omitempty"`
 }
 
+// HTTPOption describes how a Route answers plain HTTP requests.
+type HTTPOption string
+
+const (
+	// HTTPOptionEnabled serves plain HTTP alongside HTTPS.
+	HTTPOptionEnabled HTTPOption = "Enabled"
+	// HTTPOptionRedirected redirects plain HTTP requests to HTTPS.
+	HTTPOptionRedirected HTTPOption = "Redirected"
+)
+
+// CustomDomain is a host brought by the user to serve a Route at.
+// Exactly one of Host and HostRegex must be set.
+type CustomDomain struct {
+	// Host is the fully qualified domain name to serve the Route at.
+	// +optional
+	Host string `json:"host,omitempty"`
+
+	// HostRegex is a regular expression matching the fully qualified
+	// domain names to serve the Route at, e.g. "(us|eu|ap)\.example\.com".
+	// Istio can't match hosts by regex, so it must match a small, finite
+	// set of hosts, which are served as if each was listed as a Host.
+	// +optional
+	HostRegex string `json:"hostRegex,omitempty"`
+
+	// TLSSecret, when set, names the Secret holding the certificate
+	// (tls.cert) and private key (tls.key) to terminate TLS for Host.
+	// +optional
+	TLSSecret *corev1.LocalObjectReference `json:"tlsSecret,omitempty"`
+}
+
+// DefaultRolloutStepPercent is the RolloutStepPercent used when it isn't
+// specified.
+const DefaultRolloutStepPercent = 20
+
 const (
 	// RouteConditionReady is set when the service is configured
 	// and has available backends ready to receive traffic.
@@ -119,6 +317,17 @@ const (
 	// RouteConditionIngressReady is set to False when the
 	// ClusterIngress fails to become Ready.
 	RouteConditionIngressReady duckv1alpha1.ConditionType = "IngressReady"
+
+	// RouteConditionSubsetsKnown is set to False when a traffic target
+	// refers to a DestinationRule subset that isn't defined.  It is only
+	// informational, and doesn't affect the readiness of the Route.
+	RouteConditionSubsetsKnown duckv1alpha1.ConditionType = "SubsetsKnown"
+
+	// RouteConditionTrafficPolicyApplied is set to False when the session
+	// affinity or outlier detection requested for the Route can't be
+	// applied to one of its Revisions.  It is only informational, and
+	// doesn't affect the readiness of the Route.
+	RouteConditionTrafficPolicyApplied duckv1alpha1.ConditionType = "TrafficPolicyApplied"
 )
 
 var routeCondSet = duckv1alpha1.NewLivingConditionSet(RouteConditionAllTrafficAssigned, RouteConditionIngressReady)
@@ -141,6 +350,28 @@ type RouteStatus struct {
 	// +optional
 	Address *duckv1alpha1.Addressable `json:"address,omitempty"`
 
+	// IngressAddress holds the address at which the ingress gateway serving
+	// the Route is reached from outside the cluster.  It's only set once
+	// the gateway's load balancer was assigned one.
+	// +optional
+	IngressAddress *IngressAddress `json:"ingressAddress,omitempty"`
+
+	// PlaceholderServiceName is the name of the Kubernetes Service the
+	// controller created for the Route, which Address points at.  It's
+	// kept once picked, so the Service is found again even when the
+	// controller's naming strategy changes.
+	// +optional
+	PlaceholderServiceName string `json:"placeholderServiceName,omitempty"`
+
+	// VirtualServiceName is the name of the Istio VirtualService, in the
+	// system namespace, programmed for the Route's ClusterIngress.  It
+	// lets tooling find the VirtualService without knowing how it's named.
+	// Only the main VirtualService is reported; the one applying to the
+	// mesh for the serving.knative.dev/meshRouting annotation is named after it
+	// with a "-mesh" suffix.
+	// +optional
+	VirtualServiceName string `json:"virtualServiceName,omitempty"`
+
 	// Traffic holds the configured traffic distribution.
 	// These entries will always contain RevisionName references.
 	// When ConfigurationName appears in the spec, this will hold the
@@ -148,6 +379,40 @@ type RouteStatus struct {
 	// +optional
 	Traffic []TrafficTarget `json:"traffic,omitempty"`
 
+	// ActiveTargets holds the resolved distribution of the Route's traffic
+	// over Revisions, and whether each of them is active or reached
+	// through the activator.  Unlike Traffic, a Revision appears only once.
+	// +optional
+	ActiveTargets []ActiveTarget `json:"activeTargets,omitempty"`
+
+	// RequiresActivation is true when some of the Route's traffic goes
+	// through the activator, i.e. to Revisions that are scaled to zero.
+	// +optional
+	RequiresActivation bool `json:"requiresActivation,omitempty"`
+
+	// TargetStatuses reports, for each of the Route's traffic targets in
+	// order, whether it resolved to a ready Revision and why not, for
+	// tooling.  It's only set while some target isn't ready; the
+	// AllTrafficAssigned condition summarizes the same information.
+	// +optional
+	TargetStatuses []TargetStatus `json:"targetStatuses,omitempty"`
+
+	// Rollouts lists the Configurations whose traffic is being moved
+	// gradually from a previous Revision to their latest ready one.
+	// +optional
+	Rollouts []RolloutStatus `json:"rollouts,omitempty"`
+
+	// Draining lists the Revisions that stopped receiving the Route's
+	// traffic within the drain window.  Their destinations are kept at 0%
+	// until the window passes, so requests already sent to them complete.
+	// +optional
+	Draining []DrainingRevision `json:"draining,omitempty"`
+
+	// TrafficHistory holds the most recent successful assignments of
+	// Traffic, oldest first.  The last entry is the current assignment.
+	// +optional
+	TrafficHistory []TrafficRecord `json:"trafficHistory,omitempty"`
+
 	// Conditions communicates information about ongoing/complete
 	// reconciliation processes that bring the "spec" inline with the observed
 	// state of the world.
@@ -161,6 +426,93 @@ type RouteStatus struct {
 	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
 }
 
+// IngressAddress describes the load balancer in front of the ingress gateway
+// serving a Route.
+type IngressAddress struct {
+	// Host is the IP address or hostname of the load balancer.
+	Host string `json:"host"`
+
+	// URL is the base URL of the load balancer, e.g. http://35.1.2.3, or
+	// https://35.1.2.3 when the Route serves a custom domain over TLS.
+	// Requests sent there pick the Route with their Host header.
+	URL string `json:"url"`
+}
+
+// ActiveTarget describes the share of a Route's traffic a Revision receives.
+type ActiveTarget struct {
+	// RevisionName is the Revision receiving the traffic.
+	RevisionName string `json:"revisionName"`
+
+	// Percent is the share of the traffic the Revision receives.
+	Percent int `json:"percent"`
+
+	// Active is false when the Revision is scaled to zero, and its traffic
+	// is sent to the activator.
+	Active bool `json:"active"`
+}
+
+// TargetStatus describes whether a traffic target of a Route can receive
+// traffic.
+type TargetStatus struct {
+	// Name of the traffic target, if it has one.
+	// +optional
+	Name string `json:"name,omitempty"`
+
+	// RevisionName is the Revision the target resolved to, as far as
+	// it got.
+	// +optional
+	RevisionName string `json:"revisionName,omitempty"`
+
+	// Ready is whether the target resolved to a Revision ready to receive
+	// its traffic.
+	Ready bool `json:"ready"`
+
+	// Reason is a one-word, CamelCase reason for the target not being
+	// ready.
+	// +optional
+	Reason string `json:"reason,omitempty"`
+
+	// Message is a human-readable explanation of the target not being
+	// ready.
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// RolloutStatus describes a gradual rollout of a Configuration's traffic
+// between two of its Revisions.
+type RolloutStatus struct {
+	// ConfigurationName is the Configuration whose traffic is rolled out.
+	ConfigurationName string `json:"configurationName"`
+
+	// PreviousRevisionName is the Revision traffic is moved away from.
+	PreviousRevisionName string `json:"previousRevisionName"`
+
+	// RevisionName is the Revision traffic is moved to.
+	RevisionName string `json:"revisionName"`
+
+	// StartTime is when the rollout started.
+	StartTime metav1.Time `json:"startTime"`
+}
+
+// DrainingRevision describes a Revision that recently stopped receiving a
+// Route's traffic.
+type DrainingRevision struct {
+	// RevisionName is the Revision being drained.
+	RevisionName string `json:"revisionName"`
+
+	// Since is when the Revision stopped receiving traffic.
+	Since metav1.Time `json:"since"`
+}
+
+// TrafficRecord is a past assignment of a Route's traffic.
+type TrafficRecord struct {
+	// Traffic is the traffic distribution that was assigned.
+	Traffic []TrafficTarget `json:"traffic"`
+
+	// Time is when the traffic distribution was assigned.
+	Time metav1.Time `json:"time"`
+}
+
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
 
 // RouteList is a list of Route resources
@@ -175,6 +527,158 @@ func (r *Route) GetGroupVersionKind() schema.GroupVersionKind {
 	return SchemeGroupVersion.WithKind("Route")
 }
 
+// Rollback rewrites the Route's traffic to the assignment recorded n
+// assignments before the current one in its status history.
+func (r *Route) Rollback(n int) error {
+	history := r.Status.TrafficHistory
+	if n < 1 || n >= len(history) {
+		return fmt.Errorf("cannot roll back %d traffic assignments, %d are recorded before the current one",
+			n, len(history)-1)
+	}
+	record := history[len(history)-1-n]
+	r.Spec.Traffic = make([]TrafficTarget, 0, len(record.Traffic))
+	for _, tt := range record.Traffic {
+		// The recorded Revisions are restored, regardless of where
+		// they came from.
+		target := *tt.DeepCopy()
+		target.ConfigurationName = ""
+		target.ServiceName = ""
+		r.Spec.Traffic = append(r.Spec.Traffic, target)
+	}
+	return nil
+}
+
+// Promote rewrites the Route's traffic to send all of it to the latest ready
+// Revision of the named Configuration, e.g. to switch from blue to green in a
+// single step.  The targets that were receiving traffic are kept at 0% so
+// that rolling back is a matter of swapping the percentages again.  Named,
+// mirror and matching targets are kept at 0% as well, so their routes stay.
+func (r *Route) Promote(configurationName string) {
+	traffic := []TrafficTarget{{
+		ConfigurationName: configurationName,
+		Percent:           100,
+	}}
+	for _, tt := range r.Spec.Traffic {
+		if tt.Name == "" && !tt.Mirror && len(tt.Match) == 0 && tt.PathPrefix == "" &&
+			(tt.ConfigurationName == configurationName || tt.Percent == 0) {
+			// Superseded by the promoted target, or a leftover of a
+			// previous promotion.
+			continue
+		}
+		kept := *tt.DeepCopy()
+		kept.Percent = 0
+		traffic = append(traffic, kept)
+	}
+	r.Spec.Traffic = traffic
+}
+
+// MaxHostRegexHosts is the largest number of hosts a CustomDomain's
+// HostRegex may match.
+const MaxHostRegexHosts = 32
+
+// Hosts returns the hosts the CustomDomain serves the Route at: its Host,
+// or the hosts its HostRegex matches, sorted.  It fails for a HostRegex
+// that doesn't compile or matches too many hosts.
+func (cd *CustomDomain) Hosts() ([]string, error) {
+	if cd.HostRegex == "" {
+		return []string{cd.Host}, nil
+	}
+	re, err := syntax.Parse(cd.HostRegex, syntax.Perl)
+	if err != nil {
+		return nil, err
+	}
+	hosts, err := expandRegexp(re.Simplify())
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[string]bool, len(hosts))
+	unique := make([]string, 0, len(hosts))
+	for _, h := range hosts {
+		// Hosts are matched without regard to case.
+		h = strings.ToLower(h)
+		if !seen[h] {
+			seen[h] = true
+			unique = append(unique, h)
+		}
+	}
+	sort.Strings(unique)
+	return unique, nil
+}
+
+// expandRegexp returns the strings re matches, as long as there are at most
+// MaxHostRegexHosts of them.
+func expandRegexp(re *syntax.Regexp) ([]string, error) {
+	switch re.Op {
+	case syntax.OpEmptyMatch, syntax.OpBeginText, syntax.OpEndText, syntax.OpBeginLine, syntax.OpEndLine:
+		// Hosts are always matched as a whole.
+		return []string{""}, nil
+	case syntax.OpLiteral:
+		return []string{string(re.Rune)}, nil
+	case syntax.OpCharClass:
+		var matches []string
+		for i := 0; i+1 < len(re.Rune); i += 2 {
+			if int(re.Rune[i+1]-re.Rune[i])+len(matches) >= MaxHostRegexHosts {
+				return nil, errTooManyHosts
+			}
+			for r := re.Rune[i]; r <= re.Rune[i+1]; r++ {
+				matches = append(matches, string(r))
+			}
+		}
+		return matches, nil
+	case syntax.OpCapture:
+		return expandRegexp(re.Sub[0])
+	case syntax.OpQuest:
+		matches, err := expandRegexp(re.Sub[0])
+		if err != nil {
+			return nil, err
+		}
+		return bounded(append(matches, ""))
+	case syntax.OpAlternate:
+		var matches []string
+		for _, sub := range re.Sub {
+			subMatches, err := expandRegexp(sub)
+			if err != nil {
+				return nil, err
+			}
+			if matches, err = bounded(append(matches, subMatches...)); err != nil {
+				return nil, err
+			}
+		}
+		return matches, nil
+	case syntax.OpConcat:
+		matches := []string{""}
+		for _, sub := range re.Sub {
+			subMatches, err := expandRegexp(sub)
+			if err != nil {
+				return nil, err
+			}
+			if len(matches)*len(subMatches) > MaxHostRegexHosts {
+				return nil, errTooManyHosts
+			}
+			product := make([]string, 0, len(matches)*len(subMatches))
+			for _, prefix := range matches {
+				for _, suffix := range subMatches {
+					product = append(product, prefix+suffix)
+				}
+			}
+			matches = product
+		}
+		return matches, nil
+	default:
+		// Wildcards and unbounded repetitions.
+		return nil, fmt.Errorf("%q matches unboundedly many hosts", re.String())
+	}
+}
+
+var errTooManyHosts = fmt.Errorf("matches more than %d hosts", MaxHostRegexHosts)
+
+func bounded(matches []string) ([]string, error) {
+	if len(matches) > MaxHostRegexHosts {
+		return nil, errTooManyHosts
+	}
+	return matches, nil
+}
+
 func (rs *RouteStatus) IsReady() bool {
 	return routeCondSet.Manage(rs).IsHappy()
 }
@@ -194,10 +698,67 @@ func (rs *RouteStatus) MarkServiceNotOwned(name string) {
 		fmt.Sprintf("There is an existing placeholder Service %q that we do not own.", name))
 }
 
+// MarkIngressNotReady changes the IngressReady status to be false with the reason being that
+// the ClusterIngress for the Route could not be created or updated.
+func (rs *RouteStatus) MarkIngressNotReady(message string) {
+	routeCondSet.Manage(rs).MarkFalse(RouteConditionIngressReady, "IngressNotReady",
+		"Failed to reconcile the ClusterIngress: %s", message)
+}
+
+// MarkServiceNotReady changes the IngressReady status to be false with the reason being that
+// the placeholder Service for the Route could not be created or updated.
+func (rs *RouteStatus) MarkServiceNotReady(name, message string) {
+	routeCondSet.Manage(rs).MarkFalse(RouteConditionIngressReady, "ServiceNotReady",
+		"Failed to reconcile the placeholder Service %q: %s", name, message)
+}
+
+// MarkDomainClaimed changes the IngressReady status to be false with the reason being that
+// one of the Route's custom domains is served by a Route that claimed it first.
+func (rs *RouteStatus) MarkDomainClaimed(host string) {
+	routeCondSet.Manage(rs).MarkFalse(RouteConditionIngressReady, "DomainClaimed",
+		"Domain %q is already claimed by another Route.", host)
+}
+
+// MarkInvalidDomain changes the IngressReady status to be false with the reason being that
+// the domain computed for the Route is not a valid hostname.
+func (rs *RouteStatus) MarkInvalidDomain(domain, message string) {
+	routeCondSet.Manage(rs).MarkFalse(RouteConditionIngressReady, "InvalidDomain",
+		"Domain %q is not a valid hostname: %s", domain, message)
+}
+
 func (rs *RouteStatus) MarkTrafficAssigned() {
 	routeCondSet.Manage(rs).MarkTrue(RouteConditionAllTrafficAssigned)
 }
 
+// RecordTrafficHistory appends the current Traffic to TrafficHistory when
+// all traffic is assigned, no rollout is moving it, and it differs from the
+// last recorded assignment.  At most limit records are kept, dropping the
+// oldest ones.
+func (rs *RouteStatus) RecordTrafficHistory(now time.Time, limit int) {
+	if limit <= 0 || len(rs.Rollouts) != 0 {
+		// The intermediate steps of a rollout aren't worth rolling
+		// back to, and would push the settled assignments out.
+		return
+	}
+	if c := rs.GetCondition(RouteConditionAllTrafficAssigned); c == nil || !c.IsTrue() {
+		return
+	}
+	if n := len(rs.TrafficHistory); n > 0 && equality.Semantic.DeepEqual(rs.TrafficHistory[n-1].Traffic, rs.Traffic) {
+		return
+	}
+	traffic := make([]TrafficTarget, 0, len(rs.Traffic))
+	for _, tt := range rs.Traffic {
+		traffic = append(traffic, *tt.DeepCopy())
+	}
+	rs.TrafficHistory = append(rs.TrafficHistory, TrafficRecord{
+		Traffic: traffic,
+		Time:    metav1.NewTime(now),
+	})
+	if extra := len(rs.TrafficHistory) - limit; extra > 0 {
+		rs.TrafficHistory = rs.TrafficHistory[extra:]
+	}
+}
+
 func (rs *RouteStatus) MarkUnknownTrafficError(msg string) {
 	routeCondSet.Manage(rs).MarkUnknown(RouteConditionAllTrafficAssigned, "Unknown", msg)
 }
@@ -226,15 +787,93 @@ func (rs *RouteStatus) MarkRevisionFailed(name string) {
 		"Revision %q failed to become ready.", name)
 }
 
+// MarkRevisionReadyTimeout changes the AllTrafficAssigned status to be false with the
+// reason being that the named Revision has not become ready for too long.  The message
+// is that of the Revision's own Ready condition.
+func (rs *RouteStatus) MarkRevisionReadyTimeout(name, message string) {
+	routeCondSet.Manage(rs).MarkFalse(RouteConditionAllTrafficAssigned,
+		"RevisionFailed",
+		"Revision %q did not become ready in time: %s", name, message)
+}
+
+// MarkInvalidTrafficTarget changes the AllTrafficAssigned status to be false with the
+// reason being that a traffic target of the Route is malformed, e.g. names both a
+// Configuration and a Revision.
+func (rs *RouteStatus) MarkInvalidTrafficTarget(message string) {
+	routeCondSet.Manage(rs).MarkFalse(RouteConditionAllTrafficAssigned,
+		"InvalidTrafficTarget",
+		"Traffic target is invalid: %s.", message)
+}
+
 func (rs *RouteStatus) MarkMissingTrafficTarget(kind, name string) {
 	routeCondSet.Manage(rs).MarkFalse(RouteConditionAllTrafficAssigned,
 		kind+"Missing",
 		"%s %q referenced in traffic not found.", kind, name)
 }
 
+// MarkUnresolvedConfigurationSelector changes the AllTrafficAssigned status to be false
+// with the reason being that the selector of a traffic target doesn't match exactly one
+// Configuration, but the given ones.
+func (rs *RouteStatus) MarkUnresolvedConfigurationSelector(selector string, matches []string) {
+	if len(matches) == 0 {
+		routeCondSet.Manage(rs).MarkFalse(RouteConditionAllTrafficAssigned,
+			"ConfigurationMissing",
+			"No Configuration matches selector %q referenced in traffic.", selector)
+		return
+	}
+	quoted := make([]string, len(matches))
+	for i, name := range matches {
+		quoted[i] = strconv.Quote(name)
+	}
+	routeCondSet.Manage(rs).MarkFalse(RouteConditionAllTrafficAssigned,
+		"ConfigurationAmbiguous",
+		"Configurations %s all match selector %q referenced in traffic.", strings.Join(quoted, ", "), selector)
+}
+
+// MarkCrossNamespaceTrafficDisabled changes the AllTrafficAssigned status to be false
+// with the reason being that a traffic target refers to another namespace, while the
+// cluster doesn't allow traffic across namespaces.
+func (rs *RouteStatus) MarkCrossNamespaceTrafficDisabled(namespace string) {
+	routeCondSet.Manage(rs).MarkFalse(RouteConditionAllTrafficAssigned,
+		"CrossNamespaceTrafficDisabled",
+		"Traffic to namespace %q is not allowed by the cluster.", namespace)
+}
+
+// MarkTrafficTargetsNotReady replaces the message of a not yet True AllTrafficAssigned
+// condition with a summary covering every traffic target of the Route.  The status and
+// reason set for the target that marked the condition are kept.
+func (rs *RouteStatus) MarkTrafficTargetsNotReady(summary string) {
+	cond := rs.GetCondition(RouteConditionAllTrafficAssigned)
+	if cond == nil {
+		return
+	}
+	switch cond.Status {
+	case corev1.ConditionFalse:
+		routeCondSet.Manage(rs).MarkFalse(RouteConditionAllTrafficAssigned, cond.Reason, "%s", summary)
+	case corev1.ConditionUnknown:
+		routeCondSet.Manage(rs).MarkUnknown(RouteConditionAllTrafficAssigned, cond.Reason, "%s", summary)
+	}
+}
+
 // PropagateClusterIngressStatus update RouteConditionIngressReady condition
 // in RouteStatus according to IngressStatus.
 func (rs *RouteStatus) PropagateClusterIngressStatus(cs v1alpha1.IngressStatus) {
+	if sc := cs.GetCondition(v1alpha1.ClusterIngressConditionSubsetsKnown); sc != nil {
+		switch sc.Status {
+		case corev1.ConditionTrue:
+			routeCondSet.Manage(rs).MarkTrue(RouteConditionSubsetsKnown)
+		case corev1.ConditionFalse:
+			routeCondSet.Manage(rs).MarkFalse(RouteConditionSubsetsKnown, sc.Reason, "%s", sc.Message)
+		}
+	}
+	if pc := cs.GetCondition(v1alpha1.ClusterIngressConditionTrafficPolicyApplied); pc != nil {
+		switch pc.Status {
+		case corev1.ConditionTrue:
+			routeCondSet.Manage(rs).MarkTrue(RouteConditionTrafficPolicyApplied)
+		case corev1.ConditionFalse:
+			routeCondSet.Manage(rs).MarkFalse(RouteConditionTrafficPolicyApplied, pc.Reason, "%s", pc.Message)
+		}
+	}
 	cc := cs.GetCondition(v1alpha1.ClusterIngressConditionReady)
 	if cc == nil {
 		return
@@ -255,8 +894,9 @@ func (rs *RouteStatus) GetConditions() duckv1alpha1.Conditions {
 	return rs.Conditions
 }
 
-// SetConditions sets the Conditions array. This enables generic handling of
+// SetConditions sets the Conditions array, only moving the LastTransitionTime
+// of the conditions whose Status changed. This enables generic handling of
 // conditions by implementing the duckv1alpha1.Conditions interface.
 func (rs *RouteStatus) SetConditions(conditions duckv1alpha1.Conditions) {
-	rs.Conditions = conditions
+	rs.Conditions = stampTransitions(rs.Conditions, conditions)
 }
@@ -17,11 +17,14 @@ package v1alpha1
 
 import (
 	"testing"
+	"time"
 
+	"github.com/google/go-cmp/cmp"
 	"github.com/knative/pkg/apis/duck"
 	duckv1alpha1 "github.com/knative/pkg/apis/duck/v1alpha1"
 	netv1alpha1 "github.com/knative/serving/pkg/apis/networking/v1alpha1"
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 )
 
@@ -142,6 +145,43 @@ func TestRouteIsReady(t *testing.T) {
 	}
 }
 
+func TestRouteConditionSeverity(t *testing.T) {
+	const conflict duckv1alpha1.ConditionType = "DomainConflict"
+
+	r := &Route{}
+	r.Status.InitializeConditions()
+	r.Status.MarkTrafficAssigned()
+	r.Status.PropagateClusterIngressStatus(netv1alpha1.IngressStatus{
+		Conditions: duckv1alpha1.Conditions{{
+			Type:   netv1alpha1.ClusterIngressConditionReady,
+			Status: corev1.ConditionTrue,
+		}},
+	})
+	checkConditionSucceededRoute(r.Status, RouteConditionReady, t)
+
+	// Conditions outside of those Ready rolls up are informational...
+	routeCondSet.Manage(&r.Status).MarkFalse(conflict, "Conflict", "Another Route serves the domain.")
+	if got, want := r.Status.GetCondition(conflict).Severity, duckv1alpha1.ConditionSeverityInfo; got != want {
+		t.Errorf("Severity = %v, want %v", got, want)
+	}
+	checkConditionSucceededRoute(r.Status, RouteConditionReady, t)
+
+	// ...and so are the ones explicitly set as warnings.
+	routeCondSet.Manage(&r.Status).SetCondition(duckv1alpha1.Condition{
+		Type:     conflict,
+		Status:   corev1.ConditionFalse,
+		Severity: duckv1alpha1.ConditionSeverityWarning,
+	})
+	checkConditionSucceededRoute(r.Status, RouteConditionReady, t)
+
+	// Those Ready rolls up are errors, and take it down with them.
+	r.Status.MarkMissingTrafficTarget("Revision", "does-not-exist")
+	if got, want := r.Status.GetCondition(RouteConditionAllTrafficAssigned).Severity, duckv1alpha1.ConditionSeverityError; got != want {
+		t.Errorf("Severity = %v, want %v", got, want)
+	}
+	checkConditionFailedRoute(r.Status, RouteConditionReady, t)
+}
+
 func TestTypicalRouteFlow(t *testing.T) {
 	r := &Route{}
 	r.Status.InitializeConditions()
@@ -288,6 +328,66 @@ func TestClusterIngressFailureRecovery(t *testing.T) {
 	checkConditionSucceededRoute(r.Status, RouteConditionReady, t)
 }
 
+func TestRouteSubsetsKnown(t *testing.T) {
+	r := &Route{}
+	r.Status.InitializeConditions()
+	r.Status.MarkTrafficAssigned()
+	r.Status.PropagateClusterIngressStatus(netv1alpha1.IngressStatus{
+		Conditions: duckv1alpha1.Conditions{{
+			Type:   netv1alpha1.ClusterIngressConditionReady,
+			Status: corev1.ConditionTrue,
+		}, {
+			Type:   netv1alpha1.ClusterIngressConditionSubsetsKnown,
+			Status: corev1.ConditionFalse,
+		}},
+	})
+	// An unknown subset doesn't keep the Route from being ready.
+	checkConditionFailedRoute(r.Status, RouteConditionSubsetsKnown, t)
+	checkConditionSucceededRoute(r.Status, RouteConditionReady, t)
+
+	r.Status.PropagateClusterIngressStatus(netv1alpha1.IngressStatus{
+		Conditions: duckv1alpha1.Conditions{{
+			Type:   netv1alpha1.ClusterIngressConditionReady,
+			Status: corev1.ConditionTrue,
+		}, {
+			Type:   netv1alpha1.ClusterIngressConditionSubsetsKnown,
+			Status: corev1.ConditionTrue,
+		}},
+	})
+	checkConditionSucceededRoute(r.Status, RouteConditionSubsetsKnown, t)
+	checkConditionSucceededRoute(r.Status, RouteConditionReady, t)
+}
+
+func TestRouteTrafficPolicyApplied(t *testing.T) {
+	r := &Route{}
+	r.Status.InitializeConditions()
+	r.Status.MarkTrafficAssigned()
+	r.Status.PropagateClusterIngressStatus(netv1alpha1.IngressStatus{
+		Conditions: duckv1alpha1.Conditions{{
+			Type:   netv1alpha1.ClusterIngressConditionReady,
+			Status: corev1.ConditionTrue,
+		}, {
+			Type:   netv1alpha1.ClusterIngressConditionTrafficPolicyApplied,
+			Status: corev1.ConditionFalse,
+		}},
+	})
+	// A conflicting DestinationRule doesn't keep the Route from being ready.
+	checkConditionFailedRoute(r.Status, RouteConditionTrafficPolicyApplied, t)
+	checkConditionSucceededRoute(r.Status, RouteConditionReady, t)
+
+	r.Status.PropagateClusterIngressStatus(netv1alpha1.IngressStatus{
+		Conditions: duckv1alpha1.Conditions{{
+			Type:   netv1alpha1.ClusterIngressConditionReady,
+			Status: corev1.ConditionTrue,
+		}, {
+			Type:   netv1alpha1.ClusterIngressConditionTrafficPolicyApplied,
+			Status: corev1.ConditionTrue,
+		}},
+	})
+	checkConditionSucceededRoute(r.Status, RouteConditionTrafficPolicyApplied, t)
+	checkConditionSucceededRoute(r.Status, RouteConditionReady, t)
+}
+
 func TestRouteNotOwnedStuff(t *testing.T) {
 	r := &Route{}
 	r.Status.InitializeConditions()
@@ -306,6 +406,38 @@ func TestRouteNotOwnedStuff(t *testing.T) {
 	checkConditionFailedRoute(r.Status, RouteConditionReady, t)
 }
 
+func TestRouteInvalidDomain(t *testing.T) {
+	r := &Route{}
+	r.Status.InitializeConditions()
+	r.Status.MarkInvalidDomain("Foo.default.example.com", "bad hostname")
+	checkConditionOngoingRoute(r.Status, RouteConditionAllTrafficAssigned, t)
+	checkConditionFailedRoute(r.Status, RouteConditionIngressReady, t)
+	checkConditionFailedRoute(r.Status, RouteConditionReady, t)
+
+	if got, want := r.Status.GetCondition(RouteConditionReady).Reason, "InvalidDomain"; got != want {
+		t.Errorf("Ready reason = %q, want %q", got, want)
+	}
+}
+
+func TestRouteChildNotReady(t *testing.T) {
+	r := &Route{}
+	r.Status.InitializeConditions()
+	r.Status.MarkTrafficAssigned()
+	r.Status.MarkIngressNotReady("boom")
+	checkConditionSucceededRoute(r.Status, RouteConditionAllTrafficAssigned, t)
+	checkConditionFailedRoute(r.Status, RouteConditionIngressReady, t)
+	checkConditionFailedRoute(r.Status, RouteConditionReady, t)
+	if got, want := r.Status.GetCondition(RouteConditionReady).Reason, "IngressNotReady"; got != want {
+		t.Errorf("Ready reason = %q, want %q", got, want)
+	}
+
+	r.Status.MarkServiceNotReady("evan", "boom")
+	checkConditionFailedRoute(r.Status, RouteConditionReady, t)
+	if got, want := r.Status.GetCondition(RouteConditionReady).Reason, "ServiceNotReady"; got != want {
+		t.Errorf("Ready reason = %q, want %q", got, want)
+	}
+}
+
 func checkConditionSucceededRoute(rs RouteStatus, rct duckv1alpha1.ConditionType, t *testing.T) {
 	t.Helper()
 	checkConditionRoute(rs, rct, corev1.ConditionTrue, t)
@@ -343,3 +475,231 @@ func TestRouteGetGroupVersionKind(t *testing.T) {
 		t.Errorf("got: %v, want: %v", got, want)
 	}
 }
+
+func TestRecordTrafficHistory(t *testing.T) {
+	start := time.Now()
+	assign := func(r *RouteStatus, rev string, limit int, after time.Duration) {
+		r.Traffic = []TrafficTarget{{RevisionName: rev, Percent: 100}}
+		r.MarkTrafficAssigned()
+		r.RecordTrafficHistory(start.Add(after), limit)
+	}
+	revisions := func(r *RouteStatus) []string {
+		var names []string
+		for _, record := range r.TrafficHistory {
+			names = append(names, record.Traffic[0].RevisionName)
+		}
+		return names
+	}
+
+	r := &RouteStatus{}
+	r.InitializeConditions()
+	r.Traffic = []TrafficTarget{{RevisionName: "rev-1", Percent: 100}}
+	r.RecordTrafficHistory(start, 3)
+	if got := len(r.TrafficHistory); got != 0 {
+		t.Fatalf("len(TrafficHistory) = %d before traffic is assigned, want 0", got)
+	}
+
+	assign(r, "rev-1", 3, 0)
+	assign(r, "rev-1", 3, time.Second)
+	if got, want := r.TrafficHistory, []TrafficRecord{{
+		Traffic: []TrafficTarget{{RevisionName: "rev-1", Percent: 100}},
+		Time:    metav1.NewTime(start),
+	}}; !cmp.Equal(got, want) {
+		t.Errorf("TrafficHistory (-want, +got) = %v", cmp.Diff(want, got))
+	}
+
+	for i, rev := range []string{"rev-2", "rev-3", "rev-4"} {
+		assign(r, rev, 3, time.Duration(i+2)*time.Second)
+	}
+	if got, want := revisions(r), []string{"rev-2", "rev-3", "rev-4"}; !cmp.Equal(got, want) {
+		t.Errorf("TrafficHistory revisions (-want, +got) = %v", cmp.Diff(want, got))
+	}
+
+	assign(r, "rev-5", 0, time.Minute)
+	if got, want := revisions(r), []string{"rev-2", "rev-3", "rev-4"}; !cmp.Equal(got, want) {
+		t.Errorf("TrafficHistory revisions with no limit (-want, +got) = %v", cmp.Diff(want, got))
+	}
+
+	r.Rollouts = []RolloutStatus{{ConfigurationName: "config", PreviousRevisionName: "rev-4", RevisionName: "rev-6"}}
+	assign(r, "rev-6", 3, 2*time.Minute)
+	if got, want := revisions(r), []string{"rev-2", "rev-3", "rev-4"}; !cmp.Equal(got, want) {
+		t.Errorf("TrafficHistory revisions during a rollout (-want, +got) = %v", cmp.Diff(want, got))
+	}
+
+	r.Rollouts = nil
+	assign(r, "rev-6", 3, 3*time.Minute)
+	if got, want := revisions(r), []string{"rev-3", "rev-4", "rev-6"}; !cmp.Equal(got, want) {
+		t.Errorf("TrafficHistory revisions after a rollout (-want, +got) = %v", cmp.Diff(want, got))
+	}
+}
+
+func TestRouteRollback(t *testing.T) {
+	r := &Route{
+		Spec: RouteSpec{
+			Traffic: []TrafficTarget{{ConfigurationName: "config", Percent: 100}},
+		},
+		Status: RouteStatus{
+			TrafficHistory: []TrafficRecord{{
+				Traffic: []TrafficTarget{{RevisionName: "rev-1", Percent: 100}},
+			}, {
+				Traffic: []TrafficTarget{{RevisionName: "rev-1", Percent: 50}, {
+					// The ancestry of the Revision isn't restored.
+					ConfigurationName: "config",
+					ServiceName:       "service",
+					RevisionName:      "rev-2",
+					Percent:           50,
+				}},
+			}, {
+				Traffic: []TrafficTarget{{RevisionName: "rev-3", Percent: 100}},
+			}},
+		},
+	}
+
+	for _, n := range []int{0, 3, -1} {
+		if err := r.Rollback(n); err == nil {
+			t.Errorf("Rollback(%d) = nil, wanted an error", n)
+		}
+	}
+
+	if err := r.Rollback(1); err != nil {
+		t.Fatalf("Rollback(1) = %v", err)
+	}
+	want := []TrafficTarget{{RevisionName: "rev-1", Percent: 50}, {RevisionName: "rev-2", Percent: 50}}
+	if diff := cmp.Diff(want, r.Spec.Traffic); diff != "" {
+		t.Errorf("Spec.Traffic after Rollback(1) (-want, +got) = %v", diff)
+	}
+
+	if err := r.Rollback(2); err != nil {
+		t.Fatalf("Rollback(2) = %v", err)
+	}
+	want = []TrafficTarget{{RevisionName: "rev-1", Percent: 100}}
+	if diff := cmp.Diff(want, r.Spec.Traffic); diff != "" {
+		t.Errorf("Spec.Traffic after Rollback(2) (-want, +got) = %v", diff)
+	}
+}
+
+func TestRoutePromote(t *testing.T) {
+	tests := []struct {
+		name    string
+		traffic []TrafficTarget
+		want    []TrafficTarget
+	}{{
+		name:    "blue to green",
+		traffic: []TrafficTarget{{ConfigurationName: "blue", Percent: 100}},
+		want: []TrafficTarget{
+			{ConfigurationName: "green", Percent: 100},
+			{ConfigurationName: "blue", Percent: 0},
+		},
+	}, {
+		name: "back to blue",
+		traffic: []TrafficTarget{
+			{ConfigurationName: "green", Percent: 100},
+			{ConfigurationName: "blue", Percent: 0},
+		},
+		want: []TrafficTarget{
+			{ConfigurationName: "blue", Percent: 100},
+			{ConfigurationName: "green", Percent: 0},
+		},
+	}, {
+		name: "drops leftovers of previous promotions",
+		traffic: []TrafficTarget{
+			{ConfigurationName: "green", Percent: 100},
+			{RevisionName: "blue-00001", Percent: 0},
+		},
+		want: []TrafficTarget{
+			{ConfigurationName: "blue", Percent: 100},
+			{ConfigurationName: "green", Percent: 0},
+		},
+	}, {
+		name: "split",
+		traffic: []TrafficTarget{
+			{ConfigurationName: "blue", Percent: 90},
+			{RevisionName: "green-00001", Percent: 10},
+		},
+		want: []TrafficTarget{
+			{ConfigurationName: "green", Percent: 100},
+			{ConfigurationName: "blue", Percent: 0},
+			{RevisionName: "green-00001", Percent: 0},
+		},
+	}, {
+		name: "keeps named and mirror targets",
+		traffic: []TrafficTarget{
+			{ConfigurationName: "blue", Percent: 100},
+			{Name: "candidate", ConfigurationName: "green", Percent: 0},
+			{ConfigurationName: "green", Mirror: true},
+		},
+		want: []TrafficTarget{
+			{ConfigurationName: "green", Percent: 100},
+			{ConfigurationName: "blue", Percent: 0},
+			{Name: "candidate", ConfigurationName: "green", Percent: 0},
+			{ConfigurationName: "green", Mirror: true},
+		},
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := &Route{Spec: RouteSpec{Traffic: test.traffic}}
+			r.Promote(test.want[0].ConfigurationName)
+			if diff := cmp.Diff(test.want, r.Spec.Traffic); diff != "" {
+				t.Errorf("Spec.Traffic after Promote (-want, +got) = %v", diff)
+			}
+			if err := r.Spec.Validate(); err != nil {
+				t.Errorf("Spec.Validate() after Promote = %v", err)
+			}
+		})
+	}
+}
+
+func TestCustomDomainHosts(t *testing.T) {
+	tests := []struct {
+		name    string
+		cd      CustomDomain
+		want    []string
+		wantErr string
+	}{{
+		name: "host",
+		cd:   CustomDomain{Host: "www.example.com"},
+		want: []string{"www.example.com"},
+	}, {
+		name: "alternation",
+		cd:   CustomDomain{HostRegex: `^(us|eu|ap)\.example\.com$`},
+		want: []string{"ap.example.com", "eu.example.com", "us.example.com"},
+	}, {
+		name: "optional parts and classes",
+		cd:   CustomDomain{HostRegex: `(www\.)?shard[0-2]\.Example\.com`},
+		want: []string{
+			"shard0.example.com", "shard1.example.com", "shard2.example.com",
+			"www.shard0.example.com", "www.shard1.example.com", "www.shard2.example.com",
+		},
+	}, {
+		name:    "wildcard",
+		cd:      CustomDomain{HostRegex: `.*\.example\.com`},
+		wantErr: `"(?-s:.*)" matches unboundedly many hosts`,
+	}, {
+		name:    "too many hosts",
+		cd:      CustomDomain{HostRegex: `[a-z][a-z]\.example\.com`},
+		wantErr: "matches more than 32 hosts",
+	}, {
+		name:    "does not compile",
+		cd:      CustomDomain{HostRegex: `(us|eu`},
+		wantErr: "error parsing regexp: missing closing ): `(us|eu`",
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := test.cd.Hosts()
+			if test.wantErr != "" {
+				if err == nil || err.Error() != test.wantErr {
+					t.Fatalf("Hosts() = %v, wanted error %q", err, test.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Hosts() = %v", err)
+			}
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("Hosts() (-want, +got) = %v", diff)
+			}
+		})
+	}
+}
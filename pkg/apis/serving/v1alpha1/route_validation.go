@@ -19,18 +19,88 @@ package v1alpha1
 import (
 	"fmt"
 	"strconv"
+	"strings"
+	"time"
 
 	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"github.com/knative/pkg/apis"
+	"github.com/knative/pkg/kmp"
+	"github.com/knative/serving/pkg/apis/serving"
 	"k8s.io/apimachinery/pkg/util/validation"
 )
 
 func (r *Route) Validate() *apis.FieldError {
-	return ValidateObjectMetadata(r.GetObjectMeta()).ViaField("metadata").
+	return ValidateObjectMetadata(r.GetObjectMeta()).
+		Also(validateOutlierDetection(r.Annotations).ViaField("annotations")).ViaField("metadata").
 		Also(r.Spec.Validate().ViaField("spec"))
 }
 
+// validateOutlierDetection checks that every setting of the outlier detection
+// annotation is known and well formed, since Istio would reject the whole
+// DestinationRule otherwise.
+func validateOutlierDetection(annotations map[string]string) *apis.FieldError {
+	value, ok := annotations[serving.OutlierDetectionAnnotationKey]
+	if !ok {
+		return nil
+	}
+	invalid := func(message string) *apis.FieldError {
+		return &apis.FieldError{
+			Message: fmt.Sprintf("Invalid %s annotation value: %s", serving.OutlierDetectionAnnotationKey, message),
+			Paths:   []string{serving.OutlierDetectionAnnotationKey},
+		}
+	}
+	for _, setting := range strings.Split(value, ",") {
+		parts := strings.SplitN(strings.TrimSpace(setting), "=", 2)
+		if len(parts) != 2 {
+			return invalid(fmt.Sprintf("%q must be of the form <name>=<value>", setting))
+		}
+		switch parts[0] {
+		case "consecutiveErrors":
+			if n, err := strconv.ParseInt(parts[1], 10, 32); err != nil || n < 1 {
+				return invalid(fmt.Sprintf("%s must be an integer greater than 0", parts[0]))
+			}
+		case "interval", "baseEjectionTime":
+			if d, err := time.ParseDuration(parts[1]); err != nil || d < time.Millisecond {
+				return invalid(fmt.Sprintf("%s must be a duration of at least 1ms", parts[0]))
+			}
+		default:
+			return invalid(fmt.Sprintf("unknown setting %q", parts[0]))
+		}
+	}
+	return nil
+}
+
+// CheckImmutableFields checks that the Service a Route belongs to, which the
+// Service controller records in its label, isn't changed or removed once
+// set.  The rest of the Route, e.g. its traffic, may be edited.
+func (current *Route) CheckImmutableFields(og apis.Immutable) *apis.FieldError {
+	original, ok := og.(*Route)
+	if !ok {
+		return &apis.FieldError{Message: "The provided original was not a Route"}
+	}
+
+	service, ok := original.Labels[serving.ServiceLabelKey]
+	if !ok || current.Labels[serving.ServiceLabelKey] == service {
+		return nil
+	}
+	path := fmt.Sprintf("metadata.labels[%s]", serving.ServiceLabelKey)
+	diff, err := kmp.SafeDiff(service, current.Labels[serving.ServiceLabelKey])
+	if err != nil {
+		return &apis.FieldError{
+			Message: "Failed to diff Route",
+			Paths:   []string{path},
+			Details: err.Error(),
+		}
+	}
+	return &apis.FieldError{
+		Message: "Immutable fields changed (-old +new)",
+		Paths:   []string{path},
+		Details: diff,
+	}
+}
+
 func (rs *RouteSpec) Validate() *apis.FieldError {
 	if equality.Semantic.DeepEqual(rs, &RouteSpec{}) {
 		return apis.ErrMissingField(apis.CurrentField)
@@ -45,14 +115,45 @@ func (rs *RouteSpec) Validate() *apis.FieldError {
 
 	// Track the targets of named TrafficTarget entries (to detect duplicates).
 	trafficMap := make(map[string]namedTarget)
+	// Track the first target claiming each path prefix (to detect conflicts).
+	prefixes := make(map[string]int)
 
 	var errs *apis.FieldError
 	percentSum := 0
+	mirror := -1
 	for i, tt := range rs.Traffic {
 		errs = errs.Also(tt.Validate().ViaFieldIndex("traffic", i))
 
 		percentSum += tt.Percent
 
+		if tt.Mirror {
+			if mirror >= 0 {
+				errs = errs.Also(&apis.FieldError{
+					Message: "Multiple mirror targets",
+					Paths: []string{
+						fmt.Sprintf("traffic[%d].mirror", mirror),
+						fmt.Sprintf("traffic[%d].mirror", i),
+					},
+				})
+			} else {
+				mirror = i
+			}
+		}
+
+		if tt.PathPrefix != "" {
+			if j, ok := prefixes[tt.PathPrefix]; !ok {
+				prefixes[tt.PathPrefix] = i
+			} else if conflictingPrefixTargets(&rs.Traffic[j], &tt) {
+				errs = errs.Also(&apis.FieldError{
+					Message: fmt.Sprintf("Multiple targets for path prefix %q", tt.PathPrefix),
+					Paths: []string{
+						fmt.Sprintf("traffic[%d].pathPrefix", j),
+						fmt.Sprintf("traffic[%d].pathPrefix", i),
+					},
+				})
+			}
+		}
+
 		if tt.Name == "" {
 			// No Name field, so skip the uniqueness check.
 			continue
@@ -84,28 +185,314 @@ func (rs *RouteSpec) Validate() *apis.FieldError {
 			Paths:   []string{"traffic"},
 		})
 	}
+	if rs.RolloutDuration != nil && rs.RolloutDuration.Duration < 0 {
+		errs = errs.Also(apis.ErrInvalidValue(rs.RolloutDuration.Duration.String(), "rolloutDuration"))
+	}
+	if rs.RolloutStepPercent < 0 || rs.RolloutStepPercent > 100 {
+		errs = errs.Also(apis.ErrOutOfBoundsValue(strconv.Itoa(rs.RolloutStepPercent), "0", "100", "rolloutStepPercent"))
+	}
+
+	hosts := make(map[string]int, len(rs.Domains))
+	for i, d := range rs.Domains {
+		errs = errs.Also(d.Validate().ViaFieldIndex("domains", i))
+		// Invalid patterns are reported above.
+		dhosts, _ := d.Hosts()
+		for _, host := range dhosts {
+			if j, ok := hosts[host]; ok {
+				errs = errs.Also(&apis.FieldError{
+					Message: fmt.Sprintf("Multiple definitions for %q", host),
+					Paths: []string{
+						fmt.Sprintf("domains[%d].%s", j, rs.Domains[j].hostField()),
+						fmt.Sprintf("domains[%d].%s", i, d.hostField()),
+					},
+				})
+			} else {
+				hosts[host] = i
+			}
+		}
+	}
+
+	switch rs.HTTPOption {
+	case "", HTTPOptionEnabled, HTTPOptionRedirected:
+	default:
+		errs = errs.Also(apis.ErrInvalidValue(string(rs.HTTPOption), "httpOption"))
+	}
 	return errs
 }
 
+// Validate verifies that CustomDomain is properly configured.
+func (cd *CustomDomain) Validate() *apis.FieldError {
+	var errs *apis.FieldError
+	switch {
+	case cd.Host != "" && cd.HostRegex != "":
+		errs = apis.ErrMultipleOneOf("host", "hostRegex")
+	case cd.Host != "":
+		if verrs := validation.IsDNS1123Subdomain(cd.Host); len(verrs) > 0 {
+			errs = apis.ErrInvalidValue(cd.Host, "host")
+		}
+	case cd.HostRegex != "":
+		hosts, err := cd.Hosts()
+		if err != nil {
+			errs = &apis.FieldError{
+				Message: fmt.Sprintf("invalid value %q", cd.HostRegex),
+				Paths:   []string{"hostRegex"},
+				Details: fmt.Sprintf("Istio can't match hosts by regex, so it must match at most %d hosts: %v",
+					MaxHostRegexHosts, err),
+			}
+			break
+		}
+		for _, host := range hosts {
+			if verrs := validation.IsDNS1123Subdomain(host); len(verrs) > 0 {
+				errs = errs.Also(&apis.FieldError{
+					Message: fmt.Sprintf("invalid value %q", cd.HostRegex),
+					Paths:   []string{"hostRegex"},
+					Details: fmt.Sprintf("Matches %q, which is not a valid host", host),
+				})
+				break
+			}
+		}
+	default:
+		errs = apis.ErrMissingOneOf("host", "hostRegex")
+	}
+	if cd.TLSSecret != nil && cd.TLSSecret.Name == "" {
+		errs = errs.Also(apis.ErrMissingField("tlsSecret.name"))
+	}
+	return errs
+}
+
+// hostField returns the field the hosts of the CustomDomain are set by.
+func (cd *CustomDomain) hostField() string {
+	if cd.HostRegex != "" {
+		return "hostRegex"
+	}
+	return "host"
+}
+
 // Validate verifies that TrafficTarget is properly configured.
 func (tt *TrafficTarget) Validate() *apis.FieldError {
 	var errs *apis.FieldError
-	switch {
-	case tt.RevisionName != "" && tt.ConfigurationName != "":
-		errs = apis.ErrMultipleOneOf("revisionName", "configurationName")
-	case tt.RevisionName != "":
+	set := []string{}
+	if tt.RevisionName != "" {
+		set = append(set, "revisionName")
 		if verrs := validation.IsQualifiedName(tt.RevisionName); len(verrs) > 0 {
-			errs = apis.ErrInvalidKeyName(tt.RevisionName, "revisionName", verrs...)
+			errs = errs.Also(apis.ErrInvalidKeyName(tt.RevisionName, "revisionName", verrs...))
 		}
-	case tt.ConfigurationName != "":
+	}
+	if tt.ConfigurationName != "" {
+		set = append(set, "configurationName")
 		if verrs := validation.IsQualifiedName(tt.ConfigurationName); len(verrs) > 0 {
-			errs = apis.ErrInvalidKeyName(tt.ConfigurationName, "configurationName", verrs...)
+			errs = errs.Also(apis.ErrInvalidKeyName(tt.ConfigurationName, "configurationName", verrs...))
+		}
+	}
+	if tt.ConfigurationSelector != nil {
+		set = append(set, "configurationSelector")
+		errs = errs.Also(validateConfigurationSelector(tt.ConfigurationSelector))
+	}
+	if len(set) > 1 {
+		errs = errs.Also(apis.ErrMultipleOneOf(set...))
+	} else if len(set) == 0 {
+		errs = errs.Also(apis.ErrMissingOneOf("revisionName", "configurationName", "configurationSelector"))
+	}
+	// These are only ever reported in the Route's status.
+	var disallowed []string
+	if tt.ServiceName != "" {
+		disallowed = append(disallowed, "serviceName")
+	}
+	if tt.ImageDigest != "" {
+		disallowed = append(disallowed, "imageDigest")
+	}
+	if len(disallowed) > 0 {
+		errs = errs.Also(apis.ErrDisallowedFields(disallowed...))
+	}
+	if tt.ConfigurationGeneration != nil {
+		switch {
+		case tt.ConfigurationName == "":
+			errs = errs.Also(&apis.FieldError{
+				Message: "Pinning a generation requires a configurationName",
+				Paths:   []string{"configurationGeneration"},
+			})
+		case *tt.ConfigurationGeneration < 1:
+			errs = errs.Also(apis.ErrInvalidValue(
+				strconv.FormatInt(*tt.ConfigurationGeneration, 10), "configurationGeneration"))
+		}
+	}
+	if tt.SplitEvenly {
+		if tt.ConfigurationSelector == nil {
+			errs = errs.Also(&apis.FieldError{
+				Message: "Splitting evenly requires a configurationSelector",
+				Paths:   []string{"splitEvenly"},
+			})
+		}
+		if tt.Mirror {
+			errs = errs.Also(&apis.FieldError{
+				Message: "A mirror target may not be split",
+				Paths:   []string{"splitEvenly"},
+			})
+		} else if tt.Percent == 0 {
+			errs = errs.Also(&apis.FieldError{
+				Message: "Splitting evenly requires a percent to split",
+				Paths:   []string{"splitEvenly"},
+			})
+		}
+	}
+	if tt.Namespace != "" {
+		if tt.ConfigurationSelector != nil {
+			errs = errs.Also(&apis.FieldError{
+				Message: "A configurationSelector only matches Configurations of the Route's namespace",
+				Paths:   []string{"namespace"},
+			})
+		}
+		if verrs := validation.IsDNS1123Label(tt.Namespace); len(verrs) > 0 {
+			errs = errs.Also(apis.ErrInvalidValue(tt.Namespace, "namespace"))
 		}
-	default:
-		errs = apis.ErrMissingOneOf("revisionName", "configurationName")
 	}
 	if tt.Percent < 0 || tt.Percent > 100 {
 		errs = errs.Also(apis.ErrOutOfBoundsValue(strconv.Itoa(tt.Percent), "0", "100", "percent"))
 	}
+	if tt.Mirror && tt.Percent != 0 {
+		errs = errs.Also(&apis.FieldError{
+			Message: "A mirror target may not receive a percentage of the traffic",
+			Paths:   []string{"percent"},
+		})
+	}
+	if len(tt.Match) > 0 && tt.Name == "" {
+		errs = errs.Also(&apis.FieldError{
+			Message: "Header matching requires a named traffic target",
+			Paths:   []string{"name"},
+		})
+	}
+	// Track the first match of each header, as header names are case
+	// insensitive (to detect duplicates).
+	headers := make(map[string]int, len(tt.Match))
+	for i, hm := range tt.Match {
+		errs = errs.Also(hm.Validate().ViaFieldIndex("match", i))
+		if hm.Name == "" {
+			continue
+		}
+		if j, ok := headers[strings.ToLower(hm.Name)]; ok {
+			errs = errs.Also(&apis.FieldError{
+				Message: fmt.Sprintf("Multiple matches for header %q", hm.Name),
+				Paths: []string{
+					fmt.Sprintf("match[%d].name", j),
+					fmt.Sprintf("match[%d].name", i),
+				},
+			})
+		} else {
+			headers[strings.ToLower(hm.Name)] = i
+		}
+	}
+	if tt.PathPrefix != "" {
+		if tt.Name == "" {
+			errs = errs.Also(&apis.FieldError{
+				Message: "Path prefix matching requires a named traffic target",
+				Paths:   []string{"name"},
+			})
+		}
+		if !strings.HasPrefix(tt.PathPrefix, "/") {
+			errs = errs.Also(apis.ErrInvalidValue(tt.PathPrefix, "pathPrefix"))
+		}
+	}
+	if tt.Fault != nil {
+		if tt.Name == "" {
+			errs = errs.Also(&apis.FieldError{
+				Message: "Fault injection requires a named traffic target",
+				Paths:   []string{"name"},
+			})
+		}
+		errs = errs.Also(tt.Fault.Validate().ViaField("fault"))
+	}
+	if tt.Rewrite != nil {
+		if tt.PathPrefix == "" {
+			errs = errs.Also(&apis.FieldError{
+				Message: "Rewriting requests requires a path prefix match",
+				Paths:   []string{"pathPrefix"},
+			})
+		}
+		errs = errs.Also(tt.Rewrite.Validate().ViaField("rewrite"))
+	}
+	if tt.Subset != "" {
+		if verrs := validation.IsDNS1123Label(tt.Subset); len(verrs) > 0 {
+			errs = errs.Also(apis.ErrInvalidValue(tt.Subset, "subset"))
+		}
+	}
+	return errs
+}
+
+// Validate verifies that RewriteSpec is properly configured.
+func (rs *RewriteSpec) Validate() *apis.FieldError {
+	if rs.Authority == "" && rs.URI == "" {
+		return apis.ErrMissingOneOf("authority", "uri")
+	}
+	if rs.URI != "" && !strings.HasPrefix(rs.URI, "/") {
+		return apis.ErrInvalidValue(rs.URI, "uri")
+	}
+	return nil
+}
+
+// Validate verifies that FaultSpec is properly configured.
+func (fs *FaultSpec) Validate() *apis.FieldError {
+	var errs *apis.FieldError
+	if fs.FixedDelay == nil && fs.HTTPStatus == 0 {
+		errs = apis.ErrMissingOneOf("fixedDelay", "httpStatus")
+	}
+	if fs.DelayPercent < 0 || fs.DelayPercent > 100 {
+		errs = errs.Also(apis.ErrOutOfBoundsValue(strconv.Itoa(fs.DelayPercent), "0", "100", "delayPercent"))
+	}
+	if fs.DelayPercent != 0 && fs.FixedDelay == nil {
+		errs = errs.Also(apis.ErrMissingField("fixedDelay"))
+	}
+	if fs.FixedDelay != nil && fs.FixedDelay.Duration < time.Millisecond {
+		errs = errs.Also(apis.ErrInvalidValue(fs.FixedDelay.Duration.String(), "fixedDelay"))
+	}
+	if fs.AbortPercent < 0 || fs.AbortPercent > 100 {
+		errs = errs.Also(apis.ErrOutOfBoundsValue(strconv.Itoa(fs.AbortPercent), "0", "100", "abortPercent"))
+	}
+	if (fs.AbortPercent != 0 || fs.HTTPStatus != 0) && (fs.HTTPStatus < 100 || fs.HTTPStatus > 599) {
+		errs = errs.Also(apis.ErrOutOfBoundsValue(strconv.Itoa(fs.HTTPStatus), "100", "599", "httpStatus"))
+	}
+	return errs
+}
+
+// conflictingPrefixTargets returns whether requests matching both targets'
+// identical path prefix could go to either of two different destinations.
+// Targets telling requests apart by their headers don't conflict.
+func conflictingPrefixTargets(a, b *TrafficTarget) bool {
+	if !equality.Semantic.DeepEqual(a.Match, b.Match) {
+		return false
+	}
+	return a.RevisionName != b.RevisionName ||
+		a.ConfigurationName != b.ConfigurationName ||
+		!equality.Semantic.DeepEqual(a.ConfigurationSelector, b.ConfigurationSelector) ||
+		!equality.Semantic.DeepEqual(a.ConfigurationGeneration, b.ConfigurationGeneration)
+}
+
+// validateConfigurationSelector verifies that a traffic target's
+// ConfigurationSelector can be matched against Configurations.
+func validateConfigurationSelector(ls *metav1.LabelSelector) *apis.FieldError {
+	selector, err := metav1.LabelSelectorAsSelector(ls)
+	if err != nil {
+		return apis.ErrInvalidValue(err.Error(), "configurationSelector")
+	}
+	if selector.Empty() {
+		return &apis.FieldError{
+			Message: "An empty selector matches every Configuration",
+			Paths:   []string{"configurationSelector"},
+		}
+	}
+	return nil
+}
+
+// Validate verifies that HeaderMatch is properly configured.
+func (hm *HeaderMatch) Validate() *apis.FieldError {
+	var errs *apis.FieldError
+	if hm.Name == "" {
+		errs = apis.ErrMissingField("name")
+	}
+	switch {
+	case hm.Exact != "" && hm.Prefix != "":
+		errs = errs.Also(apis.ErrMultipleOneOf("exact", "prefix"))
+	case hm.Exact == "" && hm.Prefix == "":
+		errs = errs.Also(apis.ErrMissingOneOf("exact", "prefix"))
+	}
 	return errs
 }
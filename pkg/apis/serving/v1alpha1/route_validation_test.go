@@ -19,11 +19,16 @@ package v1alpha1
 import (
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
 
 	"github.com/knative/pkg/apis"
+	"github.com/knative/serving/pkg/apis/serving"
+	apitesting "github.com/knative/serving/pkg/apis/testing"
 )
 
 func TestRouteValidation(t *testing.T) {
@@ -72,6 +77,7 @@ func TestRouteValidation(t *testing.T) {
 			Message: "expected exactly one, got neither",
 			Paths: []string{
 				"spec.traffic[0].configurationName",
+				"spec.traffic[0].configurationSelector",
 				"spec.traffic[0].revisionName",
 			},
 		},
@@ -118,6 +124,79 @@ func TestRouteValidation(t *testing.T) {
 			},
 		},
 		want: &apis.FieldError{Message: "Invalid resource name: length must be no more than 63 characters", Paths: []string{"metadata.name"}},
+	}, {
+		name: "valid outlier detection",
+		r: &Route{
+			ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{
+					serving.OutlierDetectionAnnotationKey: "consecutiveErrors=5, interval=10s,baseEjectionTime=30s",
+				},
+			},
+			Spec: RouteSpec{
+				Traffic: []TrafficTarget{{
+					RevisionName: "foo",
+					Percent:      100,
+				}},
+			},
+		},
+		want: nil,
+	}, {
+		name: "invalid outlier detection - unknown setting",
+		r: &Route{
+			ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{
+					serving.OutlierDetectionAnnotationKey: "consecutiveErrors=5,maxEjectionPercent=50",
+				},
+			},
+			Spec: RouteSpec{
+				Traffic: []TrafficTarget{{
+					RevisionName: "foo",
+					Percent:      100,
+				}},
+			},
+		},
+		want: &apis.FieldError{
+			Message: `Invalid serving.knative.dev/outlierDetection annotation value: unknown setting "maxEjectionPercent"`,
+			Paths:   []string{"metadata.annotations.serving.knative.dev/outlierDetection"},
+		},
+	}, {
+		name: "invalid outlier detection - malformed setting",
+		r: &Route{
+			ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{
+					serving.OutlierDetectionAnnotationKey: "consecutiveErrors",
+				},
+			},
+			Spec: RouteSpec{
+				Traffic: []TrafficTarget{{
+					RevisionName: "foo",
+					Percent:      100,
+				}},
+			},
+		},
+		want: &apis.FieldError{
+			Message: `Invalid serving.knative.dev/outlierDetection annotation value: "consecutiveErrors" must be of the form <name>=<value>`,
+			Paths:   []string{"metadata.annotations.serving.knative.dev/outlierDetection"},
+		},
+	}, {
+		name: "invalid outlier detection - bad values",
+		r: &Route{
+			ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{
+					serving.OutlierDetectionAnnotationKey: "interval=500us",
+				},
+			},
+			Spec: RouteSpec{
+				Traffic: []TrafficTarget{{
+					RevisionName: "foo",
+					Percent:      100,
+				}},
+			},
+		},
+		want: &apis.FieldError{
+			Message: "Invalid serving.knative.dev/outlierDetection annotation value: interval must be a duration of at least 1ms",
+			Paths:   []string{"metadata.annotations.serving.knative.dev/outlierDetection"},
+		},
 	}}
 
 	for _, test := range tests {
@@ -130,6 +209,140 @@ func TestRouteValidation(t *testing.T) {
 	}
 }
 
+func TestRouteImmutableFields(t *testing.T) {
+	traffic := []TrafficTarget{{
+		RevisionName: "foo",
+		Percent:      100,
+	}}
+	tests := []struct {
+		name string
+		new  apis.Immutable
+		old  apis.Immutable
+		want *apis.FieldError
+	}{{
+		name: "good (traffic change)",
+		new: &Route{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels: map[string]string{serving.ServiceLabelKey: "foo"},
+			},
+			Spec: RouteSpec{
+				Traffic: []TrafficTarget{{
+					RevisionName: "bar",
+					Percent:      100,
+				}},
+			},
+		},
+		old: &Route{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels: map[string]string{serving.ServiceLabelKey: "foo"},
+			},
+			Spec: RouteSpec{Traffic: traffic},
+		},
+		want: nil,
+	}, {
+		name: "good (other label change)",
+		new: &Route{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels: map[string]string{
+					serving.ServiceLabelKey: "foo",
+					"team":                  "blue",
+				},
+			},
+			Spec: RouteSpec{Traffic: traffic},
+		},
+		old: &Route{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels: map[string]string{serving.ServiceLabelKey: "foo"},
+			},
+			Spec: RouteSpec{Traffic: traffic},
+		},
+		want: nil,
+	}, {
+		name: "good (service label added)",
+		new: &Route{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels: map[string]string{serving.ServiceLabelKey: "foo"},
+			},
+			Spec: RouteSpec{Traffic: traffic},
+		},
+		old: &Route{
+			Spec: RouteSpec{Traffic: traffic},
+		},
+		want: nil,
+	}, {
+		// The API server drops status changes sent with an update of the
+		// Route, and updates of its status subresource skip the webhook,
+		// so a domain edit never reaches this check.
+		name: "good (domain edit left to the status subresource)",
+		new: &Route{
+			Spec:   RouteSpec{Traffic: traffic},
+			Status: RouteStatus{Domain: "bar.default.example.com"},
+		},
+		old: &Route{
+			Spec:   RouteSpec{Traffic: traffic},
+			Status: RouteStatus{Domain: "foo.default.example.com"},
+		},
+		want: nil,
+	}, {
+		name: "bad (type mismatch)",
+		new: &Route{
+			Spec: RouteSpec{Traffic: traffic},
+		},
+		old:  &notARevision{},
+		want: &apis.FieldError{Message: "The provided original was not a Route"},
+	}, {
+		name: "bad (service label change)",
+		new: &Route{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels: map[string]string{serving.ServiceLabelKey: "bar"},
+			},
+			Spec: RouteSpec{Traffic: traffic},
+		},
+		old: &Route{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels: map[string]string{serving.ServiceLabelKey: "foo"},
+			},
+			Spec: RouteSpec{Traffic: traffic},
+		},
+		want: &apis.FieldError{
+			Message: "Immutable fields changed (-old +new)",
+			Paths:   []string{"metadata.labels[serving.knative.dev/service]"},
+			Details: `{string}:
+	-: "foo"
+	+: "bar"
+`,
+		},
+	}, {
+		name: "bad (service label removal)",
+		new: &Route{
+			Spec: RouteSpec{Traffic: traffic},
+		},
+		old: &Route{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels: map[string]string{serving.ServiceLabelKey: "foo"},
+			},
+			Spec: RouteSpec{Traffic: traffic},
+		},
+		want: &apis.FieldError{
+			Message: "Immutable fields changed (-old +new)",
+			Paths:   []string{"metadata.labels[serving.knative.dev/service]"},
+			Details: `{string}:
+	-: "foo"
+	+: ""
+`,
+		},
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := test.new.CheckImmutableFields(test.old)
+			if diff := cmp.Diff(test.want.Error(), got.Error()); diff != "" {
+				t.Errorf("CheckImmutableFields (-want, +got) = %v", diff)
+			}
+		})
+	}
+}
+
 func TestRouteSpecValidation(t *testing.T) {
 	multipleDefinitionError := &apis.FieldError{
 		Message: `Multiple definitions for "foo"`,
@@ -162,6 +375,36 @@ func TestRouteSpecValidation(t *testing.T) {
 			}},
 		},
 		want: nil,
+	}, {
+		name: "valid mirror",
+		rs: &RouteSpec{
+			Traffic: []TrafficTarget{{
+				RevisionName: "foo",
+				Percent:      100,
+			}, {
+				ConfigurationName: "bar",
+				Mirror:            true,
+			}},
+		},
+		want: nil,
+	}, {
+		name: "multiple mirrors",
+		rs: &RouteSpec{
+			Traffic: []TrafficTarget{{
+				RevisionName: "foo",
+				Percent:      100,
+			}, {
+				RevisionName: "bar",
+				Mirror:       true,
+			}, {
+				RevisionName: "baz",
+				Mirror:       true,
+			}},
+		},
+		want: &apis.FieldError{
+			Message: "Multiple mirror targets",
+			Paths:   []string{"traffic[1].mirror", "traffic[2].mirror"},
+		},
 	}, {
 		name: "empty spec",
 		rs:   &RouteSpec{},
@@ -178,6 +421,7 @@ func TestRouteSpecValidation(t *testing.T) {
 			Message: "expected exactly one, got neither",
 			Paths: []string{
 				"traffic[0].configurationName",
+				"traffic[0].configurationSelector",
 				"traffic[0].revisionName",
 			},
 		},
@@ -264,6 +508,220 @@ func TestRouteSpecValidation(t *testing.T) {
 			Message: "Traffic targets sum to 198, want 100",
 			Paths:   []string{"traffic"},
 		},
+	}, {
+		name: "valid rollout",
+		rs: &RouteSpec{
+			Traffic: []TrafficTarget{{
+				ConfigurationName: "foo",
+				Percent:           100,
+			}},
+			RolloutDuration:    &metav1.Duration{Duration: 10 * time.Minute},
+			RolloutStepPercent: 25,
+		},
+		want: nil,
+	}, {
+		name: "negative rollout duration",
+		rs: &RouteSpec{
+			Traffic: []TrafficTarget{{
+				ConfigurationName: "foo",
+				Percent:           100,
+			}},
+			RolloutDuration: &metav1.Duration{Duration: -time.Minute},
+		},
+		want: apis.ErrInvalidValue("-1m0s", "rolloutDuration"),
+	}, {
+		name: "rollout step too high",
+		rs: &RouteSpec{
+			Traffic: []TrafficTarget{{
+				ConfigurationName: "foo",
+				Percent:           100,
+			}},
+			RolloutDuration:    &metav1.Duration{Duration: time.Minute},
+			RolloutStepPercent: 101,
+		},
+		want: apis.ErrOutOfBoundsValue("101", "0", "100", "rolloutStepPercent"),
+	}, {
+		name: "valid custom domains",
+		rs: &RouteSpec{
+			Traffic: []TrafficTarget{{
+				RevisionName: "foo",
+				Percent:      100,
+			}},
+			Domains: []CustomDomain{{
+				Host: "api.mycompany.com",
+				TLSSecret: &corev1.LocalObjectReference{
+					Name: "api-cert",
+				},
+			}, {
+				Host: "www.mycompany.com",
+			}},
+		},
+		want: nil,
+	}, {
+		name: "invalid custom domain",
+		rs: &RouteSpec{
+			Traffic: []TrafficTarget{{
+				RevisionName: "foo",
+				Percent:      100,
+			}},
+			Domains: []CustomDomain{{
+				Host:      "API.mycompany.com",
+				TLSSecret: &corev1.LocalObjectReference{},
+			}, {}},
+		},
+		want: apis.ErrInvalidValue("API.mycompany.com", "domains[0].host").Also(
+			apis.ErrMissingField("domains[0].tlsSecret.name"),
+			apis.ErrMissingOneOf("domains[1].host", "domains[1].hostRegex")),
+	}, {
+		name: "valid custom domain regex",
+		rs: &RouteSpec{
+			Traffic: []TrafficTarget{{
+				RevisionName: "foo",
+				Percent:      100,
+			}},
+			Domains: []CustomDomain{{
+				HostRegex: `^(us|eu)-(east|west)\.mycompany\.com$`,
+			}, {
+				Host: "www.mycompany.com",
+			}},
+		},
+		want: nil,
+	}, {
+		name: "custom domain regex Istio can't match",
+		rs: &RouteSpec{
+			Traffic: []TrafficTarget{{
+				RevisionName: "foo",
+				Percent:      100,
+			}},
+			Domains: []CustomDomain{{
+				HostRegex: `[a-z]+\.mycompany\.com`,
+			}, {
+				HostRegex: `(us|eu\.mycompany\.com`,
+			}, {
+				Host:      "www.mycompany.com",
+				HostRegex: `www\.mycompany\.com`,
+			}},
+		},
+		want: (&apis.FieldError{
+			Message: `invalid value "[a-z]+\\.mycompany\\.com"`,
+			Paths:   []string{"domains[0].hostRegex"},
+			Details: `Istio can't match hosts by regex, so it must match at most 32 hosts: "[a-z]+" matches unboundedly many hosts`,
+		}).Also(&apis.FieldError{
+			Message: `invalid value "(us|eu\\.mycompany\\.com"`,
+			Paths:   []string{"domains[1].hostRegex"},
+			Details: "Istio can't match hosts by regex, so it must match at most 32 hosts: error parsing regexp: missing closing ): `(us|eu\\.mycompany\\.com`",
+		}, apis.ErrMultipleOneOf("domains[2].host", "domains[2].hostRegex")),
+	}, {
+		name: "custom domain regex matching an invalid host",
+		rs: &RouteSpec{
+			Traffic: []TrafficTarget{{
+				RevisionName: "foo",
+				Percent:      100,
+			}},
+			Domains: []CustomDomain{{
+				HostRegex: `(api|_api)\.mycompany\.com`,
+			}},
+		},
+		want: &apis.FieldError{
+			Message: `invalid value "(api|_api)\\.mycompany\\.com"`,
+			Paths:   []string{"domains[0].hostRegex"},
+			Details: `Matches "_api.mycompany.com", which is not a valid host`,
+		},
+	}, {
+		name: "custom domain regex overlapping a host",
+		rs: &RouteSpec{
+			Traffic: []TrafficTarget{{
+				RevisionName: "foo",
+				Percent:      100,
+			}},
+			Domains: []CustomDomain{{
+				Host: "eu.mycompany.com",
+			}, {
+				HostRegex: `(us|eu)\.mycompany\.com`,
+			}},
+		},
+		want: &apis.FieldError{
+			Message: `Multiple definitions for "eu.mycompany.com"`,
+			Paths:   []string{"domains[0].host", "domains[1].hostRegex"},
+		},
+	}, {
+		name: "duplicate custom domains",
+		rs: &RouteSpec{
+			Traffic: []TrafficTarget{{
+				RevisionName: "foo",
+				Percent:      100,
+			}},
+			Domains: []CustomDomain{{
+				Host: "api.mycompany.com",
+			}, {
+				Host: "api.mycompany.com",
+			}},
+		},
+		want: &apis.FieldError{
+			Message: `Multiple definitions for "api.mycompany.com"`,
+			Paths:   []string{"domains[0].host", "domains[1].host"},
+		},
+	}, {
+		name: "redirected HTTP",
+		rs: &RouteSpec{
+			Traffic: []TrafficTarget{{
+				RevisionName: "foo",
+				Percent:      100,
+			}},
+			HTTPOption: HTTPOptionRedirected,
+		},
+		want: nil,
+	}, {
+		name: "invalid HTTP option",
+		rs: &RouteSpec{
+			Traffic: []TrafficTarget{{
+				RevisionName: "foo",
+				Percent:      100,
+			}},
+			HTTPOption: "Disabled",
+		},
+		want: apis.ErrInvalidValue("Disabled", "httpOption"),
+	}, {
+		name: "valid path prefixes",
+		rs: &RouteSpec{
+			Traffic: []TrafficTarget{{
+				RevisionName: "foo",
+				Percent:      100,
+			}, {
+				Name:         "v2",
+				RevisionName: "bar",
+				PathPrefix:   "/v2",
+			}, {
+				Name:         "v2-beta",
+				RevisionName: "baz",
+				PathPrefix:   "/v2",
+				Match: []HeaderMatch{{
+					Name:  "x-beta",
+					Exact: "true",
+				}},
+			}},
+		},
+		want: nil,
+	}, {
+		name: "conflicting path prefixes",
+		rs: &RouteSpec{
+			Traffic: []TrafficTarget{{
+				RevisionName: "foo",
+				Percent:      100,
+			}, {
+				Name:         "v2",
+				RevisionName: "bar",
+				PathPrefix:   "/v2",
+			}, {
+				Name:         "v2-next",
+				RevisionName: "baz",
+				PathPrefix:   "/v2",
+			}},
+		},
+		want: &apis.FieldError{
+			Message: `Multiple targets for path prefix "/v2"`,
+			Paths:   []string{"traffic[1].pathPrefix", "traffic[2].pathPrefix"},
+		},
 	}}
 
 	for _, test := range tests {
@@ -311,6 +769,153 @@ func TestTrafficTargetValidation(t *testing.T) {
 			Percent:           100,
 		},
 		want: nil,
+	}, {
+		name: "valid with configuration generation",
+		tt: &TrafficTarget{
+			ConfigurationName:       "booga",
+			ConfigurationGeneration: apitesting.Int64Ptr(2),
+			Percent:                 100,
+		},
+		want: nil,
+	}, {
+		name: "invalid generation without configuration",
+		tt: &TrafficTarget{
+			RevisionName:            "foo",
+			ConfigurationGeneration: apitesting.Int64Ptr(2),
+			Percent:                 100,
+		},
+		want: &apis.FieldError{
+			Message: "Pinning a generation requires a configurationName",
+			Paths:   []string{"configurationGeneration"},
+		},
+	}, {
+		name: "invalid configuration generation",
+		tt: &TrafficTarget{
+			ConfigurationName:       "booga",
+			ConfigurationGeneration: apitesting.Int64Ptr(0),
+			Percent:                 100,
+		},
+		want: apis.ErrInvalidValue("0", "configurationGeneration"),
+	}, {
+		name: "invalid service name in spec",
+		tt: &TrafficTarget{
+			ConfigurationName: "booga",
+			ServiceName:       "booga",
+			Percent:           100,
+		},
+		want: apis.ErrDisallowedFields("serviceName"),
+	}, {
+		name: "invalid image digest in spec",
+		tt: &TrafficTarget{
+			RevisionName: "booga-00001",
+			ImageDigest:  "gcr.io/repo/image@sha256:deadbeef",
+			Percent:      100,
+		},
+		want: apis.ErrDisallowedFields("imageDigest"),
+	}, {
+		name: "invalid revision name is reported with the other errors",
+		tt: &TrafficTarget{
+			RevisionName:      "b@r",
+			ConfigurationName: "booga",
+			Percent:           100,
+		},
+		want: apis.ErrInvalidKeyName("b@r", "revisionName",
+			validation.IsQualifiedName("b@r")...).Also(
+			apis.ErrMultipleOneOf("revisionName", "configurationName")),
+	}, {
+		name: "valid even split over a configuration selector",
+		tt: &TrafficTarget{
+			ConfigurationSelector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{"env": "preview"},
+			},
+			SplitEvenly: true,
+			Percent:     100,
+		},
+		want: nil,
+	}, {
+		name: "invalid even split without configuration selector",
+		tt: &TrafficTarget{
+			ConfigurationName: "booga",
+			SplitEvenly:       true,
+			Percent:           100,
+		},
+		want: &apis.FieldError{
+			Message: "Splitting evenly requires a configurationSelector",
+			Paths:   []string{"splitEvenly"},
+		},
+	}, {
+		name: "invalid even split of no traffic",
+		tt: &TrafficTarget{
+			Name: "preview",
+			ConfigurationSelector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{"env": "preview"},
+			},
+			SplitEvenly: true,
+		},
+		want: &apis.FieldError{
+			Message: "Splitting evenly requires a percent to split",
+			Paths:   []string{"splitEvenly"},
+		},
+	}, {
+		name: "invalid even split of a mirror",
+		tt: &TrafficTarget{
+			ConfigurationSelector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{"env": "preview"},
+			},
+			SplitEvenly: true,
+			Mirror:      true,
+		},
+		want: &apis.FieldError{
+			Message: "A mirror target may not be split",
+			Paths:   []string{"splitEvenly"},
+		},
+	}, {
+		name: "valid revision in another namespace",
+		tt: &TrafficTarget{
+			RevisionName: "foo",
+			Namespace:    "stable",
+			Percent:      100,
+		},
+		want: nil,
+	}, {
+		name: "invalid namespace",
+		tt: &TrafficTarget{
+			RevisionName: "foo",
+			Namespace:    "Not_A_Namespace",
+			Percent:      100,
+		},
+		want: apis.ErrInvalidValue("Not_A_Namespace", "namespace"),
+	}, {
+		name: "invalid namespace with configuration selector",
+		tt: &TrafficTarget{
+			ConfigurationSelector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{"team": "colors"},
+			},
+			Namespace: "stable",
+			Percent:   100,
+		},
+		want: &apis.FieldError{
+			Message: "A configurationSelector only matches Configurations of the Route's namespace",
+			Paths:   []string{"namespace"},
+		},
+	}, {
+		name: "valid mirror",
+		tt: &TrafficTarget{
+			RevisionName: "foo",
+			Mirror:       true,
+		},
+		want: nil,
+	}, {
+		name: "invalid mirror with percent",
+		tt: &TrafficTarget{
+			RevisionName: "foo",
+			Mirror:       true,
+			Percent:      10,
+		},
+		want: &apis.FieldError{
+			Message: "A mirror target may not receive a percentage of the traffic",
+			Paths:   []string{"percent"},
+		},
 	}, {
 		name: "invalid with both",
 		tt: &TrafficTarget{
@@ -329,8 +934,50 @@ func TestTrafficTargetValidation(t *testing.T) {
 		},
 		want: &apis.FieldError{
 			Message: "expected exactly one, got neither",
-			Paths:   []string{"revisionName", "configurationName"},
+			Paths:   []string{"revisionName", "configurationName", "configurationSelector"},
+		},
+	}, {
+		name: "valid with configuration selector",
+		tt: &TrafficTarget{
+			ConfigurationSelector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{"app": "foo"},
+			},
+			Percent: 100,
+		},
+	}, {
+		name: "invalid with configuration selector and name",
+		tt: &TrafficTarget{
+			ConfigurationName: "bar",
+			ConfigurationSelector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{"app": "foo"},
+			},
+		},
+		want: &apis.FieldError{
+			Message: "expected exactly one, got both",
+			Paths:   []string{"configurationName", "configurationSelector"},
+		},
+	}, {
+		name: "invalid with empty configuration selector",
+		tt: &TrafficTarget{
+			ConfigurationSelector: &metav1.LabelSelector{},
+			Percent:               100,
+		},
+		want: &apis.FieldError{
+			Message: "An empty selector matches every Configuration",
+			Paths:   []string{"configurationSelector"},
 		},
+	}, {
+		name: "invalid configuration selector",
+		tt: &TrafficTarget{
+			ConfigurationSelector: &metav1.LabelSelector{
+				MatchExpressions: []metav1.LabelSelectorRequirement{{
+					Key:      "app",
+					Operator: "Near",
+				}},
+			},
+			Percent: 100,
+		},
+		want: apis.ErrInvalidValue(`"Near" is not a valid pod selector operator`, "configurationSelector"),
 	}, {
 		name: "invalid percent too low",
 		tt: &TrafficTarget{
@@ -345,6 +992,259 @@ func TestTrafficTargetValidation(t *testing.T) {
 			Percent:      101,
 		},
 		want: apis.ErrOutOfBoundsValue("101", "0", "100", "percent"),
+	}, {
+		name: "valid with header match",
+		tt: &TrafficTarget{
+			Name:         "canary",
+			RevisionName: "foo",
+			Match: []HeaderMatch{{
+				Name:  "x-canary",
+				Exact: "true",
+			}},
+		},
+		want: nil,
+	}, {
+		name: "invalid header match without name",
+		tt: &TrafficTarget{
+			RevisionName: "foo",
+			Match: []HeaderMatch{{
+				Name:   "x-canary",
+				Prefix: "t",
+			}},
+		},
+		want: &apis.FieldError{
+			Message: "Header matching requires a named traffic target",
+			Paths:   []string{"name"},
+		},
+	}, {
+		name: "invalid header match missing header name",
+		tt: &TrafficTarget{
+			Name:         "canary",
+			RevisionName: "foo",
+			Match: []HeaderMatch{{
+				Exact: "true",
+			}},
+		},
+		want: apis.ErrMissingField("match[0].name"),
+	}, {
+		name: "invalid header match with exact and prefix",
+		tt: &TrafficTarget{
+			Name:         "canary",
+			RevisionName: "foo",
+			Match: []HeaderMatch{{
+				Name:   "x-canary",
+				Exact:  "true",
+				Prefix: "t",
+			}},
+		},
+		want: apis.ErrMultipleOneOf("match[0].exact", "match[0].prefix"),
+	}, {
+		name: "invalid header match with neither exact nor prefix",
+		tt: &TrafficTarget{
+			Name:         "canary",
+			RevisionName: "foo",
+			Match: []HeaderMatch{{
+				Name: "x-canary",
+			}},
+		},
+		want: apis.ErrMissingOneOf("match[0].exact", "match[0].prefix"),
+	}, {
+		name: "invalid header match of the same header twice",
+		tt: &TrafficTarget{
+			Name:         "canary",
+			RevisionName: "foo",
+			Match: []HeaderMatch{{
+				Name:  "x-canary",
+				Exact: "true",
+			}, {
+				Name:   "X-Canary",
+				Prefix: "t",
+			}},
+		},
+		want: &apis.FieldError{
+			Message: `Multiple matches for header "X-Canary"`,
+			Paths:   []string{"match[0].name", "match[1].name"},
+		},
+	}, {
+		name: "valid header matches of different headers",
+		tt: &TrafficTarget{
+			Name:         "canary",
+			RevisionName: "foo",
+			Match: []HeaderMatch{{
+				Name:  "x-canary",
+				Exact: "true",
+			}, {
+				Name:   "x-user",
+				Prefix: "beta-",
+			}},
+		},
+		want: nil,
+	}, {
+		name: "valid with path prefix",
+		tt: &TrafficTarget{
+			Name:         "v2",
+			RevisionName: "foo",
+			PathPrefix:   "/v2",
+		},
+		want: nil,
+	}, {
+		name: "invalid path prefix without name",
+		tt: &TrafficTarget{
+			RevisionName: "foo",
+			PathPrefix:   "/v2",
+		},
+		want: &apis.FieldError{
+			Message: "Path prefix matching requires a named traffic target",
+			Paths:   []string{"name"},
+		},
+	}, {
+		name: "invalid relative path prefix",
+		tt: &TrafficTarget{
+			Name:         "v2",
+			RevisionName: "foo",
+			PathPrefix:   "v2",
+		},
+		want: apis.ErrInvalidValue("v2", "pathPrefix"),
+	}, {
+		name: "valid with fault",
+		tt: &TrafficTarget{
+			Name:         "chaos",
+			RevisionName: "foo",
+			Fault: &FaultSpec{
+				DelayPercent: 50,
+				FixedDelay:   &metav1.Duration{Duration: 2 * time.Second},
+				AbortPercent: 10,
+				HTTPStatus:   500,
+			},
+		},
+		want: nil,
+	}, {
+		name: "invalid fault without name",
+		tt: &TrafficTarget{
+			RevisionName: "foo",
+			Fault:        &FaultSpec{AbortPercent: 10, HTTPStatus: 500},
+		},
+		want: &apis.FieldError{
+			Message: "Fault injection requires a named traffic target",
+			Paths:   []string{"name"},
+		},
+	}, {
+		name: "invalid empty fault",
+		tt: &TrafficTarget{
+			Name:         "chaos",
+			RevisionName: "foo",
+			Fault:        &FaultSpec{},
+		},
+		want: apis.ErrMissingOneOf("fault.fixedDelay", "fault.httpStatus"),
+	}, {
+		name: "invalid fault percentages",
+		tt: &TrafficTarget{
+			Name:         "chaos",
+			RevisionName: "foo",
+			Fault: &FaultSpec{
+				DelayPercent: 101,
+				FixedDelay:   &metav1.Duration{Duration: time.Second},
+				AbortPercent: -1,
+				HTTPStatus:   503,
+			},
+		},
+		want: apis.ErrOutOfBoundsValue("101", "0", "100", "fault.delayPercent").Also(
+			apis.ErrOutOfBoundsValue("-1", "0", "100", "fault.abortPercent")),
+	}, {
+		name: "invalid fault delay",
+		tt: &TrafficTarget{
+			Name:         "chaos",
+			RevisionName: "foo",
+			Fault: &FaultSpec{
+				DelayPercent: 10,
+				FixedDelay:   &metav1.Duration{Duration: time.Microsecond},
+			},
+		},
+		want: apis.ErrInvalidValue("1µs", "fault.fixedDelay"),
+	}, {
+		name: "invalid fault delay percent without delay",
+		tt: &TrafficTarget{
+			Name:         "chaos",
+			RevisionName: "foo",
+			Fault:        &FaultSpec{DelayPercent: 10, HTTPStatus: 500},
+		},
+		want: apis.ErrMissingField("fault.fixedDelay"),
+	}, {
+		name: "invalid fault abort code",
+		tt: &TrafficTarget{
+			Name:         "chaos",
+			RevisionName: "foo",
+			Fault:        &FaultSpec{AbortPercent: 10, HTTPStatus: 600},
+		},
+		want: apis.ErrOutOfBoundsValue("600", "100", "599", "fault.httpStatus"),
+	}, {
+		name: "invalid fault abort percent without code",
+		tt: &TrafficTarget{
+			Name:         "chaos",
+			RevisionName: "foo",
+			Fault: &FaultSpec{
+				AbortPercent: 10,
+				FixedDelay:   &metav1.Duration{Duration: time.Second},
+			},
+		},
+		want: apis.ErrOutOfBoundsValue("0", "100", "599", "fault.httpStatus"),
+	}, {
+		name: "valid with rewrite",
+		tt: &TrafficTarget{
+			Name:         "legacy",
+			RevisionName: "foo",
+			PathPrefix:   "/legacy",
+			Rewrite: &RewriteSpec{
+				Authority: "legacy.example.com",
+				URI:       "/",
+			},
+		},
+		want: nil,
+	}, {
+		name: "invalid rewrite without path prefix",
+		tt: &TrafficTarget{
+			Name:         "legacy",
+			RevisionName: "foo",
+			Rewrite:      &RewriteSpec{URI: "/"},
+		},
+		want: &apis.FieldError{
+			Message: "Rewriting requests requires a path prefix match",
+			Paths:   []string{"pathPrefix"},
+		},
+	}, {
+		name: "invalid empty rewrite",
+		tt: &TrafficTarget{
+			Name:         "legacy",
+			RevisionName: "foo",
+			PathPrefix:   "/legacy",
+			Rewrite:      &RewriteSpec{},
+		},
+		want: apis.ErrMissingOneOf("rewrite.authority", "rewrite.uri"),
+	}, {
+		name: "invalid rewrite uri",
+		tt: &TrafficTarget{
+			Name:         "legacy",
+			RevisionName: "foo",
+			PathPrefix:   "/legacy",
+			Rewrite:      &RewriteSpec{URI: "v2"},
+		},
+		want: apis.ErrInvalidValue("v2", "rewrite.uri"),
+	}, {
+		name: "valid subset",
+		tt: &TrafficTarget{
+			RevisionName: "foo",
+			Percent:      100,
+			Subset:       "canary",
+		},
+		want: nil,
+	}, {
+		name: "invalid subset",
+		tt: &TrafficTarget{
+			RevisionName: "foo",
+			Percent:      100,
+			Subset:       "Canary_1",
+		},
+		want: apis.ErrInvalidValue("Canary_1", "subset"),
 	}}
 
 	for _, test := range tests {
@@ -50,6 +50,16 @@ type Options struct {
 	ConfigMapWatcher configmap.Watcher
 	Logger           *zap.SugaredLogger
 
+	// PropagatedMetadataPrefix selects the labels and annotations of a
+	// Route that are copied onto the resources generated for it.  Nothing
+	// is copied when it is empty.
+	PropagatedMetadataPrefix string
+
+	// ChildNaming is the strategy naming the placeholder Services of
+	// Routes, see the route/resources/names package.  Routes' own names
+	// are used when it is empty.
+	ChildNaming string
+
 	ResyncPeriod time.Duration
 	StopChannel  <-chan struct{}
 }
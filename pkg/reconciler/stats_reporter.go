@@ -33,6 +33,18 @@ const (
 	ServiceReadyCountN = "service_ready_count"
 	// ServiceReadyLatencyN is the time it takes for a service to become ready since the resource is created.
 	ServiceReadyLatencyN = "service_ready_latency"
+	// RouteReconcileDurationN is the time it takes to reconcile a route.
+	RouteReconcileDurationN = "route_reconcile_duration_seconds"
+	// RouteReconcileTotalN is the number of route reconciles, by result.
+	RouteReconcileTotalN = "route_reconcile_total"
+
+	// ReconcileSuccess is the result of a reconcile that converged.
+	ReconcileSuccess = "success"
+	// ReconcileError is the result of a reconcile that failed.
+	ReconcileError = "error"
+	// ReconcileRequeue is the result of a reconcile that scheduled another
+	// one to finish its work, e.g. to advance a gradual rollout.
+	ReconcileRequeue = "requeue"
 )
 
 var (
@@ -44,9 +56,18 @@ var (
 		ServiceReadyCountN,
 		"Number of services that became ready",
 		stats.UnitDimensionless)
+	routeReconcileDurationStat = stats.Float64(
+		RouteReconcileDurationN,
+		"Time it takes to reconcile a route",
+		"s")
+	routeReconcileTotalStat = stats.Int64(
+		RouteReconcileTotalN,
+		"Number of route reconciles",
+		stats.UnitDimensionless)
 
 	reconcilerTagKey tag.Key
 	keyTagKey        tag.Key
+	resultTagKey     tag.Key
 )
 
 func init() {
@@ -58,6 +79,7 @@ func init() {
 	// - characters are printable US-ASCII
 	reconcilerTagKey = mustNewTagKey("reconciler")
 	keyTagKey = mustNewTagKey("key")
+	resultTagKey = mustNewTagKey("result")
 
 	// Create views to see our measurements. This can return an error if
 	// a previously-registered view has the same name with a different value.
@@ -75,6 +97,20 @@ func init() {
 			Aggregation: view.LastValue(),
 			TagKeys:     []tag.Key{reconcilerTagKey, keyTagKey},
 		},
+		// The route reconcile views are only broken down by result, so
+		// that their cardinality doesn't grow with the number of routes.
+		&view.View{
+			Description: routeReconcileDurationStat.Description(),
+			Measure:     routeReconcileDurationStat,
+			Aggregation: view.Distribution(.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10),
+			TagKeys:     []tag.Key{resultTagKey},
+		},
+		&view.View{
+			Description: routeReconcileTotalStat.Description(),
+			Measure:     routeReconcileTotalStat,
+			Aggregation: view.Count(),
+			TagKeys:     []tag.Key{resultTagKey},
+		},
 	)
 	if err != nil {
 		panic(err)
@@ -85,6 +121,10 @@ func init() {
 type StatsReporter interface {
 	// ReportServiceReady reports the time it took a service to become Ready.
 	ReportServiceReady(namespace, service string, d time.Duration) error
+
+	// ReportRouteReconcile reports how long a route reconcile took, and its
+	// result: one of ReconcileSuccess, ReconcileError or ReconcileRequeue.
+	ReportRouteReconcile(d time.Duration, result string) error
 }
 
 type reporter struct {
@@ -118,6 +158,20 @@ func (r *reporter) ReportServiceReady(namespace, service string, d time.Duration
 	return nil
 }
 
+// ReportRouteReconcile reports how long a route reconcile took, and its result.
+func (r *reporter) ReportRouteReconcile(d time.Duration, result string) error {
+	ctx, err := tag.New(
+		r.ctx,
+		tag.Insert(resultTagKey, result))
+	if err != nil {
+		return err
+	}
+
+	stats.Record(ctx, routeReconcileTotalStat.M(1))
+	stats.Record(ctx, routeReconcileDurationStat.M(d.Seconds()))
+	return nil
+}
+
 func mustNewTagKey(s string) tag.Key {
 	tagKey, err := tag.NewKey(s)
 	if err != nil {
@@ -75,6 +75,30 @@ func TestReporter_ReportDuration(t *testing.T) {
 	checkTags(t, expectedTags, count.Tags)
 }
 
+func TestReporter_ReportRouteReconcile(t *testing.T) {
+	reporter, err := NewStatsReporter(reconcilerMockName)
+	if err != nil {
+		t.Errorf("Failed to create reporter: %v", err)
+	}
+
+	if err = reporter.ReportRouteReconcile(250*time.Millisecond, ReconcileError); err != nil {
+		t.Error(err)
+	}
+	expectedTags := []tag.Tag{{Key: resultTagKey, Value: ReconcileError}}
+
+	duration := getMetric(t, RouteReconcileDurationN)
+	if d := duration.Data.(*view.DistributionData); d.Count != 1 || d.Mean != 0.25 {
+		t.Errorf("expected 1 reconcile lasting 0.25s, got %v lasting %vs", d.Count, d.Mean)
+	}
+	checkTags(t, expectedTags, duration.Tags)
+
+	count := getMetric(t, RouteReconcileTotalN)
+	if v := count.Data.(*view.CountData).Value; v != 1 {
+		t.Errorf("expected count %v, Got %v", 1, v)
+	}
+	checkTags(t, expectedTags, count.Tags)
+}
+
 func getMetric(t *testing.T, metric string) *view.Row {
 	rows, err := view.RetrieveData(metric)
 	if err != nil {
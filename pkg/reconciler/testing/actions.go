@@ -27,6 +27,7 @@ type Actions struct {
 	Updates []clientgotesting.UpdateAction
 	Deletes []clientgotesting.DeleteAction
 	Patches []clientgotesting.PatchAction
+	Gets    []clientgotesting.GetAction
 }
 
 type ActionRecorder interface {
@@ -53,6 +54,9 @@ func (l ActionRecorderList) ActionsByVerb() (Actions, error) {
 			case "patch":
 				a.Patches = append(a.Patches,
 					action.(clientgotesting.PatchAction))
+			case "get":
+				a.Gets = append(a.Gets,
+					action.(clientgotesting.GetAction))
 			case "list": // avoid 'unexpected verb list' error
 			case "watch": // avoid 'unexpected verb watch' error
 			default:
@@ -30,6 +30,7 @@ func TestActionsByVerb(t *testing.T) {
 			newUpdateAction(),
 			newDeleteAction(),
 			newPatchAction(),
+			newGetAction(),
 		},
 		fakeRecorder{
 			newCreateAction(),
@@ -67,6 +68,10 @@ func TestActionsByVerb(t *testing.T) {
 	if got, want := len(actions.Patches), 2; got != want {
 		t.Errorf("patch action count is incorrect got %d - want %d", got, want)
 	}
+
+	if got, want := len(actions.Gets), 1; got != want {
+		t.Errorf("get action count is incorrect got %d - want %d", got, want)
+	}
 }
 
 func TestActionsByVerb_UnrecognizedVerb(t *testing.T) {
@@ -99,6 +104,10 @@ func newPatchAction() clientgotesting.Action {
 	return clientgotesting.NewPatchAction(schema.GroupVersionResource{}, "namespace", "name", nil)
 }
 
+func newGetAction() clientgotesting.Action {
+	return clientgotesting.NewGetAction(schema.GroupVersionResource{}, "namespace", "name")
+}
+
 type fakeRecorder []clientgotesting.Action
 
 func (f fakeRecorder) Actions() []clientgotesting.Action {
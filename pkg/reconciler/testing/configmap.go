@@ -24,7 +24,7 @@ import (
 
 // ConfigMapFromTestFile creates a v1.ConfigMap from a YAML file
 // It loads the YAML file from the testdata folder.
-func ConfigMapFromTestFile(t *testing.T, name string) *corev1.ConfigMap {
+func ConfigMapFromTestFile(t testing.TB, name string) *corev1.ConfigMap {
 	t.Helper()
 
 	b, err := ioutil.ReadFile(fmt.Sprintf("testdata/%s.yaml", name))
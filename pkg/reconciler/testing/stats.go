@@ -23,7 +23,8 @@ import (
 
 // FakeStatsReporter is a fake implementation of StatsReporter
 type FakeStatsReporter struct {
-	servicesReady map[string]int
+	servicesReady   map[string]int
+	routeReconciles map[string]int
 }
 
 func (r *FakeStatsReporter) ReportServiceReady(namespace, service string, d time.Duration) error {
@@ -38,3 +39,15 @@ func (r *FakeStatsReporter) ReportServiceReady(namespace, service string, d time
 func (r *FakeStatsReporter) GetServiceReadyStats() map[string]int {
 	return r.servicesReady
 }
+
+func (r *FakeStatsReporter) ReportRouteReconcile(d time.Duration, result string) error {
+	if r.routeReconciles == nil {
+		r.routeReconciles = make(map[string]int)
+	}
+	r.routeReconciles[result]++
+	return nil
+}
+
+func (r *FakeStatsReporter) GetRouteReconcileStats() map[string]int {
+	return r.routeReconciles
+}
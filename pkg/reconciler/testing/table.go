@@ -66,6 +66,11 @@ type TableRow struct {
 	// WantPatches holds the set of Patch calls we expect during reconciliation.
 	WantPatches []clientgotesting.PatchActionImpl
 
+	// WantGets holds the set of Get calls we expect during reconciliation.
+	// Reconcilers read from their informers' caches, so this is only for
+	// the reads that mustn't see a stale object.
+	WantGets []clientgotesting.GetActionImpl
+
 	// WantEvents holds the set of events we expect during reconciliation.
 	WantEvents []string
 
@@ -258,6 +263,25 @@ func (r *TableRow) Test(t *testing.T, factory Factory) {
 		}
 	}
 
+	for i, want := range r.WantGets {
+		if i >= len(actions.Gets) {
+			t.Errorf("Missing get: %#v", want)
+			continue
+		}
+		got := actions.Gets[i]
+		if got.GetName() != want.GetName() {
+			t.Errorf("Unexpected get[%d]: %#v", i, got)
+		}
+		if !r.SkipNamespaceValidation && got.GetNamespace() != expectedNamespace {
+			t.Errorf("Unexpected get[%d]: %#v", i, got)
+		}
+	}
+	if got, want := len(actions.Gets), len(r.WantGets); got > want {
+		for _, extra := range actions.Gets[want:] {
+			t.Errorf("Extra get: %#v", extra)
+		}
+	}
+
 	gotEvents := eventList.Events()
 	for i, want := range r.WantEvents {
 		if i >= len(gotEvents) {
@@ -32,6 +32,7 @@ import (
 	informers "github.com/knative/serving/pkg/client/informers/externalversions"
 	"github.com/knative/serving/pkg/reconciler"
 	revisionresources "github.com/knative/serving/pkg/reconciler/v1alpha1/revision/resources"
+	"github.com/knative/serving/pkg/reconciler/v1alpha1/revision/resources/names"
 	"github.com/knative/serving/pkg/system"
 	_ "github.com/knative/serving/pkg/system/testing"
 	"go.uber.org/atomic"
@@ -736,11 +737,10 @@ func newTestRevision(namespace string, name string) *v1alpha1.Revision {
 }
 
 func makeEndpoints(rev *v1alpha1.Revision) *corev1.Endpoints {
-	service := revisionresources.MakeK8sService(rev)
 	return &corev1.Endpoints{
 		ObjectMeta: metav1.ObjectMeta{
-			Namespace: service.Namespace,
-			Name:      service.Name,
+			Namespace: rev.Namespace,
+			Name:      names.K8sService(rev),
 		},
 	}
 }
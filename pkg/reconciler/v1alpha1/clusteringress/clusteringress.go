@@ -20,25 +20,31 @@ import (
 	"context"
 	"fmt"
 	"reflect"
+	"strings"
 
 	"github.com/knative/pkg/apis/istio/v1alpha3"
 	istioinformers "github.com/knative/pkg/client/informers/externalversions/istio/v1alpha3"
 	istiolisters "github.com/knative/pkg/client/listers/istio/v1alpha3"
 	"github.com/knative/pkg/configmap"
 	"github.com/knative/pkg/controller"
+	"github.com/knative/pkg/kmp"
 	"github.com/knative/pkg/logging"
 	"github.com/knative/serving/pkg/apis/networking"
 	"github.com/knative/serving/pkg/apis/networking/v1alpha1"
+	"github.com/knative/serving/pkg/apis/serving"
 	informers "github.com/knative/serving/pkg/client/informers/externalversions/networking/v1alpha1"
 	listers "github.com/knative/serving/pkg/client/listers/networking/v1alpha1"
 	"github.com/knative/serving/pkg/reconciler"
 	"github.com/knative/serving/pkg/reconciler/v1alpha1/clusteringress/config"
 	"github.com/knative/serving/pkg/reconciler/v1alpha1/clusteringress/resources"
+	"github.com/knative/serving/pkg/reconciler/v1alpha1/clusteringress/resources/names"
+	"github.com/knative/serving/pkg/system"
 	"go.uber.org/zap"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/equality"
 	apierrs "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/client-go/tools/cache"
 )
 
@@ -60,9 +66,11 @@ type Reconciler struct {
 	*reconciler.Base
 
 	// listers index properties about resources
-	clusterIngressLister listers.ClusterIngressLister
-	virtualServiceLister istiolisters.VirtualServiceLister
-	configStore          configStore
+	clusterIngressLister  listers.ClusterIngressLister
+	virtualServiceLister  istiolisters.VirtualServiceLister
+	destinationRuleLister istiolisters.DestinationRuleLister
+	gatewayLister         istiolisters.GatewayLister
+	configStore           configStore
 }
 
 // Check that our Reconciler implements controller.Reconciler
@@ -74,12 +82,16 @@ func NewController(
 	opt reconciler.Options,
 	clusterIngressInformer informers.ClusterIngressInformer,
 	virtualServiceInformer istioinformers.VirtualServiceInformer,
+	destinationRuleInformer istioinformers.DestinationRuleInformer,
+	gatewayInformer istioinformers.GatewayInformer,
 ) *controller.Impl {
 
 	c := &Reconciler{
-		Base:                 reconciler.NewBase(opt, controllerAgentName),
-		clusterIngressLister: clusterIngressInformer.Lister(),
-		virtualServiceLister: virtualServiceInformer.Lister(),
+		Base:                  reconciler.NewBase(opt, controllerAgentName),
+		clusterIngressLister:  clusterIngressInformer.Lister(),
+		virtualServiceLister:  virtualServiceInformer.Lister(),
+		destinationRuleLister: destinationRuleInformer.Lister(),
+		gatewayLister:         gatewayInformer.Lister(),
 	}
 	impl := controller.NewImpl(c, c.Logger, "ClusterIngresses", reconciler.MustNewStatsReporter("ClusterIngress", c.Logger))
 
@@ -103,6 +115,24 @@ func NewController(
 		},
 	})
 
+	destinationRuleInformer.Informer().AddEventHandler(cache.FilteringResourceEventHandler{
+		FilterFunc: controller.Filter(v1alpha1.SchemeGroupVersion.WithKind("ClusterIngress")),
+		Handler: cache.ResourceEventHandlerFuncs{
+			AddFunc:    impl.EnqueueLabelOfClusterScopedResource(networking.IngressLabelKey),
+			UpdateFunc: controller.PassNew(impl.EnqueueLabelOfClusterScopedResource(networking.IngressLabelKey)),
+			DeleteFunc: impl.EnqueueLabelOfClusterScopedResource(networking.IngressLabelKey),
+		},
+	})
+
+	gatewayInformer.Informer().AddEventHandler(cache.FilteringResourceEventHandler{
+		FilterFunc: controller.Filter(v1alpha1.SchemeGroupVersion.WithKind("ClusterIngress")),
+		Handler: cache.ResourceEventHandlerFuncs{
+			AddFunc:    impl.EnqueueLabelOfClusterScopedResource(networking.IngressLabelKey),
+			UpdateFunc: controller.PassNew(impl.EnqueueLabelOfClusterScopedResource(networking.IngressLabelKey)),
+			DeleteFunc: impl.EnqueueLabelOfClusterScopedResource(networking.IngressLabelKey),
+		},
+	})
+
 	c.Logger.Info("Setting up ConfigMap receivers")
 	resyncIngressesOnIstioConfigChange := configmap.TypeFilter(&config.Istio{})(func(string, interface{}) {
 		impl.GlobalResync(clusterIngressInformer.Informer())
@@ -182,7 +212,25 @@ func (c *Reconciler) reconcile(ctx context.Context, ci *v1alpha1.ClusterIngress)
 	ci.SetDefaults()
 
 	ci.Status.InitializeConditions()
-	vs := resources.MakeVirtualService(ci, gatewayNamesFromContext(ctx, ci))
+	gateways, err := gatewaysFromContext(ctx, ci)
+	if err != nil {
+		// Only a change of the ClusterIngress or of config-istio can fix this.
+		logger.Errorw("Failed to select the Gateways", zap.Error(err))
+		ci.Status.MarkUnknownGateway(ci.Annotations[serving.GatewayAnnotationKey])
+		return nil
+	}
+	gwNames := gatewayNames(gateways)
+
+	// Terminate TLS for the hosts of the ClusterIngress that come with
+	// their own certificates in a Gateway of its own.
+	gw := resources.MakeGateway(ci)
+	if err := c.reconcileGateway(ctx, ci, gw); err != nil {
+		return err
+	}
+	if gw != nil {
+		gwNames = append(gwNames, gw.Name)
+	}
+	vs := resources.MakeVirtualService(ci, gwNames)
 
 	logger.Infof("Reconciling clusterIngress :%v", ci)
 	logger.Info("Creating/Updating VirtualService")
@@ -191,15 +239,113 @@ func (c *Reconciler) reconcile(ctx context.Context, ci *v1alpha1.ClusterIngress)
 		// when error reconciling VirtualService?
 		return err
 	}
+	if err := c.reconcileMeshVirtualService(ctx, ci, resources.MakeMeshVirtualService(ci, gwNames)); err != nil {
+		return err
+	}
+	if err := c.reconcileDestinationRules(ctx, ci, resources.MakeDestinationRules(ci)); err != nil {
+		return err
+	}
+	if err := c.checkSubsets(ci); err != nil {
+		return err
+	}
 	// As underlying network programming (VirtualService now) is stateless,
 	// here we simply mark the ingress as ready if the VirtualService
 	// is successfully synced.
 	ci.Status.MarkNetworkConfigured()
-	ci.Status.MarkLoadBalancerReady(getLBStatus(gatewayServiceURLFromContext(ctx, ci)))
+	ci.Status.MarkLoadBalancerReady(getLBStatus(gatewayServiceURL(gateways)))
 	logger.Info("ClusterIngress successfully synced")
 	return nil
 }
 
+// checkSubsets records in the status of the ClusterIngress whether the
+// DestinationRule subsets its backends refer to are defined.  Traffic sent
+// to an unknown subset fails, but the subset may well be defined later on,
+// so we still route to it.
+func (c *Reconciler) checkSubsets(ci *v1alpha1.ClusterIngress) error {
+	var backends []v1alpha1.ClusterIngressBackend
+	for _, rule := range ci.Spec.Rules {
+		for _, path := range rule.HTTP.Paths {
+			for _, split := range path.Splits {
+				if split.Subset != "" {
+					backends = append(backends, split.ClusterIngressBackend)
+				}
+			}
+			if path.Mirror != nil && path.Mirror.Subset != "" {
+				backends = append(backends, *path.Mirror)
+			}
+		}
+	}
+	if len(backends) == 0 {
+		// Clear up a complaint about subsets no longer referred to.
+		if ci.Status.GetCondition(v1alpha1.ClusterIngressConditionSubsetsKnown) != nil {
+			ci.Status.MarkSubsetsKnown()
+		}
+		return nil
+	}
+
+	drs, err := c.destinationRuleLister.List(labels.Everything())
+	if err != nil {
+		return err
+	}
+	for _, b := range backends {
+		if !subsetDefined(drs, b) {
+			ci.Status.MarkUnknownSubset(reconciler.GetK8sServiceFullname(b.ServiceName, b.ServiceNamespace), b.Subset)
+			return nil
+		}
+	}
+	ci.Status.MarkSubsetsKnown()
+	return nil
+}
+
+// subsetDefined returns whether one of the given DestinationRules defines
+// the subset of the given backend.
+func subsetDefined(drs []*v1alpha3.DestinationRule, b v1alpha1.ClusterIngressBackend) bool {
+	fullname := reconciler.GetK8sServiceFullname(b.ServiceName, b.ServiceNamespace)
+	for _, dr := range drs {
+		if resolvedHost(dr) != fullname {
+			continue
+		}
+		for _, subset := range dr.Spec.Subsets {
+			if subset.Name == b.Subset {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// resolvedHost returns the fully qualified host of the given DestinationRule.
+// Short hosts are resolved in the namespace of the DestinationRule.
+func resolvedHost(dr *v1alpha3.DestinationRule) string {
+	parts := strings.Split(dr.Spec.Host, ".")
+	switch {
+	case len(parts) == 1:
+		return reconciler.GetK8sServiceFullname(parts[0], dr.Namespace)
+	case len(parts) == 2, len(parts) == 3 && parts[2] == "svc":
+		if parts[1] == dr.Namespace {
+			return reconciler.GetK8sServiceFullname(parts[0], parts[1])
+		}
+	}
+	return dr.Spec.Host
+}
+
+// conflictingDestinationRule returns the DestinationRule among the given ones
+// that already configures the host of the desired one, if any.  Istio applies
+// a single DestinationRule to each host, so we must leave those configured by
+// someone else alone, e.g. by a user defining subsets, or by the ClusterIngress
+// of another Route sending traffic to the same Revision.
+func conflictingDestinationRule(drs []*v1alpha3.DestinationRule, desired *v1alpha3.DestinationRule) *v1alpha3.DestinationRule {
+	for _, dr := range drs {
+		if dr.Namespace == desired.Namespace && dr.Name == desired.Name {
+			continue
+		}
+		if resolvedHost(dr) == desired.Spec.Host {
+			return dr
+		}
+	}
+	return nil
+}
+
 func getLBStatus(gatewayServiceURL string) []v1alpha1.LoadBalancerIngressStatus {
 	// The ClusterIngress isn't load-balanced by any particular
 	// Service, but through a Service mesh.
@@ -213,32 +359,43 @@ func getLBStatus(gatewayServiceURL string) []v1alpha1.LoadBalancerIngressStatus
 	}
 }
 
-// gatewayServiceURLFromContext return an address of a load-balancer
-// that the given ClusterIngress is exposed to, or empty string if
-// none.
-func gatewayServiceURLFromContext(ctx context.Context, ci *v1alpha1.ClusterIngress) string {
+// gatewaysFromContext returns the Gateways the given ClusterIngress is
+// exposed through.  These are all the Gateways configured for its
+// visibility, unless it asks for a single one of them, in which case an
+// error is returned if that one isn't configured.
+func gatewaysFromContext(ctx context.Context, ci *v1alpha1.ClusterIngress) ([]config.Gateway, error) {
 	cfg := config.FromContext(ctx).Istio
-	if len(cfg.IngressGateways) > 0 && ci.IsPublic() {
-		return cfg.IngressGateways[0].ServiceURL
+	gateways := cfg.LocalGateways
+	if ci.IsPublic() {
+		gateways = cfg.IngressGateways
+	}
+	name, ok := ci.Annotations[serving.GatewayAnnotationKey]
+	if !ok {
+		return gateways, nil
+	}
+	for _, gw := range gateways {
+		if gw.GatewayName == name {
+			return []config.Gateway{gw}, nil
+		}
 	}
-	if len(cfg.LocalGateways) > 0 && !ci.IsPublic() {
-		return cfg.LocalGateways[0].ServiceURL
+	return nil, fmt.Errorf("gateway %q is not configured", name)
+}
+
+// gatewayServiceURL return an address of a load-balancer that the given
+// Gateways are exposed to, or empty string if none.
+func gatewayServiceURL(gateways []config.Gateway) string {
+	if len(gateways) > 0 {
+		return gateways[0].ServiceURL
 	}
 	return ""
 }
 
-func gatewayNamesFromContext(ctx context.Context, ci *v1alpha1.ClusterIngress) []string {
-	gateways := []string{}
-	if ci.IsPublic() {
-		for _, gw := range config.FromContext(ctx).Istio.IngressGateways {
-			gateways = append(gateways, gw.GatewayName)
-		}
-	} else {
-		for _, gw := range config.FromContext(ctx).Istio.LocalGateways {
-			gateways = append(gateways, gw.GatewayName)
-		}
+func gatewayNames(gateways []config.Gateway) []string {
+	names := []string{}
+	for _, gw := range gateways {
+		names = append(names, gw.GatewayName)
 	}
-	return dedup(gateways)
+	return dedup(names)
 }
 
 func dedup(strs []string) []string {
@@ -277,6 +434,14 @@ func (c *Reconciler) reconcileVirtualService(ctx context.Context, ci *v1alpha1.C
 		ci.Status.MarkResourceNotOwned("VirtualService", name)
 		return fmt.Errorf("ClusterIngress: %q does not own VirtualService: %q", ci.Name, name)
 	} else if !equality.Semantic.DeepEqual(vs.Spec, desired.Spec) {
+		// Diffing is costly, so only do it when it will be logged.
+		if logger.Desugar().Core().Enabled(zap.DebugLevel) {
+			if diff, err := kmp.SafeDiff(desired.Spec, vs.Spec); err != nil {
+				logger.Warnw("Failed to diff VirtualService", zap.Error(err))
+			} else {
+				logger.Debugf("Reconciling VirtualService diff (-desired, +observed): %s", diff)
+			}
+		}
 		// Don't modify the informers copy
 		existing := vs.DeepCopy()
 		existing.Spec = desired.Spec
@@ -291,3 +456,159 @@ func (c *Reconciler) reconcileVirtualService(ctx context.Context, ci *v1alpha1.C
 
 	return nil
 }
+
+// reconcileMeshVirtualService reconciles the VirtualService routing the
+// traffic from within the mesh apart for the ClusterIngress, and deletes it
+// once that traffic is no longer routed apart.
+func (c *Reconciler) reconcileMeshVirtualService(ctx context.Context, ci *v1alpha1.ClusterIngress,
+	desired *v1alpha3.VirtualService) error {
+	if desired != nil {
+		return c.reconcileVirtualService(ctx, ci, desired)
+	}
+	logger := logging.FromContext(ctx)
+	ns := system.Namespace()
+	name := names.MeshVirtualService(ci)
+
+	vs, err := c.virtualServiceLister.VirtualServices(ns).Get(name)
+	if apierrs.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return err
+	} else if !metav1.IsControlledBy(vs, ci) {
+		// Not ours to delete.
+		return nil
+	}
+	if err := c.SharedClientSet.NetworkingV1alpha3().VirtualServices(ns).Delete(name, &metav1.DeleteOptions{}); err != nil {
+		logger.Error("Failed to delete VirtualService", zap.Error(err))
+		return err
+	}
+	c.Recorder.Eventf(ci, corev1.EventTypeNormal, "Deleted",
+		"Deleted VirtualService %q/%q", ns, name)
+	return nil
+}
+
+func (c *Reconciler) reconcileGateway(ctx context.Context, ci *v1alpha1.ClusterIngress,
+	desired *v1alpha3.Gateway) error {
+	logger := logging.FromContext(ctx)
+	ns := system.Namespace()
+	name := names.Gateway(ci)
+
+	gw, err := c.gatewayLister.Gateways(ns).Get(name)
+	if apierrs.IsNotFound(err) {
+		if desired == nil {
+			return nil
+		}
+		if _, err := c.SharedClientSet.NetworkingV1alpha3().Gateways(ns).Create(desired); err != nil {
+			logger.Error("Failed to create Gateway", zap.Error(err))
+			c.Recorder.Eventf(ci, corev1.EventTypeWarning, "CreationFailed",
+				"Failed to create Gateway %q/%q: %v", ns, name, err)
+			return err
+		}
+		c.Recorder.Eventf(ci, corev1.EventTypeNormal, "Created",
+			"Created Gateway %q", name)
+	} else if err != nil {
+		return err
+	} else if !metav1.IsControlledBy(gw, ci) {
+		// Surface an error in the ClusterIngress's status, and return an error.
+		ci.Status.MarkResourceNotOwned("Gateway", name)
+		return fmt.Errorf("ClusterIngress: %q does not own Gateway: %q", ci.Name, name)
+	} else if desired == nil {
+		// The ClusterIngress no longer terminates TLS itself.
+		if err := c.SharedClientSet.NetworkingV1alpha3().Gateways(ns).Delete(name, &metav1.DeleteOptions{}); err != nil {
+			logger.Error("Failed to delete Gateway", zap.Error(err))
+			return err
+		}
+		c.Recorder.Eventf(ci, corev1.EventTypeNormal, "Deleted",
+			"Deleted Gateway %q/%q", ns, name)
+	} else if !equality.Semantic.DeepEqual(gw.Spec, desired.Spec) {
+		// Don't modify the informers copy
+		existing := gw.DeepCopy()
+		existing.Spec = desired.Spec
+		if _, err := c.SharedClientSet.NetworkingV1alpha3().Gateways(ns).Update(existing); err != nil {
+			logger.Error("Failed to update Gateway", zap.Error(err))
+			return err
+		}
+		c.Recorder.Eventf(ci, corev1.EventTypeNormal, "Updated",
+			"Updated Gateway %q/%q", ns, name)
+	}
+	return nil
+}
+
+func (c *Reconciler) reconcileDestinationRules(ctx context.Context, ci *v1alpha1.ClusterIngress,
+	desired []*v1alpha3.DestinationRule) error {
+	logger := logging.FromContext(ctx)
+	ns := system.Namespace()
+
+	all, err := c.destinationRuleLister.List(labels.Everything())
+	if err != nil {
+		return err
+	}
+	var conflict *v1alpha3.DestinationRule
+	wanted := make(map[string]struct{}, len(desired))
+	for _, d := range desired {
+		if other := conflictingDestinationRule(all, d); other != nil {
+			logger.Infof("DestinationRule %s/%s already configures %s", other.Namespace, other.Name, d.Spec.Host)
+			if conflict == nil {
+				conflict = other
+				ci.Status.MarkDestinationRuleConflict(other.Namespace, other.Name, d.Spec.Host)
+			}
+			continue
+		}
+		wanted[d.Name] = struct{}{}
+		dr, err := c.destinationRuleLister.DestinationRules(ns).Get(d.Name)
+		if apierrs.IsNotFound(err) {
+			if _, err := c.SharedClientSet.NetworkingV1alpha3().DestinationRules(ns).Create(d); err != nil {
+				logger.Error("Failed to create DestinationRule", zap.Error(err))
+				c.Recorder.Eventf(ci, corev1.EventTypeWarning, "CreationFailed",
+					"Failed to create DestinationRule %q/%q: %v", ns, d.Name, err)
+				return err
+			}
+			c.Recorder.Eventf(ci, corev1.EventTypeNormal, "Created",
+				"Created DestinationRule %q", d.Name)
+		} else if err != nil {
+			return err
+		} else if !metav1.IsControlledBy(dr, ci) {
+			// Surface an error in the ClusterIngress's status, and return an error.
+			ci.Status.MarkResourceNotOwned("DestinationRule", d.Name)
+			return fmt.Errorf("ClusterIngress: %q does not own DestinationRule: %q", ci.Name, d.Name)
+		} else if !equality.Semantic.DeepEqual(dr.Spec, d.Spec) {
+			// Don't modify the informers copy
+			existing := dr.DeepCopy()
+			existing.Spec = d.Spec
+			if _, err := c.SharedClientSet.NetworkingV1alpha3().DestinationRules(ns).Update(existing); err != nil {
+				logger.Error("Failed to update DestinationRule", zap.Error(err))
+				return err
+			}
+			c.Recorder.Eventf(ci, corev1.EventTypeNormal, "Updated",
+				"Updated DestinationRule %q/%q", ns, d.Name)
+		}
+	}
+
+	switch {
+	case conflict != nil:
+	case len(desired) > 0, ci.Status.GetCondition(v1alpha1.ClusterIngressConditionTrafficPolicyApplied) != nil:
+		// Clear up a complaint about a conflict that went away.
+		ci.Status.MarkTrafficPolicyApplied()
+	}
+
+	// Remove the DestinationRules of backends we no longer route to, or
+	// whose host is now configured by someone else, or of all backends
+	// once session affinity is turned off.
+	existing, err := c.destinationRuleLister.DestinationRules(ns).List(labels.SelectorFromSet(
+		labels.Set{networking.IngressLabelKey: ci.Name}))
+	if err != nil {
+		return err
+	}
+	for _, dr := range existing {
+		if _, ok := wanted[dr.Name]; ok || !metav1.IsControlledBy(dr, ci) {
+			continue
+		}
+		if err := c.SharedClientSet.NetworkingV1alpha3().DestinationRules(ns).Delete(dr.Name, &metav1.DeleteOptions{}); err != nil {
+			logger.Error("Failed to delete DestinationRule", zap.Error(err))
+			return err
+		}
+		c.Recorder.Eventf(ci, corev1.EventTypeNormal, "Deleted",
+			"Deleted DestinationRule %q/%q", ns, dr.Name)
+	}
+	return nil
+}
@@ -17,13 +17,20 @@ limitations under the License.
 package clusteringress
 
 import (
+	"bytes"
 	"context"
+	"fmt"
+	"strings"
 	"testing"
 	"time"
 
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/util/intstr"
 	kubeinformers "k8s.io/client-go/informers"
 	fakekubeclientset "k8s.io/client-go/kubernetes/fake"
@@ -36,6 +43,7 @@ import (
 	"github.com/knative/pkg/configmap"
 	"github.com/knative/pkg/controller"
 	"github.com/knative/pkg/kmeta"
+	"github.com/knative/pkg/logging"
 	"github.com/knative/serving/pkg/apis/networking"
 	"github.com/knative/serving/pkg/apis/networking/v1alpha1"
 	"github.com/knative/serving/pkg/apis/serving"
@@ -147,6 +155,180 @@ func TestReconcile(t *testing.T) {
 			Eventf(corev1.EventTypeNormal, "Created", "Created VirtualService %q", "no-virtualservice-yet"),
 		},
 		Key: "no-virtualservice-yet",
+	}, {
+		Name:                    "cluster-local ingress is only attached to the mesh",
+		SkipNamespaceValidation: true,
+		Objects: []runtime.Object{
+			withClusterLocal(ingress("cluster-local", 1234)),
+		},
+		// No local Gateway is configured, and the public ones are left
+		// out, so only the "mesh" Gateway remains.
+		WantCreates: []metav1.Object{
+			resources.MakeVirtualService(withClusterLocal(ingress("cluster-local", 1234)), []string{}),
+		},
+		WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
+			Object: withClusterLocal(ingressWithStatus("cluster-local", 1234,
+				v1alpha1.IngressStatus{
+					LoadBalancer: &v1alpha1.LoadBalancerStatus{
+						Ingress: []v1alpha1.LoadBalancerIngressStatus{{MeshOnly: true}},
+					},
+					Conditions: readyIngressStatus().Conditions,
+				},
+			)),
+		}},
+		WantEvents: []string{
+			Eventf(corev1.EventTypeNormal, "Created", "Created VirtualService %q", "cluster-local"),
+		},
+		Key: "cluster-local",
+	}, {
+		Name:                    "create separate VirtualServices for the mesh and the gateways",
+		SkipNamespaceValidation: true,
+		Objects: []runtime.Object{
+			withMeshSplit(ingress("mesh-split", 1234)),
+		},
+		WantCreates: []metav1.Object{
+			resources.MakeVirtualService(withMeshSplit(ingress("mesh-split", 1234)),
+				[]string{"knative-shared-gateway", "knative-ingress-gateway"}),
+			resources.MakeMeshVirtualService(withMeshSplit(ingress("mesh-split", 1234)),
+				[]string{"knative-shared-gateway", "knative-ingress-gateway"}),
+		},
+		WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
+			Object: withMeshSplit(ingressWithStatus("mesh-split", 1234, readyIngressStatus())),
+		}},
+		WantEvents: []string{
+			Eventf(corev1.EventTypeNormal, "Created", "Created VirtualService %q", "mesh-split"),
+			Eventf(corev1.EventTypeNormal, "Created", "Created VirtualService %q", "mesh-split-mesh"),
+		},
+		Key: "mesh-split",
+	}, {
+		Name:                    "delete the mesh VirtualService once the mesh isn't routed apart",
+		SkipNamespaceValidation: true,
+		Objects: []runtime.Object{
+			ingress("mesh-split", 1234),
+			resources.MakeVirtualService(ingress("mesh-split", 1234),
+				[]string{"knative-shared-gateway", "knative-ingress-gateway"}),
+			resources.MakeMeshVirtualService(withMeshSplit(ingress("mesh-split", 1234)),
+				[]string{"knative-shared-gateway", "knative-ingress-gateway"}),
+		},
+		WantDeletes: []clientgotesting.DeleteActionImpl{{
+			ActionImpl: clientgotesting.ActionImpl{
+				Namespace: system.Namespace(),
+				Verb:      "delete",
+				Resource: schema.GroupVersionResource{
+					Group:    "networking.istio.io",
+					Version:  "v1alpha3",
+					Resource: "virtualservices",
+				},
+			},
+			Name: "mesh-split-mesh",
+		}},
+		WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
+			Object: ingressWithStatus("mesh-split", 1234, readyIngressStatus()),
+		}},
+		WantEvents: []string{
+			Eventf(corev1.EventTypeNormal, "Deleted", "Deleted VirtualService %q/%q",
+				system.Namespace(), "mesh-split-mesh"),
+		},
+		Key: "mesh-split",
+	}, {
+		Name:                    "create VirtualService with zero-weight destinations",
+		SkipNamespaceValidation: true,
+		Objects: []runtime.Object{
+			withZeroPercentSplit(ingress("zero-percent", 1234)),
+		},
+		WantCreates: []metav1.Object{
+			resources.MakeVirtualService(withZeroPercentSplit(ingress("zero-percent", 1234)),
+				[]string{"knative-shared-gateway", "knative-ingress-gateway"}),
+		},
+		WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
+			Object: withZeroPercentSplit(ingressWithStatus("zero-percent", 1234, readyIngressStatus())),
+		}},
+		WantEvents: []string{
+			Eventf(corev1.EventTypeNormal, "Created", "Created VirtualService %q", "zero-percent"),
+		},
+		Key: "zero-percent",
+	}, {
+		Name:                    "create VirtualService routing to a known subset",
+		SkipNamespaceValidation: true,
+		Objects: []runtime.Object{
+			withSubset(ingress("subset", 1234), "canary"),
+			subsetDestinationRule("test-service", "canary"),
+		},
+		WantCreates: []metav1.Object{
+			resources.MakeVirtualService(withSubset(ingress("subset", 1234), "canary"),
+				[]string{"knative-shared-gateway", "knative-ingress-gateway"}),
+		},
+		WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
+			Object: withSubset(ingressWithStatus("subset", 1234,
+				withSubsetsKnown(readyIngressStatus(), corev1.ConditionTrue, "", "")), "canary"),
+		}},
+		WantEvents: []string{
+			Eventf(corev1.EventTypeNormal, "Created", "Created VirtualService %q", "subset"),
+		},
+		Key: "subset",
+	}, {
+		Name:                    "create VirtualService routing to an unknown subset",
+		SkipNamespaceValidation: true,
+		Objects: []runtime.Object{
+			withSubset(ingress("subset", 1234), "canary"),
+			subsetDestinationRule("test-service", "stable"),
+		},
+		WantCreates: []metav1.Object{
+			resources.MakeVirtualService(withSubset(ingress("subset", 1234), "canary"),
+				[]string{"knative-shared-gateway", "knative-ingress-gateway"}),
+		},
+		WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
+			Object: withSubset(ingressWithStatus("subset", 1234,
+				withSubsetsKnown(readyIngressStatus(), corev1.ConditionFalse, "UnknownSubset",
+					`No DestinationRule for "test-service.test-ns.svc.cluster.local" defines the subset "canary".`)),
+				"canary"),
+		}},
+		WantEvents: []string{
+			Eventf(corev1.EventTypeNormal, "Created", "Created VirtualService %q", "subset"),
+		},
+		Key: "subset",
+	}, {
+		Name:                    "create VirtualService injecting faults",
+		SkipNamespaceValidation: true,
+		Objects: []runtime.Object{
+			withAbortFault(ingress("fault", 1234)),
+		},
+		WantCreates: []metav1.Object{
+			withVirtualServiceFault(resources.MakeVirtualService(ingress("fault", 1234),
+				[]string{"knative-shared-gateway", "knative-ingress-gateway"}),
+				&v1alpha3.HTTPFaultInjection{
+					Abort: &v1alpha3.InjectAbort{
+						Perecent:   10,
+						HttpStatus: 500,
+					},
+				}),
+		},
+		WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
+			Object: withAbortFault(ingressWithStatus("fault", 1234, readyIngressStatus())),
+		}},
+		WantEvents: []string{
+			Eventf(corev1.EventTypeNormal, "Created", "Created VirtualService %q", "fault"),
+		},
+		Key: "fault",
+	}, {
+		Name:                    "create VirtualService rewriting a path prefix",
+		SkipNamespaceValidation: true,
+		Objects: []runtime.Object{
+			withPrefixRewrite(withPathPrefix(ingress("rewrite", 1234), "/legacy")),
+		},
+		WantCreates: []metav1.Object{
+			withVirtualServiceRewrite(resources.MakeVirtualService(withPathPrefix(ingress("rewrite", 1234), "/legacy"),
+				[]string{"knative-shared-gateway", "knative-ingress-gateway"}),
+				&v1alpha3.HTTPRewrite{Uri: "/v2"}),
+		},
+		WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
+			Object: withPrefixRewrite(withPathPrefix(
+				ingressWithStatus("rewrite", 1234, readyIngressStatus()), "/legacy")),
+		}},
+		WantEvents: []string{
+			Eventf(corev1.EventTypeNormal, "Created", "Created VirtualService %q", "rewrite"),
+		},
+		Key: "rewrite",
 	}, {
 		Name:                    "reconcile VirtualService to match desired one",
 		SkipNamespaceValidation: true,
@@ -199,13 +381,337 @@ func TestReconcile(t *testing.T) {
 				system.Namespace(), "reconcile-virtualservice"),
 		},
 		Key: "reconcile-virtualservice",
+	}, {
+		Name:                    "create VirtualService for the requested gateway",
+		SkipNamespaceValidation: true,
+		Objects: []runtime.Object{
+			withGateway(ingress("gateway-selected", 1234), "knative-ingress-gateway"),
+		},
+		WantCreates: []metav1.Object{
+			// Only the requested Gateway, along with the mesh.
+			resources.MakeVirtualService(withGateway(ingress("gateway-selected", 1234), "knative-ingress-gateway"),
+				[]string{"knative-ingress-gateway"}),
+		},
+		WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
+			Object: withGateway(ingressWithStatus("gateway-selected", 1234,
+				v1alpha1.IngressStatus{
+					LoadBalancer: &v1alpha1.LoadBalancerStatus{
+						Ingress: []v1alpha1.LoadBalancerIngressStatus{
+							{DomainInternal: reconciler.GetK8sServiceFullname("istio-ingressgateway", "istio-system")},
+						},
+					},
+					Conditions: duckv1alpha1.Conditions{{
+						Type:     v1alpha1.ClusterIngressConditionLoadBalancerReady,
+						Status:   corev1.ConditionTrue,
+						Severity: "Error",
+					}, {
+						Type:     v1alpha1.ClusterIngressConditionNetworkConfigured,
+						Status:   corev1.ConditionTrue,
+						Severity: "Error",
+					}, {
+						Type:     v1alpha1.ClusterIngressConditionReady,
+						Status:   corev1.ConditionTrue,
+						Severity: "Error",
+					}},
+				},
+			), "knative-ingress-gateway"),
+		}},
+		WantEvents: []string{
+			Eventf(corev1.EventTypeNormal, "Created", "Created VirtualService %q", "gateway-selected"),
+		},
+		Key: "gateway-selected",
+	}, {
+		Name:                    "reject a gateway that isn't configured",
+		SkipNamespaceValidation: true,
+		Objects: []runtime.Object{
+			withGateway(ingress("gateway-unknown", 1234), "not-a-gateway"),
+		},
+		WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
+			Object: withGateway(ingressWithStatus("gateway-unknown", 1234,
+				v1alpha1.IngressStatus{
+					Conditions: duckv1alpha1.Conditions{{
+						Type:     v1alpha1.ClusterIngressConditionLoadBalancerReady,
+						Status:   corev1.ConditionUnknown,
+						Severity: "Error",
+					}, {
+						Type:     v1alpha1.ClusterIngressConditionNetworkConfigured,
+						Status:   corev1.ConditionFalse,
+						Severity: "Error",
+						Reason:   "UnknownGateway",
+						Message:  `Gateway "not-a-gateway" is not configured for this ClusterIngress.`,
+					}, {
+						Type:     v1alpha1.ClusterIngressConditionReady,
+						Status:   corev1.ConditionFalse,
+						Severity: "Error",
+						Reason:   "UnknownGateway",
+						Message:  `Gateway "not-a-gateway" is not configured for this ClusterIngress.`,
+					}},
+				},
+			), "not-a-gateway"),
+		}},
+		Key: "gateway-unknown",
+	}, {
+		Name:                    "create DestinationRules for session affinity",
+		SkipNamespaceValidation: true,
+		Objects: []runtime.Object{
+			withSessionAffinity(ingress("session-affinity", 1234)),
+			resources.MakeVirtualService(withSessionAffinity(ingress("session-affinity", 1234)),
+				[]string{"knative-shared-gateway", "knative-ingress-gateway"}),
+		},
+		WantCreates: []metav1.Object{
+			resources.MakeDestinationRules(withSessionAffinity(ingress("session-affinity", 1234)))[0],
+		},
+		WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
+			Object: withSessionAffinity(ingressWithStatus("session-affinity", 1234,
+				withTrafficPolicyApplied(readyIngressStatus(), corev1.ConditionTrue, "", ""))),
+		}},
+		WantEvents: []string{
+			Eventf(corev1.EventTypeNormal, "Created", "Created DestinationRule %q", "session-affinity-test-service"),
+		},
+		Key: "session-affinity",
+	}, {
+		Name:                    "delete DestinationRules once session affinity is off",
+		SkipNamespaceValidation: true,
+		Objects: []runtime.Object{
+			ingress("session-affinity", 1234),
+			resources.MakeVirtualService(ingress("session-affinity", 1234),
+				[]string{"knative-shared-gateway", "knative-ingress-gateway"}),
+			resources.MakeDestinationRules(withSessionAffinity(ingress("session-affinity", 1234)))[0],
+		},
+		WantDeletes: []clientgotesting.DeleteActionImpl{{
+			ActionImpl: clientgotesting.ActionImpl{
+				Namespace: system.Namespace(),
+				Verb:      "delete",
+				Resource: schema.GroupVersionResource{
+					Group:    "networking.istio.io",
+					Version:  "v1alpha3",
+					Resource: "destinationrules",
+				},
+			},
+			Name: "session-affinity-test-service",
+		}},
+		WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
+			Object: ingressWithStatus("session-affinity", 1234, readyIngressStatus()),
+		}},
+		WantEvents: []string{
+			Eventf(corev1.EventTypeNormal, "Deleted", "Deleted DestinationRule %q/%q",
+				system.Namespace(), "session-affinity-test-service"),
+		},
+		Key: "session-affinity",
+	}, {
+		Name:                    "create DestinationRules for outlier detection",
+		SkipNamespaceValidation: true,
+		Objects: []runtime.Object{
+			withOutlierDetection(ingress("outlier", 1234), "consecutiveErrors=5"),
+			resources.MakeVirtualService(withOutlierDetection(ingress("outlier", 1234), "consecutiveErrors=5"),
+				[]string{"knative-shared-gateway", "knative-ingress-gateway"}),
+		},
+		WantCreates: []metav1.Object{
+			resources.MakeDestinationRules(withOutlierDetection(ingress("outlier", 1234), "consecutiveErrors=5"))[0],
+		},
+		WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
+			Object: withOutlierDetection(ingressWithStatus("outlier", 1234,
+				withTrafficPolicyApplied(readyIngressStatus(), corev1.ConditionTrue, "", "")), "consecutiveErrors=5"),
+		}},
+		WantEvents: []string{
+			Eventf(corev1.EventTypeNormal, "Created", "Created DestinationRule %q", "outlier-test-service"),
+		},
+		Key: "outlier",
+	}, {
+		Name:                    "update DestinationRules when outlier detection changes",
+		SkipNamespaceValidation: true,
+		Objects: []runtime.Object{
+			withOutlierDetection(ingress("outlier", 1234), "consecutiveErrors=3,interval=1s"),
+			resources.MakeVirtualService(withOutlierDetection(ingress("outlier", 1234), "consecutiveErrors=3,interval=1s"),
+				[]string{"knative-shared-gateway", "knative-ingress-gateway"}),
+			resources.MakeDestinationRules(withOutlierDetection(ingress("outlier", 1234), "consecutiveErrors=5"))[0],
+		},
+		WantUpdates: []clientgotesting.UpdateActionImpl{{
+			Object: resources.MakeDestinationRules(withOutlierDetection(ingress("outlier", 1234), "consecutiveErrors=3,interval=1s"))[0],
+		}},
+		WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
+			Object: withOutlierDetection(ingressWithStatus("outlier", 1234,
+				withTrafficPolicyApplied(readyIngressStatus(), corev1.ConditionTrue, "", "")), "consecutiveErrors=3,interval=1s"),
+		}},
+		WantEvents: []string{
+			Eventf(corev1.EventTypeNormal, "Updated", "Updated DestinationRule %q/%q",
+				system.Namespace(), "outlier-test-service"),
+		},
+		Key: "outlier",
+	}, {
+		Name:                    "leave a host configured by another ClusterIngress alone",
+		SkipNamespaceValidation: true,
+		Objects: []runtime.Object{
+			withSessionAffinity(ingress("session-affinity", 1234)),
+			resources.MakeVirtualService(withSessionAffinity(ingress("session-affinity", 1234)),
+				[]string{"knative-shared-gateway", "knative-ingress-gateway"}),
+			// Another Route with session affinity sends traffic to the same Revision.
+			resources.MakeDestinationRules(withSessionAffinity(ingress("other-route", 1234)))[0],
+		},
+		WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
+			Object: withSessionAffinity(ingressWithStatus("session-affinity", 1234,
+				withTrafficPolicyApplied(readyIngressStatus(), corev1.ConditionFalse, "DestinationRuleConflict",
+					fmt.Sprintf(`DestinationRule %q/%q already configures the traffic of "test-service.test-ns.svc.cluster.local".`,
+						system.Namespace(), "other-route-test-service")))),
+		}},
+		Key: "session-affinity",
+	}, {
+		Name:                    "hand a host over to a DestinationRule set up by the user",
+		SkipNamespaceValidation: true,
+		Objects: []runtime.Object{
+			withSessionAffinity(ingressWithStatus("session-affinity", 1234,
+				withTrafficPolicyApplied(readyIngressStatus(), corev1.ConditionTrue, "", ""))),
+			resources.MakeVirtualService(withSessionAffinity(ingress("session-affinity", 1234)),
+				[]string{"knative-shared-gateway", "knative-ingress-gateway"}),
+			resources.MakeDestinationRules(withSessionAffinity(ingress("session-affinity", 1234)))[0],
+			subsetDestinationRule("test-service", "canary"),
+		},
+		WantDeletes: []clientgotesting.DeleteActionImpl{{
+			ActionImpl: clientgotesting.ActionImpl{
+				Namespace: system.Namespace(),
+				Verb:      "delete",
+				Resource: schema.GroupVersionResource{
+					Group:    "networking.istio.io",
+					Version:  "v1alpha3",
+					Resource: "destinationrules",
+				},
+			},
+			Name: "session-affinity-test-service",
+		}},
+		WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
+			Object: withSessionAffinity(ingressWithStatus("session-affinity", 1234,
+				withTrafficPolicyApplied(readyIngressStatus(), corev1.ConditionFalse, "DestinationRuleConflict",
+					`DestinationRule "test-ns"/"test-service" already configures the traffic of "test-service.test-ns.svc.cluster.local".`))),
+		}},
+		WantEvents: []string{
+			Eventf(corev1.EventTypeNormal, "Deleted", "Deleted DestinationRule %q/%q",
+				system.Namespace(), "session-affinity-test-service"),
+		},
+		Key: "session-affinity",
+	}, {
+		Name:                    "leave outlier detection to a DestinationRule defining subsets",
+		SkipNamespaceValidation: true,
+		Objects: []runtime.Object{
+			withOutlierDetection(ingress("outlier", 1234), "consecutiveErrors=5"),
+			resources.MakeVirtualService(withOutlierDetection(ingress("outlier", 1234), "consecutiveErrors=5"),
+				[]string{"knative-shared-gateway", "knative-ingress-gateway"}),
+			subsetDestinationRule("test-service", "canary"),
+		},
+		WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
+			Object: withOutlierDetection(ingressWithStatus("outlier", 1234,
+				withTrafficPolicyApplied(readyIngressStatus(), corev1.ConditionFalse, "DestinationRuleConflict",
+					`DestinationRule "test-ns"/"test-service" already configures the traffic of "test-service.test-ns.svc.cluster.local".`)),
+				"consecutiveErrors=5"),
+		}},
+		Key: "outlier",
+	}, {
+		Name:                    "create Gateway terminating TLS",
+		SkipNamespaceValidation: true,
+		Objects: []runtime.Object{
+			withTLS(ingress("tls", 1234)),
+		},
+		WantCreates: []metav1.Object{
+			resources.MakeGateway(withTLS(ingress("tls", 1234))),
+			resources.MakeVirtualService(withTLS(ingress("tls", 1234)),
+				[]string{"knative-shared-gateway", "knative-ingress-gateway", "tls"}),
+		},
+		WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
+			Object: withTLS(ingressWithStatus("tls", 1234, readyIngressStatus())),
+		}},
+		WantEvents: []string{
+			Eventf(corev1.EventTypeNormal, "Created", "Created Gateway %q", "tls"),
+			Eventf(corev1.EventTypeNormal, "Created", "Created VirtualService %q", "tls"),
+		},
+		Key: "tls",
+	}, {
+		Name:                    "create Gateway redirecting HTTP to HTTPS",
+		SkipNamespaceValidation: true,
+		Objects: []runtime.Object{
+			withHTTPSRedirect(withTLS(ingress("tls", 1234))),
+		},
+		WantCreates: []metav1.Object{
+			resources.MakeGateway(withHTTPSRedirect(withTLS(ingress("tls", 1234)))),
+			resources.MakeVirtualService(withHTTPSRedirect(withTLS(ingress("tls", 1234))),
+				[]string{"knative-shared-gateway", "knative-ingress-gateway", "tls"}),
+		},
+		WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
+			Object: withHTTPSRedirect(withTLS(ingressWithStatus("tls", 1234, readyIngressStatus()))),
+		}},
+		WantEvents: []string{
+			Eventf(corev1.EventTypeNormal, "Created", "Created Gateway %q", "tls"),
+			Eventf(corev1.EventTypeNormal, "Created", "Created VirtualService %q", "tls"),
+		},
+		Key: "tls",
+	}, {
+		Name:                    "update Gateway once HTTP is redirected",
+		SkipNamespaceValidation: true,
+		Objects: []runtime.Object{
+			withHTTPSRedirect(withTLS(ingress("tls", 1234))),
+			resources.MakeGateway(withTLS(ingress("tls", 1234))),
+			resources.MakeVirtualService(withTLS(ingress("tls", 1234)),
+				[]string{"knative-shared-gateway", "knative-ingress-gateway", "tls"}),
+		},
+		// The fake clientset files seeded Gateways under the guessed
+		// resource "gatewaies", so it can't find them to update.
+		WithReactors: []clientgotesting.ReactionFunc{
+			func(action clientgotesting.Action) (bool, runtime.Object, error) {
+				return action.Matches("update", "gateways"), nil, nil
+			},
+		},
+		WantUpdates: []clientgotesting.UpdateActionImpl{{
+			Object: resources.MakeGateway(withHTTPSRedirect(withTLS(ingress("tls", 1234)))),
+		}},
+		WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
+			Object: withHTTPSRedirect(withTLS(ingressWithStatus("tls", 1234, readyIngressStatus()))),
+		}},
+		WantEvents: []string{
+			Eventf(corev1.EventTypeNormal, "Updated", "Updated Gateway %q/%q", system.Namespace(), "tls"),
+		},
+		Key: "tls",
+	}, {
+		Name:                    "delete Gateway once TLS is off",
+		SkipNamespaceValidation: true,
+		Objects: []runtime.Object{
+			ingress("tls", 1234),
+			resources.MakeGateway(withTLS(ingress("tls", 1234))),
+			resources.MakeVirtualService(ingress("tls", 1234),
+				[]string{"knative-shared-gateway", "knative-ingress-gateway"}),
+		},
+		// The fake clientset files seeded Gateways under the guessed
+		// resource "gatewaies", so it can't find them to delete.
+		WithReactors: []clientgotesting.ReactionFunc{
+			func(action clientgotesting.Action) (bool, runtime.Object, error) {
+				return action.Matches("delete", "gateways"), nil, nil
+			},
+		},
+		WantDeletes: []clientgotesting.DeleteActionImpl{{
+			ActionImpl: clientgotesting.ActionImpl{
+				Namespace: system.Namespace(),
+				Verb:      "delete",
+				Resource: schema.GroupVersionResource{
+					Group:    "networking.istio.io",
+					Version:  "v1alpha3",
+					Resource: "gateways",
+				},
+			},
+			Name: "tls",
+		}},
+		WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
+			Object: ingressWithStatus("tls", 1234, readyIngressStatus()),
+		}},
+		WantEvents: []string{
+			Eventf(corev1.EventTypeNormal, "Deleted", "Deleted Gateway %q/%q", system.Namespace(), "tls"),
+		},
+		Key: "tls",
 	}}
 
 	table.Test(t, MakeFactory(func(listers *Listers, opt reconciler.Options) controller.Reconciler {
 		return &Reconciler{
-			Base:                 reconciler.NewBase(opt, controllerAgentName),
-			virtualServiceLister: listers.GetVirtualServiceLister(),
-			clusterIngressLister: listers.GetClusterIngressLister(),
+			Base:                  reconciler.NewBase(opt, controllerAgentName),
+			virtualServiceLister:  listers.GetVirtualServiceLister(),
+			destinationRuleLister: listers.GetDestinationRuleLister(),
+			gatewayLister:         listers.GetGatewayLister(),
+			clusterIngressLister:  listers.GetClusterIngressLister(),
 			configStore: &testConfigStore{
 				config: ReconcilerTestConfig(),
 			},
@@ -224,6 +730,204 @@ func addAnnotations(ing *v1alpha1.ClusterIngress, annos map[string]string) *v1al
 	return ing
 }
 
+func withSessionAffinity(ing *v1alpha1.ClusterIngress) *v1alpha1.ClusterIngress {
+	return addAnnotations(ing, map[string]string{serving.SessionAffinityAnnotationKey: "cookie=session"})
+}
+
+func withOutlierDetection(ing *v1alpha1.ClusterIngress, settings string) *v1alpha1.ClusterIngress {
+	return addAnnotations(ing, map[string]string{serving.OutlierDetectionAnnotationKey: settings})
+}
+
+func withClusterLocal(ing *v1alpha1.ClusterIngress) *v1alpha1.ClusterIngress {
+	ing.Spec.Visibility = v1alpha1.IngressVisibilityClusterLocal
+	return ing
+}
+
+func withTLS(ing *v1alpha1.ClusterIngress) *v1alpha1.ClusterIngress {
+	ing.Spec.TLS = []v1alpha1.ClusterIngressTLS{{
+		Hosts:             []string{"domain.com"},
+		SecretName:        "domain-cert",
+		SecretNamespace:   "test-ns",
+		ServerCertificate: "tls.cert",
+		PrivateKey:        "tls.key",
+	}}
+	return ing
+}
+
+func withHTTPSRedirect(ing *v1alpha1.ClusterIngress) *v1alpha1.ClusterIngress {
+	ing.Spec.HTTPOption = v1alpha1.HTTPOptionRedirected
+	return ing
+}
+
+func withGateway(ing *v1alpha1.ClusterIngress, gateway string) *v1alpha1.ClusterIngress {
+	return addAnnotations(ing, map[string]string{serving.GatewayAnnotationKey: gateway})
+}
+
+// withZeroPercentSplit adds a split receiving no traffic, e.g. a Revision
+// being drained, to the ingress.
+func withZeroPercentSplit(ing *v1alpha1.ClusterIngress) *v1alpha1.ClusterIngress {
+	rules := make([]v1alpha1.ClusterIngressRule, len(ing.Spec.Rules))
+	for i, rule := range ing.Spec.Rules {
+		rules[i] = *rule.DeepCopy()
+	}
+	path := &rules[0].HTTP.Paths[0]
+	path.Splits = append(path.Splits, v1alpha1.ClusterIngressBackendSplit{
+		ClusterIngressBackend: v1alpha1.ClusterIngressBackend{
+			ServiceNamespace: "test-ns",
+			ServiceName:      "previous-service",
+			ServicePort:      intstr.FromInt(80),
+		},
+		Percent: 0,
+	})
+	ing.Spec.Rules = rules
+	return ing
+}
+
+// withMeshSplit has the requests from within the mesh go straight to the
+// backend that the ingress sends the others to through the activator.
+func withMeshSplit(ing *v1alpha1.ClusterIngress) *v1alpha1.ClusterIngress {
+	rules := make([]v1alpha1.ClusterIngressRule, len(ing.Spec.Rules))
+	for i, rule := range ing.Spec.Rules {
+		rules[i] = *rule.DeepCopy()
+	}
+	path := &rules[0].HTTP.Paths[0]
+	path.MeshSplits = path.Splits
+	path.Splits = []v1alpha1.ClusterIngressBackendSplit{{
+		ClusterIngressBackend: v1alpha1.ClusterIngressBackend{
+			ServiceNamespace: "knative-serving",
+			ServiceName:      "activator-service",
+			ServicePort:      intstr.FromInt(80),
+		},
+		Percent: 100,
+	}}
+	ing.Spec.Rules = rules
+	return ing
+}
+
+// withSubset has the ingress send its traffic to the given subset of its
+// backends.
+func withSubset(ing *v1alpha1.ClusterIngress, subset string) *v1alpha1.ClusterIngress {
+	rules := make([]v1alpha1.ClusterIngressRule, len(ing.Spec.Rules))
+	for i, rule := range ing.Spec.Rules {
+		rules[i] = *rule.DeepCopy()
+		for j := range rules[i].HTTP.Paths {
+			for k := range rules[i].HTTP.Paths[j].Splits {
+				rules[i].HTTP.Paths[j].Splits[k].Subset = subset
+			}
+		}
+	}
+	ing.Spec.Rules = rules
+	return ing
+}
+
+// subsetDestinationRule is a DestinationRule set up by the user, defining
+// a subset of the given Service of test-ns.
+func subsetDestinationRule(service, subset string) *v1alpha3.DestinationRule {
+	return &v1alpha3.DestinationRule{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      service,
+			Namespace: "test-ns",
+		},
+		Spec: v1alpha3.DestinationRuleSpec{
+			Host: service,
+			Subsets: []v1alpha3.Subset{{
+				Name:   subset,
+				Labels: map[string]string{"track": subset},
+			}},
+		},
+	}
+}
+
+func withSubsetsKnown(status v1alpha1.IngressStatus, cs corev1.ConditionStatus, reason, message string) v1alpha1.IngressStatus {
+	status.Conditions = append(status.Conditions, duckv1alpha1.Condition{
+		Type:     v1alpha1.ClusterIngressConditionSubsetsKnown,
+		Status:   cs,
+		Reason:   reason,
+		Message:  message,
+		Severity: duckv1alpha1.ConditionSeverityInfo,
+	})
+	return status
+}
+
+func withTrafficPolicyApplied(status v1alpha1.IngressStatus, cs corev1.ConditionStatus, reason, message string) v1alpha1.IngressStatus {
+	status.Conditions = append(status.Conditions, duckv1alpha1.Condition{
+		Type:     v1alpha1.ClusterIngressConditionTrafficPolicyApplied,
+		Status:   cs,
+		Reason:   reason,
+		Message:  message,
+		Severity: duckv1alpha1.ConditionSeverityInfo,
+	})
+	return status
+}
+
+// withAbortFault has the ingress abort 10% of its requests with a 500.
+func withAbortFault(ing *v1alpha1.ClusterIngress) *v1alpha1.ClusterIngress {
+	rules := make([]v1alpha1.ClusterIngressRule, len(ing.Spec.Rules))
+	for i, rule := range ing.Spec.Rules {
+		rules[i] = *rule.DeepCopy()
+	}
+	rules[0].HTTP.Paths[0].Fault = &v1alpha1.HTTPFault{
+		AbortPercent: 10,
+		HTTPStatus:   500,
+	}
+	ing.Spec.Rules = rules
+	return ing
+}
+
+func withVirtualServiceFault(vs *v1alpha3.VirtualService, fault *v1alpha3.HTTPFaultInjection) *v1alpha3.VirtualService {
+	for i := range vs.Spec.Http {
+		vs.Spec.Http[i].Fault = fault
+	}
+	return vs
+}
+
+// withPathPrefix has the ingress only match requests under the given prefix.
+func withPathPrefix(ing *v1alpha1.ClusterIngress, prefix string) *v1alpha1.ClusterIngress {
+	rules := make([]v1alpha1.ClusterIngressRule, len(ing.Spec.Rules))
+	for i, rule := range ing.Spec.Rules {
+		rules[i] = *rule.DeepCopy()
+	}
+	rules[0].HTTP.Paths[0].PathPrefix = prefix
+	ing.Spec.Rules = rules
+	return ing
+}
+
+// withPrefixRewrite has the ingress rewrite its path prefix to /v2.
+func withPrefixRewrite(ing *v1alpha1.ClusterIngress) *v1alpha1.ClusterIngress {
+	ing.Spec.Rules[0].HTTP.Paths[0].Rewrite = &v1alpha1.HTTPRewrite{URI: "/v2"}
+	return ing
+}
+
+func withVirtualServiceRewrite(vs *v1alpha3.VirtualService, rewrite *v1alpha3.HTTPRewrite) *v1alpha3.VirtualService {
+	for i := range vs.Spec.Http {
+		vs.Spec.Http[i].Rewrite = rewrite
+	}
+	return vs
+}
+
+func readyIngressStatus() v1alpha1.IngressStatus {
+	return v1alpha1.IngressStatus{
+		LoadBalancer: &v1alpha1.LoadBalancerStatus{
+			Ingress: []v1alpha1.LoadBalancerIngressStatus{
+				{DomainInternal: reconciler.GetK8sServiceFullname("knative-ingressgateway", "istio-system")},
+			},
+		},
+		Conditions: duckv1alpha1.Conditions{{
+			Type:     v1alpha1.ClusterIngressConditionLoadBalancerReady,
+			Status:   corev1.ConditionTrue,
+			Severity: "Error",
+		}, {
+			Type:     v1alpha1.ClusterIngressConditionNetworkConfigured,
+			Status:   corev1.ConditionTrue,
+			Severity: "Error",
+		}, {
+			Type:     v1alpha1.ClusterIngressConditionReady,
+			Status:   corev1.ConditionTrue,
+			Severity: "Error",
+		}},
+	}
+}
+
 type testConfigStore struct {
 	config *config.Config
 }
@@ -316,6 +1020,8 @@ func newTestSetup(t *testing.T, configs ...*corev1.ConfigMap) (
 		},
 		servingInformer.Networking().V1alpha1().ClusterIngresses(),
 		sharedInformer.Networking().V1alpha3().VirtualServices(),
+		sharedInformer.Networking().V1alpha3().DestinationRules(),
+		sharedInformer.Networking().V1alpha3().Gateways(),
 	)
 
 	rclr = controller.Reconciler.(*Reconciler)
@@ -403,3 +1109,44 @@ func TestGlobalResyncOnUpdateGatewayConfigMap(t *testing.T) {
 		t.Error(err)
 	}
 }
+
+func TestReconcileVirtualServiceLogsDiff(t *testing.T) {
+	ci := ingress("vs-diff", 1234)
+	desired := resources.MakeVirtualService(ci, []string{"knative-shared-gateway"})
+	mutated := desired.DeepCopy()
+	mutated.Spec.Hosts = []string{"mutated.example.com"}
+
+	tests := []struct {
+		name     string
+		existing *v1alpha3.VirtualService
+		wantDiff bool
+	}{{
+		name:     "steady state",
+		existing: desired,
+		wantDiff: false,
+	}, {
+		name:     "mutated",
+		existing: mutated,
+		wantDiff: true,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, sharedClient, _, _, rclr, _, sharedInformer, _, _ := newTestSetup(t)
+			sharedClient.NetworkingV1alpha3().VirtualServices(test.existing.Namespace).Create(test.existing)
+			sharedInformer.Networking().V1alpha3().VirtualServices().Informer().GetIndexer().Add(test.existing)
+
+			var buf bytes.Buffer
+			core := zapcore.NewCore(zapcore.NewJSONEncoder(zap.NewDevelopmentEncoderConfig()),
+				zapcore.AddSync(&buf), zap.DebugLevel)
+			ctx := logging.WithLogger(context.Background(), zap.New(core).Sugar())
+
+			if err := rclr.reconcileVirtualService(ctx, ci, desired); err != nil {
+				t.Fatalf("reconcileVirtualService() = %v", err)
+			}
+			if got := strings.Contains(buf.String(), "VirtualService diff"); got != test.wantDiff {
+				t.Errorf("Logged diff = %v, want %v; logs: %s", got, test.wantDiff, buf.String())
+			}
+		})
+	}
+}
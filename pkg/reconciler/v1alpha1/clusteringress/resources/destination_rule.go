@@ -0,0 +1,175 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/knative/pkg/apis/istio/v1alpha3"
+	"github.com/knative/pkg/kmeta"
+	"github.com/knative/serving/pkg/activator"
+	"github.com/knative/serving/pkg/apis/networking"
+	"github.com/knative/serving/pkg/apis/networking/v1alpha1"
+	"github.com/knative/serving/pkg/apis/serving"
+	"github.com/knative/serving/pkg/reconciler"
+	"github.com/knative/serving/pkg/reconciler/v1alpha1/clusteringress/resources/names"
+	"github.com/knative/serving/pkg/system"
+)
+
+const (
+	sessionAffinityCookiePrefix = "cookie="
+
+	// sessionCookieTTL of zero makes Envoy generate session cookies.
+	sessionCookieTTL = "0s"
+
+	outlierConsecutiveErrorsKey = "consecutiveErrors"
+	outlierIntervalKey          = "interval"
+	outlierBaseEjectionTimeKey  = "baseEjectionTime"
+)
+
+// MakeDestinationRules creates the Istio DestinationRules that configure the
+// traffic policy of each backend of the given ClusterIngress, as requested by
+// its session affinity and outlier detection annotations.  It returns nil
+// when neither is requested.
+func MakeDestinationRules(ci *v1alpha1.ClusterIngress) []*v1alpha3.DestinationRule {
+	cookie := sessionAffinityCookie(ci)
+	outlier := outlierDetection(ci)
+	if cookie == "" && outlier == nil {
+		return nil
+	}
+
+	backends := make(map[string]v1alpha1.ClusterIngressBackend)
+	for _, rule := range ci.Spec.Rules {
+		for _, path := range rule.HTTP.Paths {
+			for _, split := range path.Splits {
+				b := split.ClusterIngressBackend
+				// Traffic to inactive Revisions goes through the shared
+				// activator, which we mustn't reconfigure per ingress.
+				if b.ServiceName == activator.K8sServiceName && b.ServiceNamespace == system.Namespace() {
+					continue
+				}
+				backends[b.ServiceName+"."+b.ServiceNamespace] = b
+			}
+		}
+	}
+	keys := make([]string, 0, len(backends))
+	for k := range backends {
+		keys = append(keys, k)
+	}
+	// Sort the keys to give things a deterministic ordering.
+	sort.Strings(keys)
+
+	drs := make([]*v1alpha3.DestinationRule, 0, len(keys))
+	for _, k := range keys {
+		drs = append(drs, makeDestinationRule(ci, backends[k], cookie, outlier))
+	}
+	return drs
+}
+
+func makeDestinationRule(ci *v1alpha1.ClusterIngress, b v1alpha1.ClusterIngressBackend,
+	cookie string, outlier *v1alpha3.OutlierDetection) *v1alpha3.DestinationRule {
+	policy := &v1alpha3.TrafficPolicy{}
+	if cookie != "" {
+		policy.LoadBalancer = &v1alpha3.LoadBalancerSettings{
+			ConsistentHash: &v1alpha3.ConsistentHashLB{
+				HttpCookie: &v1alpha3.HTTPCookie{
+					Name: cookie,
+					Ttl:  sessionCookieTTL,
+				},
+			},
+		}
+	}
+	if outlier != nil {
+		// Copy so that the rules of different backends don't share state.
+		o := *outlier
+		policy.OutlierDetection = &o
+	}
+	return &v1alpha3.DestinationRule{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            names.DestinationRule(ci, b.ServiceName),
+			Namespace:       system.Namespace(),
+			OwnerReferences: []metav1.OwnerReference{*kmeta.NewControllerRef(ci)},
+			Labels: map[string]string{
+				networking.IngressLabelKey:     ci.Name,
+				serving.RouteLabelKey:          ci.Labels[serving.RouteLabelKey],
+				serving.RouteNamespaceLabelKey: ci.Labels[serving.RouteNamespaceLabelKey],
+			},
+		},
+		Spec: v1alpha3.DestinationRuleSpec{
+			Host:          reconciler.GetK8sServiceFullname(b.ServiceName, b.ServiceNamespace),
+			TrafficPolicy: policy,
+		},
+	}
+}
+
+// sessionAffinityCookie returns the name of the cookie to hash on, or empty
+// string if the ClusterIngress doesn't ask for cookie based session affinity.
+func sessionAffinityCookie(ci *v1alpha1.ClusterIngress) string {
+	value := ci.Annotations[serving.SessionAffinityAnnotationKey]
+	if !strings.HasPrefix(value, sessionAffinityCookiePrefix) {
+		return ""
+	}
+	return strings.TrimPrefix(value, sessionAffinityCookiePrefix)
+}
+
+// outlierDetection returns the outlier detection settings requested by the
+// ClusterIngress, or nil if it doesn't ask for any.  The settings are checked
+// when the Route is validated, but those of Routes created before that are
+// skipped here when malformed, leaving Istio to apply its defaults for them.
+func outlierDetection(ci *v1alpha1.ClusterIngress) *v1alpha3.OutlierDetection {
+	value := ci.Annotations[serving.OutlierDetectionAnnotationKey]
+	if value == "" {
+		return nil
+	}
+	od := &v1alpha3.OutlierDetection{}
+	for _, setting := range strings.Split(value, ",") {
+		parts := strings.SplitN(strings.TrimSpace(setting), "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		switch parts[0] {
+		case outlierConsecutiveErrorsKey:
+			if n, err := strconv.ParseInt(parts[1], 10, 32); err == nil && n > 0 {
+				od.ConsecutiveErrors = int32(n)
+			}
+		case outlierIntervalKey:
+			if validEjectionDuration(parts[1]) {
+				od.Interval = parts[1]
+			}
+		case outlierBaseEjectionTimeKey:
+			if validEjectionDuration(parts[1]) {
+				od.BaseEjectionTime = parts[1]
+			}
+		}
+	}
+	if *od == (v1alpha3.OutlierDetection{}) {
+		return nil
+	}
+	return od
+}
+
+// validEjectionDuration reports whether Istio accepts the given duration for
+// the outlier detection interval and ejection time, which must be >=1ms.
+func validEjectionDuration(s string) bool {
+	d, err := time.ParseDuration(s)
+	return err == nil && d >= time.Millisecond
+}
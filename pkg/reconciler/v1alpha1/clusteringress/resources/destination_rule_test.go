@@ -0,0 +1,195 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/knative/pkg/apis/istio/v1alpha3"
+	"github.com/knative/pkg/kmeta"
+	"github.com/knative/serving/pkg/activator"
+	"github.com/knative/serving/pkg/apis/networking"
+	"github.com/knative/serving/pkg/apis/networking/v1alpha1"
+	"github.com/knative/serving/pkg/apis/serving"
+	"github.com/knative/serving/pkg/system"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func TestMakeDestinationRules(t *testing.T) {
+	split := func(name, ns string) v1alpha1.ClusterIngressBackendSplit {
+		return v1alpha1.ClusterIngressBackendSplit{
+			ClusterIngressBackend: v1alpha1.ClusterIngressBackend{
+				ServiceNamespace: ns,
+				ServiceName:      name,
+				ServicePort:      intstr.FromInt(80),
+			},
+			Percent: 50,
+		}
+	}
+	ingress := func(annotations map[string]string) *v1alpha1.ClusterIngress {
+		ci := &v1alpha1.ClusterIngress{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "test-ingress",
+				Labels: map[string]string{
+					serving.RouteLabelKey:          "test-route",
+					serving.RouteNamespaceLabelKey: "test-ns",
+				},
+			},
+			Spec: v1alpha1.IngressSpec{
+				Rules: []v1alpha1.ClusterIngressRule{{
+					Hosts: []string{"domain.com"},
+					HTTP: &v1alpha1.HTTPClusterIngressRuleValue{
+						Paths: []v1alpha1.HTTPClusterIngressPath{{
+							Splits: []v1alpha1.ClusterIngressBackendSplit{
+								split("v2-service", "test-ns"),
+								split("v1-service", "test-ns"),
+							},
+						}},
+					},
+				}, {
+					Hosts: []string{"v1.domain.com"},
+					HTTP: &v1alpha1.HTTPClusterIngressRuleValue{
+						Paths: []v1alpha1.HTTPClusterIngressPath{{
+							Splits: []v1alpha1.ClusterIngressBackendSplit{
+								split("v1-service", "test-ns"),
+								split(activator.K8sServiceName, system.Namespace()),
+							},
+						}},
+					},
+				}},
+			},
+		}
+		ci.Annotations = annotations
+		return ci
+	}
+	affinity := func(value string) map[string]string {
+		return map[string]string{serving.SessionAffinityAnnotationKey: value}
+	}
+	outlier := func(value string) map[string]string {
+		return map[string]string{serving.OutlierDetectionAnnotationKey: value}
+	}
+	cookiePolicy := &v1alpha3.TrafficPolicy{
+		LoadBalancer: &v1alpha3.LoadBalancerSettings{
+			ConsistentHash: &v1alpha3.ConsistentHashLB{
+				HttpCookie: &v1alpha3.HTTPCookie{
+					Name: "session",
+					Ttl:  "0s",
+				},
+			},
+		},
+	}
+	rule := func(ci *v1alpha1.ClusterIngress, name string, policy *v1alpha3.TrafficPolicy) *v1alpha3.DestinationRule {
+		return &v1alpha3.DestinationRule{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            "test-ingress-" + name,
+				Namespace:       system.Namespace(),
+				OwnerReferences: []metav1.OwnerReference{*kmeta.NewControllerRef(ci)},
+				Labels: map[string]string{
+					networking.IngressLabelKey:     "test-ingress",
+					serving.RouteLabelKey:          "test-route",
+					serving.RouteNamespaceLabelKey: "test-ns",
+				},
+			},
+			Spec: v1alpha3.DestinationRuleSpec{
+				Host:          name + ".test-ns.svc.cluster.local",
+				TrafficPolicy: policy,
+			},
+		}
+	}
+
+	tests := []struct {
+		name string
+		ci   *v1alpha1.ClusterIngress
+		want func(*v1alpha1.ClusterIngress) []*v1alpha3.DestinationRule
+	}{{
+		name: "no annotation",
+		ci:   ingress(nil),
+		want: func(*v1alpha1.ClusterIngress) []*v1alpha3.DestinationRule { return nil },
+	}, {
+		name: "unsupported affinity",
+		ci:   ingress(affinity("sourceIP")),
+		want: func(*v1alpha1.ClusterIngress) []*v1alpha3.DestinationRule { return nil },
+	}, {
+		name: "cookie affinity",
+		ci:   ingress(affinity("cookie=session")),
+		want: func(ci *v1alpha1.ClusterIngress) []*v1alpha3.DestinationRule {
+			return []*v1alpha3.DestinationRule{
+				rule(ci, "v1-service", cookiePolicy),
+				rule(ci, "v2-service", cookiePolicy),
+			}
+		},
+	}, {
+		name: "outlier detection",
+		ci:   ingress(outlier("consecutiveErrors=7, interval=5m,baseEjectionTime=15m")),
+		want: func(ci *v1alpha1.ClusterIngress) []*v1alpha3.DestinationRule {
+			policy := &v1alpha3.TrafficPolicy{
+				OutlierDetection: &v1alpha3.OutlierDetection{
+					ConsecutiveErrors: 7,
+					Interval:          "5m",
+					BaseEjectionTime:  "15m",
+				},
+			}
+			return []*v1alpha3.DestinationRule{
+				rule(ci, "v1-service", policy),
+				rule(ci, "v2-service", policy),
+			}
+		},
+	}, {
+		name: "malformed outlier detection settings are ignored",
+		ci:   ingress(outlier("consecutiveErrors=lots,interval=0s,baseEjectionTime=30s,maxEjectionPercent=50,bogus")),
+		want: func(ci *v1alpha1.ClusterIngress) []*v1alpha3.DestinationRule {
+			policy := &v1alpha3.TrafficPolicy{
+				OutlierDetection: &v1alpha3.OutlierDetection{
+					BaseEjectionTime: "30s",
+				},
+			}
+			return []*v1alpha3.DestinationRule{
+				rule(ci, "v1-service", policy),
+				rule(ci, "v2-service", policy),
+			}
+		},
+	}, {
+		name: "no valid outlier detection settings",
+		ci:   ingress(outlier("consecutiveErrors=-1,interval=soon")),
+		want: func(*v1alpha1.ClusterIngress) []*v1alpha3.DestinationRule { return nil },
+	}, {
+		name: "cookie affinity with outlier detection",
+		ci: ingress(map[string]string{
+			serving.SessionAffinityAnnotationKey:  "cookie=session",
+			serving.OutlierDetectionAnnotationKey: "consecutiveErrors=3",
+		}),
+		want: func(ci *v1alpha1.ClusterIngress) []*v1alpha3.DestinationRule {
+			policy := cookiePolicy.DeepCopy()
+			policy.OutlierDetection = &v1alpha3.OutlierDetection{ConsecutiveErrors: 3}
+			return []*v1alpha3.DestinationRule{
+				rule(ci, "v1-service", policy),
+				rule(ci, "v2-service", policy),
+			}
+		},
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := MakeDestinationRules(test.ci)
+			if diff := cmp.Diff(test.want(test.ci), got); diff != "" {
+				t.Errorf("MakeDestinationRules (-want, +got) = %v", diff)
+			}
+		})
+	}
+}
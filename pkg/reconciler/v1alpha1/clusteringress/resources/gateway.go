@@ -0,0 +1,100 @@
+/*
+Copyright 2019 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"fmt"
+	"path"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/knative/pkg/apis/istio/v1alpha3"
+	"github.com/knative/pkg/kmeta"
+	"github.com/knative/serving/pkg/apis/networking"
+	"github.com/knative/serving/pkg/apis/networking/v1alpha1"
+	"github.com/knative/serving/pkg/apis/serving"
+	"github.com/knative/serving/pkg/reconciler/v1alpha1/clusteringress/resources/names"
+	"github.com/knative/serving/pkg/system"
+)
+
+// TLSCertificatesPath is where the Istio ingress gateway is expected to
+// mount the Secrets referenced by ClusterIngress TLS settings, each in a
+// directory named <namespace>-<name> after its Secret.
+const TLSCertificatesPath = "/etc/istio/ingressgateway-certs"
+
+// ingressGatewaySelector selects the pods of the Istio ingress gateway
+// behind knative-ingress-gateway.
+var ingressGatewaySelector = map[string]string{"istio": "ingressgateway"}
+
+// MakeGateway creates an Istio Gateway terminating TLS for the hosts of the
+// given ClusterIngress, with the certificates of its TLS settings.  When the
+// ClusterIngress asks for it, the Gateway also redirects plain HTTP requests
+// for those hosts to HTTPS.  It returns nil when the ClusterIngress has no
+// TLS settings, or isn't exposed outside of the cluster.
+func MakeGateway(ci *v1alpha1.ClusterIngress) *v1alpha3.Gateway {
+	if len(ci.Spec.TLS) == 0 || !ci.IsPublic() {
+		return nil
+	}
+	servers := make([]v1alpha3.Server, 0, 2*len(ci.Spec.TLS))
+	for i, tls := range ci.Spec.TLS {
+		dir := path.Join(TLSCertificatesPath, tls.SecretNamespace+"-"+tls.SecretName)
+		servers = append(servers, v1alpha3.Server{
+			Port: v1alpha3.Port{
+				Number:   443,
+				Name:     fmt.Sprintf("https-%d", i),
+				Protocol: v1alpha3.ProtocolHTTPS,
+			},
+			Hosts: tls.Hosts,
+			TLS: &v1alpha3.TLSOptions{
+				Mode:              v1alpha3.TLSModeSimple,
+				ServerCertificate: path.Join(dir, tls.ServerCertificate),
+				PrivateKey:        path.Join(dir, tls.PrivateKey),
+			},
+		})
+	}
+	if ci.Spec.HTTPOption == v1alpha1.HTTPOptionRedirected {
+		for i, tls := range ci.Spec.TLS {
+			servers = append(servers, v1alpha3.Server{
+				Port: v1alpha3.Port{
+					Number:   80,
+					Name:     fmt.Sprintf("http-%d", i),
+					Protocol: v1alpha3.ProtocolHTTP,
+				},
+				Hosts: tls.Hosts,
+				TLS: &v1alpha3.TLSOptions{
+					HttpsRedirect: true,
+				},
+			})
+		}
+	}
+	return &v1alpha3.Gateway{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            names.Gateway(ci),
+			Namespace:       system.Namespace(),
+			OwnerReferences: []metav1.OwnerReference{*kmeta.NewControllerRef(ci)},
+			Labels: map[string]string{
+				networking.IngressLabelKey:     ci.Name,
+				serving.RouteLabelKey:          ci.Labels[serving.RouteLabelKey],
+				serving.RouteNamespaceLabelKey: ci.Labels[serving.RouteNamespaceLabelKey],
+			},
+		},
+		Spec: v1alpha3.GatewaySpec{
+			Selector: ingressGatewaySelector,
+			Servers:  servers,
+		},
+	}
+}
@@ -0,0 +1,181 @@
+/*
+Copyright 2019 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/knative/pkg/apis/istio/v1alpha3"
+	"github.com/knative/pkg/kmeta"
+	"github.com/knative/serving/pkg/apis/networking"
+	"github.com/knative/serving/pkg/apis/networking/v1alpha1"
+	"github.com/knative/serving/pkg/apis/serving"
+	"github.com/knative/serving/pkg/system"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestMakeGateway(t *testing.T) {
+	ingress := func(visibility v1alpha1.IngressVisibility, tls ...v1alpha1.ClusterIngressTLS) *v1alpha1.ClusterIngress {
+		return &v1alpha1.ClusterIngress{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "test-ingress",
+				Labels: map[string]string{
+					serving.RouteLabelKey:          "test-route",
+					serving.RouteNamespaceLabelKey: "test-ns",
+				},
+			},
+			Spec: v1alpha1.IngressSpec{
+				TLS:        tls,
+				Visibility: visibility,
+			},
+		}
+	}
+	redirected := func(ci *v1alpha1.ClusterIngress) *v1alpha1.ClusterIngress {
+		ci.Spec.HTTPOption = v1alpha1.HTTPOptionRedirected
+		return ci
+	}
+	tls := func(host, secret string) v1alpha1.ClusterIngressTLS {
+		return v1alpha1.ClusterIngressTLS{
+			Hosts:             []string{host},
+			SecretName:        secret,
+			SecretNamespace:   "test-ns",
+			ServerCertificate: "tls.cert",
+			PrivateKey:        "tls.key",
+		}
+	}
+
+	tests := []struct {
+		name string
+		ci   *v1alpha1.ClusterIngress
+		want []v1alpha3.Server
+	}{{
+		name: "no TLS",
+		ci:   ingress(v1alpha1.IngressVisibilityExternalIP),
+	}, {
+		name: "cluster local",
+		ci:   ingress(v1alpha1.IngressVisibilityClusterLocal, tls("foo.com", "foo-cert")),
+	}, {
+		name: "several hosts",
+		ci: ingress(v1alpha1.IngressVisibilityExternalIP,
+			tls("foo.com", "foo-cert"), tls("bar.com", "bar-cert")),
+		want: []v1alpha3.Server{{
+			Port: v1alpha3.Port{
+				Number:   443,
+				Name:     "https-0",
+				Protocol: v1alpha3.ProtocolHTTPS,
+			},
+			Hosts: []string{"foo.com"},
+			TLS: &v1alpha3.TLSOptions{
+				Mode:              v1alpha3.TLSModeSimple,
+				ServerCertificate: "/etc/istio/ingressgateway-certs/test-ns-foo-cert/tls.cert",
+				PrivateKey:        "/etc/istio/ingressgateway-certs/test-ns-foo-cert/tls.key",
+			},
+		}, {
+			Port: v1alpha3.Port{
+				Number:   443,
+				Name:     "https-1",
+				Protocol: v1alpha3.ProtocolHTTPS,
+			},
+			Hosts: []string{"bar.com"},
+			TLS: &v1alpha3.TLSOptions{
+				Mode:              v1alpha3.TLSModeSimple,
+				ServerCertificate: "/etc/istio/ingressgateway-certs/test-ns-bar-cert/tls.cert",
+				PrivateKey:        "/etc/istio/ingressgateway-certs/test-ns-bar-cert/tls.key",
+			},
+		}},
+	}, {
+		name: "redirect to HTTPS",
+		ci: redirected(ingress(v1alpha1.IngressVisibilityExternalIP,
+			tls("foo.com", "foo-cert"), tls("bar.com", "bar-cert"))),
+		want: []v1alpha3.Server{{
+			Port: v1alpha3.Port{
+				Number:   443,
+				Name:     "https-0",
+				Protocol: v1alpha3.ProtocolHTTPS,
+			},
+			Hosts: []string{"foo.com"},
+			TLS: &v1alpha3.TLSOptions{
+				Mode:              v1alpha3.TLSModeSimple,
+				ServerCertificate: "/etc/istio/ingressgateway-certs/test-ns-foo-cert/tls.cert",
+				PrivateKey:        "/etc/istio/ingressgateway-certs/test-ns-foo-cert/tls.key",
+			},
+		}, {
+			Port: v1alpha3.Port{
+				Number:   443,
+				Name:     "https-1",
+				Protocol: v1alpha3.ProtocolHTTPS,
+			},
+			Hosts: []string{"bar.com"},
+			TLS: &v1alpha3.TLSOptions{
+				Mode:              v1alpha3.TLSModeSimple,
+				ServerCertificate: "/etc/istio/ingressgateway-certs/test-ns-bar-cert/tls.cert",
+				PrivateKey:        "/etc/istio/ingressgateway-certs/test-ns-bar-cert/tls.key",
+			},
+		}, {
+			Port: v1alpha3.Port{
+				Number:   80,
+				Name:     "http-0",
+				Protocol: v1alpha3.ProtocolHTTP,
+			},
+			Hosts: []string{"foo.com"},
+			TLS:   &v1alpha3.TLSOptions{HttpsRedirect: true},
+		}, {
+			Port: v1alpha3.Port{
+				Number:   80,
+				Name:     "http-1",
+				Protocol: v1alpha3.ProtocolHTTP,
+			},
+			Hosts: []string{"bar.com"},
+			TLS:   &v1alpha3.TLSOptions{HttpsRedirect: true},
+		}},
+	}, {
+		name: "redirect without TLS",
+		ci:   redirected(ingress(v1alpha1.IngressVisibilityExternalIP)),
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := MakeGateway(test.ci)
+			if test.want == nil {
+				if got != nil {
+					t.Errorf("MakeGateway() = %v, wanted nil", got)
+				}
+				return
+			}
+			want := &v1alpha3.Gateway{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:            "test-ingress",
+					Namespace:       system.Namespace(),
+					OwnerReferences: []metav1.OwnerReference{*kmeta.NewControllerRef(test.ci)},
+					Labels: map[string]string{
+						networking.IngressLabelKey:     "test-ingress",
+						serving.RouteLabelKey:          "test-route",
+						serving.RouteNamespaceLabelKey: "test-ns",
+					},
+				},
+				Spec: v1alpha3.GatewaySpec{
+					Selector: map[string]string{"istio": "ingressgateway"},
+					Servers:  test.want,
+				},
+			}
+			if diff := cmp.Diff(want, got); diff != "" {
+				t.Errorf("Unexpected Gateway (-want +got): %v", diff)
+			}
+		})
+	}
+}
@@ -17,10 +17,44 @@ limitations under the License.
 package names
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+
 	"github.com/knative/serving/pkg/apis/networking/v1alpha1"
 )
 
+// hashLength is the number of hex digits of the hash suffix of names that
+// would otherwise be too long.
+const hashLength = 10
+
 // VirtualService returns the name of the VirtualService child resource for given ClusterIngress.
 func VirtualService(i *v1alpha1.ClusterIngress) string {
 	return i.Name
 }
+
+// MeshVirtualService returns the name of the VirtualService child resource
+// routing the traffic from within the mesh for given ClusterIngress, when
+// it's routed apart from the traffic coming through its Gateways.
+func MeshVirtualService(i *v1alpha1.ClusterIngress) string {
+	return i.Name + "-mesh"
+}
+
+// DestinationRule returns the name of the DestinationRule child resource for
+// the given backend Service of the ClusterIngress.  Names too long for a label
+// are truncated and suffixed with a hash of the full name, to stay distinct.
+func DestinationRule(i *v1alpha1.ClusterIngress, serviceName string) string {
+	name := i.Name + "-" + serviceName
+	if len(name) <= validation.DNS1123LabelMaxLength {
+		return name
+	}
+	sum := sha256.Sum256([]byte(name))
+	return name[:validation.DNS1123LabelMaxLength-hashLength-1] + "-" + hex.EncodeToString(sum[:])[:hashLength]
+}
+
+// Gateway returns the name of the Gateway child resource terminating TLS
+// for the given ClusterIngress.
+func Gateway(i *v1alpha1.ClusterIngress) string {
+	return i.Name
+}
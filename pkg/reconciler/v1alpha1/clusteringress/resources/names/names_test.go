@@ -17,6 +17,7 @@ limitations under the License.
 package names
 
 import (
+	"strings"
 	"testing"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -39,6 +40,24 @@ func TestNamer(t *testing.T) {
 		},
 		f:    VirtualService,
 		want: "foo",
+	}, {
+		name: "MeshVirtualService",
+		ingress: &v1alpha1.ClusterIngress{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "foo",
+			},
+		},
+		f:    MeshVirtualService,
+		want: "foo-mesh",
+	}, {
+		name: "Gateway",
+		ingress: &v1alpha1.ClusterIngress{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "foo",
+			},
+		},
+		f:    Gateway,
+		want: "foo",
 	}}
 
 	for _, test := range tests {
@@ -50,3 +69,24 @@ func TestNamer(t *testing.T) {
 		})
 	}
 }
+
+func TestDestinationRule(t *testing.T) {
+	ingress := &v1alpha1.ClusterIngress{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "foo",
+		},
+	}
+	if got, want := DestinationRule(ingress, "bar"), "foo-bar"; got != want {
+		t.Errorf("DestinationRule() = %v, wanted %v", got, want)
+	}
+
+	// Long names are kept to a label, and stay distinct per Service.
+	ingress.Name = strings.Repeat("a", 57)
+	long := DestinationRule(ingress, strings.Repeat("b", 63))
+	if want := strings.Repeat("a", 52) + "-9906424ed1"; long != want {
+		t.Errorf("DestinationRule() = %v, wanted %v", long, want)
+	}
+	if other := DestinationRule(ingress, strings.Repeat("b", 62)+"c"); other == long {
+		t.Errorf("DestinationRule() = %v for different Services", long)
+	}
+}
@@ -35,21 +35,72 @@ import (
 
 // MakeVirtualService creates an Istio VirtualService as network programming.
 // Such VirtualService specifies which Gateways and Hosts that it applies to,
-// as well as the routing rules.
+// as well as the routing rules.  The HTTP routes keep the order of the
+// ClusterIngress rules and paths: Istio uses the first route matching a
+// request, so they aren't re-sorted here.
+//
+// When some paths of the ClusterIngress route the traffic from within the
+// mesh apart, the VirtualService only applies to the given Gateways, and
+// the one made by MakeMeshVirtualService applies to the mesh.
 func MakeVirtualService(ci *v1alpha1.ClusterIngress, gateways []string) *v1alpha3.VirtualService {
+	switch {
+	case !hasMeshSplits(ci):
+		// We want to connect to two Gateways: the Knative shared
+		// Gateway, and the 'mesh' Gateway.  The former provides
+		// access from outside of the cluster, and the latter provides
+		// access for services from inside the cluster.
+		return makeVirtualService(ci, names.VirtualService(ci), append(gateways, "mesh"), false)
+	case len(gateways) == 0:
+		// All the traffic comes from within the mesh.
+		return makeVirtualService(ci, names.VirtualService(ci), []string{"mesh"}, true)
+	default:
+		return makeVirtualService(ci, names.VirtualService(ci), gateways, false)
+	}
+}
+
+// MakeMeshVirtualService creates the Istio VirtualService routing the traffic
+// from within the mesh, when some paths of the ClusterIngress route it apart
+// from the traffic coming through the given Gateways.  It returns nil when
+// none do.
+func MakeMeshVirtualService(ci *v1alpha1.ClusterIngress, gateways []string) *v1alpha3.VirtualService {
+	if !hasMeshSplits(ci) || len(gateways) == 0 {
+		return nil
+	}
+	return makeVirtualService(ci, names.MeshVirtualService(ci), []string{"mesh"}, true)
+}
+
+// hasMeshSplits returns whether some path of the ClusterIngress routes the
+// traffic from within the mesh apart.
+func hasMeshSplits(ci *v1alpha1.ClusterIngress) bool {
+	for _, rule := range ci.Spec.Rules {
+		for _, p := range rule.HTTP.Paths {
+			if len(p.MeshSplits) != 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// makeVirtualService creates a VirtualService applying to the given Gateways,
+// which forwards to the MeshSplits of the paths that have them when mesh is
+// true.
+func makeVirtualService(ci *v1alpha1.ClusterIngress, name string, gateways []string, mesh bool) *v1alpha3.VirtualService {
 	vs := &v1alpha3.VirtualService{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:            names.VirtualService(ci),
+			Name:            name,
 			Namespace:       system.Namespace(),
 			OwnerReferences: []metav1.OwnerReference{*kmeta.NewControllerRef(ci)},
 			Annotations:     ci.ObjectMeta.Annotations,
 		},
-		Spec: *makeVirtualServiceSpec(ci, gateways),
+		Spec: *makeVirtualServiceSpec(ci, gateways, mesh),
 	}
 
-	// Populate the ClusterIngress labels.
-	if vs.Labels == nil {
-		vs.Labels = make(map[string]string)
+	// Populate the ClusterIngress labels, carrying over any others it has
+	// (e.g. ones propagated from its Route for telemetry).
+	vs.Labels = make(map[string]string, len(ci.Labels)+3)
+	for k, v := range ci.Labels {
+		vs.Labels[k] = v
 	}
 	vs.Labels[networking.IngressLabelKey] = ci.Name
 
@@ -60,25 +111,41 @@ func MakeVirtualService(ci *v1alpha1.ClusterIngress, gateways []string) *v1alpha
 	return vs
 }
 
-func makeVirtualServiceSpec(ci *v1alpha1.ClusterIngress, gateways []string) *v1alpha3.VirtualServiceSpec {
+func makeVirtualServiceSpec(ci *v1alpha1.ClusterIngress, gateways []string, mesh bool) *v1alpha3.VirtualServiceSpec {
 	spec := v1alpha3.VirtualServiceSpec{
-		// We want to connect to two Gateways: the Knative shared
-		// Gateway, and the 'mesh' Gateway.  The former provides
-		// access from outside of the cluster, and the latter provides
-		// access for services from inside the cluster.
-		Gateways: append(gateways, "mesh"),
+		Gateways: gateways,
 		Hosts:    getHosts(ci),
 	}
 
 	for _, rule := range ci.Spec.Rules {
 		hosts := rule.Hosts
 		for _, p := range rule.HTTP.Paths {
+			if mesh && len(p.MeshSplits) != 0 {
+				p.Splits = p.MeshSplits
+			}
 			spec.Http = append(spec.Http, *makeVirtualServiceRoute(hosts, &p))
 		}
 	}
 	return &spec
 }
 
+func makeFault(f *v1alpha1.HTTPFault) *v1alpha3.HTTPFaultInjection {
+	fault := &v1alpha3.HTTPFaultInjection{}
+	if f.FixedDelay != nil {
+		fault.Delay = &v1alpha3.InjectDelay{
+			Percent:    f.DelayPercent,
+			FixedDelay: f.FixedDelay.Duration.String(),
+		}
+	}
+	if f.HTTPStatus != 0 {
+		fault.Abort = &v1alpha3.InjectAbort{
+			Perecent:   f.AbortPercent,
+			HttpStatus: f.HTTPStatus,
+		}
+	}
+	return fault
+}
+
 func makePortSelector(ios intstr.IntOrString) v1alpha3.PortSelector {
 	if ios.Type == intstr.Int {
 		return v1alpha3.PortSelector{
@@ -93,20 +160,23 @@ func makePortSelector(ios intstr.IntOrString) v1alpha3.PortSelector {
 func makeVirtualServiceRoute(hosts []string, http *v1alpha1.HTTPClusterIngressPath) *v1alpha3.HTTPRoute {
 	matches := []v1alpha3.HTTPMatchRequest{}
 	for _, host := range hosts {
-		matches = append(matches, makeMatch(host, http.Path))
+		matches = append(matches, makeMatch(host, http))
 	}
 	weights := []v1alpha3.DestinationWeight{}
 	for _, split := range http.Splits {
+		// Splits without any traffic are kept, e.g. for Revisions being
+		// drained, so that Istio lets their in-flight requests complete.
 		weights = append(weights, v1alpha3.DestinationWeight{
 			Destination: v1alpha3.Destination{
 				Host: reconciler.GetK8sServiceFullname(
 					split.ServiceName, split.ServiceNamespace),
-				Port: makePortSelector(split.ServicePort),
+				Port:   makePortSelector(split.ServicePort),
+				Subset: split.Subset,
 			},
 			Weight: split.Percent,
 		})
 	}
-	return &v1alpha3.HTTPRoute{
+	route := &v1alpha3.HTTPRoute{
 		Match:   matches,
 		Route:   weights,
 		Timeout: http.Timeout.Duration.String(),
@@ -117,19 +187,51 @@ func makeVirtualServiceRoute(hosts []string, http *v1alpha1.HTTPClusterIngressPa
 		AppendHeaders:    http.AppendHeaders,
 		WebsocketUpgrade: true,
 	}
+	if http.Fault != nil {
+		route.Fault = makeFault(http.Fault)
+	}
+	if http.Rewrite != nil {
+		route.Rewrite = &v1alpha3.HTTPRewrite{
+			Uri:       http.Rewrite.URI,
+			Authority: http.Rewrite.Authority,
+		}
+	}
+	if http.Mirror != nil {
+		route.Mirror = &v1alpha3.Destination{
+			Host: reconciler.GetK8sServiceFullname(
+				http.Mirror.ServiceName, http.Mirror.ServiceNamespace),
+			Port:   makePortSelector(http.Mirror.ServicePort),
+			Subset: http.Mirror.Subset,
+		}
+	}
+	return route
 }
 
-func makeMatch(host string, pathRegExp string) v1alpha3.HTTPMatchRequest {
+func makeMatch(host string, http *v1alpha1.HTTPClusterIngressPath) v1alpha3.HTTPMatchRequest {
 	match := v1alpha3.HTTPMatchRequest{
 		Authority: &istiov1alpha1.StringMatch{
 			Exact: host,
 		},
 	}
-	// Empty pathRegExp is considered match all path. We only need to
-	// consider pathRegExp when it's non-empty.
-	if pathRegExp != "" {
+	// Empty path regex and prefix are considered match all path. We only
+	// need to consider them when they're non-empty.
+	switch {
+	case http.PathPrefix != "":
 		match.Uri = &istiov1alpha1.StringMatch{
-			Regex: pathRegExp,
+			Prefix: http.PathPrefix,
+		}
+	case http.Path != "":
+		match.Uri = &istiov1alpha1.StringMatch{
+			Regex: http.Path,
+		}
+	}
+	if len(http.Headers) != 0 {
+		match.Headers = make(map[string]istiov1alpha1.StringMatch, len(http.Headers))
+		for name, h := range http.Headers {
+			match.Headers[name] = istiov1alpha1.StringMatch{
+				Exact:  h.Exact,
+				Prefix: h.Prefix,
+			}
 		}
 	}
 	return match
@@ -19,6 +19,7 @@ package resources
 import (
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 	istiov1alpha1 "github.com/knative/pkg/apis/istio/common/v1alpha1"
@@ -62,6 +63,43 @@ func TestMakeVirtualServiceSpec_CorrectMetadata(t *testing.T) {
 	}
 }
 
+func TestMakeVirtualServiceSpec_PropagatedMetadata(t *testing.T) {
+	ci := &v1alpha1.ClusterIngress{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "test-ingress",
+			Labels: map[string]string{
+				serving.RouteLabelKey:          "test-route",
+				serving.RouteNamespaceLabelKey: "test-ns",
+				"telemetry.knative.dev/team":   "payments",
+			},
+			Annotations: map[string]string{
+				"telemetry.knative.dev/sampling": "0.1",
+			},
+		},
+		Spec: v1alpha1.IngressSpec{},
+	}
+	expected := metav1.ObjectMeta{
+		Name:      "test-ingress",
+		Namespace: system.Namespace(),
+		Labels: map[string]string{
+			networking.IngressLabelKey:     "test-ingress",
+			serving.RouteLabelKey:          "test-route",
+			serving.RouteNamespaceLabelKey: "test-ns",
+			"telemetry.knative.dev/team":   "payments",
+		},
+		Annotations: map[string]string{
+			"telemetry.knative.dev/sampling": "0.1",
+		},
+		OwnerReferences: []metav1.OwnerReference{
+			*kmeta.NewControllerRef(ci),
+		},
+	}
+	meta := MakeVirtualService(ci, []string{}).ObjectMeta
+	if diff := cmp.Diff(expected, meta); diff != "" {
+		t.Errorf("Unexpected metadata (-want +got): %v", diff)
+	}
+}
+
 func TestMakeVirtualServiceSpec_CorrectGateways(t *testing.T) {
 	ci := &v1alpha1.ClusterIngress{
 		ObjectMeta: metav1.ObjectMeta{
@@ -191,6 +229,131 @@ func TestMakeVirtualServiceSpec_CorrectRoutes(t *testing.T) {
 	}
 }
 
+func TestMakeVirtualService_MeshSplits(t *testing.T) {
+	ci := &v1alpha1.ClusterIngress{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "test-ingress",
+			Labels: map[string]string{
+				serving.RouteLabelKey:          "test-route",
+				serving.RouteNamespaceLabelKey: "test-ns",
+			},
+		},
+		Spec: v1alpha1.IngressSpec{
+			Rules: []v1alpha1.ClusterIngressRule{{
+				Hosts: []string{"domain.com"},
+				HTTP: &v1alpha1.HTTPClusterIngressRuleValue{
+					Paths: []v1alpha1.HTTPClusterIngressPath{{
+						Splits: []v1alpha1.ClusterIngressBackendSplit{{
+							ClusterIngressBackend: v1alpha1.ClusterIngressBackend{
+								ServiceNamespace: "knative-serving",
+								ServiceName:      "activator-service",
+								ServicePort:      intstr.FromInt(80),
+							},
+							Percent: 100,
+						}},
+						MeshSplits: []v1alpha1.ClusterIngressBackendSplit{{
+							ClusterIngressBackend: v1alpha1.ClusterIngressBackend{
+								ServiceNamespace: "test-ns",
+								ServiceName:      "v1-service",
+								ServicePort:      intstr.FromInt(80),
+							},
+							Percent: 100,
+						}},
+						Timeout: &metav1.Duration{Duration: v1alpha1.DefaultTimeout},
+						Retries: &v1alpha1.HTTPRetry{
+							PerTryTimeout: &metav1.Duration{Duration: v1alpha1.DefaultTimeout},
+							Attempts:      v1alpha1.DefaultRetryCount,
+						},
+					}},
+				},
+			}},
+		},
+	}
+	route := func(host string) []v1alpha3.HTTPRoute {
+		return []v1alpha3.HTTPRoute{{
+			Match: []v1alpha3.HTTPMatchRequest{{
+				Authority: &istiov1alpha1.StringMatch{Exact: "domain.com"},
+			}},
+			Route: []v1alpha3.DestinationWeight{{
+				Destination: v1alpha3.Destination{
+					Host: host,
+					Port: v1alpha3.PortSelector{Number: 80},
+				},
+				Weight: 100,
+			}},
+			Timeout: v1alpha1.DefaultTimeout.String(),
+			Retries: &v1alpha3.HTTPRetry{
+				Attempts:      v1alpha1.DefaultRetryCount,
+				PerTryTimeout: v1alpha1.DefaultTimeout.String(),
+			},
+			WebsocketUpgrade: true,
+		}}
+	}
+	activator := route("activator-service.knative-serving.svc.cluster.local")
+	direct := route("v1-service.test-ns.svc.cluster.local")
+
+	tests := []struct {
+		name         string
+		gateways     []string
+		wantGateways []string
+		wantRoutes   []v1alpha3.HTTPRoute
+		wantMesh     *v1alpha3.VirtualService
+	}{{
+		name:         "ingress and mesh",
+		gateways:     []string{"gateway"},
+		wantGateways: []string{"gateway"},
+		wantRoutes:   activator,
+		wantMesh: &v1alpha3.VirtualService{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            "test-ingress-mesh",
+				Namespace:       system.Namespace(),
+				OwnerReferences: []metav1.OwnerReference{*kmeta.NewControllerRef(ci)},
+				Labels: map[string]string{
+					networking.IngressLabelKey:     "test-ingress",
+					serving.RouteLabelKey:          "test-route",
+					serving.RouteNamespaceLabelKey: "test-ns",
+				},
+			},
+			Spec: v1alpha3.VirtualServiceSpec{
+				Gateways: []string{"mesh"},
+				Hosts:    []string{"domain.com"},
+				Http:     direct,
+			},
+		},
+	}, {
+		name:         "mesh only",
+		gateways:     []string{},
+		wantGateways: []string{"mesh"},
+		wantRoutes:   direct,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			vs := MakeVirtualService(ci, test.gateways)
+			if diff := cmp.Diff(test.wantGateways, vs.Spec.Gateways); diff != "" {
+				t.Errorf("Unexpected gateways (-want +got): %v", diff)
+			}
+			if diff := cmp.Diff(test.wantRoutes, vs.Spec.Http); diff != "" {
+				t.Errorf("Unexpected routes (-want +got): %v", diff)
+			}
+			if diff := cmp.Diff(test.wantMesh, MakeMeshVirtualService(ci, test.gateways)); diff != "" {
+				t.Errorf("Unexpected mesh VirtualService (-want +got): %v", diff)
+			}
+		})
+	}
+}
+
+func TestMakeMeshVirtualService_NoMeshSplits(t *testing.T) {
+	ci := &v1alpha1.ClusterIngress{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "test-ingress",
+		},
+	}
+	if vs := MakeMeshVirtualService(ci, []string{"gateway"}); vs != nil {
+		t.Errorf("MakeMeshVirtualService() = %v, wanted nil", vs)
+	}
+}
+
 // One active target.
 func TestMakeVirtualServiceRoute_Vanilla(t *testing.T) {
 	ingressPath := &v1alpha1.HTTPClusterIngressPath{
@@ -290,6 +453,273 @@ func TestMakeVirtualServiceRoute_TwoTargets(t *testing.T) {
 	}
 }
 
+func TestMakeVirtualServiceRoute_ZeroWeight(t *testing.T) {
+	ingressPath := &v1alpha1.HTTPClusterIngressPath{
+		Splits: []v1alpha1.ClusterIngressBackendSplit{{
+			ClusterIngressBackend: v1alpha1.ClusterIngressBackend{
+				ServiceNamespace: "test-ns",
+				ServiceName:      "new-revision-service",
+				ServicePort:      intstr.FromInt(80),
+			},
+			Percent: 100,
+		}, {
+			ClusterIngressBackend: v1alpha1.ClusterIngressBackend{
+				ServiceNamespace: "test-ns",
+				ServiceName:      "draining-revision-service",
+				ServicePort:      intstr.FromInt(80),
+			},
+			Percent: 0,
+		}},
+		Timeout: &metav1.Duration{Duration: v1alpha1.DefaultTimeout},
+		Retries: &v1alpha1.HTTPRetry{
+			PerTryTimeout: &metav1.Duration{Duration: v1alpha1.DefaultTimeout},
+			Attempts:      v1alpha1.DefaultRetryCount,
+		},
+	}
+	route := makeVirtualServiceRoute([]string{"test.org"}, ingressPath)
+	want := []v1alpha3.DestinationWeight{{
+		Destination: v1alpha3.Destination{
+			Host: "new-revision-service.test-ns.svc.cluster.local",
+			Port: v1alpha3.PortSelector{Number: 80},
+		},
+		Weight: 100,
+	}, {
+		Destination: v1alpha3.Destination{
+			Host: "draining-revision-service.test-ns.svc.cluster.local",
+			Port: v1alpha3.PortSelector{Number: 80},
+		},
+		Weight: 0,
+	}}
+	if diff := cmp.Diff(want, route.Route); diff != "" {
+		t.Errorf("Unexpected destinations (-want +got): %v", diff)
+	}
+}
+
+func TestMakeVirtualServiceRoute_Subset(t *testing.T) {
+	ingressPath := &v1alpha1.HTTPClusterIngressPath{
+		Splits: []v1alpha1.ClusterIngressBackendSplit{{
+			ClusterIngressBackend: v1alpha1.ClusterIngressBackend{
+				ServiceNamespace: "test-ns",
+				ServiceName:      "revision-service",
+				ServicePort:      intstr.FromInt(80),
+				Subset:           "canary",
+			},
+			Percent: 10,
+		}, {
+			ClusterIngressBackend: v1alpha1.ClusterIngressBackend{
+				ServiceNamespace: "test-ns",
+				ServiceName:      "revision-service",
+				ServicePort:      intstr.FromInt(80),
+			},
+			Percent: 90,
+		}},
+		Timeout: &metav1.Duration{Duration: v1alpha1.DefaultTimeout},
+		Retries: &v1alpha1.HTTPRetry{
+			PerTryTimeout: &metav1.Duration{Duration: v1alpha1.DefaultTimeout},
+			Attempts:      v1alpha1.DefaultRetryCount,
+		},
+	}
+	route := makeVirtualServiceRoute([]string{"test.org"}, ingressPath)
+	want := []v1alpha3.DestinationWeight{{
+		Destination: v1alpha3.Destination{
+			Host:   "revision-service.test-ns.svc.cluster.local",
+			Port:   v1alpha3.PortSelector{Number: 80},
+			Subset: "canary",
+		},
+		Weight: 10,
+	}, {
+		Destination: v1alpha3.Destination{
+			Host: "revision-service.test-ns.svc.cluster.local",
+			Port: v1alpha3.PortSelector{Number: 80},
+		},
+		Weight: 90,
+	}}
+	if diff := cmp.Diff(want, route.Route); diff != "" {
+		t.Errorf("Unexpected destinations (-want +got): %v", diff)
+	}
+}
+
+func TestMakeVirtualServiceRoute_Mirror(t *testing.T) {
+	ingressPath := &v1alpha1.HTTPClusterIngressPath{
+		Splits: []v1alpha1.ClusterIngressBackendSplit{{
+			ClusterIngressBackend: v1alpha1.ClusterIngressBackend{
+				ServiceNamespace: "test-ns",
+				ServiceName:      "revision-service",
+				ServicePort:      intstr.FromInt(80),
+			},
+			Percent: 100,
+		}},
+		Mirror: &v1alpha1.ClusterIngressBackend{
+			ServiceNamespace: "test-ns",
+			ServiceName:      "new-revision-service",
+			ServicePort:      intstr.FromInt(80),
+		},
+		Timeout: &metav1.Duration{Duration: v1alpha1.DefaultTimeout},
+		Retries: &v1alpha1.HTTPRetry{
+			PerTryTimeout: &metav1.Duration{Duration: v1alpha1.DefaultTimeout},
+			Attempts:      v1alpha1.DefaultRetryCount,
+		},
+	}
+	route := makeVirtualServiceRoute([]string{"test.org"}, ingressPath)
+	expected := v1alpha3.HTTPRoute{
+		Match: []v1alpha3.HTTPMatchRequest{{
+			Authority: &istiov1alpha1.StringMatch{Exact: "test.org"},
+		}},
+		Route: []v1alpha3.DestinationWeight{{
+			Destination: v1alpha3.Destination{
+				Host: "revision-service.test-ns.svc.cluster.local",
+				Port: v1alpha3.PortSelector{Number: 80},
+			},
+			Weight: 100,
+		}},
+		Mirror: &v1alpha3.Destination{
+			Host: "new-revision-service.test-ns.svc.cluster.local",
+			Port: v1alpha3.PortSelector{Number: 80},
+		},
+		Timeout: v1alpha1.DefaultTimeout.String(),
+		Retries: &v1alpha3.HTTPRetry{
+			Attempts:      v1alpha1.DefaultRetryCount,
+			PerTryTimeout: v1alpha1.DefaultTimeout.String(),
+		},
+		WebsocketUpgrade: true,
+	}
+	if diff := cmp.Diff(&expected, route); diff != "" {
+		t.Errorf("Unexpected route  (-want +got): %v", diff)
+	}
+}
+
+func TestMakeVirtualServiceRoute_HeaderMatch(t *testing.T) {
+	ingressPath := &v1alpha1.HTTPClusterIngressPath{
+		Headers: map[string]v1alpha1.HeaderMatch{
+			"x-canary": {Exact: "true"},
+			"x-user":   {Prefix: "beta-"},
+		},
+		Splits: []v1alpha1.ClusterIngressBackendSplit{{
+			ClusterIngressBackend: v1alpha1.ClusterIngressBackend{
+				ServiceNamespace: "test-ns",
+				ServiceName:      "canary-service",
+				ServicePort:      intstr.FromInt(80),
+			},
+			Percent: 100,
+		}},
+		Timeout: &metav1.Duration{Duration: v1alpha1.DefaultTimeout},
+		Retries: &v1alpha1.HTTPRetry{
+			PerTryTimeout: &metav1.Duration{Duration: v1alpha1.DefaultTimeout},
+			Attempts:      v1alpha1.DefaultRetryCount,
+		},
+	}
+	route := makeVirtualServiceRoute([]string{"test.org"}, ingressPath)
+	expected := v1alpha3.HTTPRoute{
+		Match: []v1alpha3.HTTPMatchRequest{{
+			Authority: &istiov1alpha1.StringMatch{Exact: "test.org"},
+			Headers: map[string]istiov1alpha1.StringMatch{
+				"x-canary": {Exact: "true"},
+				"x-user":   {Prefix: "beta-"},
+			},
+		}},
+		Route: []v1alpha3.DestinationWeight{{
+			Destination: v1alpha3.Destination{
+				Host: "canary-service.test-ns.svc.cluster.local",
+				Port: v1alpha3.PortSelector{Number: 80},
+			},
+			Weight: 100,
+		}},
+		Timeout: v1alpha1.DefaultTimeout.String(),
+		Retries: &v1alpha3.HTTPRetry{
+			Attempts:      v1alpha1.DefaultRetryCount,
+			PerTryTimeout: v1alpha1.DefaultTimeout.String(),
+		},
+		WebsocketUpgrade: true,
+	}
+	if diff := cmp.Diff(&expected, route); diff != "" {
+		t.Errorf("Unexpected route  (-want +got): %v", diff)
+	}
+}
+
+func TestMakeVirtualServiceRoute_PathPrefix(t *testing.T) {
+	ingressPath := &v1alpha1.HTTPClusterIngressPath{
+		PathPrefix: "/v2",
+		Splits: []v1alpha1.ClusterIngressBackendSplit{{
+			ClusterIngressBackend: v1alpha1.ClusterIngressBackend{
+				ServiceNamespace: "test-ns",
+				ServiceName:      "v2-service",
+				ServicePort:      intstr.FromInt(80),
+			},
+			Percent: 100,
+		}},
+		Timeout: &metav1.Duration{Duration: v1alpha1.DefaultTimeout},
+		Retries: &v1alpha1.HTTPRetry{
+			PerTryTimeout: &metav1.Duration{Duration: v1alpha1.DefaultTimeout},
+			Attempts:      v1alpha1.DefaultRetryCount,
+		},
+	}
+	route := makeVirtualServiceRoute([]string{"test.org"}, ingressPath)
+	expected := v1alpha3.HTTPRoute{
+		Match: []v1alpha3.HTTPMatchRequest{{
+			Authority: &istiov1alpha1.StringMatch{Exact: "test.org"},
+			Uri:       &istiov1alpha1.StringMatch{Prefix: "/v2"},
+		}},
+		Route: []v1alpha3.DestinationWeight{{
+			Destination: v1alpha3.Destination{
+				Host: "v2-service.test-ns.svc.cluster.local",
+				Port: v1alpha3.PortSelector{Number: 80},
+			},
+			Weight: 100,
+		}},
+		Timeout: v1alpha1.DefaultTimeout.String(),
+		Retries: &v1alpha3.HTTPRetry{
+			Attempts:      v1alpha1.DefaultRetryCount,
+			PerTryTimeout: v1alpha1.DefaultTimeout.String(),
+		},
+		WebsocketUpgrade: true,
+	}
+	if diff := cmp.Diff(&expected, route); diff != "" {
+		t.Errorf("Unexpected route  (-want +got): %v", diff)
+	}
+}
+
+func TestMakeFault(t *testing.T) {
+	tests := []struct {
+		name  string
+		fault *v1alpha1.HTTPFault
+		want  *v1alpha3.HTTPFaultInjection
+	}{{
+		name: "delay",
+		fault: &v1alpha1.HTTPFault{
+			DelayPercent: 50,
+			FixedDelay:   &metav1.Duration{Duration: 2 * time.Second},
+		},
+		want: &v1alpha3.HTTPFaultInjection{
+			Delay: &v1alpha3.InjectDelay{Percent: 50, FixedDelay: "2s"},
+		},
+	}, {
+		name:  "abort",
+		fault: &v1alpha1.HTTPFault{AbortPercent: 10, HTTPStatus: 503},
+		want: &v1alpha3.HTTPFaultInjection{
+			Abort: &v1alpha3.InjectAbort{Perecent: 10, HttpStatus: 503},
+		},
+	}, {
+		name: "both",
+		fault: &v1alpha1.HTTPFault{
+			DelayPercent: 5,
+			FixedDelay:   &metav1.Duration{Duration: 100 * time.Millisecond},
+			AbortPercent: 1,
+			HTTPStatus:   500,
+		},
+		want: &v1alpha3.HTTPFaultInjection{
+			Delay: &v1alpha3.InjectDelay{Percent: 5, FixedDelay: "100ms"},
+			Abort: &v1alpha3.InjectAbort{Perecent: 1, HttpStatus: 500},
+		},
+	}}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if diff := cmp.Diff(test.want, makeFault(test.fault)); diff != "" {
+				t.Errorf("Unexpected fault (-want +got): %v", diff)
+			}
+		})
+	}
+}
+
 func TestGetHosts_Duplicate(t *testing.T) {
 	ci := &v1alpha1.ClusterIngress{
 		Spec: v1alpha1.IngressSpec{
@@ -77,13 +77,14 @@ func NewRouteToConfigurationController(
 // converge the two. In this case, it attempts to label all Configurations
 // with the Routes that direct traffic to their Revisions.
 func (c *Reconciler) Reconcile(ctx context.Context, key string) error {
+	logger := logging.FromContext(ctx)
+
 	// Convert the namespace/name string into a distinct namespace and name
 	namespace, name, err := cache.SplitMetaNamespaceKey(key)
 	if err != nil {
-		c.Logger.Errorf("invalid resource key: %s", key)
+		logger.Errorf("invalid resource key: %s", key)
 		return nil
 	}
-	logger := logging.FromContext(ctx)
 
 	// Get the Route resource with this namespace/name
 	route, err := c.routeLister.Routes(namespace).Get(name)
@@ -84,10 +84,22 @@ func TestReconcile(t *testing.T) {
 		WantErr: true,
 		Objects: []runtime.Object{
 			simpleRunLatest("default", "the-route", "the-config"),
+			simpleRunLatest("default", "another-route", "the-config"),
 			routeLabel(simpleConfig("default", "the-config"), "another-route"),
 			simpleRevision("default", "the-config"),
 		},
 		Key: "default/the-route",
+	}, {
+		Name: "relabel config labelled by a deleted route",
+		Objects: []runtime.Object{
+			simpleRunLatest("default", "the-route", "the-config"),
+			routeLabel(simpleConfig("default", "the-config"), "deleted-route"),
+			simpleRevision("default", "the-config"),
+		},
+		WantPatches: []clientgotesting.PatchActionImpl{
+			patchAddLabel("default", "the-config", "serving.knative.dev/route", "the-route", "v1"),
+		},
+		Key: "default/the-route",
 	}, {
 		Name: "change configurations",
 		Objects: []runtime.Object{
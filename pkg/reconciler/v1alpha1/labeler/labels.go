@@ -23,6 +23,7 @@ import (
 	"sort"
 
 	"github.com/knative/pkg/logging"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/types"
@@ -37,6 +38,11 @@ func (c *Reconciler) syncLabels(ctx context.Context, r *v1alpha1.Route) error {
 	// Walk the revisions in Route's .status.traffic and build a list
 	// of Configurations to label from their OwnerReferences.
 	for _, tt := range r.Status.Traffic {
+		if tt.Namespace != "" && tt.Namespace != r.Namespace {
+			// The route label only names Routes of the Configuration's
+			// own namespace.
+			continue
+		}
 		rev, err := c.revisionLister.Revisions(r.Namespace).Get(tt.RevisionName)
 		if err != nil {
 			return err
@@ -78,10 +84,20 @@ func (c *Reconciler) setLabelForGivenConfigurations(
 		if !ok {
 			continue
 		}
-		if routeName != route.Name {
-			return fmt.Errorf("Configuration %q is already in use by %q, and cannot be used by %q",
+		if routeName == route.Name {
+			continue
+		}
+		// A label left behind by a Route that no longer exists is stale,
+		// so we take the Configuration over instead of failing forever.
+		if _, err := c.routeLister.Routes(route.Namespace).Get(routeName); apierrs.IsNotFound(err) {
+			logger.Infof("Configuration %q is labelled by deleted Route %q, relabelling it for %q",
 				config.Name, routeName, route.Name)
+			continue
+		} else if err != nil {
+			return err
 		}
+		return fmt.Errorf("Configuration %q is already in use by %q, and cannot be used by %q",
+			config.Name, routeName, route.Name)
 	}
 	// Sort the names to give things a deterministic ordering.
 	sort.Strings(configurationOrder)
@@ -92,7 +108,7 @@ func (c *Reconciler) setLabelForGivenConfigurations(
 		config := configMap[configName]
 		if config.Labels == nil {
 			config.Labels = make(map[string]string)
-		} else if _, ok := config.Labels[serving.RouteLabelKey]; ok {
+		} else if config.Labels[serving.RouteLabelKey] == route.Name {
 			continue
 		}
 
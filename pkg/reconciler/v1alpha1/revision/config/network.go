@@ -17,7 +17,9 @@ limitations under the License.
 package config
 
 import (
+	"fmt"
 	"net"
+	"strconv"
 	"strings"
 
 	corev1 "k8s.io/api/core/v1"
@@ -31,6 +33,15 @@ const (
 	// IstioOutboundIPRangesKey is the name of the configuration entry
 	// that specifies Istio outbound ip ranges.
 	IstioOutboundIPRangesKey = "istio.sidecar.includeOutboundIPRanges"
+
+	// DefaultRevisionPortKey is the name of the configuration entry
+	// that specifies the port the Kubernetes Services of Revisions
+	// and Routes listen on.
+	DefaultRevisionPortKey = "defaultRevisionPort"
+
+	// defaultRevisionPort is the port Revisions are served on when
+	// DefaultRevisionPortKey is absent.
+	defaultRevisionPort = int32(80)
 )
 
 // Network contains the networking configuration defined in the
@@ -39,6 +50,10 @@ type Network struct {
 	// IstioOutboundIPRange specifies the IP ranges to intercept
 	// by Istio sidecar.
 	IstioOutboundIPRanges string
+
+	// DefaultRevisionPort specifies the port the Kubernetes Services
+	// of Revisions and Routes listen on.
+	DefaultRevisionPort int32
 }
 
 func validateAndNormalizeOutboundIPRanges(s string) (string, error) {
@@ -68,7 +83,9 @@ func validateAndNormalizeOutboundIPRanges(s string) (string, error) {
 
 // NewNetworkFromConfigMap creates a Network from the supplied ConfigMap
 func NewNetworkFromConfigMap(configMap *corev1.ConfigMap) (*Network, error) {
-	nc := &Network{}
+	nc := &Network{
+		DefaultRevisionPort: defaultRevisionPort,
+	}
 	if ipr, ok := configMap.Data[IstioOutboundIPRangesKey]; !ok {
 		// It is OK for this to be absent, we will elide the annotation.
 	} else if normalizedIpr, err := validateAndNormalizeOutboundIPRanges(ipr); err != nil {
@@ -76,5 +93,12 @@ func NewNetworkFromConfigMap(configMap *corev1.ConfigMap) (*Network, error) {
 	} else {
 		nc.IstioOutboundIPRanges = normalizedIpr
 	}
+	if raw, ok := configMap.Data[DefaultRevisionPortKey]; ok {
+		port, err := strconv.ParseInt(raw, 10, 32)
+		if err != nil || port < 1 || port > 65535 {
+			return nil, fmt.Errorf("%s = %q, must be a port number between 1 and 65535", DefaultRevisionPortKey, raw)
+		}
+		nc.DefaultRevisionPort = int32(port)
+	}
 	return nc, nil
 }
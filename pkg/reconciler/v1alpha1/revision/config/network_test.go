@@ -44,7 +44,7 @@ func TestNetworkConfiguration(t *testing.T) {
 	}{{
 		name:           "network configuration with no network input",
 		wantErr:        false,
-		wantController: &Network{},
+		wantController: &Network{DefaultRevisionPort: 80},
 		config: &corev1.ConfigMap{
 			ObjectMeta: metav1.ObjectMeta{
 				Namespace: system.Namespace(),
@@ -65,7 +65,7 @@ func TestNetworkConfiguration(t *testing.T) {
 		}}, {
 		name:           "network configuration with empty network",
 		wantErr:        false,
-		wantController: &Network{},
+		wantController: &Network{DefaultRevisionPort: 80},
 		config: &corev1.ConfigMap{
 			ObjectMeta: metav1.ObjectMeta{
 				Namespace: system.Namespace(),
@@ -137,7 +137,7 @@ func TestNetworkConfiguration(t *testing.T) {
 		}}, {
 		name:           "network configuration with invalid network string",
 		wantErr:        false,
-		wantController: &Network{},
+		wantController: &Network{DefaultRevisionPort: 80},
 		config: &corev1.ConfigMap{
 			ObjectMeta: metav1.ObjectMeta{
 				Namespace: system.Namespace(),
@@ -149,7 +149,7 @@ func TestNetworkConfiguration(t *testing.T) {
 		}}, {
 		name:           "network configuration with invalid network string",
 		wantErr:        false,
-		wantController: &Network{},
+		wantController: &Network{DefaultRevisionPort: 80},
 		config: &corev1.ConfigMap{
 			ObjectMeta: metav1.ObjectMeta{
 				Namespace: system.Namespace(),
@@ -161,7 +161,7 @@ func TestNetworkConfiguration(t *testing.T) {
 		}}, {
 		name:           "network configuration with invalid network range",
 		wantErr:        false,
-		wantController: &Network{},
+		wantController: &Network{DefaultRevisionPort: 80},
 		config: &corev1.ConfigMap{
 			ObjectMeta: metav1.ObjectMeta{
 				Namespace: system.Namespace(),
@@ -175,6 +175,7 @@ func TestNetworkConfiguration(t *testing.T) {
 		wantErr: false,
 		wantController: &Network{
 			IstioOutboundIPRanges: "10.10.10.0/24",
+			DefaultRevisionPort:   80,
 		},
 		config: &corev1.ConfigMap{
 			ObjectMeta: metav1.ObjectMeta{
@@ -189,6 +190,7 @@ func TestNetworkConfiguration(t *testing.T) {
 		wantErr: false,
 		wantController: &Network{
 			IstioOutboundIPRanges: "10.10.10.0/24,10.240.10.0/14,192.192.10.0/16",
+			DefaultRevisionPort:   80,
 		},
 		config: &corev1.ConfigMap{
 			ObjectMeta: metav1.ObjectMeta{
@@ -203,6 +205,7 @@ func TestNetworkConfiguration(t *testing.T) {
 		wantErr: false,
 		wantController: &Network{
 			IstioOutboundIPRanges: "*",
+			DefaultRevisionPort:   80,
 		},
 		config: &corev1.ConfigMap{
 			ObjectMeta: metav1.ObjectMeta{
@@ -212,6 +215,44 @@ func TestNetworkConfiguration(t *testing.T) {
 			Data: map[string]string{
 				IstioOutboundIPRangesKey: "*",
 			},
+		}}, {
+		name:    "network configuration with custom revision port",
+		wantErr: false,
+		wantController: &Network{
+			DefaultRevisionPort: 8080,
+		},
+		config: &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: system.Namespace(),
+				Name:      NetworkConfigName,
+			},
+			Data: map[string]string{
+				DefaultRevisionPortKey: "8080",
+			},
+		}}, {
+		name:           "network configuration with out of range revision port",
+		wantErr:        true,
+		wantController: (*Network)(nil),
+		config: &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: system.Namespace(),
+				Name:      NetworkConfigName,
+			},
+			Data: map[string]string{
+				DefaultRevisionPortKey: "65536",
+			},
+		}}, {
+		name:           "network configuration with non-numeric revision port",
+		wantErr:        true,
+		wantController: (*Network)(nil),
+		config: &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: system.Namespace(),
+				Name:      NetworkConfigName,
+			},
+			Data: map[string]string{
+				DefaultRevisionPortKey: "http",
+			},
 		}},
 	}
 
@@ -110,11 +110,11 @@ func (c *Reconciler) createKPA(ctx context.Context, rev *v1alpha1.Revision) (*kp
 	return c.ServingClientSet.AutoscalingV1alpha1().PodAutoscalers(kpa.Namespace).Create(kpa)
 }
 
-type serviceFactory func(*v1alpha1.Revision) *corev1.Service
+type serviceFactory func(*v1alpha1.Revision, *config.Network) *corev1.Service
 
 func (c *Reconciler) createService(ctx context.Context, rev *v1alpha1.Revision, sf serviceFactory) (*corev1.Service, error) {
 	// Create the service.
-	service := sf(rev)
+	service := sf(rev, config.FromContext(ctx).Network)
 
 	return c.KubeClientSet.CoreV1().Services(service.Namespace).Create(service)
 }
@@ -123,7 +123,7 @@ func (c *Reconciler) checkAndUpdateService(ctx context.Context, rev *v1alpha1.Re
 	logger := logging.FromContext(ctx)
 
 	// Note: only reconcile the spec we set.
-	rawDesiredService := sf(rev)
+	rawDesiredService := sf(rev, config.FromContext(ctx).Network)
 	desiredService := service.DeepCopy()
 	desiredService.Spec.Selector = rawDesiredService.Spec.Selector
 	desiredService.Spec.Ports = rawDesiredService.Spec.Ports
@@ -40,7 +40,12 @@ const (
 
 	// ServicePortName is the name of the external port of the service
 	ServicePortName = "http"
-	// ServicePort is the external port of the service
+	// ServicePortNameH2C is the name of the external port of the service
+	// when it serves HTTP/2 without TLS.  Istio infers the protocol of a
+	// port from its name.
+	ServicePortNameH2C = "http2"
+	// ServicePort is the external port of the activator's service.  The
+	// services of Revisions listen on the port set by the network config.
 	ServicePort = int32(80)
 	// MetricsPortName is the name of the external port of the service for metrics
 	MetricsPortName = "metrics"
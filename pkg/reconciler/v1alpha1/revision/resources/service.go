@@ -21,6 +21,7 @@ import (
 	"github.com/knative/serving/pkg/apis/autoscaling"
 	"github.com/knative/serving/pkg/apis/serving"
 	"github.com/knative/serving/pkg/apis/serving/v1alpha1"
+	"github.com/knative/serving/pkg/reconciler/v1alpha1/revision/config"
 	"github.com/knative/serving/pkg/reconciler/v1alpha1/revision/resources/names"
 
 	corev1 "k8s.io/api/core/v1"
@@ -28,11 +29,20 @@ import (
 	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
-var (
-	servicePorts = []corev1.ServicePort{{
-		Name:       ServicePortName,
+// ServicePortNameFor returns the name of the external port of the service
+// for a Revision serving the given protocol.
+func ServicePortNameFor(protocol v1alpha1.RevisionProtocolType) string {
+	if protocol == v1alpha1.RevisionProtocolH2C {
+		return ServicePortNameH2C
+	}
+	return ServicePortName
+}
+
+func makeServicePorts(rev *v1alpha1.Revision, port int32) []corev1.ServicePort {
+	return []corev1.ServicePort{{
+		Name:       ServicePortNameFor(rev.GetProtocol()),
 		Protocol:   corev1.ProtocolTCP,
-		Port:       ServicePort,
+		Port:       port,
 		TargetPort: intstr.FromString(v1alpha1.RequestQueuePortName),
 	}, {
 		Name:       MetricsPortName,
@@ -40,11 +50,12 @@ var (
 		Port:       MetricsPort,
 		TargetPort: intstr.FromString(v1alpha1.RequestQueueMetricsPortName),
 	}}
-)
+}
 
 // MakeK8sService creates a Kubernetes Service that targets all pods with the same
-// serving.RevisionLabelKey label. Traffic is routed to queue-proxy port.
-func MakeK8sService(rev *v1alpha1.Revision) *corev1.Service {
+// serving.RevisionLabelKey label. Traffic is routed to queue-proxy port, from
+// the port configured by the network config.
+func MakeK8sService(rev *v1alpha1.Revision, networkConfig *config.Network) *corev1.Service {
 	labels := makeLabels(rev)
 	labels[autoscaling.KPALabelKey] = names.KPA(rev)
 	return &corev1.Service{
@@ -56,7 +67,7 @@ func MakeK8sService(rev *v1alpha1.Revision) *corev1.Service {
 			OwnerReferences: []metav1.OwnerReference{*kmeta.NewControllerRef(rev)},
 		},
 		Spec: corev1.ServiceSpec{
-			Ports: servicePorts,
+			Ports: makeServicePorts(rev, networkConfig.DefaultRevisionPort),
 			Selector: map[string]string{
 				serving.RevisionLabelKey: rev.Name,
 			},
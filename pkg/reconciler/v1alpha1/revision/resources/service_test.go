@@ -22,19 +22,23 @@ import (
 	"github.com/google/go-cmp/cmp"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
 
 	"github.com/knative/serving/pkg/apis/autoscaling"
 	"github.com/knative/serving/pkg/apis/serving"
 	"github.com/knative/serving/pkg/apis/serving/v1alpha1"
+	"github.com/knative/serving/pkg/reconciler/v1alpha1/revision/config"
 )
 
 func TestMakeK8sService(t *testing.T) {
 	tests := []struct {
 		name string
 		rev  *v1alpha1.Revision
+		port int32
 		want *corev1.Service
 	}{{
 		name: "name is bar",
+		port: 80,
 		rev: &v1alpha1.Revision{
 			ObjectMeta: metav1.ObjectMeta{
 				Namespace: "foo",
@@ -63,14 +67,15 @@ func TestMakeK8sService(t *testing.T) {
 				}},
 			},
 			Spec: corev1.ServiceSpec{
-				Ports: servicePorts,
+				Ports: wantServicePorts(ServicePortName, 80),
 				Selector: map[string]string{
 					serving.RevisionLabelKey: "bar",
 				},
 			},
 		},
 	}, {
-		name: "name is baz",
+		name: "name is baz, on a custom port",
+		port: 8080,
 		rev: &v1alpha1.Revision{
 			ObjectMeta: metav1.ObjectMeta{
 				Namespace: "blah",
@@ -99,20 +104,76 @@ func TestMakeK8sService(t *testing.T) {
 				}},
 			},
 			Spec: corev1.ServiceSpec{
-				Ports: servicePorts,
+				Ports: wantServicePorts(ServicePortName, 8080),
 				Selector: map[string]string{
 					serving.RevisionLabelKey: "baz",
 				},
 			},
 		},
+	}, {
+		name: "h2c revision",
+		port: 80,
+		rev: &v1alpha1.Revision{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "blah",
+				Name:      "grpc",
+				UID:       "1234",
+			},
+			Spec: v1alpha1.RevisionSpec{
+				Container: corev1.Container{
+					Ports: []corev1.ContainerPort{{Name: "h2c"}},
+				},
+			},
+		},
+		want: &corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "blah",
+				Name:      "grpc-service",
+				Labels: map[string]string{
+					autoscaling.KPALabelKey:  "grpc",
+					serving.RevisionLabelKey: "grpc",
+					serving.RevisionUID:      "1234",
+					AppLabelKey:              "grpc",
+				},
+				Annotations: map[string]string{},
+				OwnerReferences: []metav1.OwnerReference{{
+					APIVersion:         v1alpha1.SchemeGroupVersion.String(),
+					Kind:               "Revision",
+					Name:               "grpc",
+					UID:                "1234",
+					Controller:         &boolTrue,
+					BlockOwnerDeletion: &boolTrue,
+				}},
+			},
+			Spec: corev1.ServiceSpec{
+				Ports: wantServicePorts(ServicePortNameH2C, 80),
+				Selector: map[string]string{
+					serving.RevisionLabelKey: "grpc",
+				},
+			},
+		},
 	}}
 
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
-			got := MakeK8sService(test.rev)
+			got := MakeK8sService(test.rev, &config.Network{DefaultRevisionPort: test.port})
 			if diff := cmp.Diff(test.want, got); diff != "" {
 				t.Errorf("MakeK8sService (-want, +got) = %v", diff)
 			}
 		})
 	}
 }
+
+func wantServicePorts(name string, port int32) []corev1.ServicePort {
+	return []corev1.ServicePort{{
+		Name:       name,
+		Protocol:   corev1.ProtocolTCP,
+		Port:       port,
+		TargetPort: intstr.FromString(v1alpha1.RequestQueuePortName),
+	}, {
+		Name:       MetricsPortName,
+		Protocol:   corev1.ProtocolTCP,
+		Port:       MetricsPort,
+		TargetPort: intstr.FromString(v1alpha1.RequestQueueMetricsPortName),
+	}}
+}
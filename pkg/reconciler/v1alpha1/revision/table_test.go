@@ -959,7 +959,7 @@ func kpa(namespace, name string, ko ...PodAutoscalerOption) *autoscalingv1alpha1
 
 func svc(namespace, name string, so ...K8sServiceOption) *corev1.Service {
 	rev := rev(namespace, name)
-	s := resources.MakeK8sService(rev)
+	s := resources.MakeK8sService(rev, ReconcilerTestConfig().Network)
 	for _, opt := range so {
 		opt(s)
 	}
@@ -1016,7 +1016,7 @@ var _ configStore = (*testConfigStore)(nil)
 func ReconcilerTestConfig() *config.Config {
 	return &config.Config{
 		Controller: getTestControllerConfig(),
-		Network:    &config.Network{IstioOutboundIPRanges: "*"},
+		Network:    &config.Network{IstioOutboundIPRanges: "*", DefaultRevisionPort: 80},
 		Observability: &config.Observability{
 			LoggingURLTemplate: "http://logger.io/${REVISION_UID}",
 		},
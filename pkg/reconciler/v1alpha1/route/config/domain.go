@@ -17,12 +17,16 @@ limitations under the License.
 package config
 
 import (
+	"bytes"
 	"fmt"
 	"strings"
+	"sync"
+	"text/template"
 
 	"github.com/ghodss/yaml"
 	"github.com/knative/serving/pkg/utils"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
 )
 
 const (
@@ -36,6 +40,10 @@ const (
 	// that will result to the Route/KService getting a cluster local
 	// domain suffix.
 	VisibilityClusterLocal = "cluster-local"
+	// DomainTemplateKey is the config-domain key holding an optional Go
+	// text/template that lays out the hosts of Routes, in place of the
+	// default {{.Name}}.{{.Namespace}}.{{.Domain}}.
+	DomainTemplateKey = "domainTemplate"
 )
 
 // LabelSelector represents map of {key,value} pairs. A single {key,value} in the
@@ -61,12 +69,31 @@ func (s *LabelSelector) Matches(labels map[string]string) bool {
 
 // Domain maps domains to routes by matching the domain's
 // label selectors to the route's labels.
+//
+// +k8s:deepcopy-gen=false
 type Domain struct {
 	// Domains map from domain to label selector.  If a route has
 	// labels matching a particular selector, it will use the
 	// corresponding domain.  If multiple selectors match, we choose
 	// the most specific selector.
 	Domains map[string]*LabelSelector
+
+	// DomainTemplate optionally lays out the hosts of Routes, and is
+	// rendered with DomainTemplateValues.  It's parsed once, when the
+	// ConfigMap is read, and never modified afterwards.
+	DomainTemplate *template.Template
+}
+
+// DomainTemplateValues are the values a DomainTemplate is rendered with.
+type DomainTemplateValues struct {
+	// Name of the Route.
+	Name string
+	// Namespace of the Route.
+	Namespace string
+	// Domain is the domain looked up for the Route's labels.
+	Domain string
+	// Labels of the Route.
+	Labels map[string]string
 }
 
 // NewDomainFromConfigMap creates a Domain from the supplied ConfigMap
@@ -74,6 +101,14 @@ func NewDomainFromConfigMap(configMap *corev1.ConfigMap) (*Domain, error) {
 	c := Domain{Domains: map[string]*LabelSelector{}}
 	hasDefault := false
 	for k, v := range configMap.Data {
+		if k == DomainTemplateKey {
+			tmpl, err := template.New(DomainTemplateKey).Option("missingkey=error").Parse(v)
+			if err != nil {
+				return nil, fmt.Errorf("Invalid %s %q: %v", DomainTemplateKey, v, err)
+			}
+			c.DomainTemplate = tmpl
+			continue
+		}
 		labelSelector := LabelSelector{}
 		err := yaml.Unmarshal([]byte(v), &labelSelector)
 		if err != nil {
@@ -114,3 +149,129 @@ func (c *Domain) LookupDomainForLabels(labels map[string]string) string {
 
 	return domain
 }
+
+// maxCachedDomains bounds the number of label sets a DomainCache remembers
+// the domain of.
+const maxCachedDomains = 4096
+
+// DomainCache memoizes the domains a Domain looks up for the labels of
+// Routes, so that its selectors are matched once per distinct combination of
+// the labels they look at, rather than on every reconcile.  Lookups are keyed
+// by a hash of those labels only, and don't allocate once cached.  A
+// DomainCache is bound to the Domain it was created for, and is dropped with
+// it when config-domain changes.
+//
+// +k8s:deepcopy-gen=false
+type DomainCache struct {
+	domain *Domain
+	// keys are the labels a lookup depends on, in a fixed order.
+	keys []string
+
+	mu      sync.RWMutex
+	domains map[uint64]cachedDomain
+}
+
+// cachedDomain is the domain looked up for the given values of the keys of
+// a DomainCache.
+type cachedDomain struct {
+	labels []cachedLabel
+	domain string
+}
+
+type cachedLabel struct {
+	value string
+	ok    bool
+}
+
+// NewDomainCache creates an empty DomainCache for the given Domain, which
+// mustn't be modified afterwards.
+func NewDomainCache(domain *Domain) *DomainCache {
+	keys := sets.NewString(VisibilityLabelKey)
+	for _, selector := range domain.Domains {
+		for k := range selector.Selector {
+			keys.Insert(k)
+		}
+	}
+	return &DomainCache{
+		domain:  domain,
+		keys:    keys.List(),
+		domains: make(map[uint64]cachedDomain),
+	}
+}
+
+// LookupDomainForLabels returns the same domain as the LookupDomainForLabels
+// of the cached Domain.
+func (c *DomainCache) LookupDomainForLabels(labels map[string]string) string {
+	key := c.hash(labels)
+
+	c.mu.RLock()
+	cached, ok := c.domains[key]
+	c.mu.RUnlock()
+	if ok && c.matches(cached, labels) {
+		return cached.domain
+	}
+
+	domain := c.domain.LookupDomainForLabels(labels)
+	if ok {
+		// Don't evict the label set colliding with this one.
+		return domain
+	}
+	cached = cachedDomain{
+		labels: make([]cachedLabel, len(c.keys)),
+		domain: domain,
+	}
+	for i, k := range c.keys {
+		cached.labels[i].value, cached.labels[i].ok = labels[k]
+	}
+	c.mu.Lock()
+	if len(c.domains) < maxCachedDomains {
+		c.domains[key] = cached
+	}
+	c.mu.Unlock()
+	return domain
+}
+
+// hash returns the FNV-1a hash of the labels the lookup depends on.
+func (c *DomainCache) hash(labels map[string]string) uint64 {
+	const (
+		offset64 = 14695981039346656037
+		prime64  = 1099511628211
+	)
+	h := uint64(offset64)
+	for _, k := range c.keys {
+		value, ok := labels[k]
+		if ok {
+			h = (h ^ 1) * prime64
+		} else {
+			h = (h ^ 0) * prime64
+		}
+		for i := 0; i < len(value); i++ {
+			h = (h ^ uint64(value[i])) * prime64
+		}
+		// Label values can't contain 0xff, which ends each of them.
+		h = (h ^ 0xff) * prime64
+	}
+	return h
+}
+
+// matches returns whether the cached domain was looked up for the same
+// labels as the given ones, as far as the lookup is concerned.
+func (c *DomainCache) matches(cached cachedDomain, labels map[string]string) bool {
+	for i, k := range c.keys {
+		value, ok := labels[k]
+		if ok != cached.labels[i].ok || value != cached.labels[i].value {
+			return false
+		}
+	}
+	return true
+}
+
+// RenderDomainTemplate renders the DomainTemplate with the given values.
+// It fails when the template refers to missing values.
+func (c *Domain) RenderDomainTemplate(values DomainTemplateValues) (string, error) {
+	buf := bytes.Buffer{}
+	if err := c.DomainTemplate.Execute(&buf, values); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
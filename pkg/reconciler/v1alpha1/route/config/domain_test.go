@@ -122,6 +122,89 @@ func TestNewConfig(t *testing.T) {
 	}
 }
 
+func TestNewConfigDomainTemplate(t *testing.T) {
+	newDomain := func(tmpl string) (*Domain, error) {
+		return NewDomainFromConfigMap(&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: system.Namespace(),
+				Name:      DomainConfigName,
+			},
+			Data: map[string]string{
+				"default.com":     "",
+				DomainTemplateKey: tmpl,
+			},
+		})
+	}
+	c, err := newDomain("{{.Name}}-{{.Namespace}}.{{.Domain}}")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if diff := cmp.Diff(map[string]*LabelSelector{"default.com": {}}, c.Domains); diff != "" {
+		t.Errorf("Unexpected domains diff (-want +got): %s", diff)
+	}
+	if c.DomainTemplate == nil {
+		t.Fatal("DomainTemplate = nil, wanted the parsed template")
+	}
+	got, err := c.RenderDomainTemplate(DomainTemplateValues{Name: "my-route", Namespace: "default", Domain: "default.com"})
+	if err != nil {
+		t.Fatalf("RenderDomainTemplate() = %v", err)
+	}
+	if want := "my-route-default.default.com"; got != want {
+		t.Errorf("RenderDomainTemplate() = %q, want %q", got, want)
+	}
+
+	if _, err := newDomain("{{.Name"); err == nil {
+		t.Error("NewDomainFromConfigMap() = nil, wanted an error for an invalid template")
+	}
+}
+
+func TestRenderDomainTemplate(t *testing.T) {
+	values := DomainTemplateValues{
+		Name:      "my-route",
+		Namespace: "default",
+		Domain:    "example.com",
+		Labels:    map[string]string{"team": "payments"},
+	}
+	tests := []struct {
+		name     string
+		template string
+		want     string
+		wantErr  bool
+	}{{
+		name:     "omits the default namespace",
+		template: `{{.Name}}{{if ne .Namespace "default"}}.{{.Namespace}}{{end}}.{{.Domain}}`,
+		want:     "my-route.example.com",
+	}, {
+		name:     "uses labels",
+		template: `{{.Name}}.{{index .Labels "team"}}.{{.Domain}}`,
+		want:     "my-route.payments.example.com",
+	}, {
+		name:     "unknown field",
+		template: "{{.Host}}.{{.Domain}}",
+		wantErr:  true,
+	}}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c, err := NewDomainFromConfigMap(&corev1.ConfigMap{
+				Data: map[string]string{
+					"default.com":     "",
+					DomainTemplateKey: test.template,
+				},
+			})
+			if err != nil {
+				t.Fatalf("NewDomainFromConfigMap() = %v", err)
+			}
+			got, err := c.RenderDomainTemplate(values)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("RenderDomainTemplate() = %v, wantErr %v", err, test.wantErr)
+			}
+			if got != test.want {
+				t.Errorf("RenderDomainTemplate() = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
 func TestLookupDomainForLabels(t *testing.T) {
 	config := Domain{
 		Domains: map[string]*LabelSelector{
@@ -172,11 +255,93 @@ func TestLookupDomainForLabels(t *testing.T) {
 		domain: "svc." + utils.GetClusterDomainName(),
 	}}
 
+	cache := NewDomainCache(&config)
 	for _, expected := range expectations {
 		domain := config.LookupDomainForLabels(expected.labels)
 		if expected.domain != domain {
 			t.Errorf("Expected domain %q got %q", expected.domain, domain)
 		}
+		// Look up twice, to check both cache misses and hits.
+		for i := 0; i < 2; i++ {
+			if domain := cache.LookupDomainForLabels(expected.labels); expected.domain != domain {
+				t.Errorf("Expected cached domain %q got %q", expected.domain, domain)
+			}
+		}
+	}
+}
+
+func benchmarkDomain() (*Domain, map[string]string) {
+	config := &Domain{
+		Domains: map[string]*LabelSelector{"default.com": {}},
+	}
+	for i := 0; i < 100; i++ {
+		config.Domains[fmt.Sprintf("team%d.com", i)] = &LabelSelector{
+			Selector: map[string]string{
+				"team": fmt.Sprintf("team%d", i),
+				"env":  "prod",
+			},
+		}
+	}
+	labels := map[string]string{
+		"team":                        "team42",
+		"env":                         "prod",
+		"serving.knative.dev/service": "hello",
+	}
+	return config, labels
+}
+
+func BenchmarkLookupDomainForLabels(b *testing.B) {
+	config, labels := benchmarkDomain()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		config.LookupDomainForLabels(labels)
+	}
+}
+
+func BenchmarkDomainCacheLookupDomainForLabels(b *testing.B) {
+	config, labels := benchmarkDomain()
+	cache := NewDomainCache(config)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		cache.LookupDomainForLabels(labels)
+	}
+}
+
+func TestDomainCacheKeys(t *testing.T) {
+	config, labels := benchmarkDomain()
+	cache := NewDomainCache(config)
+
+	// Labels no selector looks at don't make for another entry.
+	for i := 0; i < 10; i++ {
+		labels["serving.knative.dev/service"] = fmt.Sprintf("hello-%d", i)
+		if got, want := cache.LookupDomainForLabels(labels), "team42.com"; got != want {
+			t.Errorf("LookupDomainForLabels() = %q, want %q", got, want)
+		}
+	}
+	if got := len(cache.domains); got != 1 {
+		t.Errorf("len(domains) = %d, want 1", got)
+	}
+
+	// A label set with a label missing isn't the one with it empty.
+	delete(labels, "env")
+	if got, want := cache.LookupDomainForLabels(labels), "default.com"; got != want {
+		t.Errorf("LookupDomainForLabels() = %q, want %q", got, want)
+	}
+	labels["env"] = ""
+	if got, want := cache.LookupDomainForLabels(labels), "default.com"; got != want {
+		t.Errorf("LookupDomainForLabels() = %q, want %q", got, want)
+	}
+	if got := len(cache.domains); got != 3 {
+		t.Errorf("len(domains) = %d, want 3", got)
+	}
+
+	// The cache stops growing once full.
+	for i := 0; i < maxCachedDomains+10; i++ {
+		labels["team"] = fmt.Sprintf("other%d", i)
+		cache.LookupDomainForLabels(labels)
+	}
+	if got := len(cache.domains); got != maxCachedDomains {
+		t.Errorf("len(domains) = %d, want %d", got, maxCachedDomains)
 	}
 }
 
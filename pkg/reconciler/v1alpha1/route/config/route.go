@@ -0,0 +1,133 @@
+/*
+Copyright 2019 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// RouteConfigName is the name of the configmap containing the
+	// customizations of how Routes are reconciled.
+	RouteConfigName = "config-route"
+
+	// AllowCrossNamespaceTrafficKey is the name of the configuration
+	// entry that specifies whether Routes may send traffic to the
+	// Revisions of other namespaces.
+	AllowCrossNamespaceTrafficKey = "allowCrossNamespaceTraffic"
+
+	// TrafficHistoryLimitKey is the name of the configuration entry
+	// that specifies how many past traffic assignments a Route keeps
+	// in its status.
+	TrafficHistoryLimitKey = "trafficHistoryLimit"
+
+	// RevisionReadyTimeoutKey is the name of the configuration entry
+	// that specifies how long a Revision targeted by a Route may stay
+	// not ready before the Route reports it as failed.
+	RevisionReadyTimeoutKey = "revisionReadyTimeout"
+
+	// RevisionReadyPollIntervalKey is the name of the configuration
+	// entry that specifies how often a Route waiting for a Revision
+	// to become ready looks at it again.
+	RevisionReadyPollIntervalKey = "revisionReadyPollInterval"
+
+	// RevisionDrainWindowKey is the name of the configuration entry
+	// that specifies how long a Revision that stopped receiving a
+	// Route's traffic keeps its destination at 0%.
+	RevisionDrainWindowKey = "revisionDrainWindow"
+
+	// defaultTrafficHistoryLimit is the number of past traffic
+	// assignments kept when TrafficHistoryLimitKey is absent.
+	defaultTrafficHistoryLimit = 5
+)
+
+// Route contains the configuration of how Routes are reconciled, defined
+// in the route config map.
+type Route struct {
+	// AllowCrossNamespaceTraffic specifies whether Routes may send
+	// traffic to the Revisions of other namespaces.
+	AllowCrossNamespaceTraffic bool
+
+	// TrafficHistoryLimit specifies how many past traffic assignments
+	// a Route keeps in its status, or zero to keep none.
+	TrafficHistoryLimit int
+
+	// RevisionReadyTimeout specifies how long a Revision targeted by a
+	// Route may stay not ready before the Route reports it as failed,
+	// or zero to wait indefinitely.
+	RevisionReadyTimeout time.Duration
+
+	// RevisionReadyPollInterval specifies how often a Route waiting for
+	// a Revision to become ready looks at it again, or zero to only wait
+	// for changes to the Revision.
+	RevisionReadyPollInterval time.Duration
+
+	// RevisionDrainWindow specifies how long a Revision that stopped
+	// receiving a Route's traffic keeps its destination at 0% so that
+	// in-flight requests complete, or zero to remove it right away.
+	RevisionDrainWindow time.Duration
+}
+
+// parseDuration sets *field to the non-negative duration of the
+// configuration entry key, when it is present.
+func parseDuration(data map[string]string, key string, field *time.Duration) error {
+	raw, ok := data[key]
+	if !ok {
+		return nil
+	}
+	d, err := time.ParseDuration(raw)
+	if err != nil || d < 0 {
+		return fmt.Errorf("%s = %q, must be a non-negative duration", key, raw)
+	}
+	*field = d
+	return nil
+}
+
+// NewRouteFromConfigMap creates a Route from the supplied ConfigMap
+func NewRouteFromConfigMap(configMap *corev1.ConfigMap) (*Route, error) {
+	rc := &Route{
+		TrafficHistoryLimit: defaultTrafficHistoryLimit,
+	}
+	if raw, ok := configMap.Data[AllowCrossNamespaceTrafficKey]; ok {
+		allow, err := strconv.ParseBool(raw)
+		if err != nil {
+			return nil, fmt.Errorf("%s = %q, must be true or false", AllowCrossNamespaceTrafficKey, raw)
+		}
+		rc.AllowCrossNamespaceTraffic = allow
+	}
+	if raw, ok := configMap.Data[TrafficHistoryLimitKey]; ok {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit < 0 {
+			return nil, fmt.Errorf("%s = %q, must be a non-negative number", TrafficHistoryLimitKey, raw)
+		}
+		rc.TrafficHistoryLimit = limit
+	}
+	if err := parseDuration(configMap.Data, RevisionReadyTimeoutKey, &rc.RevisionReadyTimeout); err != nil {
+		return nil, err
+	}
+	if err := parseDuration(configMap.Data, RevisionReadyPollIntervalKey, &rc.RevisionReadyPollInterval); err != nil {
+		return nil, err
+	}
+	if err := parseDuration(configMap.Data, RevisionDrainWindowKey, &rc.RevisionDrainWindow); err != nil {
+		return nil, err
+	}
+	return rc, nil
+}
@@ -0,0 +1,139 @@
+/*
+Copyright 2019 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/knative/serving/pkg/system"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	. "github.com/knative/serving/pkg/reconciler/testing"
+)
+
+func TestOurRoute(t *testing.T) {
+	cm := ConfigMapFromTestFile(t, RouteConfigName)
+
+	if _, err := NewRouteFromConfigMap(cm); err != nil {
+		t.Errorf("NewRouteFromConfigMap() = %v", err)
+	}
+}
+
+func TestRouteConfiguration(t *testing.T) {
+	routeConfigTests := []struct {
+		name      string
+		data      map[string]string
+		wantErr   bool
+		wantRoute *Route
+	}{{
+		name:      "route configuration with no input",
+		wantRoute: &Route{TrafficHistoryLimit: 5},
+	}, {
+		name: "route configuration allowing cross-namespace traffic",
+		data: map[string]string{
+			AllowCrossNamespaceTrafficKey: "true",
+		},
+		wantRoute: &Route{
+			TrafficHistoryLimit:        5,
+			AllowCrossNamespaceTraffic: true,
+		},
+	}, {
+		name: "route configuration with invalid cross-namespace traffic flag",
+		data: map[string]string{
+			AllowCrossNamespaceTrafficKey: "sometimes",
+		},
+		wantErr: true,
+	}, {
+		name: "route configuration without traffic history",
+		data: map[string]string{
+			TrafficHistoryLimitKey: "0",
+		},
+		wantRoute: &Route{},
+	}, {
+		name: "route configuration with negative traffic history limit",
+		data: map[string]string{
+			TrafficHistoryLimitKey: "-1",
+		},
+		wantErr: true,
+	}, {
+		name: "route configuration with revision ready timeout",
+		data: map[string]string{
+			RevisionReadyTimeoutKey: "10m",
+		},
+		wantRoute: &Route{
+			TrafficHistoryLimit:  5,
+			RevisionReadyTimeout: 10 * time.Minute,
+		},
+	}, {
+		name: "route configuration with invalid revision ready timeout",
+		data: map[string]string{
+			RevisionReadyTimeoutKey: "soon",
+		},
+		wantErr: true,
+	}, {
+		name: "route configuration with revision ready poll interval",
+		data: map[string]string{
+			RevisionReadyPollIntervalKey: "30s",
+		},
+		wantRoute: &Route{
+			TrafficHistoryLimit:       5,
+			RevisionReadyPollInterval: 30 * time.Second,
+		},
+	}, {
+		name: "route configuration with negative revision ready poll interval",
+		data: map[string]string{
+			RevisionReadyPollIntervalKey: "-30s",
+		},
+		wantErr: true,
+	}, {
+		name: "route configuration with revision drain window",
+		data: map[string]string{
+			RevisionDrainWindowKey: "1m",
+		},
+		wantRoute: &Route{
+			TrafficHistoryLimit: 5,
+			RevisionDrainWindow: time.Minute,
+		},
+	}, {
+		name: "route configuration with invalid revision drain window",
+		data: map[string]string{
+			RevisionDrainWindowKey: "a while",
+		},
+		wantErr: true,
+	}}
+
+	for _, tt := range routeConfigTests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewRouteFromConfigMap(&corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: system.Namespace(),
+					Name:      RouteConfigName,
+				},
+				Data: tt.data,
+			})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewRouteFromConfigMap() error = %v, WantErr %v", err, tt.wantErr)
+			}
+			if diff := cmp.Diff(tt.wantRoute, got); diff != "" {
+				t.Errorf("Unexpected route config (-want, +got): %v", diff)
+			}
+		})
+	}
+}
@@ -18,17 +18,28 @@ package config
 
 import (
 	"context"
+	"sync"
 
 	"github.com/knative/pkg/configmap"
 	"github.com/knative/serving/pkg/gc"
+	ingressconfig "github.com/knative/serving/pkg/reconciler/v1alpha1/clusteringress/config"
+	revisionconfig "github.com/knative/serving/pkg/reconciler/v1alpha1/revision/config"
 )
 
 type cfgKey struct{}
 
 // +k8s:deepcopy-gen=false
 type Config struct {
-	Domain *Domain
-	GC     *gc.Config
+	// Domain shares its selectors with the Store, which mustn't be modified.
+	Domain  *Domain
+	GC      *gc.Config
+	Network *revisionconfig.Network
+	Route   *Route
+	Istio   *ingressconfig.Istio
+
+	// DomainCache memoizes the lookups of Domain.  It is nil when the
+	// Config wasn't loaded from a Store.
+	DomainCache *DomainCache
 }
 
 func FromContext(ctx context.Context) *Config {
@@ -45,6 +56,11 @@ func ToContext(ctx context.Context, c *Config) context.Context {
 // +k8s:deepcopy-gen=false
 type Store struct {
 	*configmap.UntypedStore
+
+	// domainCache memoizes the lookups of the Domain currently stored,
+	// and is replaced as soon as another one is.
+	domainCacheMu sync.Mutex
+	domainCache   *DomainCache
 }
 
 // NewStore creates a configmap.UntypedStore based config store.
@@ -62,8 +78,11 @@ func NewStore(logger configmap.Logger, onAfterStore ...func(name string, value i
 			"route",
 			logger,
 			configmap.Constructors{
-				DomainConfigName: NewDomainFromConfigMap,
-				gc.ConfigName:    gc.NewConfigFromConfigMap,
+				DomainConfigName:                 NewDomainFromConfigMap,
+				gc.ConfigName:                    gc.NewConfigFromConfigMap,
+				revisionconfig.NetworkConfigName: revisionconfig.NewNetworkFromConfigMap,
+				RouteConfigName:                  NewRouteFromConfigMap,
+				ingressconfig.IstioConfigName:    ingressconfig.NewIstioFromConfigMap,
 			},
 			onAfterStore...,
 		),
@@ -77,8 +96,29 @@ func (s *Store) ToContext(ctx context.Context) context.Context {
 }
 
 func (s *Store) Load() *Config {
+	domain := s.UntypedLoad(DomainConfigName).(*Domain)
+	// Copying the selectors would allocate for each of them on every
+	// reconcile, so they are shared with the stored Domain, and mustn't be
+	// modified.
+	shared := *domain
 	return &Config{
-		Domain: s.UntypedLoad(DomainConfigName).(*Domain).DeepCopy(),
-		GC:     s.UntypedLoad(gc.ConfigName).(*gc.Config).DeepCopy(),
+		Domain:      &shared,
+		GC:          s.UntypedLoad(gc.ConfigName).(*gc.Config).DeepCopy(),
+		Network:     s.UntypedLoad(revisionconfig.NetworkConfigName).(*revisionconfig.Network).DeepCopy(),
+		Route:       s.UntypedLoad(RouteConfigName).(*Route).DeepCopy(),
+		Istio:       s.UntypedLoad(ingressconfig.IstioConfigName).(*ingressconfig.Istio).DeepCopy(),
+		DomainCache: s.domainCacheFor(domain),
+	}
+}
+
+// domainCacheFor returns the DomainCache of the given stored Domain.  The
+// stored Domain is swapped out rather than modified when config-domain
+// changes, so a cache of another one is stale.
+func (s *Store) domainCacheFor(domain *Domain) *DomainCache {
+	s.domainCacheMu.Lock()
+	defer s.domainCacheMu.Unlock()
+	if s.domainCache == nil || s.domainCache.domain != domain {
+		s.domainCache = NewDomainCache(domain)
 	}
+	return s.domainCache
 }
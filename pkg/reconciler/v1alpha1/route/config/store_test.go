@@ -18,10 +18,16 @@ package config
 
 import (
 	"context"
+	"fmt"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/knative/serving/pkg/gc"
+	ingressconfig "github.com/knative/serving/pkg/reconciler/v1alpha1/clusteringress/config"
+	revisionconfig "github.com/knative/serving/pkg/reconciler/v1alpha1/revision/config"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	. "github.com/knative/pkg/logging/testing"
 	. "github.com/knative/serving/pkg/reconciler/testing"
@@ -32,9 +38,15 @@ func TestStoreLoadWithContext(t *testing.T) {
 
 	domainConfig := ConfigMapFromTestFile(t, DomainConfigName)
 	gcConfig := ConfigMapFromTestFile(t, gc.ConfigName)
+	networkConfig := ConfigMapFromTestFile(t, revisionconfig.NetworkConfigName)
+	routeConfig := ConfigMapFromTestFile(t, RouteConfigName)
+	istioConfig := ConfigMapFromTestFile(t, ingressconfig.IstioConfigName)
 
 	store.OnConfigChanged(domainConfig)
 	store.OnConfigChanged(gcConfig)
+	store.OnConfigChanged(networkConfig)
+	store.OnConfigChanged(routeConfig)
+	store.OnConfigChanged(istioConfig)
 
 	config := FromContext(store.ToContext(context.Background()))
 
@@ -51,12 +63,36 @@ func TestStoreLoadWithContext(t *testing.T) {
 			t.Errorf("Unexpected controller config (-want, +got): %v", diff)
 		}
 	})
+
+	t.Run("network", func(t *testing.T) {
+		expected, _ := revisionconfig.NewNetworkFromConfigMap(networkConfig)
+		if diff := cmp.Diff(expected, config.Network); diff != "" {
+			t.Errorf("Unexpected network config (-want, +got): %v", diff)
+		}
+	})
+
+	t.Run("route", func(t *testing.T) {
+		expected, _ := NewRouteFromConfigMap(routeConfig)
+		if diff := cmp.Diff(expected, config.Route); diff != "" {
+			t.Errorf("Unexpected route config (-want, +got): %v", diff)
+		}
+	})
+
+	t.Run("istio", func(t *testing.T) {
+		expected, _ := ingressconfig.NewIstioFromConfigMap(istioConfig)
+		if diff := cmp.Diff(expected, config.Istio); diff != "" {
+			t.Errorf("Unexpected istio config (-want, +got): %v", diff)
+		}
+	})
 }
 
 func TestStoreImmutableConfig(t *testing.T) {
 	store := NewStore(TestLogger(t))
 	store.OnConfigChanged(ConfigMapFromTestFile(t, DomainConfigName))
 	store.OnConfigChanged(ConfigMapFromTestFile(t, gc.ConfigName))
+	store.OnConfigChanged(ConfigMapFromTestFile(t, revisionconfig.NetworkConfigName))
+	store.OnConfigChanged(ConfigMapFromTestFile(t, RouteConfigName))
+	store.OnConfigChanged(ConfigMapFromTestFile(t, ingressconfig.IstioConfigName))
 
 	config := store.Load()
 
@@ -70,3 +106,66 @@ func TestStoreImmutableConfig(t *testing.T) {
 		t.Error("Domain config is not immutable")
 	}
 }
+
+func TestStoreDomainCache(t *testing.T) {
+	store := NewStore(TestLogger(t))
+	store.OnConfigChanged(ConfigMapFromTestFile(t, DomainConfigName))
+	store.OnConfigChanged(ConfigMapFromTestFile(t, gc.ConfigName))
+	store.OnConfigChanged(ConfigMapFromTestFile(t, revisionconfig.NetworkConfigName))
+	store.OnConfigChanged(ConfigMapFromTestFile(t, RouteConfigName))
+	store.OnConfigChanged(ConfigMapFromTestFile(t, ingressconfig.IstioConfigName))
+
+	labels := map[string]string{"app": "prod"}
+	config := store.Load()
+	if got, want := config.DomainCache.LookupDomainForLabels(labels), "example.com"; got != want {
+		t.Errorf("LookupDomainForLabels() = %q, want %q", got, want)
+	}
+	if store.Load().DomainCache != config.DomainCache {
+		t.Error("DomainCache was not kept while the domain config was unchanged")
+	}
+
+	store.OnConfigChanged(&corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: DomainConfigName,
+		},
+		Data: map[string]string{
+			"example.com": "",
+			"example.org": "selector:\n  app: prod",
+		},
+	})
+	if got, want := store.Load().DomainCache.LookupDomainForLabels(labels), "example.org"; got != want {
+		t.Errorf("LookupDomainForLabels() after update = %q, want %q", got, want)
+	}
+	// Configs loaded before the update keep resolving consistently with
+	// their Domain.
+	if got, want := config.DomainCache.LookupDomainForLabels(labels), "example.com"; got != want {
+		t.Errorf("LookupDomainForLabels() of the old config = %q, want %q", got, want)
+	}
+}
+
+func BenchmarkStoreLoadLookupDomain(b *testing.B) {
+	store := NewStore(zap.NewNop().Sugar())
+	data := map[string]string{"default.com": ""}
+	for i := 0; i < 100; i++ {
+		data[fmt.Sprintf("team%d.com", i)] = fmt.Sprintf("selector:\n  team: team%d\n  env: prod", i)
+	}
+	store.OnConfigChanged(&corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: DomainConfigName},
+		Data:       data,
+	})
+	store.OnConfigChanged(ConfigMapFromTestFile(b, gc.ConfigName))
+	store.OnConfigChanged(ConfigMapFromTestFile(b, revisionconfig.NetworkConfigName))
+	store.OnConfigChanged(ConfigMapFromTestFile(b, RouteConfigName))
+	store.OnConfigChanged(ConfigMapFromTestFile(b, ingressconfig.IstioConfigName))
+	labels := map[string]string{
+		"team":                        "team42",
+		"env":                         "prod",
+		"serving.knative.dev/service": "hello",
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		// As each reconcile of a Route does.
+		store.Load().DomainCache.LookupDomainForLabels(labels)
+	}
+}
@@ -21,52 +21,40 @@ limitations under the License.
 package config
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *Domain) DeepCopyInto(out *Domain) {
+func (in *LabelSelector) DeepCopyInto(out *LabelSelector) {
 	*out = *in
-	if in.Domains != nil {
-		in, out := &in.Domains, &out.Domains
-		*out = make(map[string]*LabelSelector, len(*in))
+	if in.Selector != nil {
+		in, out := &in.Selector, &out.Selector
+		*out = make(map[string]string, len(*in))
 		for key, val := range *in {
-			if val == nil {
-				(*out)[key] = nil
-			} else {
-				(*out)[key] = new(LabelSelector)
-				val.DeepCopyInto((*out)[key])
-			}
+			(*out)[key] = val
 		}
 	}
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Domain.
-func (in *Domain) DeepCopy() *Domain {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LabelSelector.
+func (in *LabelSelector) DeepCopy() *LabelSelector {
 	if in == nil {
 		return nil
 	}
-	out := new(Domain)
+	out := new(LabelSelector)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *LabelSelector) DeepCopyInto(out *LabelSelector) {
+func (in *Route) DeepCopyInto(out *Route) {
 	*out = *in
-	if in.Selector != nil {
-		in, out := &in.Selector, &out.Selector
-		*out = make(map[string]string, len(*in))
-		for key, val := range *in {
-			(*out)[key] = val
-		}
-	}
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LabelSelector.
-func (in *LabelSelector) DeepCopy() *LabelSelector {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Route.
+func (in *Route) DeepCopy() *Route {
 	if in == nil {
 		return nil
 	}
-	out := new(LabelSelector)
+	out := new(Route)
 	in.DeepCopyInto(out)
 	return out
 }
@@ -0,0 +1,42 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package route
+
+import (
+	"context"
+
+	"github.com/knative/serving/pkg/apis/serving/v1alpha1"
+)
+
+// DomainResolver computes the domain a Route is served at, e.g. by asking
+// an external DNS or IPAM system for one.
+type DomainResolver interface {
+	// Resolve returns the domain of the given Route.  The context carries
+	// the configuration the Route is being reconciled with.
+	Resolve(ctx context.Context, route *v1alpha1.Route) (string, error)
+}
+
+// DefaultDomainResolver picks the domain of a Route according to the label
+// selectors and domain template of the config-domain ConfigMap.
+var DefaultDomainResolver DomainResolver = configDomainResolver{}
+
+type configDomainResolver struct{}
+
+// Resolve implements DomainResolver.
+func (configDomainResolver) Resolve(ctx context.Context, route *v1alpha1.Route) (string, error) {
+	return routeDomain(ctx, route), nil
+}
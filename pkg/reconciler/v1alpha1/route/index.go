@@ -0,0 +1,142 @@
+/*
+Copyright 2019 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package route
+
+import (
+	"fmt"
+	"sort"
+
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	"github.com/knative/serving/pkg/apis/serving"
+	"github.com/knative/serving/pkg/apis/serving/v1alpha1"
+)
+
+const (
+	// configurationIndex is the name of the Route informer index keyed by the
+	// namespace/name of the Configurations named in the Routes' traffic.
+	configurationIndex = "configuration"
+
+	// configurationSelectorIndex is the name of the Route informer index keyed
+	// by the namespace of the Routes selecting Configurations in their traffic.
+	configurationSelectorIndex = "configurationSelector"
+)
+
+// indexByConfiguration is the cache.IndexFunc of configurationIndex.
+func indexByConfiguration(obj interface{}) ([]string, error) {
+	route, ok := obj.(*v1alpha1.Route)
+	if !ok {
+		return nil, fmt.Errorf("object of type %T is not a Route", obj)
+	}
+	var keys []string
+	seen := make(map[string]struct{})
+	for _, tt := range route.Spec.Traffic {
+		if tt.ConfigurationName == "" {
+			continue
+		}
+		ns := route.Namespace
+		if tt.Namespace != "" {
+			ns = tt.Namespace
+		}
+		key := ns + "/" + tt.ConfigurationName
+		if _, ok := seen[key]; !ok {
+			seen[key] = struct{}{}
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
+
+// indexByConfigurationSelector is the cache.IndexFunc of
+// configurationSelectorIndex.
+func indexByConfigurationSelector(obj interface{}) ([]string, error) {
+	route, ok := obj.(*v1alpha1.Route)
+	if !ok {
+		return nil, fmt.Errorf("object of type %T is not a Route", obj)
+	}
+	for _, tt := range route.Spec.Traffic {
+		if tt.ConfigurationSelector != nil {
+			return []string{route.Namespace}, nil
+		}
+	}
+	return nil, nil
+}
+
+// RoutesForConfiguration returns the Routes sending traffic to the given
+// Configuration, sorted by name.  These are the Routes naming or selecting it
+// in their traffic, along with the Route recorded by the
+// serving.knative.dev/route label we put on it, which also covers Routes
+// targeting its Revisions.
+func (c *Reconciler) RoutesForConfiguration(cfg *v1alpha1.Configuration) ([]*v1alpha1.Route, error) {
+	objs, err := c.routeIndexer.ByIndex(configurationIndex, cfg.Namespace+"/"+cfg.Name)
+	if err != nil {
+		return nil, err
+	}
+	byName := make(map[string]*v1alpha1.Route, len(objs)+1)
+	for _, obj := range objs {
+		route := obj.(*v1alpha1.Route)
+		byName[route.Name] = route
+	}
+
+	objs, err = c.routeIndexer.ByIndex(configurationSelectorIndex, cfg.Namespace)
+	if err != nil {
+		return nil, err
+	}
+	for _, obj := range objs {
+		route := obj.(*v1alpha1.Route)
+		if _, ok := byName[route.Name]; !ok && selectsConfiguration(route, cfg) {
+			byName[route.Name] = route
+		}
+	}
+
+	if name, ok := cfg.Labels[serving.RouteLabelKey]; ok {
+		if _, ok := byName[name]; !ok {
+			route, err := c.routeLister.Routes(cfg.Namespace).Get(name)
+			if err != nil && !apierrs.IsNotFound(err) {
+				return nil, err
+			} else if err == nil {
+				byName[name] = route
+			}
+		}
+	}
+
+	routes := make([]*v1alpha1.Route, 0, len(byName))
+	for _, route := range byName {
+		routes = append(routes, route)
+	}
+	sort.Slice(routes, func(i, j int) bool {
+		return routes[i].Name < routes[j].Name
+	})
+	return routes, nil
+}
+
+// selectsConfiguration returns whether a traffic target of the Route has a
+// ConfigurationSelector matching the given Configuration.
+func selectsConfiguration(route *v1alpha1.Route, cfg *v1alpha1.Configuration) bool {
+	for _, tt := range route.Spec.Traffic {
+		if tt.ConfigurationSelector == nil {
+			continue
+		}
+		selector, err := metav1.LabelSelectorAsSelector(tt.ConfigurationSelector)
+		if err == nil && selector.Matches(labels.Set(cfg.Labels)) {
+			return true
+		}
+	}
+	return false
+}
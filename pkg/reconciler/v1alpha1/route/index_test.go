@@ -0,0 +1,115 @@
+/*
+Copyright 2019 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package route
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/knative/serving/pkg/apis/serving"
+	"github.com/knative/serving/pkg/apis/serving/v1alpha1"
+	. "github.com/knative/serving/pkg/reconciler/v1alpha1/testing"
+)
+
+func TestRoutesForConfiguration(t *testing.T) {
+	_, _, c, _, servingInformer, _ := newTestReconciler(t)
+
+	configTarget := func(name string, percent int) v1alpha1.TrafficTarget {
+		return v1alpha1.TrafficTarget{ConfigurationName: name, Percent: percent}
+	}
+	for _, r := range []*v1alpha1.Route{
+		route("default", "blue-green", WithSpecTraffic(configTarget("blue", 50), configTarget("green", 50))),
+		route("default", "green", WithConfigTarget("green")),
+		// Targets the Configuration through its Revision, as recorded by its label.
+		route("default", "pinned", WithRevTarget("blue-00001")),
+		route("default", "canary", WithConfigSelectorTarget("track", "canary")),
+		// Same Configuration name, different namespace.
+		route("other", "green", WithConfigTarget("green")),
+		route("other", "canary", WithConfigSelectorTarget("track", "canary")),
+	} {
+		servingInformer.Serving().V1alpha1().Routes().Informer().GetIndexer().Add(r)
+	}
+
+	tests := []struct {
+		name   string
+		config *v1alpha1.Configuration
+		want   []string
+	}{{
+		name:   "by traffic",
+		config: cfg("default", "green"),
+		want:   []string{"blue-green", "green"},
+	}, {
+		name:   "by traffic and label",
+		config: cfg("default", "blue", WithConfigLabel(serving.RouteLabelKey, "pinned")),
+		want:   []string{"blue-green", "pinned"},
+	}, {
+		name:   "label of a missing route",
+		config: cfg("other", "green", WithConfigLabel(serving.RouteLabelKey, "deleted")),
+		want:   []string{"green"},
+	}, {
+		name:   "by selector",
+		config: cfg("default", "red", WithConfigLabel("track", "canary")),
+		want:   []string{"canary"},
+	}, {
+		name:   "by traffic and selector",
+		config: cfg("default", "green", WithConfigLabel("track", "canary")),
+		want:   []string{"blue-green", "canary", "green"},
+	}, {
+		name:   "not targeted",
+		config: cfg("default", "red", WithConfigLabel("track", "stable")),
+		want:   []string{},
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			routes, err := c.RoutesForConfiguration(test.config)
+			if err != nil {
+				t.Fatalf("RoutesForConfiguration() = %v", err)
+			}
+			got := []string{}
+			for _, route := range routes {
+				if route.Namespace != test.config.Namespace {
+					t.Errorf("RoutesForConfiguration() returned Route %s/%s from another namespace",
+						route.Namespace, route.Name)
+				}
+				got = append(got, route.Name)
+			}
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("RoutesForConfiguration() (-want, +got) = %v", diff)
+			}
+		})
+	}
+}
+
+func TestConfigurationChangeEnqueuesItsRoutes(t *testing.T) {
+	_, _, c, _, servingInformer, _ := newTestReconciler(t)
+
+	routes := servingInformer.Serving().V1alpha1().Routes().Informer().GetIndexer()
+	routes.Add(route(testNamespace, "targeting", WithConfigTarget("the-config")))
+	routes.Add(route(testNamespace, "selecting", WithConfigSelectorTarget("track", "canary")))
+	routes.Add(route(testNamespace, "unrelated", WithConfigTarget("another-config")))
+
+	got := []string{}
+	c.enqueueRoutesForConfiguration(func(obj interface{}) {
+		got = append(got, obj.(*v1alpha1.Route).Name)
+	})(cfg(testNamespace, "the-config", WithConfigLabel("track", "canary")))
+
+	if diff := cmp.Diff([]string{"selecting", "targeting"}, got); diff != "" {
+		t.Errorf("Unexpected enqueued Routes (-want +got): %v", diff)
+	}
+}
@@ -28,6 +28,8 @@ import (
 	informers "github.com/knative/serving/pkg/client/informers/externalversions"
 	"github.com/knative/serving/pkg/gc"
 	"github.com/knative/serving/pkg/reconciler"
+	ingressconfig "github.com/knative/serving/pkg/reconciler/v1alpha1/clusteringress/config"
+	revisionconfig "github.com/knative/serving/pkg/reconciler/v1alpha1/revision/config"
 	"github.com/knative/serving/pkg/reconciler/v1alpha1/route/config"
 	"github.com/knative/serving/pkg/system"
 	"golang.org/x/sync/errgroup"
@@ -76,6 +78,26 @@ func TestNewRouteCallsSyncHandler(t *testing.T) {
 			Namespace: system.Namespace(),
 		},
 		Data: map[string]string{},
+	}, &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      revisionconfig.NetworkConfigName,
+			Namespace: system.Namespace(),
+		},
+		Data: map[string]string{},
+	}, &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      config.RouteConfigName,
+			Namespace: system.Namespace(),
+		},
+		Data: map[string]string{},
+	}, &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      ingressconfig.IstioConfigName,
+			Namespace: system.Namespace(),
+		},
+		Data: map[string]string{
+			"gateway.knative-ingress-gateway": "istio-ingressgateway.istio-system.svc.cluster.local",
+		},
 	})
 	sharedClient := fakesharedclientset.NewSimpleClientset()
 	servingClient := fakeclientset.NewSimpleClientset()
@@ -85,7 +107,7 @@ func TestNewRouteCallsSyncHandler(t *testing.T) {
 	kubeInformer := kubeinformers.NewSharedInformerFactory(kubeClient, 0)
 	servingInformer := informers.NewSharedInformerFactory(servingClient, 0)
 
-	controller := NewController(
+	controller, err := NewController(
 		reconciler.Options{
 			KubeClientSet:    kubeClient,
 			SharedClientSet:  sharedClient,
@@ -99,6 +121,9 @@ func TestNewRouteCallsSyncHandler(t *testing.T) {
 		kubeInformer.Core().V1().Services(),
 		servingInformer.Networking().V1alpha1().ClusterIngresses(),
 	)
+	if err != nil {
+		t.Fatalf("NewController() = %v", err)
+	}
 
 	h := NewHooks()
 
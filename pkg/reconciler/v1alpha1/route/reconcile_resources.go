@@ -20,6 +20,7 @@ import (
 	"context"
 	"fmt"
 	"reflect"
+	"strings"
 
 	"github.com/knative/pkg/apis/duck"
 	"github.com/knative/pkg/logging"
@@ -30,6 +31,7 @@ import (
 	"github.com/knative/serving/pkg/reconciler/v1alpha1/route/resources"
 	resourcenames "github.com/knative/serving/pkg/reconciler/v1alpha1/route/resources/names"
 	"github.com/knative/serving/pkg/reconciler/v1alpha1/route/traffic"
+	"github.com/knative/serving/pkg/utils"
 	"go.uber.org/zap"
 	"golang.org/x/sync/errgroup"
 	corev1 "k8s.io/api/core/v1"
@@ -38,6 +40,8 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/util/retry"
 )
 
 func (c *Reconciler) getClusterIngressForRoute(route *v1alpha1.Route) (*netv1alpha1.ClusterIngress, error) {
@@ -61,16 +65,90 @@ func (c *Reconciler) getClusterIngressForRoute(route *v1alpha1.Route) (*netv1alp
 	return ingresses[0], nil
 }
 
+// claimedCustomHosts returns the hosts of the Route's custom domains that
+// another Route, which claimed them first, serves instead, in the order the
+// Route lists them.
+func (c *Reconciler) claimedCustomHosts(r *v1alpha1.Route) ([]string, error) {
+	if len(r.Spec.Domains) == 0 {
+		return nil, nil
+	}
+	routes, err := c.routeLister.List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	owned := sets.NewString()
+	for _, other := range routes {
+		if (other.Namespace == r.Namespace && other.Name == r.Name) || !claimsFirst(other, r) {
+			continue
+		}
+		owned.Insert(resources.CustomHosts(other)...)
+	}
+	var claimed []string
+	for _, host := range resources.CustomHosts(r) {
+		if owned.Has(host) {
+			claimed = append(claimed, host)
+		}
+	}
+	return claimed, nil
+}
+
+// claimsFirst returns whether Route a owns the custom hosts it shares with
+// Route b.  The oldest Route wins, and ties go to the first namespace/name.
+func claimsFirst(a, b *v1alpha1.Route) bool {
+	if !a.CreationTimestamp.Equal(&b.CreationTimestamp) {
+		return a.CreationTimestamp.Before(&b.CreationTimestamp)
+	}
+	if a.Namespace != b.Namespace {
+		return a.Namespace < b.Namespace
+	}
+	return a.Name < b.Name
+}
+
+// withoutHosts returns the Route serving only the custom hosts that aren't
+// in the given list.  Domains that lose some of their hosts are split into
+// one domain per remaining host.
+func withoutHosts(r *v1alpha1.Route, hosts []string) *v1alpha1.Route {
+	if len(hosts) == 0 {
+		return r
+	}
+	drop := sets.NewString(hosts...)
+	served := r.DeepCopy()
+	served.Spec.Domains = nil
+	for _, d := range r.Spec.Domains {
+		dhosts, _ := d.Hosts()
+		if !drop.HasAny(dhosts...) {
+			served.Spec.Domains = append(served.Spec.Domains, d)
+			continue
+		}
+		for _, host := range dhosts {
+			if !drop.Has(host) {
+				served.Spec.Domains = append(served.Spec.Domains, v1alpha1.CustomDomain{
+					Host:      host,
+					TLSSecret: d.TLSSecret,
+				})
+			}
+		}
+	}
+	return served
+}
+
 func (c *Reconciler) reconcileClusterIngress(
-	ctx context.Context, r *v1alpha1.Route, desired *netv1alpha1.ClusterIngress) (*netv1alpha1.ClusterIngress, error) {
+	ctx context.Context, r *v1alpha1.Route, tc *traffic.Config, claimed []string) (*netv1alpha1.ClusterIngress, error) {
 	logger := logging.FromContext(ctx)
+	// Serving the claimed hosts would steal traffic from their owner.
+	served := withoutHosts(r, claimed)
 	clusterIngress, err := c.getClusterIngressForRoute(r)
 	if apierrs.IsNotFound(err) {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		desired := c.makeClusterIngress(ctx, served, tc)
 		clusterIngress, err = c.ServingClientSet.NetworkingV1alpha1().ClusterIngresses().Create(desired)
 		if err != nil {
 			logger.Error("Failed to create ClusterIngress", zap.Error(err))
 			c.Recorder.Eventf(r, corev1.EventTypeWarning, "CreationFailed",
 				"Failed to create ClusterIngress for route %s/%s: %v", r.Namespace, r.Name, err)
+			r.Status.MarkIngressNotReady(err.Error())
 			return nil, err
 		}
 		c.Recorder.Eventf(r, corev1.EventTypeNormal, "Created",
@@ -78,46 +156,143 @@ func (c *Reconciler) reconcileClusterIngress(
 		return clusterIngress, nil
 	} else if err != nil {
 		return nil, err
-	} else {
-		// TODO(#642): Remove this (needed to avoid continuous updates)
-		desired.Spec.DeprecatedGeneration = clusterIngress.Spec.DeprecatedGeneration
-		if !equality.Semantic.DeepEqual(clusterIngress.Spec, desired.Spec) {
-			// Don't modify the informers copy
-			origin := clusterIngress.DeepCopy()
-			origin.Spec = desired.Spec
+	}
 
-			updated, err := c.ServingClientSet.NetworkingV1alpha1().ClusterIngresses().Update(origin)
-			if err != nil {
-				logger.Error("Failed to update ClusterIngress", zap.Error(err))
-				return nil, err
-			}
-			return updated, nil
+	hash := resources.TrafficHash(served, tc, config.FromContext(ctx).Network.DefaultRevisionPort)
+	if clusterIngress.Annotations[serving.TrafficHashAnnotationKey] == hash &&
+		clusterIngress.Annotations[serving.SpecHashAnnotationKey] == resources.SpecHash(clusterIngress.Spec) {
+		// Nothing the ClusterIngress is built from has changed since we
+		// last wrote it, and nobody edited its spec since, so skip
+		// building and diffing it.
+		return clusterIngress, nil
+	}
+
+	desired := c.makeClusterIngress(ctx, served, tc)
+	// TODO(#642): Remove this (needed to avoid continuous updates)
+	desired.Spec.DeprecatedGeneration = clusterIngress.Spec.DeprecatedGeneration
+
+	// Don't modify the informers copy
+	origin := clusterIngress.DeepCopy()
+	origin.Spec = desired.Spec
+	if origin.Annotations == nil {
+		origin.Annotations = make(map[string]string, 2)
+	}
+	origin.Annotations[serving.TrafficHashAnnotationKey] = hash
+	origin.Annotations[serving.SpecHashAnnotationKey] = desired.Annotations[serving.SpecHashAnnotationKey]
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	updated, err := c.ServingClientSet.NetworkingV1alpha1().ClusterIngresses().Update(origin)
+	if err != nil {
+		logger.Error("Failed to update ClusterIngress", zap.Error(err))
+		r.Status.MarkIngressNotReady(err.Error())
+		return nil, err
+	}
+	return updated, nil
+}
+
+func (c *Reconciler) makeClusterIngress(ctx context.Context, r *v1alpha1.Route, tc *traffic.Config) *netv1alpha1.ClusterIngress {
+	desired := resources.MakeClusterIngress(r, tc, config.FromContext(ctx).Network.DefaultRevisionPort)
+	resources.PropagateMetadata(c.propagatedMetadataPrefix, r, desired)
+	return desired
+}
+
+// ingressAddress returns the address of the load balancer in front of the
+// ingress gateway the ClusterIngress is exposed through, or nil while there
+// is none. The URL uses https when the ClusterIngress terminates TLS.
+func (c *Reconciler) ingressAddress(ci *netv1alpha1.ClusterIngress) (*v1alpha1.IngressAddress, error) {
+	if ci.Status.LoadBalancer == nil || len(ci.Status.LoadBalancer.Ingress) != 1 {
+		return nil, nil
+	}
+	scheme := "http"
+	if len(ci.Spec.TLS) > 0 {
+		scheme = "https"
+	}
+	balancer := ci.Status.LoadBalancer.Ingress[0]
+	switch {
+	case balancer.IP != "":
+		return makeIngressAddress(scheme, balancer.IP), nil
+	case balancer.Domain != "":
+		return makeIngressAddress(scheme, balancer.Domain), nil
+	}
+
+	// Otherwise the ClusterIngress points at the gateway Service, whose
+	// load balancer is the one reached from outside the cluster.
+	name, ns, ok := splitK8sServiceFullname(balancer.DomainInternal)
+	if !ok {
+		return nil, nil
+	}
+	gateway, err := c.serviceLister.Services(ns).Get(name)
+	if apierrs.IsNotFound(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	for _, lb := range gateway.Status.LoadBalancer.Ingress {
+		switch {
+		case lb.IP != "":
+			return makeIngressAddress(scheme, lb.IP), nil
+		case lb.Hostname != "":
+			return makeIngressAddress(scheme, lb.Hostname), nil
 		}
 	}
+	// The load balancer is still pending.
+	return nil, nil
+}
 
-	return clusterIngress, err
+func makeIngressAddress(scheme, host string) *v1alpha1.IngressAddress {
+	return &v1alpha1.IngressAddress{
+		Host: host,
+		URL:  scheme + "://" + host,
+	}
 }
 
-func (c *Reconciler) reconcilePlaceholderService(ctx context.Context, route *v1alpha1.Route, ingress *netv1alpha1.ClusterIngress) error {
+// splitK8sServiceFullname returns the name and namespace of the Service with
+// the given cluster-local hostname, as made by GetK8sServiceFullname.
+func splitK8sServiceFullname(hostname string) (name, namespace string, ok bool) {
+	suffix := ".svc." + utils.GetClusterDomainName()
+	if !strings.HasSuffix(hostname, suffix) {
+		return "", "", false
+	}
+	parts := strings.Split(strings.TrimSuffix(hostname, suffix), ".")
+	if len(parts) != 2 {
+		return "", "", false
+	}
+	return parts[0], parts[1], true
+}
+
+func (c *Reconciler) reconcilePlaceholderService(ctx context.Context, route *v1alpha1.Route,
+	ingress *netv1alpha1.ClusterIngress, tc *traffic.Config) error {
 	logger := logging.FromContext(ctx)
 	ns := route.Namespace
 	name := resourcenames.K8sService(route)
 
-	desiredService, err := resources.MakeK8sService(route, ingress)
+	if !placeholderServiceEnabled(route) {
+		return c.deletePlaceholderService(ctx, route)
+	}
+
+	desiredService, err := resources.MakeK8sService(route, ingress, tc.Protocol(), config.FromContext(ctx).Network.DefaultRevisionPort)
 	if err != nil {
 		// Loadbalancer not ready, no need to create.
 		logger.Warnf("Failed to construct placeholder k8s service: %v", err)
 		return nil
 	}
+	resources.PropagateMetadata(c.propagatedMetadataPrefix, route, desiredService)
+	resources.SetReadinessPath(desiredService, tc.ReadinessPath())
 
 	service, err := c.serviceLister.Services(ns).Get(name)
 	if apierrs.IsNotFound(err) {
 		// Doesn't exist, create it.
+		if err := ctx.Err(); err != nil {
+			return err
+		}
 		service, err = c.KubeClientSet.CoreV1().Services(ns).Create(desiredService)
 		if err != nil {
 			logger.Error("Failed to create service", zap.Error(err))
 			c.Recorder.Eventf(route, corev1.EventTypeWarning, "CreationFailed",
 				"Failed to create service %q: %v", name, err)
+			route.Status.MarkServiceNotReady(name, err.Error())
 			return err
 		}
 		logger.Infof("Created service %s", name)
@@ -129,13 +304,35 @@ func (c *Reconciler) reconcilePlaceholderService(ctx context.Context, route *v1a
 		route.Status.MarkServiceNotOwned(name)
 		return fmt.Errorf("Route: %q does not own Service: %q", route.Name, name)
 	} else {
+		// The cluster IP of a ClusterIP Service is allocated by the API server,
+		// or may have been set to None to make it headless.  Either way it
+		// can't be changed, so don't fight over it, even when the Route asks
+		// for the other.
+		if desiredService.Spec.Type == corev1.ServiceTypeClusterIP && service.Spec.ClusterIP != "" {
+			desiredService.Spec.ClusterIP = service.Spec.ClusterIP
+		}
 		// Make sure that the service has the proper specification.
-		if !equality.Semantic.DeepEqual(service.Spec, desiredService.Spec) {
+		readinessPath := desiredService.Annotations[serving.ReadinessPathAnnotationKey]
+		if !equality.Semantic.DeepEqual(service.Spec, desiredService.Spec) ||
+			service.Annotations[serving.ReadinessPathAnnotationKey] != readinessPath {
 			// Don't modify the informers copy
 			existing := service.DeepCopy()
 			existing.Spec = desiredService.Spec
+			if readinessPath != "" {
+				if existing.Annotations == nil {
+					existing.Annotations = make(map[string]string)
+				}
+				existing.Annotations[serving.ReadinessPathAnnotationKey] = readinessPath
+			} else {
+				delete(existing.Annotations, serving.ReadinessPathAnnotationKey)
+			}
+			if err := ctx.Err(); err != nil {
+				return err
+			}
 			_, err = c.KubeClientSet.CoreV1().Services(ns).Update(existing)
 			if err != nil {
+				logger.Error("Failed to update service", zap.Error(err))
+				route.Status.MarkServiceNotReady(name, err.Error())
 				return err
 			}
 		}
@@ -146,36 +343,96 @@ func (c *Reconciler) reconcilePlaceholderService(ctx context.Context, route *v1a
 	return nil
 }
 
+// deletePlaceholderService removes the placeholder Service of a Route that
+// opted out of it, if we created one earlier.
+func (c *Reconciler) deletePlaceholderService(ctx context.Context, route *v1alpha1.Route) error {
+	logger := logging.FromContext(ctx)
+	ns := route.Namespace
+	name := resourcenames.K8sService(route)
+
+	service, err := c.serviceLister.Services(ns).Get(name)
+	if apierrs.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return err
+	} else if !metav1.IsControlledBy(service, route) {
+		// Someone else's Service, leave it be.
+		return nil
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := c.KubeClientSet.CoreV1().Services(ns).Delete(name, &metav1.DeleteOptions{}); err != nil {
+		logger.Error("Failed to delete service", zap.Error(err))
+		route.Status.MarkServiceNotReady(name, err.Error())
+		return err
+	}
+	logger.Infof("Deleted service %s", name)
+	c.Recorder.Eventf(route, corev1.EventTypeNormal, "Deleted", "Deleted service %q", name)
+	return nil
+}
+
+// placeholderServiceEnabled returns whether the Route wants a placeholder
+// K8s Service, which is the default.
+func placeholderServiceEnabled(route *v1alpha1.Route) bool {
+	return route.ObjectMeta.Annotations[serving.CreateServiceAnnotationKey] != "false"
+}
+
 // Update the Status of the route.  Caller is responsible for checking
-// for semantic differences before calling.
+// for semantic differences before calling.  When the Route was changed
+// concurrently, the status is applied again to its latest version, a
+// bounded number of times, rather than reconciling it all over again.
 func (c *Reconciler) updateStatus(desired *v1alpha1.Route) (*v1alpha1.Route, error) {
+	client := c.ServingClientSet.ServingV1alpha1().Routes(desired.Namespace)
 	route, err := c.routeLister.Routes(desired.Namespace).Get(desired.Name)
 	if err != nil {
 		return nil, err
 	}
-	// If there's nothing to update, just return.
-	if reflect.DeepEqual(route.Status, desired.Status) {
-		return route, nil
-	}
-	// Don't modify the informers copy
-	existing := route.DeepCopy()
-	existing.Status = desired.Status
-	return c.ServingClientSet.ServingV1alpha1().Routes(desired.Namespace).UpdateStatus(existing)
+	var updated *v1alpha1.Route
+	stale := false
+	err = retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		if stale {
+			// The informer may not have caught up yet, so ask the API server.
+			latest, err := client.Get(desired.Name, metav1.GetOptions{})
+			if err != nil {
+				return err
+			}
+			route = latest
+		}
+		// If there's nothing to update, just return.
+		if reflect.DeepEqual(route.Status, desired.Status) {
+			updated = route
+			return nil
+		}
+		// Don't modify the informers copy
+		existing := route.DeepCopy()
+		existing.Status = desired.Status
+		var err error
+		updated, err = client.UpdateStatus(existing)
+		stale = apierrs.IsConflict(err)
+		return err
+	})
+	return updated, err
 }
 
 // Update the lastPinned annotation on revisions we target so they don't get GC'd.
 func (c *Reconciler) reconcileTargetRevisions(ctx context.Context, t *traffic.Config, route *v1alpha1.Route) error {
+	logger := logging.FromContext(ctx)
 	gcConfig := config.FromContext(ctx).GC
 	lpDebounce := gcConfig.StaleRevisionLastpinnedDebounce
 
-	eg, _ := errgroup.WithContext(ctx)
+	eg, egCtx := errgroup.WithContext(ctx)
 	for _, target := range t.Targets {
 		for _, rt := range target {
 			tt := rt.TrafficTarget
+			ns := route.Namespace
+			if tt.Namespace != "" {
+				ns = tt.Namespace
+			}
 			eg.Go(func() error {
-				rev, err := c.revisionLister.Revisions(route.Namespace).Get(tt.RevisionName)
+				rev, err := c.revisionLister.Revisions(ns).Get(tt.RevisionName)
 				if apierrs.IsNotFound(err) {
-					c.Logger.Infof("Unable to update lastPinned for missing revision %q", tt.RevisionName)
+					logger.Infof("Unable to update lastPinned for missing revision %q", tt.RevisionName)
 					return nil
 				} else if err != nil {
 					return err
@@ -204,9 +461,14 @@ func (c *Reconciler) reconcileTargetRevisions(ctx context.Context, t *traffic.Co
 				if err != nil {
 					return err
 				}
+				// Don't start writes once the reconcile has been cancelled,
+				// or another pin has failed.
+				if err := egCtx.Err(); err != nil {
+					return err
+				}
 
-				if _, err := c.ServingClientSet.ServingV1alpha1().Revisions(route.Namespace).Patch(rev.Name, types.MergePatchType, patch); err != nil {
-					c.Logger.Errorf("Unable to set revision annotation: %v", err)
+				if _, err := c.ServingClientSet.ServingV1alpha1().Revisions(ns).Patch(rev.Name, types.MergePatchType, patch); err != nil {
+					logger.Errorf("Unable to set revision annotation: %v", err)
 					return err
 				}
 				return nil
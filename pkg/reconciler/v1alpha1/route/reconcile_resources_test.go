@@ -24,7 +24,6 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	. "github.com/knative/pkg/logging/testing"
-	netv1alpha1 "github.com/knative/serving/pkg/apis/networking/v1alpha1"
 	"github.com/knative/serving/pkg/apis/serving/v1alpha1"
 	"github.com/knative/serving/pkg/gc"
 	"github.com/knative/serving/pkg/reconciler/v1alpha1/route/config"
@@ -40,8 +39,9 @@ func TestReconcileClusterIngress_Insert(t *testing.T) {
 			Namespace: "test-ns",
 		},
 	}
-	ci := newTestClusterIngress(r)
-	if _, err := c.reconcileClusterIngress(TestContextWithLogger(t), r, ci); err != nil {
+	tc := newTestTrafficConfig()
+	ci := resources.MakeClusterIngress(r, tc, testRevisionPort)
+	if _, err := c.reconcileClusterIngress(config.ToContext(TestContextWithLogger(t), ReconcilerTestConfig()), r, tc, nil); err != nil {
 		t.Errorf("Unexpected error: %v", err)
 	}
 	created := getRouteIngressFromClient(t, servingClient, r)
@@ -59,8 +59,9 @@ func TestReconcileClusterIngress_Update(t *testing.T) {
 		},
 	}
 
-	ci := newTestClusterIngress(r)
-	if _, err := c.reconcileClusterIngress(TestContextWithLogger(t), r, ci); err != nil {
+	tc := newTestTrafficConfig()
+	ci := resources.MakeClusterIngress(r, tc, testRevisionPort)
+	if _, err := c.reconcileClusterIngress(config.ToContext(TestContextWithLogger(t), ReconcilerTestConfig()), r, tc, nil); err != nil {
 		t.Errorf("Unexpected error: %v", err)
 	}
 
@@ -68,8 +69,8 @@ func TestReconcileClusterIngress_Update(t *testing.T) {
 	servingInformer.Networking().V1alpha1().ClusterIngresses().Informer().GetIndexer().Add(updated)
 
 	r.Status.Domain = "bar.com"
-	ci2 := newTestClusterIngress(r)
-	if _, err := c.reconcileClusterIngress(TestContextWithLogger(t), r, ci2); err != nil {
+	ci2 := resources.MakeClusterIngress(r, tc, testRevisionPort)
+	if _, err := c.reconcileClusterIngress(config.ToContext(TestContextWithLogger(t), ReconcilerTestConfig()), r, tc, nil); err != nil {
 		t.Errorf("Unexpected error: %v", err)
 	}
 
@@ -138,8 +139,8 @@ func TestReconcileTargetRevisions(t *testing.T) {
 	}
 }
 
-func newTestClusterIngress(r *v1alpha1.Route) *netv1alpha1.ClusterIngress {
-	tc := &traffic.Config{Targets: map[string][]traffic.RevisionTarget{
+func newTestTrafficConfig() *traffic.Config {
+	return &traffic.Config{Targets: map[string][]traffic.RevisionTarget{
 		"": {{
 			TrafficTarget: v1alpha1.TrafficTarget{
 				RevisionName: "revision",
@@ -147,5 +148,4 @@ func newTestClusterIngress(r *v1alpha1.Route) *netv1alpha1.ClusterIngress {
 			},
 			Active: true,
 		}}}}
-	return resources.MakeClusterIngress(r, tc)
 }
@@ -17,6 +17,9 @@ limitations under the License.
 package resources
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"sort"
 	"strings"
@@ -42,8 +45,9 @@ func isClusterLocal(r *servingv1alpha1.Route) bool {
 }
 
 // MakeClusterIngress creates ClusterIngress to set up routing rules. Such ClusterIngress specifies
-// which Hosts that it applies to, as well as the routing rules.
-func MakeClusterIngress(r *servingv1alpha1.Route, tc *traffic.Config) *v1alpha1.ClusterIngress {
+// which Hosts that it applies to, as well as the routing rules.  Traffic goes to the Services of
+// Revisions on the given port.
+func MakeClusterIngress(r *servingv1alpha1.Route, tc *traffic.Config, port int32) *v1alpha1.ClusterIngress {
 	ci := &v1alpha1.ClusterIngress{
 		ObjectMeta: metav1.ObjectMeta{
 			// As ClusterIngress resource is cluster-scoped,
@@ -54,14 +58,65 @@ func MakeClusterIngress(r *servingv1alpha1.Route, tc *traffic.Config) *v1alpha1.
 				serving.RouteNamespaceLabelKey: r.Namespace,
 			},
 			OwnerReferences: []metav1.OwnerReference{*kmeta.NewControllerRef(r)},
-			Annotations:     r.ObjectMeta.Annotations,
+			Annotations:     makeClusterIngressAnnotations(r, tc, port),
 		},
-		Spec: makeClusterIngressSpec(r, tc.Targets),
+		Spec: makeClusterIngressSpec(r, tc.Targets, port),
 	}
+	ci.Annotations[serving.SpecHashAnnotationKey] = SpecHash(ci.Spec)
 	return ci
 }
 
-func makeClusterIngressSpec(r *servingv1alpha1.Route, targets map[string][]traffic.RevisionTarget) v1alpha1.IngressSpec {
+// TrafficHash returns a digest of everything MakeClusterIngress reads from
+// the Route and its traffic configuration.  A ClusterIngress annotated with
+// the same hash doesn't need to be rebuilt.
+func TrafficHash(r *servingv1alpha1.Route, tc *traffic.Config, port int32) string {
+	// Marshaling sorts map keys, so equal inputs always hash the same.
+	// None of these types can fail to marshal.
+	b, _ := json.Marshal(struct {
+		Targets       map[string][]traffic.RevisionTarget
+		Domain        string
+		ServiceName   string
+		CustomDomains []servingv1alpha1.CustomDomain
+		HTTPOption    servingv1alpha1.HTTPOption
+		Labels        map[string]string
+		Annotations   map[string]string
+		Port          int32
+	}{
+		Targets:       tc.Targets,
+		Domain:        r.Status.Domain,
+		ServiceName:   names.K8sService(r),
+		CustomDomains: r.Spec.Domains,
+		HTTPOption:    r.Spec.HTTPOption,
+		Labels:        r.Labels,
+		Annotations:   r.Annotations,
+		Port:          port,
+	})
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+// SpecHash returns a digest of the given ClusterIngress spec.  A
+// ClusterIngress whose spec doesn't hash to the one it's annotated with was
+// edited since the Route controller wrote it.
+func SpecHash(spec v1alpha1.IngressSpec) string {
+	// The generation is bumped by the webhook, not written by us.
+	spec.DeprecatedGeneration = 0
+	b, _ := json.Marshal(spec)
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+func makeClusterIngressAnnotations(r *servingv1alpha1.Route, tc *traffic.Config, port int32) map[string]string {
+	// Don't modify the Route's map.
+	annotations := make(map[string]string, len(r.Annotations)+2)
+	for k, v := range r.Annotations {
+		annotations[k] = v
+	}
+	annotations[serving.TrafficHashAnnotationKey] = TrafficHash(r, tc, port)
+	return annotations
+}
+
+func makeClusterIngressSpec(r *servingv1alpha1.Route, targets map[string][]traffic.RevisionTarget, port int32) v1alpha1.IngressSpec {
 	// Domain should have been specified in route status
 	// before calling this func.
 	domain := r.Status.Domain
@@ -71,14 +126,52 @@ func makeClusterIngressSpec(r *servingv1alpha1.Route, targets map[string][]traff
 	}
 	// Sort the names to give things a deterministic ordering.
 	sort.Strings(names)
+	revisionHeaders := revisionHeadersEnabled(r)
+	meshDirect := meshRoutingDirect(r)
 	// The routes are matching rule based on domain name to traffic split targets.
 	rules := []v1alpha1.ClusterIngressRule{}
 	for _, name := range names {
-		rules = append(rules, *makeClusterIngressRule(getRouteDomains(name, r, domain), r.Namespace, targets[name]))
+		tts := targets[name]
+		var mirror *traffic.RevisionTarget
+		if name == "" {
+			// Only the Route's default traffic is mirrored.
+			tts, mirror = splitMirror(tts)
+		}
+		hosts := getRouteDomains(name, r, domain)
+		if name == "" {
+			hosts = append(hosts, CustomHosts(r)...)
+		}
+		rule := makeClusterIngressRule(hosts, r.Namespace, tts, port)
+		if revisionHeaders {
+			addRevisionHeaders(&rule.HTTP.Paths[0], r.Namespace, tts)
+		}
+		if meshDirect {
+			addMeshSplits(&rule.HTTP.Paths[0])
+		}
+		if mirror != nil {
+			rule.HTTP.Paths[0].Mirror = &v1alpha1.ClusterIngressBackend{
+				ServiceNamespace: targetNamespace(r.Namespace, *mirror),
+				ServiceName:      reconciler.GetServingK8SServiceNameForObj(mirror.TrafficTarget.RevisionName),
+				ServicePort:      intstr.FromInt(int(port)),
+				Subset:           mirror.TrafficTarget.Subset,
+			}
+		}
+		if name != "" && len(tts) != 0 {
+			rule.HTTP.Paths[0].Fault = makeFault(tts[0].Fault)
+		}
+		if name == "" {
+			// Requests matching the headers or path prefix of a named
+			// target go to that target, ahead of the traffic split.
+			rule.HTTP.Paths = append(makeMatchPaths(r.Namespace, names, targets, revisionHeaders, meshDirect, port),
+				rule.HTTP.Paths...)
+		}
+		rules = append(rules, *rule)
 	}
 	spec := v1alpha1.IngressSpec{
 		Rules:      rules,
+		TLS:        makeClusterIngressTLS(r),
 		Visibility: v1alpha1.IngressVisibilityExternalIP,
+		HTTPOption: v1alpha1.HTTPOption(r.Spec.HTTPOption),
 	}
 	if isClusterLocal(r) {
 		spec.Visibility = v1alpha1.IngressVisibilityClusterLocal
@@ -86,6 +179,35 @@ func makeClusterIngressSpec(r *servingv1alpha1.Route, targets map[string][]traff
 	return spec
 }
 
+// CustomHosts returns the hosts the user brought for the Route.
+func CustomHosts(r *servingv1alpha1.Route) []string {
+	var hosts []string
+	for _, d := range r.Spec.Domains {
+		// Validation rejects the domains whose hosts can't be listed.
+		dhosts, _ := d.Hosts()
+		hosts = append(hosts, dhosts...)
+	}
+	return hosts
+}
+
+// makeClusterIngressTLS terminates TLS for each custom domain of the Route
+// with the Secret it references.
+func makeClusterIngressTLS(r *servingv1alpha1.Route) []v1alpha1.ClusterIngressTLS {
+	var tls []v1alpha1.ClusterIngressTLS
+	for _, d := range r.Spec.Domains {
+		if d.TLSSecret == nil {
+			continue
+		}
+		hosts, _ := d.Hosts()
+		tls = append(tls, v1alpha1.ClusterIngressTLS{
+			Hosts:           hosts,
+			SecretName:      d.TLSSecret.Name,
+			SecretNamespace: r.Namespace,
+		})
+	}
+	return tls
+}
+
 func getRouteDomains(targetName string, r *servingv1alpha1.Route, domain string) []string {
 	if targetName == "" {
 		// Nameless traffic targets correspond to many domains: the
@@ -93,8 +215,8 @@ func getRouteDomains(targetName string, r *servingv1alpha1.Route, domain string)
 		// headless Service.
 		domains := []string{domain,
 			names.K8sServiceFullname(r),
-			fmt.Sprintf("%s.%s.svc", r.Name, r.Namespace),
-			fmt.Sprintf("%s.%s", r.Name, r.Namespace),
+			fmt.Sprintf("%s.%s.svc", names.K8sService(r), r.Namespace),
+			fmt.Sprintf("%s.%s", names.K8sService(r), r.Namespace),
 		}
 		return dedup(domains)
 	}
@@ -102,6 +224,21 @@ func getRouteDomains(targetName string, r *servingv1alpha1.Route, domain string)
 	return []string{fmt.Sprintf("%s.%s", targetName, domain)}
 }
 
+// splitMirror separates the mirror target, if any, from the targets that
+// split the traffic.
+func splitMirror(targets []traffic.RevisionTarget) ([]traffic.RevisionTarget, *traffic.RevisionTarget) {
+	var mirror *traffic.RevisionTarget
+	split := make([]traffic.RevisionTarget, 0, len(targets))
+	for i, t := range targets {
+		if t.TrafficTarget.Mirror {
+			mirror = &targets[i]
+			continue
+		}
+		split = append(split, t)
+	}
+	return split, mirror
+}
+
 // groupTargets group given targets into active ones and inactive ones.
 func groupTargets(targets []traffic.RevisionTarget) (active []traffic.RevisionTarget, inactive []traffic.RevisionTarget) {
 	for _, t := range targets {
@@ -114,22 +251,78 @@ func groupTargets(targets []traffic.RevisionTarget) (active []traffic.RevisionTa
 	return active, inactive
 }
 
-func makeClusterIngressRule(domains []string, ns string, targets []traffic.RevisionTarget) *v1alpha1.ClusterIngressRule {
+func makeClusterIngressRule(domains []string, ns string, targets []traffic.RevisionTarget, port int32) *v1alpha1.ClusterIngressRule {
+	return &v1alpha1.ClusterIngressRule{
+		Hosts: domains,
+		HTTP: &v1alpha1.HTTPClusterIngressRuleValue{
+			Paths: []v1alpha1.HTTPClusterIngressPath{
+				*makeClusterIngressPath(ns, targets, port),
+			},
+		},
+	}
+}
+
+// makeMatchPaths constructs a path for each of the named targets that
+// match on request headers or a path prefix.  Longer path prefixes come
+// first, so that they take precedence over the shorter ones they extend,
+// and ties keep the order of the given names.
+func makeMatchPaths(ns string, names []string, targets map[string][]traffic.RevisionTarget, revisionHeaders, meshDirect bool, port int32) []v1alpha1.HTTPClusterIngressPath {
+	paths := []v1alpha1.HTTPClusterIngressPath{}
+	for _, name := range names {
+		tts := targets[name]
+		if name == "" || len(tts) == 0 || (len(tts[0].Match) == 0 && tts[0].PathPrefix == "") {
+			continue
+		}
+		path := makeClusterIngressPath(ns, tts, port)
+		path.PathPrefix = tts[0].PathPrefix
+		path.Fault = makeFault(tts[0].Fault)
+		path.Rewrite = makeRewrite(tts[0].Rewrite)
+		if len(tts[0].Match) != 0 {
+			path.Headers = make(map[string]v1alpha1.HeaderMatch, len(tts[0].Match))
+			for _, m := range tts[0].Match {
+				path.Headers[m.Name] = v1alpha1.HeaderMatch{
+					Exact:  m.Exact,
+					Prefix: m.Prefix,
+				}
+			}
+		}
+		if revisionHeaders {
+			addRevisionHeaders(path, ns, tts)
+		}
+		if meshDirect {
+			addMeshSplits(path)
+		}
+		paths = append(paths, *path)
+	}
+	sort.SliceStable(paths, func(i, j int) bool {
+		return len(paths[i].PathPrefix) > len(paths[j].PathPrefix)
+	})
+	return paths
+}
+
+func makeClusterIngressPath(ns string, targets []traffic.RevisionTarget, port int32) *v1alpha1.HTTPClusterIngressPath {
 	active, inactive := groupTargets(targets)
 	splits := []v1alpha1.ClusterIngressBackendSplit{}
+	draining := []v1alpha1.ClusterIngressBackendSplit{}
 	for _, t := range active {
-		if t.Percent == 0 {
+		if t.Percent == 0 && !t.Draining {
 			// Don't include 0% routes.
 			continue
 		}
-		splits = append(splits, v1alpha1.ClusterIngressBackendSplit{
+		split := v1alpha1.ClusterIngressBackendSplit{
 			ClusterIngressBackend: v1alpha1.ClusterIngressBackend{
-				ServiceNamespace: ns,
+				ServiceNamespace: targetNamespace(ns, t),
 				ServiceName:      reconciler.GetServingK8SServiceNameForObj(t.TrafficTarget.RevisionName),
-				ServicePort:      intstr.FromInt(int(revisionresources.ServicePort)),
+				ServicePort:      intstr.FromInt(int(port)),
+				Subset:           t.TrafficTarget.Subset,
 			},
 			Percent: t.Percent,
-		})
+		}
+		if t.Draining {
+			draining = append(draining, split)
+			continue
+		}
+		splits = append(splits, split)
 	}
 	path := v1alpha1.HTTPClusterIngressPath{
 		Splits: splits,
@@ -137,14 +330,13 @@ func makeClusterIngressRule(domains []string, ns string, targets []traffic.Revis
 
 	}
 	path.SetDefaults()
-	return &v1alpha1.ClusterIngressRule{
-		Hosts: domains,
-		HTTP: &v1alpha1.HTTPClusterIngressRuleValue{
-			Paths: []v1alpha1.HTTPClusterIngressPath{
-				*addInactive(&path, ns, inactive),
-			},
-		},
+	addInactive(&path, ns, inactive)
+	if len(path.Splits) != 0 {
+		// Draining Revisions come last, at 0%.  They're added after the
+		// defaults so a lone one isn't given all the traffic.
+		path.Splits = append(path.Splits, draining...)
 	}
+	return &path
 }
 
 // addInactive constructs Splits for the inactive targets, and add into given IngressPath.
@@ -171,11 +363,88 @@ func addInactive(r *v1alpha1.HTTPClusterIngressPath, ns string, inactive []traff
 	})
 	r.AppendHeaders = map[string]string{
 		activator.RevisionHeaderName:      maxInactiveTarget.RevisionName,
-		activator.RevisionHeaderNamespace: ns,
+		activator.RevisionHeaderNamespace: targetNamespace(ns, maxInactiveTarget),
 	}
 	return r
 }
 
+// makeFault translates the faults injected into the requests to a named
+// target, if any.
+func makeFault(fs *servingv1alpha1.FaultSpec) *v1alpha1.HTTPFault {
+	if fs == nil {
+		return nil
+	}
+	return &v1alpha1.HTTPFault{
+		DelayPercent: fs.DelayPercent,
+		FixedDelay:   fs.FixedDelay,
+		AbortPercent: fs.AbortPercent,
+		HTTPStatus:   fs.HTTPStatus,
+	}
+}
+
+// makeRewrite translates how the requests matching the path prefix of a
+// named target are rewritten, if at all.
+func makeRewrite(rs *servingv1alpha1.RewriteSpec) *v1alpha1.HTTPRewrite {
+	if rs == nil {
+		return nil
+	}
+	return &v1alpha1.HTTPRewrite{
+		Authority: rs.Authority,
+		URI:       rs.URI,
+	}
+}
+
+// revisionHeadersEnabled returns whether the Route has opted out of
+// headers identifying the Revision serving a request.
+func revisionHeadersEnabled(r *servingv1alpha1.Route) bool {
+	return r.ObjectMeta.Annotations[serving.DisableRevisionHeadersAnnotationKey] != "true"
+}
+
+// meshRoutingDirect returns whether the Route routes the requests from
+// within the mesh apart.
+func meshRoutingDirect(r *servingv1alpha1.Route) bool {
+	return r.ObjectMeta.Annotations[serving.MeshRoutingAnnotationKey] == serving.MeshRoutingDirect
+}
+
+// addMeshSplits routes the requests from within the mesh matching the given
+// path apart from the ones coming through the ingress gateways, to the same
+// destinations.  Scaled to zero Revisions are reached through the activator
+// either way, as nothing else would activate them.  The splits are set even
+// while they agree, so the VirtualService applying to the mesh doesn't come
+// and go as Revisions scale.
+func addMeshSplits(path *v1alpha1.HTTPClusterIngressPath) {
+	path.MeshSplits = append([]v1alpha1.ClusterIngressBackendSplit(nil), path.Splits...)
+}
+
+// addRevisionHeaders appends headers identifying the backing Revision to
+// the given path, when all of its traffic goes to a single active Revision.
+// Paths with splits across multiple Revisions can't be attributed to one
+// Revision, and paths through the activator already carry these headers.
+func addRevisionHeaders(path *v1alpha1.HTTPClusterIngressPath, ns string, targets []traffic.RevisionTarget) {
+	var routed []traffic.RevisionTarget
+	for _, t := range targets {
+		if t.Percent != 0 {
+			routed = append(routed, t)
+		}
+	}
+	if len(routed) != 1 || !routed[0].Active {
+		return
+	}
+	path.AppendHeaders = map[string]string{
+		activator.RevisionHeaderName:      routed[0].RevisionName,
+		activator.RevisionHeaderNamespace: targetNamespace(ns, routed[0]),
+	}
+}
+
+// targetNamespace returns the namespace of the Revision a target routes to,
+// given the namespace of the Route.
+func targetNamespace(ns string, t traffic.RevisionTarget) string {
+	if t.TrafficTarget.Namespace != "" {
+		return t.TrafficTarget.Namespace
+	}
+	return ns
+}
+
 func dedup(strs []string) []string {
 	existed := make(map[string]struct{})
 	unique := []string{}
@@ -22,14 +22,17 @@ import (
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/knative/pkg/kmeta"
+	"github.com/knative/serving/pkg/activator"
 	"github.com/knative/serving/pkg/apis/networking"
 	netv1alpha1 "github.com/knative/serving/pkg/apis/networking/v1alpha1"
 	"github.com/knative/serving/pkg/apis/serving"
 	"github.com/knative/serving/pkg/apis/serving/v1alpha1"
 	"github.com/knative/serving/pkg/reconciler/v1alpha1/clusteringress"
+	revisionresources "github.com/knative/serving/pkg/reconciler/v1alpha1/revision/resources"
 	"github.com/knative/serving/pkg/reconciler/v1alpha1/route/traffic"
 	"github.com/knative/serving/pkg/system"
 	_ "github.com/knative/serving/pkg/system/testing"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
 )
@@ -46,6 +49,7 @@ func TestMakeClusterIngress_CorrectMetadata(t *testing.T) {
 		},
 		Status: v1alpha1.RouteStatus{Domain: "domain.com"},
 	}
+	tc := &traffic.Config{Targets: targets}
 	expected := metav1.ObjectMeta{
 		GenerateName: "test-route-",
 		Labels: map[string]string{
@@ -54,15 +58,122 @@ func TestMakeClusterIngress_CorrectMetadata(t *testing.T) {
 		},
 		Annotations: map[string]string{
 			networking.IngressClassAnnotationKey: clusteringress.IstioIngressClassName,
+			serving.TrafficHashAnnotationKey:     TrafficHash(r, tc, 80),
+			serving.SpecHashAnnotationKey:        SpecHash(makeClusterIngressSpec(r, targets, 80)),
 		},
 		OwnerReferences: []metav1.OwnerReference{
 			*kmeta.NewControllerRef(r),
 		},
 	}
-	meta := MakeClusterIngress(r, &traffic.Config{Targets: targets}).ObjectMeta
+	meta := MakeClusterIngress(r, tc, 80).ObjectMeta
 	if diff := cmp.Diff(expected, meta); diff != "" {
 		t.Errorf("Unexpected metadata (-want +got): %v", diff)
 	}
+	if _, ok := r.Annotations[serving.TrafficHashAnnotationKey]; ok {
+		t.Error("MakeClusterIngress modified the Route's annotations")
+	}
+}
+
+func TestTrafficHash(t *testing.T) {
+	tc := &traffic.Config{Targets: map[string][]traffic.RevisionTarget{
+		"": {{
+			TrafficTarget: v1alpha1.TrafficTarget{
+				ConfigurationName: "config",
+				RevisionName:      "config-00001",
+				Percent:           100,
+			},
+			Active: true,
+		}},
+	}}
+	base := func() *v1alpha1.Route {
+		return &v1alpha1.Route{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "test-route",
+				Namespace: "test-ns",
+				Labels:    map[string]string{"app": "prod"},
+			},
+			Status: v1alpha1.RouteStatus{Domain: "domain.com"},
+		}
+	}
+	want := TrafficHash(base(), tc, 80)
+	if got := TrafficHash(base(), tc, 80); got != want {
+		t.Errorf("TrafficHash() = %q on the second call, wanted %q", got, want)
+	}
+
+	cases := []struct {
+		name   string
+		mutate func(*v1alpha1.Route, *traffic.Config)
+	}{{
+		name: "domain",
+		mutate: func(r *v1alpha1.Route, _ *traffic.Config) {
+			r.Status.Domain = "another-domain.com"
+		},
+	}, {
+		name: "labels",
+		mutate: func(r *v1alpha1.Route, _ *traffic.Config) {
+			r.Labels["app"] = "staging"
+		},
+	}, {
+		name: "annotations",
+		mutate: func(r *v1alpha1.Route, _ *traffic.Config) {
+			r.Annotations = map[string]string{serving.GatewayAnnotationKey: "knative-ingress-gateway"}
+		},
+	}, {
+		name: "targets",
+		mutate: func(_ *v1alpha1.Route, tc *traffic.Config) {
+			tc.Targets[""][0].RevisionName = "config-00002"
+		},
+	}}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			r := base()
+			mutated := &traffic.Config{Targets: map[string][]traffic.RevisionTarget{
+				"": append([]traffic.RevisionTarget(nil), tc.Targets[""]...),
+			}}
+			c.mutate(r, mutated)
+			if got := TrafficHash(r, mutated, 80); got == want {
+				t.Errorf("TrafficHash() = %q, wanted a change", got)
+			}
+		})
+	}
+
+	if got := TrafficHash(base(), tc, 8080); got == want {
+		t.Errorf("TrafficHash() = %q for another port, wanted a change", got)
+	}
+}
+
+func TestSpecHash(t *testing.T) {
+	r := &v1alpha1.Route{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-route",
+			Namespace: "test-ns",
+		},
+		Status: v1alpha1.RouteStatus{Domain: "domain.com"},
+	}
+	targets := map[string][]traffic.RevisionTarget{
+		"": {{
+			TrafficTarget: v1alpha1.TrafficTarget{
+				RevisionName: "config-00001",
+				Percent:      100,
+			},
+			Active: true,
+		}},
+	}
+	spec := makeClusterIngressSpec(r, targets, 80)
+	want := SpecHash(spec)
+
+	// The generation isn't ours, so bumping it isn't an edit.
+	bumped := *spec.DeepCopy()
+	bumped.DeprecatedGeneration = 3
+	if got := SpecHash(bumped); got != want {
+		t.Errorf("SpecHash() = %q for another generation, wanted %q", got, want)
+	}
+
+	edited := *spec.DeepCopy()
+	edited.Rules[0].HTTP.Paths[0].Splits[0].ServiceName = "another-service"
+	if got := SpecHash(edited); got == want {
+		t.Errorf("SpecHash() = %q for an edited spec, wanted a change", got)
+	}
 }
 
 func TestMakeClusterIngressSpec_CorrectRules(t *testing.T) {
@@ -108,6 +219,10 @@ func TestMakeClusterIngressSpec_CorrectRules(t *testing.T) {
 					},
 					Percent: 100,
 				}},
+				AppendHeaders: map[string]string{
+					activator.RevisionHeaderName:      "v2",
+					activator.RevisionHeaderNamespace: "test-ns",
+				},
 				Timeout: &metav1.Duration{Duration: netv1alpha1.DefaultTimeout},
 				Retries: &netv1alpha1.HTTPRetry{
 					PerTryTimeout: &metav1.Duration{Duration: netv1alpha1.DefaultTimeout},
@@ -127,6 +242,10 @@ func TestMakeClusterIngressSpec_CorrectRules(t *testing.T) {
 					},
 					Percent: 100,
 				}},
+				AppendHeaders: map[string]string{
+					activator.RevisionHeaderName:      "v1",
+					activator.RevisionHeaderNamespace: "test-ns",
+				},
 				Timeout: &metav1.Duration{Duration: netv1alpha1.DefaultTimeout},
 				Retries: &netv1alpha1.HTTPRetry{
 					PerTryTimeout: &metav1.Duration{Duration: netv1alpha1.DefaultTimeout},
@@ -135,7 +254,7 @@ func TestMakeClusterIngressSpec_CorrectRules(t *testing.T) {
 			}},
 		},
 	}}
-	rules := makeClusterIngressSpec(r, targets).Rules
+	rules := makeClusterIngressSpec(r, targets, 80).Rules
 	if diff := cmp.Diff(expected, rules); diff != "" {
 		fmt.Printf("%+v\n", rules)
 		fmt.Printf("%+v\n", expected)
@@ -143,6 +262,670 @@ func TestMakeClusterIngressSpec_CorrectRules(t *testing.T) {
 	}
 }
 
+func TestMakeClusterIngressSpec_RevisionHeaders(t *testing.T) {
+	cases := []struct {
+		name        string
+		annotations map[string]string
+		targets     []traffic.RevisionTarget
+		expected    map[string]string
+	}{{
+		name: "single active target",
+		targets: []traffic.RevisionTarget{{
+			TrafficTarget: v1alpha1.TrafficTarget{
+				RevisionName: "v1",
+				Percent:      100,
+			},
+			Active: true,
+		}},
+		expected: map[string]string{
+			activator.RevisionHeaderName:      "v1",
+			activator.RevisionHeaderNamespace: "test-ns",
+		},
+	}, {
+		name: "single active target with zero percent target",
+		targets: []traffic.RevisionTarget{{
+			TrafficTarget: v1alpha1.TrafficTarget{
+				RevisionName: "v1",
+				Percent:      100,
+			},
+			Active: true,
+		}, {
+			TrafficTarget: v1alpha1.TrafficTarget{
+				RevisionName: "v2",
+				Percent:      0,
+			},
+			Active: true,
+		}},
+		expected: map[string]string{
+			activator.RevisionHeaderName:      "v1",
+			activator.RevisionHeaderNamespace: "test-ns",
+		},
+	}, {
+		name: "split targets",
+		targets: []traffic.RevisionTarget{{
+			TrafficTarget: v1alpha1.TrafficTarget{
+				RevisionName: "v1",
+				Percent:      50,
+			},
+			Active: true,
+		}, {
+			TrafficTarget: v1alpha1.TrafficTarget{
+				RevisionName: "v2",
+				Percent:      50,
+			},
+			Active: true,
+		}},
+	}, {
+		name: "opted out",
+		annotations: map[string]string{
+			serving.DisableRevisionHeadersAnnotationKey: "true",
+		},
+		targets: []traffic.RevisionTarget{{
+			TrafficTarget: v1alpha1.TrafficTarget{
+				RevisionName: "v1",
+				Percent:      100,
+			},
+			Active: true,
+		}},
+	}}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			r := &v1alpha1.Route{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "test-route",
+					Namespace:   "test-ns",
+					Annotations: c.annotations,
+				},
+				Status: v1alpha1.RouteStatus{Domain: "domain.com"},
+			}
+			rules := makeClusterIngressSpec(r, map[string][]traffic.RevisionTarget{"": c.targets}, 80).Rules
+			headers := rules[0].HTTP.Paths[0].AppendHeaders
+			if diff := cmp.Diff(c.expected, headers); diff != "" {
+				t.Errorf("Unexpected headers (-want +got): %v", diff)
+			}
+		})
+	}
+}
+
+func TestMakeClusterIngressSpec_MeshRouting(t *testing.T) {
+	inactive := []traffic.RevisionTarget{{
+		TrafficTarget: v1alpha1.TrafficTarget{
+			RevisionName: "v1",
+			Percent:      100,
+		},
+		Active: false,
+	}}
+	active := []traffic.RevisionTarget{{
+		TrafficTarget: v1alpha1.TrafficTarget{
+			RevisionName: "v1",
+			Percent:      100,
+		},
+		Active: true,
+	}}
+	direct := []netv1alpha1.ClusterIngressBackendSplit{{
+		ClusterIngressBackend: netv1alpha1.ClusterIngressBackend{
+			ServiceNamespace: "test-ns",
+			ServiceName:      "v1-service",
+			ServicePort:      intstr.FromInt(80),
+		},
+		Percent: 100,
+	}}
+	activated := []netv1alpha1.ClusterIngressBackendSplit{{
+		ClusterIngressBackend: netv1alpha1.ClusterIngressBackend{
+			ServiceNamespace: system.Namespace(),
+			ServiceName:      activator.K8sServiceName,
+			ServicePort:      intstr.FromInt(int(revisionresources.ServicePort)),
+		},
+		Percent: 100,
+	}}
+	cases := []struct {
+		name        string
+		annotations map[string]string
+		targets     []traffic.RevisionTarget
+		expected    []netv1alpha1.ClusterIngressBackendSplit
+	}{{
+		name:    "not requested",
+		targets: inactive,
+	}, {
+		name: "inactive target is still activated",
+		annotations: map[string]string{
+			serving.MeshRoutingAnnotationKey: serving.MeshRoutingDirect,
+		},
+		targets:  inactive,
+		expected: activated,
+	}, {
+		name: "active target",
+		annotations: map[string]string{
+			serving.MeshRoutingAnnotationKey: serving.MeshRoutingDirect,
+		},
+		targets:  active,
+		expected: direct,
+	}, {
+		name: "unknown value",
+		annotations: map[string]string{
+			serving.MeshRoutingAnnotationKey: "activator",
+		},
+		targets: inactive,
+	}}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			r := &v1alpha1.Route{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "test-route",
+					Namespace:   "test-ns",
+					Annotations: c.annotations,
+				},
+				Status: v1alpha1.RouteStatus{Domain: "domain.com"},
+			}
+			rules := makeClusterIngressSpec(r, map[string][]traffic.RevisionTarget{"": c.targets}, 80).Rules
+			if diff := cmp.Diff(c.expected, rules[0].HTTP.Paths[0].MeshSplits); diff != "" {
+				t.Errorf("Unexpected mesh splits (-want +got): %v", diff)
+			}
+		})
+	}
+}
+
+func TestMakeClusterIngressSpec_HeaderMatch(t *testing.T) {
+	canary := v1alpha1.TrafficTarget{
+		Name:         "canary",
+		RevisionName: "v2",
+		Match: []v1alpha1.HeaderMatch{{
+			Name:  "x-canary",
+			Exact: "true",
+		}},
+	}
+	stable := v1alpha1.TrafficTarget{
+		ConfigurationName: "config",
+		RevisionName:      "v1",
+		Percent:           100,
+	}
+	canaryOnly := canary
+	canaryOnly.Percent = 100
+	targets := map[string][]traffic.RevisionTarget{
+		"":       {{TrafficTarget: stable, Active: true}, {TrafficTarget: canary, Active: true}},
+		"canary": {{TrafficTarget: canaryOnly, Active: true}},
+	}
+	r := &v1alpha1.Route{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-route",
+			Namespace: "test-ns",
+		},
+		Status: v1alpha1.RouteStatus{Domain: "domain.com"},
+	}
+	path := func(rev string) netv1alpha1.HTTPClusterIngressPath {
+		return netv1alpha1.HTTPClusterIngressPath{
+			Splits: []netv1alpha1.ClusterIngressBackendSplit{{
+				ClusterIngressBackend: netv1alpha1.ClusterIngressBackend{
+					ServiceNamespace: "test-ns",
+					ServiceName:      rev + "-service",
+					ServicePort:      intstr.FromInt(80),
+				},
+				Percent: 100,
+			}},
+			AppendHeaders: map[string]string{
+				activator.RevisionHeaderName:      rev,
+				activator.RevisionHeaderNamespace: "test-ns",
+			},
+			Timeout: &metav1.Duration{Duration: netv1alpha1.DefaultTimeout},
+			Retries: &netv1alpha1.HTTPRetry{
+				PerTryTimeout: &metav1.Duration{Duration: netv1alpha1.DefaultTimeout},
+				Attempts:      netv1alpha1.DefaultRetryCount,
+			},
+		}
+	}
+	matched := path("v2")
+	matched.Headers = map[string]netv1alpha1.HeaderMatch{
+		"x-canary": {Exact: "true"},
+	}
+	expected := []netv1alpha1.ClusterIngressRule{{
+		Hosts: []string{
+			"domain.com",
+			"test-route.test-ns.svc.cluster.local",
+			"test-route.test-ns.svc",
+			"test-route.test-ns",
+		},
+		HTTP: &netv1alpha1.HTTPClusterIngressRuleValue{
+			Paths: []netv1alpha1.HTTPClusterIngressPath{matched, path("v1")},
+		},
+	}, {
+		Hosts: []string{"canary.domain.com"},
+		HTTP: &netv1alpha1.HTTPClusterIngressRuleValue{
+			Paths: []netv1alpha1.HTTPClusterIngressPath{path("v2")},
+		},
+	}}
+	rules := makeClusterIngressSpec(r, targets, 80).Rules
+	if diff := cmp.Diff(expected, rules); diff != "" {
+		t.Errorf("Unexpected rules (-want +got): %v", diff)
+	}
+}
+
+func TestMakeClusterIngressSpec_PathPrefix(t *testing.T) {
+	stable := v1alpha1.TrafficTarget{
+		ConfigurationName: "config",
+		RevisionName:      "v1",
+		Percent:           100,
+	}
+	api := v1alpha1.TrafficTarget{
+		Name:         "api",
+		RevisionName: "v2",
+		PathPrefix:   "/v2",
+	}
+	apiBeta := v1alpha1.TrafficTarget{
+		Name:         "api-beta",
+		RevisionName: "v3",
+		PathPrefix:   "/v2/beta",
+	}
+	only := func(tt v1alpha1.TrafficTarget) []traffic.RevisionTarget {
+		tt.Percent = 100
+		return []traffic.RevisionTarget{{TrafficTarget: tt, Active: true}}
+	}
+	targets := map[string][]traffic.RevisionTarget{
+		"": {
+			{TrafficTarget: stable, Active: true},
+			{TrafficTarget: api, Active: true},
+			{TrafficTarget: apiBeta, Active: true},
+		},
+		"api":      only(api),
+		"api-beta": only(apiBeta),
+	}
+	r := &v1alpha1.Route{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-route",
+			Namespace: "test-ns",
+		},
+		Status: v1alpha1.RouteStatus{Domain: "domain.com"},
+	}
+	rules := makeClusterIngressSpec(r, targets, 80).Rules
+
+	// The longer prefix goes first even though its name sorts last, and
+	// both precede the traffic split.
+	var got []string
+	for _, p := range rules[0].HTTP.Paths {
+		got = append(got, p.PathPrefix+" "+p.Splits[0].ServiceName)
+	}
+	want := []string{"/v2/beta v3-service", "/v2 v2-service", " v1-service"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Unexpected default paths (-want +got): %v", diff)
+	}
+	// The dedicated hosts of the named targets serve every path.
+	for _, rule := range rules[1:] {
+		if p := rule.HTTP.Paths[0].PathPrefix; p != "" {
+			t.Errorf("Rule for %v has PathPrefix %q, want none", rule.Hosts, p)
+		}
+	}
+}
+
+// The same traffic always yields the same ClusterIngress, and hence the same
+// VirtualService, even though the targets come keyed by name in a map.
+func TestMakeClusterIngressSpec_Deterministic(t *testing.T) {
+	targets := map[string][]traffic.RevisionTarget{
+		"": {{
+			TrafficTarget: v1alpha1.TrafficTarget{RevisionName: "v1", Percent: 100},
+			Active:        true,
+		}},
+	}
+	for _, name := range []string{"e", "d", "c", "b", "a"} {
+		tt := v1alpha1.TrafficTarget{
+			Name:         name,
+			RevisionName: "rev-" + name,
+			Percent:      100,
+		}
+		switch name {
+		case "a", "c", "e":
+			// Prefixes of the same length only tie-break on the name.
+			tt.PathPrefix = "/" + name
+		case "d":
+			tt.Match = []v1alpha1.HeaderMatch{{Name: "x-version", Exact: name}}
+		}
+		targets[name] = []traffic.RevisionTarget{{TrafficTarget: tt, Active: true}}
+		targets[""] = append(targets[""], traffic.RevisionTarget{TrafficTarget: tt, Active: true})
+	}
+	r := &v1alpha1.Route{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-route",
+			Namespace: "test-ns",
+		},
+		Status: v1alpha1.RouteStatus{Domain: "domain.com"},
+	}
+
+	want := makeClusterIngressSpec(r, targets, 80)
+	for i := 0; i < 20; i++ {
+		if diff := cmp.Diff(want, makeClusterIngressSpec(r, targets, 80)); diff != "" {
+			t.Fatalf("Spec changed between builds (-first +got): %v", diff)
+		}
+	}
+	var got []string
+	for _, p := range want.Rules[0].HTTP.Paths {
+		got = append(got, p.Splits[0].ServiceName)
+	}
+	if diff := cmp.Diff([]string{"rev-a-service", "rev-c-service", "rev-e-service", "rev-d-service", "v1-service"}, got); diff != "" {
+		t.Errorf("Unexpected order of the default paths (-want +got): %v", diff)
+	}
+}
+
+func TestMakeClusterIngressSpec_ZeroPercentTarget(t *testing.T) {
+	targets := map[string][]traffic.RevisionTarget{
+		"": {{
+			TrafficTarget: v1alpha1.TrafficTarget{RevisionName: "blue", Percent: 90},
+			Active:        true,
+		}, {
+			TrafficTarget: v1alpha1.TrafficTarget{RevisionName: "green", Percent: 10},
+			Active:        true,
+		}, {
+			// Kept around for a quick rollback.
+			TrafficTarget: v1alpha1.TrafficTarget{RevisionName: "previous", Percent: 0},
+			Active:        false,
+		}},
+	}
+	r := &v1alpha1.Route{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-route",
+			Namespace: "test-ns",
+		},
+		Status: v1alpha1.RouteStatus{Domain: "domain.com"},
+	}
+	rules := makeClusterIngressSpec(r, targets, 80).Rules
+
+	// Neither a destination nor the activator stand in for the 0% target.
+	want := []netv1alpha1.ClusterIngressBackendSplit{{
+		ClusterIngressBackend: netv1alpha1.ClusterIngressBackend{
+			ServiceNamespace: "test-ns",
+			ServiceName:      "blue-service",
+			ServicePort:      intstr.FromInt(80),
+		},
+		Percent: 90,
+	}, {
+		ClusterIngressBackend: netv1alpha1.ClusterIngressBackend{
+			ServiceNamespace: "test-ns",
+			ServiceName:      "green-service",
+			ServicePort:      intstr.FromInt(80),
+		},
+		Percent: 10,
+	}}
+	if diff := cmp.Diff(want, rules[0].HTTP.Paths[0].Splits); diff != "" {
+		t.Errorf("Unexpected splits (-want +got): %v", diff)
+	}
+}
+
+func TestMakeClusterIngressSpec_Draining(t *testing.T) {
+	targets := map[string][]traffic.RevisionTarget{
+		"": {{
+			TrafficTarget: v1alpha1.TrafficTarget{RevisionName: "green", Percent: 100},
+			Active:        false,
+		}, {
+			TrafficTarget: v1alpha1.TrafficTarget{RevisionName: "blue"},
+			Active:        true,
+			Draining:      true,
+		}},
+	}
+	r := &v1alpha1.Route{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-route",
+			Namespace: "test-ns",
+		},
+		Status: v1alpha1.RouteStatus{Domain: "domain.com"},
+	}
+	rules := makeClusterIngressSpec(r, targets, 80).Rules
+
+	// The draining Revision keeps a destination after the activator.
+	want := []netv1alpha1.ClusterIngressBackendSplit{{
+		ClusterIngressBackend: netv1alpha1.ClusterIngressBackend{
+			ServiceNamespace: system.Namespace(),
+			ServiceName:      activator.K8sServiceName,
+			ServicePort:      intstr.FromInt(int(revisionresources.ServicePort)),
+		},
+		Percent: 100,
+	}, {
+		ClusterIngressBackend: netv1alpha1.ClusterIngressBackend{
+			ServiceNamespace: "test-ns",
+			ServiceName:      "blue-service",
+			ServicePort:      intstr.FromInt(80),
+		},
+		Percent: 0,
+	}}
+	if diff := cmp.Diff(want, rules[0].HTTP.Paths[0].Splits); diff != "" {
+		t.Errorf("Unexpected splits (-want +got): %v", diff)
+	}
+}
+
+func TestMakeClusterIngressSpec_Subset(t *testing.T) {
+	targets := map[string][]traffic.RevisionTarget{
+		"": {{
+			TrafficTarget: v1alpha1.TrafficTarget{RevisionName: "blue", Percent: 90},
+			Active:        true,
+		}, {
+			TrafficTarget: v1alpha1.TrafficTarget{RevisionName: "blue", Percent: 10, Subset: "canary"},
+			Active:        true,
+		}},
+	}
+	r := &v1alpha1.Route{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-route",
+			Namespace: "test-ns",
+		},
+		Status: v1alpha1.RouteStatus{Domain: "domain.com"},
+	}
+	rules := makeClusterIngressSpec(r, targets, 80).Rules
+
+	want := []netv1alpha1.ClusterIngressBackendSplit{{
+		ClusterIngressBackend: netv1alpha1.ClusterIngressBackend{
+			ServiceNamespace: "test-ns",
+			ServiceName:      "blue-service",
+			ServicePort:      intstr.FromInt(80),
+		},
+		Percent: 90,
+	}, {
+		ClusterIngressBackend: netv1alpha1.ClusterIngressBackend{
+			ServiceNamespace: "test-ns",
+			ServiceName:      "blue-service",
+			ServicePort:      intstr.FromInt(80),
+			Subset:           "canary",
+		},
+		Percent: 10,
+	}}
+	if diff := cmp.Diff(want, rules[0].HTTP.Paths[0].Splits); diff != "" {
+		t.Errorf("Unexpected splits (-want +got): %v", diff)
+	}
+}
+
+func TestMakeClusterIngressSpec_Fault(t *testing.T) {
+	stable := v1alpha1.TrafficTarget{
+		RevisionName: "v1",
+		Percent:      100,
+	}
+	chaos := v1alpha1.TrafficTarget{
+		Name:         "chaos",
+		RevisionName: "v1",
+		PathPrefix:   "/chaos",
+		Fault: &v1alpha1.FaultSpec{
+			AbortPercent: 10,
+			HTTPStatus:   500,
+		},
+	}
+	onlyChaos := chaos
+	onlyChaos.Percent = 100
+	targets := map[string][]traffic.RevisionTarget{
+		"":      {{TrafficTarget: stable, Active: true}, {TrafficTarget: chaos, Active: true}},
+		"chaos": {{TrafficTarget: onlyChaos, Active: true}},
+	}
+	r := &v1alpha1.Route{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-route",
+			Namespace: "test-ns",
+		},
+		Status: v1alpha1.RouteStatus{Domain: "domain.com"},
+	}
+	rules := makeClusterIngressSpec(r, targets, 80).Rules
+
+	want := &netv1alpha1.HTTPFault{AbortPercent: 10, HTTPStatus: 500}
+	// The path prefix and dedicated host of the target get the fault...
+	if diff := cmp.Diff(want, rules[0].HTTP.Paths[0].Fault); diff != "" {
+		t.Errorf("Unexpected fault of the path prefix (-want +got): %v", diff)
+	}
+	if diff := cmp.Diff(want, rules[1].HTTP.Paths[0].Fault); diff != "" {
+		t.Errorf("Unexpected fault of the named host (-want +got): %v", diff)
+	}
+	// ...but not the traffic split it is part of.
+	if f := rules[0].HTTP.Paths[1].Fault; f != nil {
+		t.Errorf("Traffic split has fault %v, want none", f)
+	}
+}
+
+func TestMakeClusterIngressSpec_Rewrite(t *testing.T) {
+	stable := v1alpha1.TrafficTarget{
+		RevisionName: "v1",
+		Percent:      100,
+	}
+	legacy := v1alpha1.TrafficTarget{
+		Name:         "legacy",
+		RevisionName: "v2",
+		PathPrefix:   "/legacy",
+		Rewrite: &v1alpha1.RewriteSpec{
+			Authority: "legacy.example.com",
+			URI:       "/",
+		},
+	}
+	onlyLegacy := legacy
+	onlyLegacy.Percent = 100
+	targets := map[string][]traffic.RevisionTarget{
+		"":       {{TrafficTarget: stable, Active: true}, {TrafficTarget: legacy, Active: true}},
+		"legacy": {{TrafficTarget: onlyLegacy, Active: true}},
+	}
+	r := &v1alpha1.Route{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-route",
+			Namespace: "test-ns",
+		},
+		Status: v1alpha1.RouteStatus{Domain: "domain.com"},
+	}
+	rules := makeClusterIngressSpec(r, targets, 80).Rules
+
+	want := &netv1alpha1.HTTPRewrite{Authority: "legacy.example.com", URI: "/"}
+	// Only the requests matching the path prefix are rewritten...
+	if diff := cmp.Diff(want, rules[0].HTTP.Paths[0].Rewrite); diff != "" {
+		t.Errorf("Unexpected rewrite of the path prefix (-want +got): %v", diff)
+	}
+	// ...not the traffic split nor the dedicated host of the target.
+	if rw := rules[0].HTTP.Paths[1].Rewrite; rw != nil {
+		t.Errorf("Traffic split has rewrite %v, want none", rw)
+	}
+	if rw := rules[1].HTTP.Paths[0].Rewrite; rw != nil {
+		t.Errorf("Named host has rewrite %v, want none", rw)
+	}
+}
+
+func TestMakeClusterIngressSpec_Mirror(t *testing.T) {
+	targets := map[string][]traffic.RevisionTarget{
+		"": {{
+			TrafficTarget: v1alpha1.TrafficTarget{
+				RevisionName: "v1",
+				Percent:      100,
+			},
+			Active: true,
+		}, {
+			TrafficTarget: v1alpha1.TrafficTarget{
+				RevisionName: "v2",
+				Mirror:       true,
+			},
+			Active: false,
+		}},
+	}
+	r := &v1alpha1.Route{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-route",
+			Namespace: "test-ns",
+		},
+		Status: v1alpha1.RouteStatus{Domain: "domain.com"},
+	}
+	expected := []netv1alpha1.HTTPClusterIngressPath{{
+		Splits: []netv1alpha1.ClusterIngressBackendSplit{{
+			ClusterIngressBackend: netv1alpha1.ClusterIngressBackend{
+				ServiceNamespace: "test-ns",
+				ServiceName:      "v1-service",
+				ServicePort:      intstr.FromInt(80),
+			},
+			Percent: 100,
+		}},
+		// The mirror doesn't count towards a split across Revisions.
+		AppendHeaders: map[string]string{
+			activator.RevisionHeaderName:      "v1",
+			activator.RevisionHeaderNamespace: "test-ns",
+		},
+		Timeout: &metav1.Duration{Duration: netv1alpha1.DefaultTimeout},
+		Retries: &netv1alpha1.HTTPRetry{
+			PerTryTimeout: &metav1.Duration{Duration: netv1alpha1.DefaultTimeout},
+			Attempts:      netv1alpha1.DefaultRetryCount,
+		},
+		// Even inactive mirrors get their copy directly.
+		Mirror: &netv1alpha1.ClusterIngressBackend{
+			ServiceNamespace: "test-ns",
+			ServiceName:      "v2-service",
+			ServicePort:      intstr.FromInt(80),
+		},
+	}}
+	rules := makeClusterIngressSpec(r, targets, 80).Rules
+	if diff := cmp.Diff(expected, rules[0].HTTP.Paths); diff != "" {
+		t.Errorf("Unexpected paths (-want +got): %v", diff)
+	}
+}
+
+func TestMakeClusterIngressSpec_CrossNamespace(t *testing.T) {
+	targets := map[string][]traffic.RevisionTarget{
+		"": {{
+			TrafficTarget: v1alpha1.TrafficTarget{
+				RevisionName: "v1",
+				Percent:      90,
+			},
+			Active: true,
+		}, {
+			TrafficTarget: v1alpha1.TrafficTarget{
+				RevisionName: "v2",
+				Namespace:    "other-ns",
+				Percent:      10,
+			},
+			Active: false,
+		}},
+	}
+	r := &v1alpha1.Route{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-route",
+			Namespace: "test-ns",
+		},
+		Status: v1alpha1.RouteStatus{Domain: "domain.com"},
+	}
+	expected := []netv1alpha1.HTTPClusterIngressPath{{
+		Splits: []netv1alpha1.ClusterIngressBackendSplit{{
+			ClusterIngressBackend: netv1alpha1.ClusterIngressBackend{
+				ServiceNamespace: "test-ns",
+				ServiceName:      "v1-service",
+				ServicePort:      intstr.FromInt(80),
+			},
+			Percent: 90,
+		}, {
+			ClusterIngressBackend: netv1alpha1.ClusterIngressBackend{
+				ServiceNamespace: system.Namespace(),
+				ServiceName:      "activator-service",
+				ServicePort:      intstr.FromInt(80),
+			},
+			Percent: 10,
+		}},
+		// The activator is told where the inactive Revision lives.
+		AppendHeaders: map[string]string{
+			activator.RevisionHeaderName:      "v2",
+			activator.RevisionHeaderNamespace: "other-ns",
+		},
+		Timeout: &metav1.Duration{Duration: netv1alpha1.DefaultTimeout},
+		Retries: &netv1alpha1.HTTPRetry{
+			PerTryTimeout: &metav1.Duration{Duration: netv1alpha1.DefaultTimeout},
+			Attempts:      netv1alpha1.DefaultRetryCount,
+		},
+	}}
+	rules := makeClusterIngressSpec(r, targets, 80).Rules
+	if diff := cmp.Diff(expected, rules[0].HTTP.Paths); diff != "" {
+		t.Errorf("Unexpected paths (-want +got): %v", diff)
+	}
+}
+
 func TestMakeClusterIngressSpec_CorrectVisibility(t *testing.T) {
 	cases := []struct {
 		name              string
@@ -163,7 +946,7 @@ func TestMakeClusterIngressSpec_CorrectVisibility(t *testing.T) {
 	}}
 	for _, c := range cases {
 		t.Run(c.name, func(t *testing.T) {
-			v := makeClusterIngressSpec(&c.route, nil).Visibility
+			v := makeClusterIngressSpec(&c.route, nil, 80).Visibility
 			if diff := cmp.Diff(c.expectedVisbility, v); diff != "" {
 				t.Errorf("Unexpected visibility (-want +got): %v", diff)
 			}
@@ -172,6 +955,84 @@ func TestMakeClusterIngressSpec_CorrectVisibility(t *testing.T) {
 	return
 }
 
+func TestMakeClusterIngressSpec_CustomDomains(t *testing.T) {
+	targets := map[string][]traffic.RevisionTarget{
+		"": {{
+			TrafficTarget: v1alpha1.TrafficTarget{
+				ConfigurationName: "config",
+				RevisionName:      "v2",
+				Percent:           100,
+			},
+			Active: true,
+		}},
+		"v1": {{
+			TrafficTarget: v1alpha1.TrafficTarget{
+				ConfigurationName: "config",
+				RevisionName:      "v1",
+				Percent:           100,
+			},
+			Active: true,
+		}},
+	}
+	r := &v1alpha1.Route{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-route",
+			Namespace: "test-ns",
+		},
+		Spec: v1alpha1.RouteSpec{
+			Domains: []v1alpha1.CustomDomain{{
+				Host:      "www.example.com",
+				TLSSecret: &corev1.LocalObjectReference{Name: "example-cert"},
+			}, {
+				Host: "plain.example.com",
+			}, {
+				HostRegex: `(us|eu)\.example\.com`,
+				TLSSecret: &corev1.LocalObjectReference{Name: "regional-cert"},
+			}},
+			HTTPOption: v1alpha1.HTTPOptionRedirected,
+		},
+		Status: v1alpha1.RouteStatus{Domain: "domain.com"},
+	}
+	spec := makeClusterIngressSpec(r, targets, 80)
+
+	// Only the default traffic is served on the custom domains.
+	wantHosts := [][]string{{
+		"domain.com",
+		"test-route.test-ns.svc.cluster.local",
+		"test-route.test-ns.svc",
+		"test-route.test-ns",
+		"www.example.com",
+		"plain.example.com",
+		"eu.example.com",
+		"us.example.com",
+	}, {
+		"v1.domain.com",
+	}}
+	var gotHosts [][]string
+	for _, rule := range spec.Rules {
+		gotHosts = append(gotHosts, rule.Hosts)
+	}
+	if diff := cmp.Diff(wantHosts, gotHosts); diff != "" {
+		t.Errorf("Unexpected hosts (-want +got): %v", diff)
+	}
+
+	wantTLS := []netv1alpha1.ClusterIngressTLS{{
+		Hosts:           []string{"www.example.com"},
+		SecretName:      "example-cert",
+		SecretNamespace: "test-ns",
+	}, {
+		Hosts:           []string{"eu.example.com", "us.example.com"},
+		SecretName:      "regional-cert",
+		SecretNamespace: "test-ns",
+	}}
+	if diff := cmp.Diff(wantTLS, spec.TLS); diff != "" {
+		t.Errorf("Unexpected TLS (-want +got): %v", diff)
+	}
+	if got, want := spec.HTTPOption, netv1alpha1.HTTPOptionRedirected; got != want {
+		t.Errorf("HTTPOption = %v, want %v", got, want)
+	}
+}
+
 func TestGetRouteDomains_NamelessTarget(t *testing.T) {
 	r := &v1alpha1.Route{
 		ObjectMeta: metav1.ObjectMeta{
@@ -219,7 +1080,7 @@ func TestMakeClusterIngressRule_Vanilla(t *testing.T) {
 	}}
 	domains := []string{"a.com", "b.org"}
 	ns := "test-ns"
-	rule := makeClusterIngressRule(domains, ns, targets)
+	rule := makeClusterIngressRule(domains, ns, targets, 80)
 	expected := netv1alpha1.ClusterIngressRule{
 		Hosts: []string{
 			"a.com",
@@ -268,7 +1129,7 @@ func TestMakeClusterIngressRule_ZeroPercentTarget(t *testing.T) {
 	}}
 	domains := []string{"test.org"}
 	ns := "test-ns"
-	rule := makeClusterIngressRule(domains, ns, targets)
+	rule := makeClusterIngressRule(domains, ns, targets, 80)
 	expected := netv1alpha1.ClusterIngressRule{
 		Hosts: []string{"test.org"},
 		HTTP: &netv1alpha1.HTTPClusterIngressRuleValue{
@@ -314,7 +1175,7 @@ func TestMakeClusterIngressRule_TwoTargets(t *testing.T) {
 	}}
 	domains := []string{"test.org"}
 	ns := "test-ns"
-	rule := makeClusterIngressRule(domains, ns, targets)
+	rule := makeClusterIngressRule(domains, ns, targets, 80)
 	expected := netv1alpha1.ClusterIngressRule{
 		Hosts: []string{"test.org"},
 		HTTP: &netv1alpha1.HTTPClusterIngressRuleValue{
@@ -360,7 +1221,7 @@ func TestMakeClusterIngressRule_InactiveTarget(t *testing.T) {
 	}}
 	domains := []string{"a.com", "b.org"}
 	ns := "test-ns"
-	rule := makeClusterIngressRule(domains, ns, targets)
+	rule := makeClusterIngressRule(domains, ns, targets, 80)
 	expected := netv1alpha1.ClusterIngressRule{
 		Hosts: []string{
 			"a.com",
@@ -412,7 +1273,7 @@ func TestMakeClusterIngressRule_TwoInactiveTargets(t *testing.T) {
 	}}
 	domains := []string{"a.com", "b.org"}
 	ns := "test-ns"
-	rule := makeClusterIngressRule(domains, ns, targets)
+	rule := makeClusterIngressRule(domains, ns, targets, 80)
 	expected := netv1alpha1.ClusterIngressRule{
 		Hosts: []string{
 			"a.com",
@@ -463,7 +1324,7 @@ func TestMakeClusterIngressRule_ZeroPercentTargetInactive(t *testing.T) {
 	}}
 	domains := []string{"test.org"}
 	ns := "test-ns"
-	rule := makeClusterIngressRule(domains, ns, targets)
+	rule := makeClusterIngressRule(domains, ns, targets, 80)
 	expected := netv1alpha1.ClusterIngressRule{
 		Hosts: []string{"test.org"},
 		HTTP: &netv1alpha1.HTTPClusterIngressRuleValue{
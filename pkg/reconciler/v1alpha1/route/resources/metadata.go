@@ -0,0 +1,61 @@
+/*
+Copyright 2019 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/knative/serving/pkg/apis/networking"
+	"github.com/knative/serving/pkg/apis/serving"
+	"github.com/knative/serving/pkg/apis/serving/v1alpha1"
+)
+
+// PropagateMetadata copies the labels and annotations of the Route whose keys
+// start with prefix onto obj, e.g. so that telemetry keyed on them covers the
+// resources generated for the Route.  Nothing is copied when prefix is empty,
+// and keys owned by Knative Serving are never copied as we set those ourselves.
+func PropagateMetadata(prefix string, route *v1alpha1.Route, obj metav1.Object) {
+	if prefix == "" {
+		return
+	}
+	obj.SetLabels(propagate(prefix, route.Labels, obj.GetLabels()))
+	obj.SetAnnotations(propagate(prefix, route.Annotations, obj.GetAnnotations()))
+}
+
+func propagate(prefix string, from, to map[string]string) map[string]string {
+	// Don't modify a map we may share with the Route.
+	result := make(map[string]string, len(to))
+	for k, v := range to {
+		result[k] = v
+	}
+	for k, v := range from {
+		if strings.HasPrefix(k, prefix) && !isInternalKey(k) {
+			result[k] = v
+		}
+	}
+	if len(result) == 0 {
+		return to
+	}
+	return result
+}
+
+func isInternalKey(key string) bool {
+	return strings.HasPrefix(key, serving.GroupName+"/") ||
+		strings.HasPrefix(key, networking.GroupName+"/")
+}
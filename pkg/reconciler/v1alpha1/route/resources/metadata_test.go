@@ -0,0 +1,92 @@
+/*
+Copyright 2019 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/knative/serving/pkg/apis/serving"
+	"github.com/knative/serving/pkg/apis/serving/v1alpha1"
+)
+
+func TestPropagateMetadata(t *testing.T) {
+	route := &v1alpha1.Route{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "test-route",
+			Labels: map[string]string{
+				"telemetry.knative.dev/team": "payments",
+				"app":                        "shop",
+				serving.ServiceLabelKey:      "shop",
+			},
+			Annotations: map[string]string{
+				"telemetry.knative.dev/sampling": "0.1",
+				"owner":                          "someone",
+			},
+		},
+	}
+
+	tests := []struct {
+		name            string
+		prefix          string
+		obj             *corev1.Service
+		wantLabels      map[string]string
+		wantAnnotations map[string]string
+	}{{
+		name: "no prefix",
+		obj: &corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels: map[string]string{serving.RouteLabelKey: "test-route"},
+			},
+		},
+		wantLabels: map[string]string{serving.RouteLabelKey: "test-route"},
+	}, {
+		name:   "telemetry prefix",
+		prefix: "telemetry.knative.dev/",
+		obj: &corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels: map[string]string{serving.RouteLabelKey: "test-route"},
+			},
+		},
+		wantLabels: map[string]string{
+			serving.RouteLabelKey:        "test-route",
+			"telemetry.knative.dev/team": "payments",
+		},
+		wantAnnotations: map[string]string{
+			"telemetry.knative.dev/sampling": "0.1",
+		},
+	}, {
+		name:   "internal keys are never copied",
+		prefix: serving.GroupName,
+		obj:    &corev1.Service{},
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			PropagateMetadata(test.prefix, route, test.obj)
+			if diff := cmp.Diff(test.wantLabels, test.obj.Labels); diff != "" {
+				t.Errorf("Unexpected labels (-want, +got): %v", diff)
+			}
+			if diff := cmp.Diff(test.wantAnnotations, test.obj.Annotations); diff != "" {
+				t.Errorf("Unexpected annotations (-want, +got): %v", diff)
+			}
+		})
+	}
+}
@@ -17,13 +17,84 @@ limitations under the License.
 package names
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 
+	"k8s.io/apimachinery/pkg/util/validation"
+
 	"github.com/knative/serving/pkg/apis/serving/v1alpha1"
 	"github.com/knative/serving/pkg/reconciler"
 )
 
+const (
+	// SameNameStrategy names the placeholder Service of a Route after
+	// the Route.
+	SameNameStrategy = "sameName"
+	// HashSuffixStrategy names the placeholder Service of a Route after
+	// the Route, truncated and suffixed with a hash of its name.
+	HashSuffixStrategy = "hashSuffix"
+
+	// hashLength is the number of hex digits of the hash suffix.
+	hashLength = 10
+	// generateNameSuffixLength is the number of random characters the API
+	// server appends to a GenerateName prefix.
+	generateNameSuffixLength = 5
+)
+
+// NameGenerator picks the name of the placeholder Service of a Route.  The
+// name is recorded in the Route's status once picked, see K8sService.
+type NameGenerator interface {
+	// K8sService returns the name for the placeholder Service of the
+	// given Route.  It must be a valid DNS-1035 label.
+	K8sService(route *v1alpha1.Route) string
+}
+
+var (
+	// SameName implements SameNameStrategy.
+	SameName NameGenerator = sameName{}
+	// HashSuffix implements HashSuffixStrategy.
+	HashSuffix NameGenerator = hashSuffix{}
+)
+
+// NewNameGenerator returns the NameGenerator implementing the given strategy,
+// defaulting to SameNameStrategy.
+func NewNameGenerator(strategy string) (NameGenerator, error) {
+	switch strategy {
+	case "", SameNameStrategy:
+		return SameName, nil
+	case HashSuffixStrategy:
+		return HashSuffix, nil
+	default:
+		return nil, fmt.Errorf("unknown naming strategy %q, must be %q or %q",
+			strategy, SameNameStrategy, HashSuffixStrategy)
+	}
+}
+
+type sameName struct{}
+
+// K8sService implements NameGenerator.
+func (sameName) K8sService(route *v1alpha1.Route) string {
+	return route.Name
+}
+
+// hashSuffix avoids collisions with the names of other Services of the
+// namespace, e.g. those of Revisions, which are suffixed with "-service".
+type hashSuffix struct{}
+
+// K8sService implements NameGenerator.
+func (hashSuffix) K8sService(route *v1alpha1.Route) string {
+	sum := sha256.Sum256([]byte(route.Name))
+	hash := hex.EncodeToString(sum[:])[:hashLength]
+	return truncate(route.Name, validation.DNS1035LabelMaxLength-hashLength-1) + "-" + hash
+}
+
+// K8sService returns the name of the placeholder Service of the Route, as
+// recorded in its status, or the name of the Route until one is.
 func K8sService(route *v1alpha1.Route) string {
+	if route.Status.PlaceholderServiceName != "" {
+		return route.Status.PlaceholderServiceName
+	}
 	return route.Name
 }
 
@@ -33,6 +104,15 @@ func K8sServiceFullname(route *v1alpha1.Route) string {
 
 // ClusterIngressPrefix returns GenerateName prefix of the
 // ClusterIngress child resource for given Route.
+// The generated names are kept short enough to be used as label values.
 func ClusterIngressPrefix(route *v1alpha1.Route) string {
-	return fmt.Sprintf("%s-", route.Name)
+	return fmt.Sprintf("%s-", truncate(K8sService(route),
+		validation.DNS1035LabelMaxLength-generateNameSuffixLength-1))
+}
+
+func truncate(s string, n int) string {
+	if len(s) > n {
+		return s[:n]
+	}
+	return s
 }
@@ -17,9 +17,11 @@ limitations under the License.
 package names
 
 import (
+	"strings"
 	"testing"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
 
 	"github.com/knative/serving/pkg/apis/serving/v1alpha1"
 )
@@ -71,3 +73,82 @@ func TestNamer(t *testing.T) {
 		})
 	}
 }
+
+func TestNameGenerator(t *testing.T) {
+	// The longest name a Route may have.
+	long := strings.Repeat("a", 63)
+	tests := []struct {
+		name     string
+		strategy string
+		route    string
+		want     string
+	}{{
+		name:  "default",
+		route: "blah",
+		want:  "blah",
+	}, {
+		name:     "same name",
+		strategy: SameNameStrategy,
+		route:    "blah",
+		want:     "blah",
+	}, {
+		name:     "hash suffix",
+		strategy: HashSuffixStrategy,
+		route:    "blah",
+		want:     "blah-8b7df143d9",
+	}, {
+		name:     "hash suffix of a long name",
+		strategy: HashSuffixStrategy,
+		route:    long,
+		want:     strings.Repeat("a", 52) + "-7d3e74a05d",
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			g, err := NewNameGenerator(test.strategy)
+			if err != nil {
+				t.Fatalf("NewNameGenerator(%q) = %v", test.strategy, err)
+			}
+			got := g.K8sService(&v1alpha1.Route{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      test.route,
+					Namespace: "default",
+				},
+			})
+			if got != test.want {
+				t.Errorf("K8sService() = %v, wanted %v", got, test.want)
+			}
+			if errs := validation.IsDNS1035Label(got); len(errs) != 0 {
+				t.Errorf("K8sService() = %v, not a valid Service name: %v", got, errs)
+			}
+		})
+	}
+
+	if _, err := NewNameGenerator("random"); err == nil {
+		t.Error("NewNameGenerator(random) = nil, wanted an error")
+	}
+}
+
+func TestRecordedNames(t *testing.T) {
+	r := &v1alpha1.Route{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      strings.Repeat("a", 63),
+			Namespace: "default",
+		},
+		Status: v1alpha1.RouteStatus{
+			PlaceholderServiceName: "recorded",
+		},
+	}
+	if got, want := K8sService(r), "recorded"; got != want {
+		t.Errorf("K8sService() = %v, wanted %v", got, want)
+	}
+	if got, want := K8sServiceFullname(r), "recorded.default.svc.cluster.local"; got != want {
+		t.Errorf("K8sServiceFullname() = %v, wanted %v", got, want)
+	}
+
+	// Generated ClusterIngress names stay valid label values.
+	r.Status.PlaceholderServiceName = HashSuffix.K8sService(r)
+	if got, want := ClusterIngressPrefix(r), strings.Repeat("a", 52)+"-7d3e-"; got != want {
+		t.Errorf("ClusterIngressPrefix() = %v, wanted %v", got, want)
+	}
+}
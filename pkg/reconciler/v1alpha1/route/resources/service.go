@@ -25,6 +25,7 @@ import (
 
 	"github.com/knative/pkg/kmeta"
 	netv1alpha1 "github.com/knative/serving/pkg/apis/networking/v1alpha1"
+	"github.com/knative/serving/pkg/apis/serving"
 	"github.com/knative/serving/pkg/apis/serving/v1alpha1"
 	revisionresources "github.com/knative/serving/pkg/reconciler/v1alpha1/revision/resources"
 	"github.com/knative/serving/pkg/reconciler/v1alpha1/route/resources/names"
@@ -35,8 +36,11 @@ var errLoadBalancerNotFound = errors.New("failed to fetch loadbalancer domain/IP
 // MakeK8sService creates a Service that redirect to the loadbalancer specified
 // in ClusterIngress status. It's owned by the provided v1alpha1.Route.
 // The purpose of this service is to provide a domain name for Istio routing.
-func MakeK8sService(route *v1alpha1.Route, ingress *netv1alpha1.ClusterIngress) (*corev1.Service, error) {
-	svcSpec, err := makeServiceSpec(ingress)
+// Its port is named after the given protocol the Route's Revisions serve,
+// and listens on the given port like their Services do.
+func MakeK8sService(route *v1alpha1.Route, ingress *netv1alpha1.ClusterIngress,
+	protocol v1alpha1.RevisionProtocolType, port int32) (*corev1.Service, error) {
+	svcSpec, err := makeServiceSpec(route, ingress, protocol, port)
 	if err != nil {
 		return nil, err
 	}
@@ -54,7 +58,20 @@ func MakeK8sService(route *v1alpha1.Route, ingress *netv1alpha1.ClusterIngress)
 	}, nil
 }
 
-func makeServiceSpec(ingress *netv1alpha1.ClusterIngress) (*corev1.ServiceSpec, error) {
+// SetReadinessPath advertises on the placeholder Service the path of the HTTP
+// readiness probe of the Revisions behind the Route, if there's one.
+func SetReadinessPath(svc *corev1.Service, path string) {
+	if path == "" {
+		return
+	}
+	if svc.Annotations == nil {
+		svc.Annotations = make(map[string]string)
+	}
+	svc.Annotations[serving.ReadinessPathAnnotationKey] = path
+}
+
+func makeServiceSpec(route *v1alpha1.Route, ingress *netv1alpha1.ClusterIngress,
+	protocol v1alpha1.RevisionProtocolType, port int32) (*corev1.ServiceSpec, error) {
 	ingressStatus := ingress.Status
 	if ingressStatus.LoadBalancer == nil || len(ingressStatus.LoadBalancer.Ingress) == 0 {
 		return nil, errLoadBalancerNotFound
@@ -85,16 +102,27 @@ func makeServiceSpec(ingress *netv1alpha1.ClusterIngress) (*corev1.ServiceSpec,
 		// but we still need to create a ClusterIP service to make
 		// sure the domain name is available for access within the
 		// mesh.
-		return &corev1.ServiceSpec{
+		spec := &corev1.ServiceSpec{
 			Type: corev1.ServiceTypeClusterIP,
 			Ports: []corev1.ServicePort{{
-				Name: revisionresources.ServicePortName,
-				Port: revisionresources.ServicePort,
+				Name: revisionresources.ServicePortNameFor(protocol),
+				Port: port,
 			}},
-		}, nil
+		}
+		if isHeadless(route) {
+			spec.ClusterIP = corev1.ClusterIPNone
+		}
+		return spec, nil
 	case len(balancer.IP) != 0:
 		// TODO(lichuqiang): deal with LoadBalancer IP.
 		// We'll also need ports info to make it take effect.
 	}
 	return nil, errLoadBalancerNotFound
 }
+
+// isHeadless returns whether the Route asked for a headless placeholder Service.
+// Only ClusterIP Services are affected, as ExternalName Services never get a
+// cluster IP in the first place.
+func isHeadless(route *v1alpha1.Route) bool {
+	return route.ObjectMeta.Annotations[serving.ServiceTypeAnnotationKey] == serving.ServiceTypeHeadless
+}
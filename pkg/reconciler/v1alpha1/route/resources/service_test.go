@@ -25,6 +25,7 @@ import (
 
 	"github.com/knative/pkg/kmeta"
 	netv1alpha1 "github.com/knative/serving/pkg/apis/networking/v1alpha1"
+	"github.com/knative/serving/pkg/apis/serving"
 	"github.com/knative/serving/pkg/apis/serving/v1alpha1"
 )
 
@@ -49,6 +50,7 @@ func TestNewMakeK8SService(t *testing.T) {
 		// Inputs
 		route        *v1alpha1.Route
 		ingress      *netv1alpha1.ClusterIngress
+		protocol     v1alpha1.RevisionProtocolType
 		expectedSpec corev1.ServiceSpec
 		shouldFail   bool
 	}{
@@ -130,10 +132,80 @@ func TestNewMakeK8SService(t *testing.T) {
 				}},
 			},
 		},
+		"ingress-with-only-mesh-headless": {
+			route: &v1alpha1.Route{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-route",
+					Namespace: "test-ns",
+					Annotations: map[string]string{
+						serving.ServiceTypeAnnotationKey: serving.ServiceTypeHeadless,
+					},
+				},
+			},
+			ingress: &netv1alpha1.ClusterIngress{
+				Status: netv1alpha1.IngressStatus{
+					LoadBalancer: &netv1alpha1.LoadBalancerStatus{
+						Ingress: []netv1alpha1.LoadBalancerIngressStatus{{MeshOnly: true}},
+					},
+				},
+			},
+			expectedSpec: corev1.ServiceSpec{
+				Type:      corev1.ServiceTypeClusterIP,
+				ClusterIP: corev1.ClusterIPNone,
+				Ports: []corev1.ServicePort{{
+					Name: "http",
+					Port: 80,
+				}},
+			},
+		},
+		"ingress-with-meshonly-h2c": {
+			route: r,
+			ingress: &netv1alpha1.ClusterIngress{
+				Status: netv1alpha1.IngressStatus{
+					LoadBalancer: &netv1alpha1.LoadBalancerStatus{
+						Ingress: []netv1alpha1.LoadBalancerIngressStatus{{MeshOnly: true}},
+					},
+				},
+			},
+			protocol: v1alpha1.RevisionProtocolH2C,
+			expectedSpec: corev1.ServiceSpec{
+				Type: corev1.ServiceTypeClusterIP,
+				Ports: []corev1.ServicePort{{
+					Name: "http2",
+					Port: 80,
+				}},
+			},
+		},
+		"ingress-with-domain-headless": {
+			route: &v1alpha1.Route{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-route",
+					Namespace: "test-ns",
+					Annotations: map[string]string{
+						serving.ServiceTypeAnnotationKey: serving.ServiceTypeHeadless,
+					},
+				},
+			},
+			ingress: &netv1alpha1.ClusterIngress{
+				Status: netv1alpha1.IngressStatus{
+					LoadBalancer: &netv1alpha1.LoadBalancerStatus{
+						Ingress: []netv1alpha1.LoadBalancerIngressStatus{{Domain: "domain.com"}},
+					},
+				},
+			},
+			expectedSpec: corev1.ServiceSpec{
+				Type:         corev1.ServiceTypeExternalName,
+				ExternalName: "domain.com",
+			},
+		},
 	}
 
 	for name, scenario := range scenarios {
-		service, err := MakeK8sService(scenario.route, scenario.ingress)
+		protocol := scenario.protocol
+		if protocol == "" {
+			protocol = v1alpha1.RevisionProtocolHTTP1
+		}
+		service, err := MakeK8sService(scenario.route, scenario.ingress, protocol, 80)
 		// Validate
 		if scenario.shouldFail && err == nil {
 			t.Errorf("Test %q failed: returned success but expected error", name)
@@ -151,3 +223,34 @@ func TestNewMakeK8SService(t *testing.T) {
 		}
 	}
 }
+
+func TestMakeK8sServiceOwnerReference(t *testing.T) {
+	route := r.DeepCopy()
+	route.UID = "route-uid"
+	ingress := &netv1alpha1.ClusterIngress{
+		Status: netv1alpha1.IngressStatus{
+			LoadBalancer: &netv1alpha1.LoadBalancerStatus{
+				Ingress: []netv1alpha1.LoadBalancerIngressStatus{{Domain: "domain.com"}},
+			},
+		},
+	}
+	service, err := MakeK8sService(route, ingress, v1alpha1.RevisionProtocolHTTP1, 80)
+	if err != nil {
+		t.Fatalf("MakeK8sService() = %v", err)
+	}
+	if got, want := len(service.OwnerReferences), 1; got != want {
+		t.Fatalf("len(OwnerReferences) = %d, want %d", got, want)
+	}
+	// Garbage collection deletes the Service along with the Route that
+	// controls it.
+	owner := service.OwnerReferences[0]
+	if owner.Controller == nil || !*owner.Controller {
+		t.Errorf("OwnerReference.Controller = %v, want true", owner.Controller)
+	}
+	if got, want := owner.UID, route.UID; got != want {
+		t.Errorf("OwnerReference.UID = %q, want %q", got, want)
+	}
+	if got, want := owner.Kind, "Route"; got != want {
+		t.Errorf("OwnerReference.Kind = %q, want %q", got, want)
+	}
+}
@@ -19,12 +19,20 @@ package route
 import (
 	"context"
 	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
 
 	"go.uber.org/zap"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/equality"
 	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation"
 	corev1informers "k8s.io/client-go/informers/core/v1"
 	corev1listers "k8s.io/client-go/listers/core/v1"
 	"k8s.io/client-go/tools/cache"
@@ -41,11 +49,15 @@ import (
 	networkinglisters "github.com/knative/serving/pkg/client/listers/networking/v1alpha1"
 	listers "github.com/knative/serving/pkg/client/listers/serving/v1alpha1"
 	"github.com/knative/serving/pkg/reconciler"
+	ingressconfig "github.com/knative/serving/pkg/reconciler/v1alpha1/clusteringress/config"
+	ingressnames "github.com/knative/serving/pkg/reconciler/v1alpha1/clusteringress/resources/names"
+	revisionconfig "github.com/knative/serving/pkg/reconciler/v1alpha1/revision/config"
 	"github.com/knative/serving/pkg/reconciler/v1alpha1/route/config"
 	"github.com/knative/serving/pkg/reconciler/v1alpha1/route/resources"
 	resourcenames "github.com/knative/serving/pkg/reconciler/v1alpha1/route/resources/names"
 	"github.com/knative/serving/pkg/reconciler/v1alpha1/route/traffic"
 	"github.com/knative/serving/pkg/system"
+	"github.com/knative/serving/pkg/utils"
 )
 
 const (
@@ -70,7 +82,29 @@ type Reconciler struct {
 	configStore          configStore
 	tracker              tracker.Interface
 
+	// routeIndexer indexes Routes by the Configurations they target.
+	routeIndexer cache.Indexer
+
 	clock system.Clock
+
+	// domainResolver computes the domain each Route is served at.
+	domainResolver DomainResolver
+
+	// nameGenerator names the placeholder Service of new Routes.
+	nameGenerator resourcenames.NameGenerator
+
+	// propagatedMetadataPrefix selects the Route labels and annotations
+	// copied onto the ClusterIngress and placeholder Service.
+	propagatedMetadataPrefix string
+
+	// enqueueAfter schedules the given Route to be reconciled again after
+	// a delay, to advance its gradual rollouts.
+	enqueueAfter func(obj interface{}, after time.Duration)
+
+	// gatewayServices holds the cluster-local hostnames of the gateway
+	// Services configured in config-istio.
+	gatewayServicesMu sync.RWMutex
+	gatewayServices   sets.String
 }
 
 // Check that our Reconciler implements controller.Reconciler
@@ -88,7 +122,7 @@ func NewController(
 	revisionInformer servinginformers.RevisionInformer,
 	serviceInformer corev1informers.ServiceInformer,
 	clusterIngressInformer networkinginformers.ClusterIngressInformer,
-) *controller.Impl {
+) (*controller.Impl, error) {
 	return NewControllerWithClock(opt, routeInformer, configInformer, revisionInformer,
 		serviceInformer, clusterIngressInformer, system.RealClock{})
 }
@@ -101,7 +135,46 @@ func NewControllerWithClock(
 	serviceInformer corev1informers.ServiceInformer,
 	clusterIngressInformer networkinginformers.ClusterIngressInformer,
 	clock system.Clock,
-) *controller.Impl {
+) (*controller.Impl, error) {
+	return newController(opt, routeInformer, configInformer, revisionInformer,
+		serviceInformer, clusterIngressInformer, clock, DefaultDomainResolver)
+}
+
+// NewControllerWithDomainResolver initializes the controller like NewController,
+// but has the domains of Routes computed by the given DomainResolver.
+func NewControllerWithDomainResolver(
+	opt reconciler.Options,
+	routeInformer servinginformers.RouteInformer,
+	configInformer servinginformers.ConfigurationInformer,
+	revisionInformer servinginformers.RevisionInformer,
+	serviceInformer corev1informers.ServiceInformer,
+	clusterIngressInformer networkinginformers.ClusterIngressInformer,
+	domainResolver DomainResolver,
+) (*controller.Impl, error) {
+	return newController(opt, routeInformer, configInformer, revisionInformer,
+		serviceInformer, clusterIngressInformer, system.RealClock{}, domainResolver)
+}
+
+func newController(
+	opt reconciler.Options,
+	routeInformer servinginformers.RouteInformer,
+	configInformer servinginformers.ConfigurationInformer,
+	revisionInformer servinginformers.RevisionInformer,
+	serviceInformer corev1informers.ServiceInformer,
+	clusterIngressInformer networkinginformers.ClusterIngressInformer,
+	clock system.Clock,
+	domainResolver DomainResolver,
+) (*controller.Impl, error) {
+	nameGenerator, err := resourcenames.NewNameGenerator(opt.ChildNaming)
+	if err != nil {
+		return nil, fmt.Errorf("invalid naming strategy for the resources of Routes: %v", err)
+	}
+	if err := routeInformer.Informer().AddIndexers(cache.Indexers{
+		configurationIndex:         indexByConfiguration,
+		configurationSelectorIndex: indexByConfigurationSelector,
+	}); err != nil {
+		return nil, fmt.Errorf("failed to index Routes by Configuration: %v", err)
+	}
 
 	// No need to lock domainConfigMutex yet since the informers that can modify
 	// domainConfig haven't started yet.
@@ -113,8 +186,21 @@ func NewControllerWithClock(
 		serviceLister:        serviceInformer.Lister(),
 		clusterIngressLister: clusterIngressInformer.Lister(),
 		clock:                clock,
+		domainResolver:       domainResolver,
+		nameGenerator:        nameGenerator,
+		routeIndexer:         routeInformer.Informer().GetIndexer(),
+
+		propagatedMetadataPrefix: opt.PropagatedMetadataPrefix,
 	}
 	impl := controller.NewImpl(c, c.Logger, "Routes", reconciler.MustNewStatsReporter("Routes", c.Logger))
+	c.enqueueAfter = func(obj interface{}, after time.Duration) {
+		key, err := cache.MetaNamespaceKeyFunc(obj)
+		if err != nil {
+			c.Logger.Errorw("Failed to compute the key of a Route", zap.Error(err))
+			return
+		}
+		impl.WorkQueue.AddAfter(key, after)
+	}
 
 	c.Logger.Info("Setting up event handlers")
 	routeInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
@@ -122,6 +208,14 @@ func NewControllerWithClock(
 		UpdateFunc: controller.PassNew(impl.Enqueue),
 		DeleteFunc: impl.Enqueue,
 	})
+	// A Route giving up a custom domain hands it over to the next Route
+	// claiming it.
+	routeInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		UpdateFunc: func(old, new interface{}) {
+			c.enqueueClaimingRoutes(impl.Enqueue)(old)
+		},
+		DeleteFunc: c.enqueueClaimingRoutes(impl.Enqueue),
+	})
 
 	serviceInformer.Informer().AddEventHandler(cache.FilteringResourceEventHandler{
 		FilterFunc: controller.Filter(v1alpha1.SchemeGroupVersion.WithKind("Route")),
@@ -132,6 +226,18 @@ func NewControllerWithClock(
 		},
 	})
 
+	// The ingress address of a Route comes from the load balancer of the
+	// gateway Service its ClusterIngress is exposed through.
+	enqueueIngressRoute := impl.EnqueueLabelOfNamespaceScopedResource(serving.RouteNamespaceLabelKey, serving.RouteLabelKey)
+	serviceInformer.Informer().AddEventHandler(cache.FilteringResourceEventHandler{
+		FilterFunc: c.isGatewayService,
+		Handler: cache.ResourceEventHandlerFuncs{
+			AddFunc:    c.enqueueGatewayRoutes(enqueueIngressRoute),
+			UpdateFunc: controller.PassNew(c.enqueueGatewayRoutes(enqueueIngressRoute)),
+			DeleteFunc: c.enqueueGatewayRoutes(enqueueIngressRoute),
+		},
+	})
+
 	clusterIngressInformer.Informer().AddEventHandler(cache.FilteringResourceEventHandler{
 		FilterFunc: controller.Filter(v1alpha1.SchemeGroupVersion.WithKind("Route")),
 		Handler: cache.ResourceEventHandlerFuncs{
@@ -141,14 +247,19 @@ func NewControllerWithClock(
 		},
 	})
 
-	c.tracker = tracker.New(impl.EnqueueKey, opt.GetTrackerLease())
-	gvk := v1alpha1.SchemeGroupVersion.WithKind("Configuration")
 	configInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
-		AddFunc:    controller.EnsureTypeMeta(c.tracker.OnChanged, gvk),
-		UpdateFunc: controller.PassNew(controller.EnsureTypeMeta(c.tracker.OnChanged, gvk)),
-		DeleteFunc: controller.EnsureTypeMeta(c.tracker.OnChanged, gvk),
+		AddFunc: c.enqueueRoutesForConfiguration(impl.Enqueue),
+		// Both versions, so that the Routes of a selector the Configuration
+		// stopped matching pick another one.
+		UpdateFunc: func(old, new interface{}) {
+			c.enqueueRoutesForConfiguration(impl.Enqueue)(old)
+			c.enqueueRoutesForConfiguration(impl.Enqueue)(new)
+		},
+		DeleteFunc: c.enqueueRoutesForConfiguration(impl.Enqueue),
 	})
-	gvk = v1alpha1.SchemeGroupVersion.WithKind("Revision")
+
+	c.tracker = tracker.New(impl.EnqueueKey, opt.GetTrackerLease())
+	gvk := v1alpha1.SchemeGroupVersion.WithKind("Revision")
 	revisionInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
 		AddFunc:    controller.EnsureTypeMeta(c.tracker.OnChanged, gvk),
 		UpdateFunc: controller.PassNew(controller.EnsureTypeMeta(c.tracker.OnChanged, gvk)),
@@ -156,29 +267,143 @@ func NewControllerWithClock(
 	})
 
 	c.Logger.Info("Setting up ConfigMap receivers")
-	resyncRoutesOnConfigDomainChange := configmap.TypeFilter(&config.Domain{})(func(string, interface{}) {
+	resyncRoutesOnConfigChange := configmap.TypeFilter(&config.Domain{}, &revisionconfig.Network{}, &config.Route{}, &ingressconfig.Istio{})(func(string, interface{}) {
 		impl.GlobalResync(routeInformer.Informer())
 	})
-	c.configStore = config.NewStore(c.Logger.Named("config-store"), resyncRoutesOnConfigDomainChange)
+	updateGatewayServices := configmap.TypeFilter(&ingressconfig.Istio{})(func(_ string, value interface{}) {
+		c.setGatewayServices(value.(*ingressconfig.Istio))
+	})
+	// The gateway Services must be known before the Routes are resynced.
+	c.configStore = config.NewStore(c.Logger.Named("config-store"), updateGatewayServices, resyncRoutesOnConfigChange)
 	c.configStore.WatchConfigs(opt.ConfigMapWatcher)
-	return impl
+	return impl, nil
 }
 
 /////////////////////////////////////////
 //  Event handlers
 /////////////////////////////////////////
 
+// enqueueRoutesForConfiguration returns a handler of Configuration events,
+// which enqueues the Routes sending traffic to it.
+func (c *Reconciler) enqueueRoutesForConfiguration(enqueue func(interface{})) func(interface{}) {
+	return func(obj interface{}) {
+		if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+			obj = tombstone.Obj
+		}
+		config, ok := obj.(*v1alpha1.Configuration)
+		if !ok {
+			return
+		}
+		routes, err := c.RoutesForConfiguration(config)
+		if err != nil {
+			c.Logger.Errorw("Failed to find the Routes of a Configuration", zap.Error(err))
+			return
+		}
+		for _, route := range routes {
+			enqueue(route)
+		}
+	}
+}
+
+// enqueueClaimingRoutes returns a handler of Route events, which enqueues the
+// other Routes claiming any of the custom hosts of the Route.
+func (c *Reconciler) enqueueClaimingRoutes(enqueue func(interface{})) func(interface{}) {
+	return func(obj interface{}) {
+		route, ok := obj.(*v1alpha1.Route)
+		if !ok || len(route.Spec.Domains) == 0 {
+			return
+		}
+		hosts := sets.NewString(resources.CustomHosts(route)...)
+		routes, err := c.routeLister.List(labels.Everything())
+		if err != nil {
+			c.Logger.Errorw("Failed to list Routes", zap.Error(err))
+			return
+		}
+		for _, other := range routes {
+			if (other.Namespace != route.Namespace || other.Name != route.Name) &&
+				hosts.HasAny(resources.CustomHosts(other)...) {
+				enqueue(other)
+			}
+		}
+	}
+}
+
+// setGatewayServices records the gateway Services of the given config-istio.
+func (c *Reconciler) setGatewayServices(istio *ingressconfig.Istio) {
+	services := sets.NewString()
+	for _, gateways := range [][]ingressconfig.Gateway{istio.IngressGateways, istio.LocalGateways} {
+		for _, gw := range gateways {
+			services.Insert(gw.ServiceURL)
+		}
+	}
+	c.gatewayServicesMu.Lock()
+	defer c.gatewayServicesMu.Unlock()
+	c.gatewayServices = services
+}
+
+// isGatewayService returns whether obj is one of the gateway Services
+// configured in config-istio.
+func (c *Reconciler) isGatewayService(obj interface{}) bool {
+	svc, ok := obj.(*corev1.Service)
+	if !ok {
+		return false
+	}
+	c.gatewayServicesMu.RLock()
+	defer c.gatewayServicesMu.RUnlock()
+	return c.gatewayServices.Has(reconciler.GetK8sServiceFullname(svc.Name, svc.Namespace))
+}
+
+// enqueueGatewayRoutes returns a handler of Service events, which enqueues
+// the ClusterIngresses exposed through the Service, for enqueue to map to
+// their Routes.
+func (c *Reconciler) enqueueGatewayRoutes(enqueue func(interface{})) func(interface{}) {
+	return func(obj interface{}) {
+		svc, ok := obj.(*corev1.Service)
+		if !ok {
+			return
+		}
+		ingresses, err := c.clusterIngressLister.List(labels.Everything())
+		if err != nil {
+			c.Logger.Errorw("Failed to list ClusterIngresses", zap.Error(err))
+			return
+		}
+		fullname := reconciler.GetK8sServiceFullname(svc.Name, svc.Namespace)
+		for _, ci := range ingresses {
+			if lb := ci.Status.LoadBalancer; lb != nil && len(lb.Ingress) == 1 && lb.Ingress[0].DomainInternal == fullname {
+				enqueue(ci)
+			}
+		}
+	}
+}
+
 // Reconcile compares the actual state with the desired, and attempts to
 // converge the two. It then updates the Status block of the Route resource
-// with the current status of the resource.
-func (c *Reconciler) Reconcile(ctx context.Context, key string) error {
+// with the current status of the resource.  Once the given context is done
+// no further writes are issued and its error is returned.
+func (c *Reconciler) Reconcile(ctx context.Context, key string) (err error) {
+	start := c.clock.Now()
+	requeued := false
+	ctx = context.WithValue(ctx, requeuedKey{}, &requeued)
+	defer func() {
+		result := reconciler.ReconcileSuccess
+		if err != nil {
+			result = reconciler.ReconcileError
+		} else if requeued {
+			result = reconciler.ReconcileRequeue
+		}
+		if err := c.StatsReporter.ReportRouteReconcile(c.clock.Now().Sub(start), result); err != nil {
+			logging.FromContext(ctx).Errorw("Failed to report the Route reconcile", zap.Error(err))
+		}
+	}()
+
+	logger := logging.FromContext(ctx)
+
 	// Convert the namespace/name string into a distinct namespace and name
 	namespace, name, err := cache.SplitMetaNamespaceKey(key)
 	if err != nil {
-		c.Logger.Errorf("invalid resource key: %s", key)
+		logger.Errorf("invalid resource key: %s", key)
 		return nil
 	}
-	logger := logging.FromContext(ctx)
 
 	ctx = c.configStore.ToContext(ctx)
 
@@ -197,6 +422,12 @@ func (c *Reconciler) Reconcile(ctx context.Context, key string) error {
 	// Reconcile this copy of the route and then write back any status
 	// updates regardless of whether the reconciliation errored out.
 	err = c.reconcile(ctx, route)
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		// The status may reflect a partial reconcile, and we mustn't
+		// write anything more anyway.
+		logger.Warnw("Reconcile of route aborted", zap.Error(ctxErr))
+		return ctxErr
+	}
 	if equality.Semantic.DeepEqual(original.Status, route.Status) {
 		// If we didn't change anything then don't call updateStatus.
 		// This is important because the copy we loaded from the informer's
@@ -211,6 +442,9 @@ func (c *Reconciler) Reconcile(ctx context.Context, key string) error {
 	return err
 }
 
+// reconcile brings the resources of the given Route in line with it, and
+// records the outcome in its status, without writing that back.  The context
+// must carry the logger and the configuration to reconcile it with.
 func (c *Reconciler) reconcile(ctx context.Context, r *v1alpha1.Route) error {
 	logger := logging.FromContext(ctx)
 
@@ -223,6 +457,18 @@ func (c *Reconciler) reconcile(ctx context.Context, r *v1alpha1.Route) error {
 	r.Status.InitializeConditions()
 
 	logger.Infof("Reconciling route: %v", r)
+	domain, err := c.domainResolver.Resolve(ctx, r)
+	if err != nil {
+		logger.Errorw("Failed to resolve the domain of the route", zap.Error(err))
+		return err
+	}
+	if errs := validateDomain(domain); len(errs) != 0 {
+		// There is no point in programming ingress for a domain that
+		// can't be resolved, so surface the problem and stop here.
+		r.Status.MarkInvalidDomain(domain, strings.Join(errs, "; "))
+		return nil
+	}
+
 	// Configure traffic based on the RouteSpec.
 	traffic, err := c.configureTraffic(ctx, r)
 	if traffic == nil || err != nil {
@@ -237,22 +483,46 @@ func (c *Reconciler) reconcile(ctx context.Context, r *v1alpha1.Route) error {
 		return err
 	}
 
+	if r.Status.PlaceholderServiceName == "" {
+		if r.Status.Address != nil {
+			// Reconciled before names were recorded, when the Service
+			// was named after the Route.
+			r.Status.PlaceholderServiceName = r.Name
+		} else {
+			r.Status.PlaceholderServiceName = c.nameGenerator.K8sService(r)
+		}
+	}
+
 	// Update the information that makes us Addressable.
-	r.Status.Domain = routeDomain(ctx, r)
+	r.Status.Domain = domain
 	r.Status.DomainInternal = resourcenames.K8sServiceFullname(r)
 	r.Status.Address = &duckv1alpha1.Addressable{
 		Hostname: resourcenames.K8sServiceFullname(r),
 	}
 
+	claimed, err := c.claimedCustomHosts(r)
+	if err != nil {
+		return err
+	}
+
 	logger.Info("Creating ClusterIngress.")
-	clusterIngress, err := c.reconcileClusterIngress(ctx, r, resources.MakeClusterIngress(r, traffic))
+	clusterIngress, err := c.reconcileClusterIngress(ctx, r, traffic, claimed)
 	if err != nil {
 		return err
 	}
 	r.Status.PropagateClusterIngressStatus(clusterIngress.Status)
+	if len(claimed) != 0 {
+		r.Status.MarkDomainClaimed(claimed[0])
+	}
+	r.Status.VirtualServiceName = ingressnames.VirtualService(clusterIngress)
+	address, err := c.ingressAddress(clusterIngress)
+	if err != nil {
+		return err
+	}
+	r.Status.IngressAddress = address
 
 	logger.Info("Creating/Updating placeholder k8s services")
-	if err := c.reconcilePlaceholderService(ctx, r, clusterIngress); err != nil {
+	if err := c.reconcilePlaceholderService(ctx, r, clusterIngress, traffic); err != nil {
 		return err
 	}
 
@@ -265,21 +535,24 @@ func (c *Reconciler) reconcile(ctx context.Context, r *v1alpha1.Route) error {
 // no traffic will be configured.
 //
 // If traffic is configured we update the RouteStatus with AllTrafficAssigned = True.  Otherwise we
-// mark AllTrafficAssigned = False, with a message referring to one of the missing target.
+// mark AllTrafficAssigned = False, with a message referring to the missing targets.
 func (c *Reconciler) configureTraffic(ctx context.Context, r *v1alpha1.Route) (*traffic.Config, error) {
 	logger := logging.FromContext(ctx)
-	t, err := traffic.BuildTrafficConfiguration(c.configurationLister, c.revisionLister, r)
-
-	if t != nil {
-		// Tell our trackers to reconcile Route whenever the things referred to by our
-		// Traffic stanza change.
-		gvk := v1alpha1.SchemeGroupVersion.WithKind("Configuration")
-		for _, configuration := range t.Configurations {
-			if err := c.tracker.Track(objectRef(configuration, gvk), r); err != nil {
-				return nil, err
+	if !config.FromContext(ctx).Route.AllowCrossNamespaceTraffic {
+		for _, tt := range r.Spec.Traffic {
+			if tt.Namespace != "" && tt.Namespace != r.Namespace {
+				r.Status.MarkCrossNamespaceTrafficDisabled(tt.Namespace)
+				return nil, nil
 			}
 		}
-		gvk = v1alpha1.SchemeGroupVersion.WithKind("Revision")
+	}
+	t, err := traffic.BuildTrafficConfigurationWithClock(c.configurationLister, c.revisionLister, r, c.clock)
+
+	if t != nil {
+		// Tell our trackers to reconcile Route whenever the Revisions referred
+		// to by our Traffic stanza change.  The Configurations are found
+		// through the Route indexes instead.
+		gvk := v1alpha1.SchemeGroupVersion.WithKind("Revision")
 		for _, revision := range t.Revisions {
 			if revision.Status.IsActivationRequired() {
 				logger.Infof("Revision %s/%s is inactive", revision.Namespace, revision.Name)
@@ -299,18 +572,151 @@ func (c *Reconciler) configureTraffic(ctx context.Context, r *v1alpha1.Route) (*
 	}
 	if badTarget != nil && isTargetError {
 		badTarget.MarkBadTrafficTarget(&r.Status)
+		r.Status.TargetStatuses = t.TargetStatuses
+		if !badTarget.IsFailure() {
+			c.checkRevisionReadyTimeout(ctx, r, t)
+			// Not every change that makes a Revision ready, e.g. its build
+			// completing, enqueues the Route, so look again in a while.
+			if poll := config.FromContext(ctx).Route.RevisionReadyPollInterval; poll > 0 {
+				c.requeueAfter(ctx, r, poll)
+			}
+		}
 
 		// Traffic targets aren't ready, no need to configure Route.
 		return nil, nil
 	}
 
 	logger.Info("All referred targets are routable, marking AllTrafficAssigned with traffic information.")
+	previous := r.Status.Traffic
 	r.Status.Traffic = t.GetRevisionTrafficTargets()
+	r.Status.ActiveTargets = t.GetActiveTargets()
+	r.Status.RequiresActivation = t.RequiresActivation()
+	r.Status.Rollouts = t.Rollouts
+	r.Status.TargetStatuses = nil
 	r.Status.MarkTrafficAssigned()
+	r.Status.RecordTrafficHistory(c.clock.Now(), config.FromContext(ctx).Route.TrafficHistoryLimit)
+	c.drainRevisions(ctx, r, t, previous)
+	if t.NextRolloutStep > 0 {
+		logger.Infof("Advancing the traffic rollout in %v", t.NextRolloutStep)
+		c.requeueAfter(ctx, r, t.NextRolloutStep)
+	}
 
 	return t, nil
 }
 
+// drainRevisions keeps a 0% destination for each Revision that stopped
+// receiving the Route's traffic, given its previous traffic, until the drain
+// window passes, so the requests it is still serving complete.  The time each
+// of them stopped is recorded in the Route's status.
+func (c *Reconciler) drainRevisions(ctx context.Context, r *v1alpha1.Route, t *traffic.Config, previous []v1alpha1.TrafficTarget) {
+	window := config.FromContext(ctx).Route.RevisionDrainWindow
+	if window <= 0 {
+		r.Status.Draining = nil
+		return
+	}
+	routed := func(tts []v1alpha1.TrafficTarget) map[string]bool {
+		names := make(map[string]bool, len(tts))
+		for _, tt := range tts {
+			// Only Revisions next to the Route are drained.
+			if tt.Percent > 0 && !tt.Mirror && (tt.Namespace == "" || tt.Namespace == r.Namespace) {
+				names[tt.RevisionName] = true
+			}
+		}
+		return names
+	}
+	current := routed(r.Status.Traffic)
+	now := c.clock.Now()
+	var draining []v1alpha1.DrainingRevision
+	for _, d := range r.Status.Draining {
+		if !current[d.RevisionName] && now.Sub(d.Since.Time) < window {
+			draining = append(draining, d)
+			current[d.RevisionName] = true
+		}
+	}
+	for name := range routed(previous) {
+		if !current[name] {
+			draining = append(draining, v1alpha1.DrainingRevision{
+				RevisionName: name,
+				Since:        metav1.NewTime(now),
+			})
+		}
+	}
+	sort.Slice(draining, func(i, j int) bool {
+		return draining[i].RevisionName < draining[j].RevisionName
+	})
+	r.Status.Draining = draining
+
+	var next time.Duration
+	for _, d := range draining {
+		rev, err := c.revisionLister.Revisions(r.Namespace).Get(d.RevisionName)
+		if err != nil || rev.Status.IsActivationRequired() {
+			// There's nothing left to drain.
+			continue
+		}
+		t.Drain(d.RevisionName)
+		if left := window - now.Sub(d.Since.Time); next == 0 || left < next {
+			next = left
+		}
+	}
+	if next > 0 {
+		c.requeueAfter(ctx, r, next)
+	}
+}
+
+// checkRevisionReadyTimeout fails the Route when a Revision it targets by name
+// has been waiting to become ready for longer than the configured timeout, and
+// otherwise schedules another look for when the earliest of them would time out.
+func (c *Reconciler) checkRevisionReadyTimeout(ctx context.Context, r *v1alpha1.Route, t *traffic.Config) {
+	timeout := config.FromContext(ctx).Route.RevisionReadyTimeout
+	if timeout <= 0 || t == nil {
+		return
+	}
+	now := c.clock.Now()
+	var next time.Duration
+	for _, tt := range r.Spec.Traffic {
+		ns := tt.Namespace
+		if ns == r.Namespace {
+			ns = ""
+		}
+		rev, ok := t.Revisions[traffic.ObjectKey(ns, tt.RevisionName)]
+		if tt.RevisionName == "" || !ok {
+			continue
+		}
+		cond := rev.Status.GetCondition(v1alpha1.RevisionConditionReady)
+		if cond == nil || cond.Status != corev1.ConditionUnknown {
+			continue
+		}
+		waited := now.Sub(cond.LastTransitionTime.Inner.Time)
+		if waited >= timeout {
+			message := cond.Message
+			if message == "" {
+				message = cond.Reason
+			}
+			r.Status.MarkRevisionReadyTimeout(rev.Name, message)
+			return
+		}
+		if left := timeout - waited; next == 0 || left < next {
+			next = left
+		}
+	}
+	if next > 0 {
+		c.requeueAfter(ctx, r, next)
+	}
+}
+
+// requeuedKey is the context key of the flag recording that the Route being
+// reconciled was scheduled to be reconciled again.
+type requeuedKey struct{}
+
+// requeueAfter schedules the Route to be reconciled again after the given
+// delay, and flags the ongoing reconcile as requeued.
+func (c *Reconciler) requeueAfter(ctx context.Context, r *v1alpha1.Route, after time.Duration) {
+	if requeued, ok := ctx.Value(requeuedKey{}).(*bool); ok {
+		*requeued = true
+	}
+	c.enqueueAfter(r, after)
+}
+
 /////////////////////////////////////////
 // Misc helpers.
 /////////////////////////////////////////
@@ -336,7 +742,39 @@ func objectRef(a accessor, gvk schema.GroupVersionKind) corev1.ObjectReference {
 }
 
 func routeDomain(ctx context.Context, route *v1alpha1.Route) string {
-	domainConfig := config.FromContext(ctx).Domain
-	domain := domainConfig.LookupDomainForLabels(route.ObjectMeta.Labels)
+	cfg := config.FromContext(ctx)
+	domainConfig := cfg.Domain
+	var domain string
+	if cfg.DomainCache != nil {
+		domain = cfg.DomainCache.LookupDomainForLabels(route.ObjectMeta.Labels)
+	} else {
+		domain = domainConfig.LookupDomainForLabels(route.ObjectMeta.Labels)
+	}
+	// Cluster local hosts have to follow the layout of K8s Services.
+	if domainConfig.DomainTemplate != nil && !strings.HasSuffix(domain, utils.GetClusterDomainName()) {
+		host, err := domainConfig.RenderDomainTemplate(config.DomainTemplateValues{
+			Name:      route.Name,
+			Namespace: route.Namespace,
+			Domain:    domain,
+			Labels:    route.ObjectMeta.Labels,
+		})
+		if err == nil {
+			return host
+		}
+		logging.FromContext(ctx).Warnw("Failed to render the domain template, using the default layout", zap.Error(err))
+	}
 	return fmt.Sprintf("%s.%s.%s", route.Name, route.Namespace, domain)
 }
+
+// validateDomain checks that the given domain is a valid RFC 1123 hostname,
+// returning a list of the problems found.
+func validateDomain(domain string) []string {
+	errs := validation.IsDNS1123Subdomain(domain)
+	for _, label := range strings.Split(domain, ".") {
+		if len(label) > validation.DNS1123LabelMaxLength {
+			errs = append(errs, fmt.Sprintf("label %q %s", label,
+				validation.MaxLenError(validation.DNS1123LabelMaxLength)))
+		}
+	}
+	return errs
+}
@@ -19,8 +19,10 @@ package route
 import (
 	"context"
 	"fmt"
+	"sort"
 	"strings"
 	"testing"
+	"text/template"
 	"time"
 
 	"github.com/google/go-cmp/cmp"
@@ -29,6 +31,7 @@ import (
 	"github.com/knative/pkg/apis/istio/v1alpha3"
 	"github.com/knative/pkg/configmap"
 	ctrl "github.com/knative/pkg/controller"
+	"github.com/knative/pkg/logging"
 	"github.com/knative/serving/pkg/activator"
 	netv1alpha1 "github.com/knative/serving/pkg/apis/networking/v1alpha1"
 	"github.com/knative/serving/pkg/apis/serving"
@@ -37,6 +40,8 @@ import (
 	informers "github.com/knative/serving/pkg/client/informers/externalversions"
 	"github.com/knative/serving/pkg/gc"
 	rclr "github.com/knative/serving/pkg/reconciler"
+	ingressconfig "github.com/knative/serving/pkg/reconciler/v1alpha1/clusteringress/config"
+	revisionconfig "github.com/knative/serving/pkg/reconciler/v1alpha1/revision/config"
 	"github.com/knative/serving/pkg/reconciler/v1alpha1/route/config"
 	. "github.com/knative/serving/pkg/reconciler/v1alpha1/testing"
 	"github.com/knative/serving/pkg/system"
@@ -45,6 +50,7 @@ import (
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/wait"
 	kubeinformers "k8s.io/client-go/informers"
 	fakekubeclientset "k8s.io/client-go/kubernetes/fake"
 )
@@ -191,6 +197,29 @@ func newTestSetup(t *testing.T, configs ...*corev1.ConfigMap) (
 			},
 			Data: map[string]string{},
 		},
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      revisionconfig.NetworkConfigName,
+				Namespace: system.Namespace(),
+			},
+			Data: map[string]string{},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      config.RouteConfigName,
+				Namespace: system.Namespace(),
+			},
+			Data: map[string]string{},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      ingressconfig.IstioConfigName,
+				Namespace: system.Namespace(),
+			},
+			Data: map[string]string{
+				"gateway.knative-ingress-gateway": "istio-ingressgateway.istio-system.svc.cluster.local",
+			},
+		},
 	}
 	for _, cm := range configs {
 		cms = append(cms, cm)
@@ -204,7 +233,7 @@ func newTestSetup(t *testing.T, configs ...*corev1.ConfigMap) (
 	kubeInformer = kubeinformers.NewSharedInformerFactory(kubeClient, 0)
 	servingInformer = informers.NewSharedInformerFactory(servingClient, 0)
 
-	controller = NewController(
+	controller, err := NewController(
 		rclr.Options{
 			KubeClientSet:    kubeClient,
 			ServingClientSet: servingClient,
@@ -217,6 +246,9 @@ func newTestSetup(t *testing.T, configs ...*corev1.ConfigMap) (
 		kubeInformer.Core().V1().Services(),
 		servingInformer.Networking().V1alpha1().ClusterIngresses(),
 	)
+	if err != nil {
+		t.Fatalf("NewController() = %v", err)
+	}
 
 	reconciler = controller.Reconciler.(*Reconciler)
 
@@ -285,9 +317,8 @@ func TestCreateRouteForOneReserveRevision(t *testing.T) {
 	// A route targeting the revision
 	route := getTestRouteWithTrafficTargets(
 		[]v1alpha1.TrafficTarget{{
-			RevisionName:      "test-rev",
-			ConfigurationName: "test-config",
-			Percent:           100,
+			RevisionName: "test-rev",
+			Percent:      100,
 		}},
 	)
 	servingClient.ServingV1alpha1().Routes(testNamespace).Create(route)
@@ -370,6 +401,67 @@ func TestCreateRouteForOneReserveRevision(t *testing.T) {
 	}
 }
 
+// The reconcile logic can be fed a Route directly, without it being known to
+// the lister or the API server.
+func TestReconcileRouteObject(t *testing.T) {
+	_, servingClient, _, reconciler, _, servingInformer, _ := newTestSetup(t)
+	rev := getTestRevision("test-rev")
+	servingInformer.Serving().V1alpha1().Revisions().Informer().GetIndexer().Add(rev)
+	route := getTestRouteWithTrafficTargets([]v1alpha1.TrafficTarget{{
+		RevisionName: rev.Name,
+		Percent:      100,
+	}})
+
+	ctx := logging.WithLogger(context.TODO(), reconciler.Logger)
+	ctx = reconciler.configStore.ToContext(ctx)
+	if err := reconciler.reconcile(ctx, route); err != nil {
+		t.Fatalf("reconcile() = %v", err)
+	}
+
+	if !route.Status.GetCondition(v1alpha1.RouteConditionAllTrafficAssigned).IsTrue() {
+		t.Errorf("AllTrafficAssigned = %v, want True", route.Status.GetCondition(v1alpha1.RouteConditionAllTrafficAssigned))
+	}
+	want := []v1alpha1.TrafficTarget{{RevisionName: rev.Name, Percent: 100}}
+	if diff := cmp.Diff(want, route.Status.Traffic); diff != "" {
+		t.Errorf("Unexpected status traffic (-want +got): %v", diff)
+	}
+	// The Revision was pinned and the ClusterIngress created, while writing
+	// back the status is left to Reconcile.
+	var got []string
+	for _, a := range servingClient.Actions() {
+		got = append(got, a.GetVerb()+" "+a.GetResource().Resource)
+	}
+	if diff := cmp.Diff([]string{"patch revisions", "create clusteringresses"}, got); diff != "" {
+		t.Errorf("Unexpected actions (-want +got): %v", diff)
+	}
+}
+
+// A cancelled reconcile returns the context's error without writing the
+// Revision pins, ClusterIngress, Service or status.
+func TestReconcileCancelled(t *testing.T) {
+	kubeClient, servingClient, reconciler, _, servingInformer, _ := newTestReconciler(t)
+	rev := getTestRevision("test-rev")
+	servingInformer.Serving().V1alpha1().Revisions().Informer().GetIndexer().Add(rev)
+	route := getTestRouteWithTrafficTargets([]v1alpha1.TrafficTarget{{
+		RevisionName: rev.Name,
+		Percent:      100,
+	}})
+	servingInformer.Serving().V1alpha1().Routes().Informer().GetIndexer().Add(route)
+
+	ctx, cancel := context.WithCancel(logging.WithLogger(context.TODO(), reconciler.Logger))
+	cancel()
+	if err := reconciler.Reconcile(ctx, KeyOrDie(route)); err != context.Canceled {
+		t.Errorf("Reconcile() = %v, want %v", err, context.Canceled)
+	}
+
+	if got := servingClient.Actions(); len(got) != 0 {
+		t.Errorf("Unexpected serving actions: %v", got)
+	}
+	if got := kubeClient.Actions(); len(got) != 0 {
+		t.Errorf("Unexpected kube actions: %v", got)
+	}
+}
+
 func TestCreateRouteWithMultipleTargets(t *testing.T) {
 	_, servingClient, controller, _, servingInformer, _ := newTestReconciler(t)
 	// A standalone revision
@@ -448,6 +540,141 @@ func TestCreateRouteWithMultipleTargets(t *testing.T) {
 }
 
 // Test one out of multiple target revisions is in Reserve serving state.
+// A new Revision of a Configuration is rolled out gradually, and the Route
+// requeued to take the next step.
+func TestCreateRouteWithGradualRollout(t *testing.T) {
+	_, servingClient, controller, _, servingInformer, _ := newTestReconciler(t)
+	now := time.Now()
+	controller.clock = FakeClock{Time: now}
+	var requeued []time.Duration
+	controller.enqueueAfter = func(_ interface{}, after time.Duration) {
+		requeued = append(requeued, after)
+	}
+
+	config := getTestConfiguration()
+	cfgrev := getTestRevisionForConfig(config)
+	// The previous Revision of the Configuration, which is Ready.
+	oldrev := getTestRevision("p-cafebabe")
+	oldrev.Labels = map[string]string{
+		serving.ConfigurationLabelKey: config.Name,
+	}
+	config.Status.SetLatestCreatedRevisionName(cfgrev.Name)
+	config.Status.SetLatestReadyRevisionName(cfgrev.Name)
+	servingClient.ServingV1alpha1().Configurations(testNamespace).Create(config)
+	// Since Reconcile looks in the lister, we need to add it to the informer
+	servingInformer.Serving().V1alpha1().Configurations().Informer().GetIndexer().Add(config)
+	for _, rev := range []*v1alpha1.Revision{oldrev, cfgrev} {
+		servingClient.ServingV1alpha1().Revisions(testNamespace).Create(rev)
+		servingInformer.Serving().V1alpha1().Revisions().Informer().GetIndexer().Add(rev)
+	}
+
+	// A route that last sent all traffic to the old revision.
+	route := getTestRouteWithTrafficTargets(
+		[]v1alpha1.TrafficTarget{{
+			ConfigurationName: config.Name,
+			Percent:           100,
+		}},
+	)
+	route.Spec.RolloutDuration = &metav1.Duration{Duration: 9 * time.Minute}
+	route.Spec.RolloutStepPercent = 25
+	route.Status.Traffic = []v1alpha1.TrafficTarget{{
+		RevisionName: oldrev.Name,
+		Percent:      100,
+	}}
+	servingClient.ServingV1alpha1().Routes(testNamespace).Create(route)
+	// Since Reconcile looks in the lister, we need to add it to the informer
+	servingInformer.Serving().V1alpha1().Routes().Informer().GetIndexer().Add(route)
+
+	controller.Reconcile(context.TODO(), KeyOrDie(route))
+
+	ci := getRouteIngressFromClient(t, servingClient, route)
+	expectedSplits := []netv1alpha1.ClusterIngressBackendSplit{{
+		ClusterIngressBackend: netv1alpha1.ClusterIngressBackend{
+			ServiceNamespace: testNamespace,
+			ServiceName:      oldrev.Status.ServiceName,
+			ServicePort:      intstr.FromInt(80),
+		},
+		Percent: 75,
+	}, {
+		ClusterIngressBackend: netv1alpha1.ClusterIngressBackend{
+			ServiceNamespace: testNamespace,
+			ServiceName:      cfgrev.Status.ServiceName,
+			ServicePort:      intstr.FromInt(80),
+		},
+		Percent: 25,
+	}}
+	if diff := cmp.Diff(expectedSplits, ci.Spec.Rules[0].HTTP.Paths[0].Splits); diff != "" {
+		t.Errorf("Unexpected rule spec diff (-want +got): %s", diff)
+	}
+	if diff := cmp.Diff([]time.Duration{3 * time.Minute}, requeued); diff != "" {
+		t.Errorf("Unexpected requeues (-want +got): %s", diff)
+	}
+}
+
+func TestRouteTrafficHistory(t *testing.T) {
+	_, servingClient, controller, _, servingInformer, _ := newTestReconciler(t)
+	now := time.Now()
+	controller.clock = FakeClock{Time: now}
+
+	config := getTestConfiguration()
+	oldrev := getTestRevision("p-cafebabe")
+	newrev := getTestRevision("p-deadbeef")
+	config.Status.SetLatestCreatedRevisionName(oldrev.Name)
+	config.Status.SetLatestReadyRevisionName(oldrev.Name)
+	servingClient.ServingV1alpha1().Configurations(testNamespace).Create(config)
+	// Since Reconcile looks in the lister, we need to add it to the informer
+	servingInformer.Serving().V1alpha1().Configurations().Informer().GetIndexer().Add(config)
+	for _, rev := range []*v1alpha1.Revision{oldrev, newrev} {
+		servingClient.ServingV1alpha1().Revisions(testNamespace).Create(rev)
+		servingInformer.Serving().V1alpha1().Revisions().Informer().GetIndexer().Add(rev)
+	}
+
+	route := getTestRouteWithTrafficTargets(
+		[]v1alpha1.TrafficTarget{{
+			ConfigurationName: config.Name,
+			Percent:           100,
+		}},
+	)
+	servingClient.ServingV1alpha1().Routes(testNamespace).Create(route)
+	// Since Reconcile looks in the lister, we need to add it to the informer
+	servingInformer.Serving().V1alpha1().Routes().Informer().GetIndexer().Add(route)
+
+	reconcile := func() *v1alpha1.Route {
+		t.Helper()
+		controller.Reconcile(context.TODO(), KeyOrDie(route))
+		got, err := servingClient.ServingV1alpha1().Routes(testNamespace).Get(route.Name, metav1.GetOptions{})
+		if err != nil {
+			t.Fatalf("Route.Get(%v) = %v", route.Name, err)
+		}
+		servingInformer.Serving().V1alpha1().Routes().Informer().GetIndexer().Update(got)
+		return got
+	}
+	record := func(rev string) v1alpha1.TrafficRecord {
+		return v1alpha1.TrafficRecord{
+			Traffic: []v1alpha1.TrafficTarget{{ConfigurationName: config.Name, RevisionName: rev, Percent: 100}},
+			Time:    metav1.NewTime(now),
+		}
+	}
+
+	reconcile()
+	// Reconciling again without changes must not record anything new.
+	got := reconcile()
+	want := []v1alpha1.TrafficRecord{record(oldrev.Name)}
+	if diff := cmp.Diff(want, got.Status.TrafficHistory); diff != "" {
+		t.Errorf("Unexpected traffic history (-want +got): %s", diff)
+	}
+
+	config.Status.SetLatestCreatedRevisionName(newrev.Name)
+	config.Status.SetLatestReadyRevisionName(newrev.Name)
+	servingInformer.Serving().V1alpha1().Configurations().Informer().GetIndexer().Update(config)
+
+	got = reconcile()
+	want = append(want, record(newrev.Name))
+	if diff := cmp.Diff(want, got.Status.TrafficHistory); diff != "" {
+		t.Errorf("Unexpected traffic history (-want +got): %s", diff)
+	}
+}
+
 func TestCreateRouteWithOneTargetReserve(t *testing.T) {
 	_, servingClient, controller, _, servingInformer, _ := newTestReconciler(t)
 	// A standalone inactive revision
@@ -477,9 +704,8 @@ func TestCreateRouteWithOneTargetReserve(t *testing.T) {
 			ConfigurationName: config.Name,
 			Percent:           90,
 		}, {
-			RevisionName:      rev.Name,
-			ConfigurationName: "test-config",
-			Percent:           10,
+			RevisionName: rev.Name,
+			Percent:      10,
 		}},
 	)
 	servingClient.ServingV1alpha1().Routes(testNamespace).Create(route)
@@ -635,6 +861,10 @@ func TestCreateRouteWithDuplicateTargets(t *testing.T) {
 						},
 						Percent: 100,
 					}},
+					AppendHeaders: map[string]string{
+						activator.RevisionHeaderName:      "test-rev",
+						activator.RevisionHeaderNamespace: testNamespace,
+					},
 					Timeout: &metav1.Duration{Duration: netv1alpha1.DefaultTimeout},
 					Retries: &netv1alpha1.HTTPRetry{
 						PerTryTimeout: &metav1.Duration{Duration: netv1alpha1.DefaultTimeout},
@@ -654,6 +884,10 @@ func TestCreateRouteWithDuplicateTargets(t *testing.T) {
 						},
 						Percent: 100,
 					}},
+					AppendHeaders: map[string]string{
+						activator.RevisionHeaderName:      "test-rev",
+						activator.RevisionHeaderNamespace: testNamespace,
+					},
 					Timeout: &metav1.Duration{Duration: netv1alpha1.DefaultTimeout},
 					Retries: &netv1alpha1.HTTPRetry{
 						PerTryTimeout: &metav1.Duration{Duration: netv1alpha1.DefaultTimeout},
@@ -755,6 +989,10 @@ func TestCreateRouteWithNamedTargets(t *testing.T) {
 						},
 						Percent: 100,
 					}},
+					AppendHeaders: map[string]string{
+						activator.RevisionHeaderName:      cfgrev.Name,
+						activator.RevisionHeaderNamespace: testNamespace,
+					},
 					Timeout: &metav1.Duration{Duration: netv1alpha1.DefaultTimeout},
 					Retries: &netv1alpha1.HTTPRetry{
 						PerTryTimeout: &metav1.Duration{Duration: netv1alpha1.DefaultTimeout},
@@ -774,6 +1012,10 @@ func TestCreateRouteWithNamedTargets(t *testing.T) {
 						},
 						Percent: 100,
 					}},
+					AppendHeaders: map[string]string{
+						activator.RevisionHeaderName:      rev.Name,
+						activator.RevisionHeaderNamespace: testNamespace,
+					},
 					Timeout: &metav1.Duration{Duration: netv1alpha1.DefaultTimeout},
 					Retries: &netv1alpha1.HTTPRetry{
 						PerTryTimeout: &metav1.Duration{Duration: netv1alpha1.DefaultTimeout},
@@ -791,7 +1033,13 @@ func TestCreateRouteWithNamedTargets(t *testing.T) {
 
 func TestUpdateDomainConfigMap(t *testing.T) {
 	_, servingClient, controller, _, servingInformer, watcher := newTestReconciler(t)
-	route := getTestRouteWithTrafficTargets([]v1alpha1.TrafficTarget{})
+	rev := getTestRevision("test-rev")
+	servingClient.ServingV1alpha1().Revisions(testNamespace).Create(rev)
+	servingInformer.Serving().V1alpha1().Revisions().Informer().GetIndexer().Add(rev)
+	route := getTestRouteWithTrafficTargets([]v1alpha1.TrafficTarget{{
+		RevisionName: rev.Name,
+		Percent:      100,
+	}})
 	routeClient := servingClient.ServingV1alpha1().Routes(route.Namespace)
 
 	// Create a route.
@@ -964,7 +1212,13 @@ func TestGlobalResyncOnUpdateDomainConfigMap(t *testing.T) {
 			go controller.Run(1, stopCh)
 
 			// Create a route.
-			route := getTestRouteWithTrafficTargets([]v1alpha1.TrafficTarget{})
+			rev := getTestRevision("test-rev")
+			servingClient.ServingV1alpha1().Revisions(testNamespace).Create(rev)
+			servingInformer.Serving().V1alpha1().Revisions().Informer().GetIndexer().Add(rev)
+			route := getTestRouteWithTrafficTargets([]v1alpha1.TrafficTarget{{
+				RevisionName: rev.Name,
+				Percent:      100,
+			}})
 			route.Labels = map[string]string{"app": "prod"}
 
 			servingClient.ServingV1alpha1().Routes(route.Namespace).Create(route)
@@ -977,3 +1231,176 @@ func TestGlobalResyncOnUpdateDomainConfigMap(t *testing.T) {
 		})
 	}
 }
+
+func TestDomainConfigChangeEnqueuesEveryRoute(t *testing.T) {
+	_, _, controller, _, _, servingInformer, watcher := newTestSetup(t)
+
+	routes := servingInformer.Serving().V1alpha1().Routes().Informer().GetIndexer()
+	want := []string{}
+	for _, name := range []string{"first", "second", "third"} {
+		route := getTestRouteWithTrafficTargets(nil)
+		route.Name = name
+		routes.Add(route)
+		want = append(want, testNamespace+"/"+name)
+	}
+
+	// Changes to other configs don't touch the domains of Routes.
+	watcher.OnChange(&corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      gc.ConfigName,
+			Namespace: system.Namespace(),
+		},
+	})
+	watcher.OnChange(&corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      config.DomainConfigName,
+			Namespace: system.Namespace(),
+		},
+		Data: map[string]string{
+			"newprod.net": "",
+		},
+	})
+
+	got := []string{}
+	for len(got) < len(want) {
+		key, _ := controller.WorkQueue.Get()
+		got = append(got, key.(string))
+		controller.WorkQueue.Done(key)
+	}
+	// Give duplicates, which are rate limited, time to show up.
+	time.Sleep(100 * time.Millisecond)
+	if n := controller.WorkQueue.Len(); n != 0 {
+		t.Errorf("WorkQueue.Len() = %d after every Route was enqueued, want 0", n)
+	}
+	sort.Strings(got)
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Unexpected enqueued Routes (-want +got): %v", diff)
+	}
+}
+
+func TestGatewayServices(t *testing.T) {
+	_, _, _, reconciler, _, _, watcher := newTestSetup(t)
+
+	service := func(name, namespace string) *corev1.Service {
+		return &corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: namespace,
+			},
+		}
+	}
+	// The gateway Services are recorded asynchronously once config-istio is stored.
+	eventually := func(svc *corev1.Service) bool {
+		return wait.PollImmediate(10*time.Millisecond, 5*time.Second, func() (bool, error) {
+			return reconciler.isGatewayService(svc), nil
+		}) == nil
+	}
+	gateway := service("istio-ingressgateway", "istio-system")
+	localGateway := service("cluster-local-gateway", "istio-system")
+	if !eventually(gateway) {
+		t.Errorf("isGatewayService(%s) = false, want true", gateway.Name)
+	}
+	for _, svc := range []*corev1.Service{localGateway, service("istio-ingressgateway", testNamespace)} {
+		if reconciler.isGatewayService(svc) {
+			t.Errorf("isGatewayService(%s/%s) = true, want false", svc.Namespace, svc.Name)
+		}
+	}
+
+	watcher.OnChange(&corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      ingressconfig.IstioConfigName,
+			Namespace: system.Namespace(),
+		},
+		Data: map[string]string{
+			"gateway.knative-ingress-gateway":     "istio-ingressgateway.istio-system.svc.cluster.local",
+			"local-gateway.cluster-local-gateway": "cluster-local-gateway.istio-system.svc.cluster.local",
+		},
+	})
+	if !eventually(localGateway) {
+		t.Errorf("isGatewayService(%s) = false after config-istio changed, want true", localGateway.Name)
+	}
+}
+
+func TestRouteDomain(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		labels   map[string]string
+		want     string
+	}{{
+		name: "default layout",
+		want: "my-route.default.example.com",
+	}, {
+		name:     "omits the default namespace",
+		template: `{{.Name}}{{if ne .Namespace "default"}}.{{.Namespace}}{{end}}.{{.Domain}}`,
+		want:     "my-route.example.com",
+	}, {
+		name:     "uses labels",
+		template: `{{.Name}}.{{index .Labels "team"}}.{{.Domain}}`,
+		labels:   map[string]string{"team": "payments"},
+		want:     "my-route.payments.example.com",
+	}, {
+		name:     "failing template falls back to the default layout",
+		template: "{{.Host}}.{{.Domain}}",
+		want:     "my-route.default.example.com",
+	}, {
+		name:     "cluster local ignores the template",
+		template: "{{.Name}}.{{.Domain}}",
+		labels:   map[string]string{config.VisibilityLabelKey: config.VisibilityClusterLocal},
+		want:     "my-route.default.svc.cluster.local",
+	}}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cfg := ReconcilerTestConfig()
+			if test.template != "" {
+				cfg.Domain.DomainTemplate = template.Must(template.New(config.DomainTemplateKey).Option("missingkey=error").Parse(test.template))
+			}
+			ctx := config.ToContext(context.Background(), cfg)
+			r := &v1alpha1.Route{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "my-route",
+					Namespace: "default",
+					Labels:    test.labels,
+				},
+			}
+			if got := routeDomain(ctx, r); got != test.want {
+				t.Errorf("routeDomain() = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestValidateDomain(t *testing.T) {
+	cases := []struct {
+		name    string
+		domain  string
+		wantErr bool
+	}{{
+		name:   "valid",
+		domain: "my-route.default.example.com",
+	}, {
+		name:    "uppercase",
+		domain:  "My-Route.default.example.com",
+		wantErr: true,
+	}, {
+		name:    "underscore",
+		domain:  "my_route.default.example.com",
+		wantErr: true,
+	}, {
+		name:    "label too long",
+		domain:  strings.Repeat("a", 64) + ".default.example.com",
+		wantErr: true,
+	}, {
+		name:    "hostname too long",
+		domain:  strings.Repeat(strings.Repeat("a", 60)+".", 4) + "example.com",
+		wantErr: true,
+	}}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			errs := validateDomain(c.domain)
+			if got := len(errs) != 0; got != c.wantErr {
+				t.Errorf("validateDomain(%q) = %v, wantErr %v", c.domain, errs, c.wantErr)
+			}
+		})
+	}
+}
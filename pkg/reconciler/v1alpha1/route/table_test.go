@@ -17,23 +17,38 @@ limitations under the License.
 package route
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/google/go-cmp/cmp"
+	duckv1alpha1 "github.com/knative/pkg/apis/duck/v1alpha1"
 	"github.com/knative/pkg/configmap"
 	"github.com/knative/pkg/controller"
+	"github.com/knative/pkg/logging"
+	"github.com/knative/pkg/logging/logkey"
 	netv1alpha1 "github.com/knative/serving/pkg/apis/networking/v1alpha1"
+	apitesting "github.com/knative/serving/pkg/apis/testing"
+	"github.com/knative/serving/pkg/apis/serving"
 	"github.com/knative/serving/pkg/apis/serving/v1alpha1"
 	"github.com/knative/serving/pkg/gc"
 	"github.com/knative/serving/pkg/reconciler"
 	rtesting "github.com/knative/serving/pkg/reconciler/testing"
+	revisionconfig "github.com/knative/serving/pkg/reconciler/v1alpha1/revision/config"
 	"github.com/knative/serving/pkg/reconciler/v1alpha1/route/config"
 	"github.com/knative/serving/pkg/reconciler/v1alpha1/route/resources"
+	"github.com/knative/serving/pkg/reconciler/v1alpha1/route/resources/names"
 	"github.com/knative/serving/pkg/reconciler/v1alpha1/route/traffic"
 	. "github.com/knative/serving/pkg/reconciler/v1alpha1/testing"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 	corev1 "k8s.io/api/core/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	clientgotesting "k8s.io/client-go/testing"
@@ -41,8 +56,28 @@ import (
 
 var fakeCurTime = time.Unix(1e9, 0)
 
+// testRevisionPort is the port the network config of ReconcilerTestConfig
+// serves Revisions on.
+const testRevisionPort = 80
+
+var (
+	// longRouteName is the longest allowed Route name.
+	longRouteName = strings.Repeat("a", 63)
+	// longDomainSuffix is a domain that, combined with longRouteName,
+	// exceeds the 253 character limit of a hostname.
+	longDomainSuffix = strings.Repeat(strings.Repeat("a", 60)+".", 3) + "example.com"
+)
+
 // This is heavily based on the way the OpenShift Ingress controller tests its reconciliation method.
 func TestReconcile(t *testing.T) {
+	// previews spreads the traffic over all of the preview Configurations.
+	previews := v1alpha1.TrafficTarget{
+		ConfigurationSelector: &metav1.LabelSelector{
+			MatchLabels: map[string]string{"env": "preview"},
+		},
+		SplitEvenly: true,
+		Percent:     100,
+	}
 	table := TableTest{{
 		Name: "bad workqueue key",
 		// Make sure Reconcile handles bad keys.
@@ -62,7 +97,11 @@ func TestReconcile(t *testing.T) {
 			Object: route("default", "first-reconcile", WithConfigTarget("not-ready"),
 				// The first reconciliation initializes the conditions and reflects
 				// that the referenced configuration is not yet ready.
-				WithInitRouteConditions, MarkConfigurationNotReady("not-ready")),
+				WithInitRouteConditions, MarkConfigurationNotReady("not-ready"),
+				WithTargetStatuses(v1alpha1.TargetStatus{
+					Reason:  "RevisionMissing",
+					Message: `Configuration "not-ready" is waiting for a Revision to become ready.`,
+				})),
 		}},
 		Key: "default/first-reconcile",
 	}, {
@@ -75,9 +114,33 @@ func TestReconcile(t *testing.T) {
 		},
 		WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
 			Object: route("default", "first-reconcile", WithConfigTarget("permanently-failed"),
-				WithInitRouteConditions, MarkConfigurationFailed("permanently-failed")),
+				WithInitRouteConditions, MarkConfigurationFailed("permanently-failed"),
+				WithTargetStatuses(v1alpha1.TargetStatus{
+					Reason:  "RevisionMissing",
+					Message: `Configuration "permanently-failed" does not have any ready Revision.`,
+				})),
 		}},
 		Key: "default/first-reconcile",
+	}, {
+		Name: "pinned revision stuck not ready",
+		Objects: []runtime.Object{
+			route("default", "stuck", WithRevTarget(rev("default", "config", 1).Name)),
+			cfg("default", "config", WithGeneration(1), WithLatestCreated),
+			// The Revision has been deploying since long before the timeout.
+			rev("default", "config", 1, WithInitRevConditions,
+				MarkDeploying("Deploying"), WithEmptyLTTs),
+		},
+		WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
+			Object: route("default", "stuck", WithRevTarget(rev("default", "config", 1).Name),
+				WithInitRouteConditions, MarkRevisionReadyTimeout(
+					rev("default", "config", 1).Name, "Deploying"),
+				WithTargetStatuses(v1alpha1.TargetStatus{
+					RevisionName: "config-00001",
+					Reason:       "RevisionMissing",
+					Message:      `Revision "config-00001" is not yet ready.`,
+				})),
+		}},
+		Key: "default/stuck",
 	}, {
 		Name:    "failure updating route status",
 		WantErr: true,
@@ -91,13 +154,63 @@ func TestReconcile(t *testing.T) {
 		},
 		WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
 			Object: route("default", "first-reconcile", WithConfigTarget("not-ready"),
-				WithInitRouteConditions, MarkConfigurationNotReady("not-ready")),
+				WithInitRouteConditions, MarkConfigurationNotReady("not-ready"),
+				WithTargetStatuses(v1alpha1.TargetStatus{
+					Reason:  "RevisionMissing",
+					Message: `Configuration "not-ready" is waiting for a Revision to become ready.`,
+				})),
 		}},
 		WantEvents: []string{
 			Eventf(corev1.EventTypeWarning, "UpdateFailed", "Failed to update status for Route %q: %v",
 				"first-reconcile", "inducing failure for update routes"),
 		},
 		Key: "default/first-reconcile",
+	}, {
+		Name: "conflict updating route status is retried",
+		WithReactors: []clientgotesting.ReactionFunc{
+			induceStatusConflicts(1),
+		},
+		Objects: []runtime.Object{
+			route("default", "first-reconcile", WithConfigTarget("not-ready")),
+			cfg("default", "not-ready", WithGeneration(1), WithLatestCreated),
+			rev("default", "not-ready", 1, WithInitRevConditions),
+		},
+		// The status is written again after the conflict.
+		WantStatusUpdates: repeatStatusUpdate(2,
+			route("default", "first-reconcile", WithConfigTarget("not-ready"),
+				WithInitRouteConditions, MarkConfigurationNotReady("not-ready"),
+				WithTargetStatuses(v1alpha1.TargetStatus{
+					Reason:  "RevisionMissing",
+					Message: `Configuration "not-ready" is waiting for a Revision to become ready.`,
+				}))),
+		// The Route is read again after the conflict.
+		WantGets: repeatGet(1, "default", "first-reconcile"),
+		Key:      "default/first-reconcile",
+	}, {
+		Name:    "conflicts updating route status give up",
+		WantErr: true,
+		WithReactors: []clientgotesting.ReactionFunc{
+			induceStatusConflicts(5),
+		},
+		Objects: []runtime.Object{
+			route("default", "first-reconcile", WithConfigTarget("not-ready")),
+			cfg("default", "not-ready", WithGeneration(1), WithLatestCreated),
+			rev("default", "not-ready", 1, WithInitRevConditions),
+		},
+		// Every attempt allowed by the retry conflicts.
+		WantStatusUpdates: repeatStatusUpdate(5,
+			route("default", "first-reconcile", WithConfigTarget("not-ready"),
+				WithInitRouteConditions, MarkConfigurationNotReady("not-ready"),
+				WithTargetStatuses(v1alpha1.TargetStatus{
+					Reason:  "RevisionMissing",
+					Message: `Configuration "not-ready" is waiting for a Revision to become ready.`,
+				}))),
+		WantEvents: []string{
+			Eventf(corev1.EventTypeWarning, "UpdateFailed", "Failed to update status for Route %q: %v",
+				"first-reconcile", `Operation cannot be fulfilled on routes.serving.knative.dev "first-reconcile": modified concurrently`),
+		},
+		WantGets: repeatGet(4, "default", "first-reconcile"),
+		Key:      "default/first-reconcile",
 	}, {
 		Name: "simple route becomes ready, ingress unknown",
 		Objects: []runtime.Object{
@@ -113,6 +226,7 @@ func TestReconcile(t *testing.T) {
 					Targets: map[string][]traffic.RevisionTarget{
 						"": {{
 							TrafficTarget: v1alpha1.TrafficTarget{
+								ConfigurationName: "config",
 								// Use the Revision name from the config.
 								RevisionName: rev("default", "config", 1).Name,
 								Percent:      100,
@@ -121,6 +235,7 @@ func TestReconcile(t *testing.T) {
 						}},
 					},
 				},
+				testRevisionPort,
 			),
 		},
 		WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
@@ -128,9 +243,108 @@ func TestReconcile(t *testing.T) {
 				// Populated by reconciliation when all traffic has been assigned.
 				WithDomain, WithDomainInternal, WithAddress, WithInitRouteConditions,
 				MarkTrafficAssigned, WithStatusTraffic(v1alpha1.TrafficTarget{
-					RevisionName: "config-00001",
-					Percent:      100,
-				})),
+					ConfigurationName: "config",
+					RevisionName:      "config-00001",
+					Percent:           100,
+				}),
+				WithStatusActiveTargets(
+					v1alpha1.ActiveTarget{RevisionName: "config-00001", Percent: 100, Active: true})),
+		}},
+		WantEvents: []string{
+			Eventf(corev1.EventTypeNormal, "Created", "Created ClusterIngress %q", ""),
+		},
+		Key: "default/becomes-ready",
+		// TODO(lichuqiang): config namespace validation in resource scope.
+		SkipNamespaceValidation: true,
+	}, {
+		Name: "route reports the image digest of its ready revision",
+		Objects: []runtime.Object{
+			route("default", "audited", WithConfigTarget("config")),
+			cfg("default", "config",
+				WithGeneration(1), WithLatestCreated, WithLatestReady),
+			rev("default", "config", 1, MarkRevisionReady,
+				WithImageDigest("gcr.io/example/app@sha256:deadbeef")),
+		},
+		WantCreates: []metav1.Object{
+			resources.MakeClusterIngress(
+				route("default", "audited", WithConfigTarget("config"), WithDomain),
+				&traffic.Config{
+					Targets: map[string][]traffic.RevisionTarget{
+						"": {{
+							TrafficTarget: v1alpha1.TrafficTarget{
+								ConfigurationName: "config",
+								RevisionName:      rev("default", "config", 1).Name,
+								Percent:           100,
+							},
+							Active: true,
+						}},
+					},
+				},
+				testRevisionPort,
+			),
+		},
+		WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
+			Object: route("default", "audited", WithConfigTarget("config"),
+				WithDomain, WithDomainInternal, WithAddress, WithInitRouteConditions,
+				MarkTrafficAssigned, WithStatusTraffic(v1alpha1.TrafficTarget{
+					ConfigurationName: "config",
+					RevisionName:      "config-00001",
+					Percent:           100,
+					ImageDigest:       "gcr.io/example/app@sha256:deadbeef",
+				}),
+				WithStatusActiveTargets(
+					v1alpha1.ActiveTarget{RevisionName: "config-00001", Percent: 100, Active: true})),
+		}},
+		WantEvents: []string{
+			Eventf(corev1.EventTypeNormal, "Created", "Created ClusterIngress %q", ""),
+		},
+		Key:                     "default/audited",
+		SkipNamespaceValidation: true,
+	}, {
+		Name: "labelled route propagates telemetry metadata, ingress unknown",
+		Objects: []runtime.Object{
+			route("default", "becomes-ready", WithConfigTarget("config"),
+				WithRouteLabel("telemetry.knative.dev/team", "payments"),
+				WithRouteLabel("serving.knative.dev/service", "becomes-ready")),
+			cfg("default", "config",
+				WithGeneration(1), WithLatestCreated, WithLatestReady),
+			rev("default", "config", 1, MarkRevisionReady),
+		},
+		WantCreates: []metav1.Object{
+			// Only the telemetry label is copied, our own come from the builder.
+			withIngressLabel(resources.MakeClusterIngress(
+				route("default", "becomes-ready", WithConfigTarget("config"), WithDomain,
+					WithRouteLabel("telemetry.knative.dev/team", "payments"),
+					WithRouteLabel("serving.knative.dev/service", "becomes-ready")),
+				&traffic.Config{
+					Targets: map[string][]traffic.RevisionTarget{
+						"": {{
+							TrafficTarget: v1alpha1.TrafficTarget{
+								ConfigurationName: "config",
+								// Use the Revision name from the config.
+								RevisionName: rev("default", "config", 1).Name,
+								Percent:      100,
+							},
+							Active: true,
+						}},
+					},
+				},
+				testRevisionPort,
+			), "telemetry.knative.dev/team", "payments"),
+		},
+		WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
+			Object: route("default", "becomes-ready", WithConfigTarget("config"),
+				WithRouteLabel("telemetry.knative.dev/team", "payments"),
+				WithRouteLabel("serving.knative.dev/service", "becomes-ready"),
+				// Populated by reconciliation when all traffic has been assigned.
+				WithDomain, WithDomainInternal, WithAddress, WithInitRouteConditions,
+				MarkTrafficAssigned, WithStatusTraffic(v1alpha1.TrafficTarget{
+					ConfigurationName: "config",
+					RevisionName:      "config-00001",
+					Percent:           100,
+				}),
+				WithStatusActiveTargets(
+					v1alpha1.ActiveTarget{RevisionName: "config-00001", Percent: 100, Active: true})),
 		}},
 		WantEvents: []string{
 			Eventf(corev1.EventTypeNormal, "Created", "Created ClusterIngress %q", ""),
@@ -155,6 +369,7 @@ func TestReconcile(t *testing.T) {
 					Targets: map[string][]traffic.RevisionTarget{
 						"": {{
 							TrafficTarget: v1alpha1.TrafficTarget{
+								ConfigurationName: "config",
 								// Use the Revision name from the config.
 								RevisionName: rev("default", "config", 1).Name,
 								Percent:      100,
@@ -163,6 +378,7 @@ func TestReconcile(t *testing.T) {
 						}},
 					},
 				},
+				testRevisionPort,
 			),
 		},
 		WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
@@ -171,9 +387,12 @@ func TestReconcile(t *testing.T) {
 				WithLocalDomain, WithDomainInternal, WithAddress, WithInitRouteConditions,
 				WithRouteLabel("serving.knative.dev/visibility", "cluster-local"),
 				MarkTrafficAssigned, WithStatusTraffic(v1alpha1.TrafficTarget{
-					RevisionName: "config-00001",
-					Percent:      100,
-				})),
+					ConfigurationName: "config",
+					RevisionName:      "config-00001",
+					Percent:           100,
+				}),
+				WithStatusActiveTargets(
+					v1alpha1.ActiveTarget{RevisionName: "config-00001", Percent: 100, Active: true})),
 		}},
 		WantEvents: []string{
 			Eventf(corev1.EventTypeNormal, "Created", "Created ClusterIngress %q", ""),
@@ -194,6 +413,7 @@ func TestReconcile(t *testing.T) {
 					Targets: map[string][]traffic.RevisionTarget{
 						"": {{
 							TrafficTarget: v1alpha1.TrafficTarget{
+								ConfigurationName: "config",
 								// Use the Revision name from the config.
 								RevisionName: rev("default", "config", 1).Name,
 								Percent:      100,
@@ -213,14 +433,244 @@ func TestReconcile(t *testing.T) {
 				WithDomain, WithDomainInternal, WithAddress, WithInitRouteConditions,
 				MarkTrafficAssigned, MarkIngressReady, WithStatusTraffic(
 					v1alpha1.TrafficTarget{
-						RevisionName: "config-00001",
-						Percent:      100,
-					})),
+						ConfigurationName: "config",
+						RevisionName:      "config-00001",
+						Percent:           100,
+					}),
+				WithStatusActiveTargets(
+					v1alpha1.ActiveTarget{RevisionName: "config-00001", Percent: 100, Active: true})),
 		}},
 		WantEvents: []string{
 			Eventf(corev1.EventTypeNormal, "Created", "Created service %q", "becomes-ready"),
 		},
 		Key: "default/becomes-ready",
+	}, {
+		Name: "route advertises the readiness path of its revision",
+		Objects: []runtime.Object{
+			route("default", "probed", WithConfigTarget("config")),
+			cfg("default", "config",
+				WithGeneration(1), WithLatestCreated, WithLatestReady),
+			rev("default", "config", 1, MarkRevisionReady, WithReadinessProbePath("/healthz")),
+			simpleReadyIngress(
+				route("default", "probed", WithConfigTarget("config"), WithDomain),
+				&traffic.Config{
+					Targets: map[string][]traffic.RevisionTarget{
+						"": {{
+							TrafficTarget: v1alpha1.TrafficTarget{
+								ConfigurationName: "config",
+								RevisionName:      "config-00001",
+								Percent:           100,
+							},
+							Active: true,
+						}},
+					},
+				},
+			),
+		},
+		WantCreates: []metav1.Object{
+			simpleK8sService(route("default", "probed", WithConfigTarget("config")),
+				WithReadinessPathAnnotation("/healthz")),
+		},
+		WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
+			Object: route("default", "probed", WithConfigTarget("config"),
+				WithDomain, WithDomainInternal, WithAddress, WithInitRouteConditions,
+				MarkTrafficAssigned, MarkIngressReady, WithStatusTraffic(
+					v1alpha1.TrafficTarget{
+						ConfigurationName: "config",
+						RevisionName:      "config-00001",
+						Percent:           100,
+					}),
+				WithStatusActiveTargets(
+					v1alpha1.ActiveTarget{RevisionName: "config-00001", Percent: 100, Active: true})),
+		}},
+		WantEvents: []string{
+			Eventf(corev1.EventTypeNormal, "Created", "Created service %q", "probed"),
+		},
+		Key: "default/probed",
+	}, {
+		Name: "mesh only route becomes ready with headless service",
+		Objects: []runtime.Object{
+			route("default", "headless", WithConfigTarget("config"),
+				WithRouteAnnotation(serving.ServiceTypeAnnotationKey, serving.ServiceTypeHeadless)),
+			cfg("default", "config",
+				WithGeneration(1), WithLatestCreated, WithLatestReady),
+			rev("default", "config", 1, MarkRevisionReady),
+			ingressWithStatus(
+				route("default", "headless", WithConfigTarget("config"), WithDomain,
+					WithRouteAnnotation(serving.ServiceTypeAnnotationKey, serving.ServiceTypeHeadless)),
+				&traffic.Config{
+					Targets: map[string][]traffic.RevisionTarget{
+						"": {{
+							TrafficTarget: v1alpha1.TrafficTarget{
+								ConfigurationName: "config",
+								// Use the Revision name from the config.
+								RevisionName: rev("default", "config", 1).Name,
+								Percent:      100,
+							},
+							Active: true,
+						}},
+					},
+				},
+				meshOnlyIngressStatus(),
+			),
+		},
+		WantCreates: []metav1.Object{
+			meshOnlyK8sService(route("default", "headless", WithConfigTarget("config"),
+				WithRouteAnnotation(serving.ServiceTypeAnnotationKey, serving.ServiceTypeHeadless))),
+		},
+		WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
+			Object: route("default", "headless", WithConfigTarget("config"),
+				WithRouteAnnotation(serving.ServiceTypeAnnotationKey, serving.ServiceTypeHeadless),
+				// Populated by reconciliation when the route becomes ready.
+				WithDomain, WithDomainInternal, WithAddress, WithInitRouteConditions,
+				MarkTrafficAssigned, MarkIngressReady, WithStatusTraffic(
+					v1alpha1.TrafficTarget{
+						ConfigurationName: "config",
+						RevisionName:      "config-00001",
+						Percent:           100,
+					}),
+				WithStatusActiveTargets(
+					v1alpha1.ActiveTarget{RevisionName: "config-00001", Percent: 100, Active: true})),
+		}},
+		WantEvents: []string{
+			Eventf(corev1.EventTypeNormal, "Created", "Created service %q", "headless"),
+		},
+		Key: "default/headless",
+	}, {
+		Name: "mesh only route with h2c revision",
+		Objects: []runtime.Object{
+			route("default", "headless", WithConfigTarget("config"),
+				WithRouteAnnotation(serving.ServiceTypeAnnotationKey, serving.ServiceTypeHeadless)),
+			cfg("default", "config",
+				WithGeneration(1), WithLatestCreated, WithLatestReady),
+			rev("default", "config", 1, MarkRevisionReady, WithRevContainerPortName("h2c")),
+			ingressWithStatus(
+				route("default", "headless", WithConfigTarget("config"), WithDomain,
+					WithRouteAnnotation(serving.ServiceTypeAnnotationKey, serving.ServiceTypeHeadless)),
+				&traffic.Config{
+					Targets: map[string][]traffic.RevisionTarget{
+						"": {{
+							TrafficTarget: v1alpha1.TrafficTarget{
+								ConfigurationName: "config",
+								RevisionName:      rev("default", "config", 1).Name,
+								Percent:           100,
+							},
+							Active: true,
+						}},
+					},
+				},
+				meshOnlyIngressStatus(),
+			),
+		},
+		WantCreates: []metav1.Object{
+			// The placeholder port is named so the mesh treats the traffic as HTTP/2.
+			meshOnlyK8sService(route("default", "headless", WithConfigTarget("config"),
+				WithRouteAnnotation(serving.ServiceTypeAnnotationKey, serving.ServiceTypeHeadless)),
+				WithServicePortName("http2")),
+		},
+		WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
+			Object: route("default", "headless", WithConfigTarget("config"),
+				WithRouteAnnotation(serving.ServiceTypeAnnotationKey, serving.ServiceTypeHeadless),
+				WithDomain, WithDomainInternal, WithAddress, WithInitRouteConditions,
+				MarkTrafficAssigned, MarkIngressReady, WithStatusTraffic(
+					v1alpha1.TrafficTarget{
+						ConfigurationName: "config",
+						RevisionName:      "config-00001",
+						Percent:           100,
+					}),
+				WithStatusActiveTargets(
+					v1alpha1.ActiveTarget{RevisionName: "config-00001", Percent: 100, Active: true})),
+		}},
+		WantEvents: []string{
+			Eventf(corev1.EventTypeNormal, "Created", "Created service %q", "headless"),
+		},
+		Key: "default/headless",
+	}, {
+		// The cluster IP of a mesh only placeholder service can't change, so
+		// make sure we leave an externally set headless value alone.
+		Name: "steady state with externally set headless service",
+		Objects: []runtime.Object{
+			route("default", "headless", WithConfigTarget("config"),
+				WithDomain, WithDomainInternal, WithAddress, WithInitRouteConditions,
+				MarkTrafficAssigned, MarkIngressReady, WithStatusTraffic(
+					v1alpha1.TrafficTarget{
+						ConfigurationName: "config",
+						RevisionName:      "config-00001",
+						Percent:           100,
+					}),
+				WithStatusActiveTargets(
+					v1alpha1.ActiveTarget{RevisionName: "config-00001", Percent: 100, Active: true})),
+			cfg("default", "config",
+				WithGeneration(1), WithLatestCreated, WithLatestReady,
+				// The Route controller attaches our label to this Configuration.
+				WithConfigLabel("serving.knative.dev/route", "headless"),
+			),
+			rev("default", "config", 1, MarkRevisionReady),
+			ingressWithStatus(
+				route("default", "headless", WithConfigTarget("config"), WithDomain),
+				&traffic.Config{
+					Targets: map[string][]traffic.RevisionTarget{
+						"": {{
+							TrafficTarget: v1alpha1.TrafficTarget{
+								ConfigurationName: "config",
+								// Use the Revision name from the config.
+								RevisionName: rev("default", "config", 1).Name,
+								Percent:      100,
+							},
+							Active: true,
+						}},
+					},
+				},
+				meshOnlyIngressStatus(),
+			),
+			meshOnlyK8sService(route("default", "headless", WithConfigTarget("config")),
+				WithClusterIP(corev1.ClusterIPNone)),
+		},
+		Key: "default/headless",
+	}, {
+		// make sure we leave the cluster IP alone once the Route asks for headless.
+		Name: "steady state keeps the cluster IP of a service turned headless",
+		Objects: []runtime.Object{
+			route("default", "turned-headless", WithConfigTarget("config"),
+				WithRouteAnnotation(serving.ServiceTypeAnnotationKey, serving.ServiceTypeHeadless),
+				WithDomain, WithDomainInternal, WithAddress, WithInitRouteConditions,
+				MarkTrafficAssigned, MarkIngressReady, WithStatusTraffic(
+					v1alpha1.TrafficTarget{
+						ConfigurationName: "config",
+						RevisionName:      "config-00001",
+						Percent:           100,
+					}),
+				WithStatusActiveTargets(
+					v1alpha1.ActiveTarget{RevisionName: "config-00001", Percent: 100, Active: true})),
+			cfg("default", "config",
+				WithGeneration(1), WithLatestCreated, WithLatestReady,
+				// The Route controller attaches our label to this Configuration.
+				WithConfigLabel("serving.knative.dev/route", "turned-headless"),
+			),
+			rev("default", "config", 1, MarkRevisionReady),
+			ingressWithStatus(
+				route("default", "turned-headless", WithConfigTarget("config"),
+					WithRouteAnnotation(serving.ServiceTypeAnnotationKey, serving.ServiceTypeHeadless), WithDomain),
+				&traffic.Config{
+					Targets: map[string][]traffic.RevisionTarget{
+						"": {{
+							TrafficTarget: v1alpha1.TrafficTarget{
+								ConfigurationName: "config",
+								// Use the Revision name from the config.
+								RevisionName: rev("default", "config", 1).Name,
+								Percent:      100,
+							},
+							Active: true,
+						}},
+					},
+				},
+				meshOnlyIngressStatus(),
+			),
+			meshOnlyK8sService(route("default", "turned-headless", WithConfigTarget("config"),
+				WithRouteAnnotation(serving.ServiceTypeAnnotationKey, serving.ServiceTypeHeadless)),
+				WithClusterIP("10.0.0.1")),
+		},
+		Key: "default/turned-headless",
 	}, {
 		Name: "failure creating k8s placeholder service",
 		// We induce a failure creating the placeholder service
@@ -239,6 +689,7 @@ func TestReconcile(t *testing.T) {
 					Targets: map[string][]traffic.RevisionTarget{
 						"": {{
 							TrafficTarget: v1alpha1.TrafficTarget{
+								ConfigurationName: "config",
 								// Use the Revision name from the config.
 								RevisionName: rev("default", "config", 1).Name,
 								Percent:      100,
@@ -259,9 +710,13 @@ func TestReconcile(t *testing.T) {
 				WithDomain, WithDomainInternal, WithAddress, WithInitRouteConditions,
 				MarkTrafficAssigned, MarkIngressReady, WithStatusTraffic(
 					v1alpha1.TrafficTarget{
-						RevisionName: "config-00001",
-						Percent:      100,
-					})),
+						ConfigurationName: "config",
+						RevisionName:      "config-00001",
+						Percent:           100,
+					}),
+				WithStatusActiveTargets(
+					v1alpha1.ActiveTarget{RevisionName: "config-00001", Percent: 100, Active: true}),
+				MarkServiceNotReady("inducing failure for create services")),
 		}},
 		WantEvents: []string{
 			Eventf(corev1.EventTypeWarning, "CreationFailed", "Failed to create service %q: %v",
@@ -290,6 +745,7 @@ func TestReconcile(t *testing.T) {
 					Targets: map[string][]traffic.RevisionTarget{
 						"": {{
 							TrafficTarget: v1alpha1.TrafficTarget{
+								ConfigurationName: "config",
 								// Use the Revision name from the config.
 								RevisionName: rev("default", "config", 1).Name,
 								Percent:      100,
@@ -298,6 +754,7 @@ func TestReconcile(t *testing.T) {
 						}},
 					},
 				},
+				testRevisionPort,
 			),
 		},
 		WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
@@ -306,9 +763,13 @@ func TestReconcile(t *testing.T) {
 				// the cluster ingress.
 				WithDomain, WithDomainInternal, WithAddress, WithInitRouteConditions,
 				MarkTrafficAssigned, WithStatusTraffic(v1alpha1.TrafficTarget{
-					RevisionName: "config-00001",
-					Percent:      100,
-				})),
+					ConfigurationName: "config",
+					RevisionName:      "config-00001",
+					Percent:           100,
+				}),
+				WithStatusActiveTargets(
+					v1alpha1.ActiveTarget{RevisionName: "config-00001", Percent: 100, Active: true}),
+				MarkIngressNotReady("inducing failure for create clusteringresses")),
 		}},
 		WantEvents: []string{
 			Eventf(corev1.EventTypeWarning, "CreationFailed", "Failed to create ClusterIngress for route %s/%s: %v",
@@ -323,9 +784,12 @@ func TestReconcile(t *testing.T) {
 				WithDomain, WithDomainInternal, WithAddress, WithInitRouteConditions,
 				MarkTrafficAssigned, MarkIngressReady, WithStatusTraffic(
 					v1alpha1.TrafficTarget{
-						RevisionName: "config-00001",
-						Percent:      100,
-					})),
+						ConfigurationName: "config",
+						RevisionName:      "config-00001",
+						Percent:           100,
+					}),
+				WithStatusActiveTargets(
+					v1alpha1.ActiveTarget{RevisionName: "config-00001", Percent: 100, Active: true})),
 			cfg("default", "config",
 				WithGeneration(1), WithLatestCreated, WithLatestReady,
 				// The Route controller attaches our label to this Configuration.
@@ -338,6 +802,7 @@ func TestReconcile(t *testing.T) {
 					Targets: map[string][]traffic.RevisionTarget{
 						"": {{
 							TrafficTarget: v1alpha1.TrafficTarget{
+								ConfigurationName: "config",
 								// Use the Revision name from the config.
 								RevisionName: rev("default", "config", 1).Name,
 								Percent:      100,
@@ -351,281 +816,309 @@ func TestReconcile(t *testing.T) {
 		},
 		Key: "default/steady-state",
 	}, {
-		Name:    "unhappy about ownership of placeholder service",
-		WantErr: true,
+		Name: "child names are reported",
 		Objects: []runtime.Object{
-			route("default", "unhappy-owner", WithConfigTarget("config"),
+			route("default", "child-names", WithConfigTarget("config"),
 				WithDomain, WithDomainInternal, WithAddress, WithInitRouteConditions,
 				MarkTrafficAssigned, MarkIngressReady, WithStatusTraffic(
 					v1alpha1.TrafficTarget{
-						RevisionName: "config-00001",
-						Percent:      100,
-					})),
+						ConfigurationName: "config",
+						RevisionName:      "config-00001",
+						Percent:           100,
+					}),
+				WithStatusActiveTargets(
+					v1alpha1.ActiveTarget{RevisionName: "config-00001", Percent: 100, Active: true})),
 			cfg("default", "config",
 				WithGeneration(1), WithLatestCreated, WithLatestReady,
-				// The Route controller attaches our label to this Configuration.
-				WithConfigLabel("serving.knative.dev/route", "unhappy-owner"),
+				WithConfigLabel("serving.knative.dev/route", "child-names"),
 			),
 			rev("default", "config", 1, MarkRevisionReady),
-			simpleReadyIngress(
-				route("default", "unhappy-owner", WithConfigTarget("config"), WithDomain),
+			withIngressName(simpleReadyIngress(
+				route("default", "child-names", WithConfigTarget("config"), WithDomain),
 				&traffic.Config{
 					Targets: map[string][]traffic.RevisionTarget{
 						"": {{
 							TrafficTarget: v1alpha1.TrafficTarget{
-								// Use the Revision name from the config.
-								RevisionName: rev("default", "config", 1).Name,
-								Percent:      100,
+								ConfigurationName: "config",
+								RevisionName:      rev("default", "config", 1).Name,
+								Percent:           100,
 							},
 							Active: true,
 						}},
 					},
 				},
-			),
-			simpleK8sService(route("default", "unhappy-owner", WithConfigTarget("config")),
-				WithK8sSvcOwnersRemoved),
+			), "child-names-x7k2p"),
+			simpleK8sService(route("default", "child-names", WithConfigTarget("config"))),
 		},
 		WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
-			Object: route("default", "unhappy-owner", WithConfigTarget("config"),
+			Object: route("default", "child-names", WithConfigTarget("config"),
 				WithDomain, WithDomainInternal, WithAddress, WithInitRouteConditions,
 				MarkTrafficAssigned, MarkIngressReady, WithStatusTraffic(
 					v1alpha1.TrafficTarget{
-						RevisionName: "config-00001",
-						Percent:      100,
+						ConfigurationName: "config",
+						RevisionName:      "config-00001",
+						Percent:           100,
 					}),
-				// The owner is not us, so we are unhappy.
-				MarkServiceNotOwned),
+				WithStatusActiveTargets(
+					v1alpha1.ActiveTarget{RevisionName: "config-00001", Percent: 100, Active: true}),
+				// The placeholder Service is reported by WithAddress.
+				WithVirtualServiceName("child-names-x7k2p")),
 		}},
-		Key: "default/unhappy-owner",
+		Key: "default/child-names",
 	}, {
-		// This tests that when the Route is labelled differently, it is configured with a
-		// different domain from config-domain.yaml.  This is otherwise a copy of the steady
-		// state test above.
-		Name: "different labels, different domain - steady state",
+		Name: "ingress address of the gateway load balancer is reported",
 		Objects: []runtime.Object{
-			route("default", "different-domain", WithConfigTarget("config"),
-				WithAnotherDomain, WithDomainInternal, WithAddress,
-				WithInitRouteConditions, MarkTrafficAssigned, MarkIngressReady,
-				WithStatusTraffic(v1alpha1.TrafficTarget{
-					RevisionName: "config-00001",
-					Percent:      100,
-				}), WithRouteLabel("app", "prod")),
+			route("default", "gateway-address", WithConfigTarget("config"),
+				WithDomain, WithDomainInternal, WithAddress, WithInitRouteConditions,
+				MarkTrafficAssigned, MarkIngressReady, WithStatusTraffic(
+					v1alpha1.TrafficTarget{
+						ConfigurationName: "config",
+						RevisionName:      "config-00001",
+						Percent:           100,
+					}),
+				WithStatusActiveTargets(
+					v1alpha1.ActiveTarget{RevisionName: "config-00001", Percent: 100, Active: true})),
 			cfg("default", "config",
 				WithGeneration(1), WithLatestCreated, WithLatestReady,
-				// The Route controller attaches our label to this Configuration.
-				WithConfigLabel("serving.knative.dev/route", "different-domain"),
+				WithConfigLabel("serving.knative.dev/route", "gateway-address"),
 			),
 			rev("default", "config", 1, MarkRevisionReady),
 			simpleReadyIngress(
-				route("default", "different-domain", WithConfigTarget("config"),
-					WithAnotherDomain),
+				route("default", "gateway-address", WithConfigTarget("config"), WithDomain),
 				&traffic.Config{
 					Targets: map[string][]traffic.RevisionTarget{
 						"": {{
 							TrafficTarget: v1alpha1.TrafficTarget{
-								// Use the Revision name from the config.
-								RevisionName: rev("default", "config", 1).Name,
-								Percent:      100,
+								ConfigurationName: "config",
+								RevisionName:      rev("default", "config", 1).Name,
+								Percent:           100,
 							},
 							Active: true,
 						}},
 					},
 				},
 			),
-			simpleK8sService(route("default", "different-domain", WithConfigTarget("config"))),
+			simpleK8sService(route("default", "gateway-address", WithConfigTarget("config"))),
+			gatewayService(corev1.LoadBalancerIngress{IP: "35.1.2.3"}),
 		},
-		Key: "default/different-domain",
+		WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
+			Object: route("default", "gateway-address", WithConfigTarget("config"),
+				WithDomain, WithDomainInternal, WithAddress, WithInitRouteConditions,
+				MarkTrafficAssigned, MarkIngressReady, WithStatusTraffic(
+					v1alpha1.TrafficTarget{
+						ConfigurationName: "config",
+						RevisionName:      "config-00001",
+						Percent:           100,
+					}),
+				WithStatusActiveTargets(
+					v1alpha1.ActiveTarget{RevisionName: "config-00001", Percent: 100, Active: true}),
+				WithIngressAddress("35.1.2.3")),
+		}},
+		Key: "default/gateway-address",
 	}, {
-		Name: "new latest created revision",
+		Name: "https ingress address for a custom domain with TLS",
 		Objects: []runtime.Object{
-			route("default", "new-latest-created", WithConfigTarget("config"),
+			route("default", "gateway-tls", WithConfigTarget("config"),
+				WithCustomDomain("www.example.com", "example-cert"),
 				WithDomain, WithDomainInternal, WithAddress, WithInitRouteConditions,
 				MarkTrafficAssigned, MarkIngressReady, WithStatusTraffic(
 					v1alpha1.TrafficTarget{
-						RevisionName: "config-00001",
-						Percent:      100,
-					})),
+						ConfigurationName: "config",
+						RevisionName:      "config-00001",
+						Percent:           100,
+					}),
+				WithStatusActiveTargets(
+					v1alpha1.ActiveTarget{RevisionName: "config-00001", Percent: 100, Active: true})),
 			cfg("default", "config",
 				WithGeneration(1), WithLatestCreated, WithLatestReady,
-				WithGeneration(2), WithLatestCreated,
-				WithConfigLabel("serving.knative.dev/route", "new-latest-created"),
+				WithConfigLabel("serving.knative.dev/route", "gateway-tls"),
 			),
 			rev("default", "config", 1, MarkRevisionReady),
-			// This is the name of the new revision we're referencing above.
-			rev("default", "config", 2, WithInitRevConditions),
 			simpleReadyIngress(
-				route("default", "new-latest-created", WithConfigTarget("config"), WithDomain),
+				route("default", "gateway-tls", WithConfigTarget("config"),
+					WithCustomDomain("www.example.com", "example-cert"), WithDomain),
 				&traffic.Config{
 					Targets: map[string][]traffic.RevisionTarget{
 						"": {{
 							TrafficTarget: v1alpha1.TrafficTarget{
-								// Use the Revision name from the config.
-								RevisionName: rev("default", "config", 1).Name,
-								Percent:      100,
+								ConfigurationName: "config",
+								RevisionName:      rev("default", "config", 1).Name,
+								Percent:           100,
 							},
 							Active: true,
 						}},
 					},
 				},
 			),
-			simpleK8sService(route("default", "new-latest-created", WithConfigTarget("config"))),
+			simpleK8sService(route("default", "gateway-tls", WithConfigTarget("config"),
+				WithCustomDomain("www.example.com", "example-cert"))),
+			gatewayService(corev1.LoadBalancerIngress{IP: "35.1.2.3"}),
 		},
-		// A new LatestCreatedRevisionName on the Configuration alone should result in no changes to the Route.
-		Key: "default/new-latest-created",
+		WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
+			Object: route("default", "gateway-tls", WithConfigTarget("config"),
+				WithCustomDomain("www.example.com", "example-cert"),
+				WithDomain, WithDomainInternal, WithAddress, WithInitRouteConditions,
+				MarkTrafficAssigned, MarkIngressReady, WithStatusTraffic(
+					v1alpha1.TrafficTarget{
+						ConfigurationName: "config",
+						RevisionName:      "config-00001",
+						Percent:           100,
+					}),
+				WithStatusActiveTargets(
+					v1alpha1.ActiveTarget{RevisionName: "config-00001", Percent: 100, Active: true}),
+				WithSecureIngressAddress("35.1.2.3")),
+		}},
+		Key: "default/gateway-tls",
 	}, {
-		Name: "new latest ready revision",
+		Name: "no ingress address while the gateway load balancer is pending",
 		Objects: []runtime.Object{
-			route("default", "new-latest-ready", WithConfigTarget("config"),
+			route("default", "gateway-pending", WithConfigTarget("config"),
 				WithDomain, WithDomainInternal, WithAddress, WithInitRouteConditions,
 				MarkTrafficAssigned, MarkIngressReady, WithStatusTraffic(
 					v1alpha1.TrafficTarget{
-						RevisionName: "config-00001",
-						Percent:      100,
-					})),
+						ConfigurationName: "config",
+						RevisionName:      "config-00001",
+						Percent:           100,
+					}),
+				WithStatusActiveTargets(
+					v1alpha1.ActiveTarget{RevisionName: "config-00001", Percent: 100, Active: true})),
 			cfg("default", "config",
-				WithGeneration(2), WithLatestCreated, WithLatestReady,
-				// The Route controller attaches our label to this Configuration.
-				WithConfigLabel("serving.knative.dev/route", "new-latest-ready"),
+				WithGeneration(1), WithLatestCreated, WithLatestReady,
+				WithConfigLabel("serving.knative.dev/route", "gateway-pending"),
 			),
 			rev("default", "config", 1, MarkRevisionReady),
-			// This is the name of the new revision we're referencing above.
-			rev("default", "config", 2, MarkRevisionReady),
 			simpleReadyIngress(
-				route("default", "new-latest-ready", WithConfigTarget("config"), WithDomain),
+				route("default", "gateway-pending", WithConfigTarget("config"), WithDomain),
 				&traffic.Config{
 					Targets: map[string][]traffic.RevisionTarget{
 						"": {{
 							TrafficTarget: v1alpha1.TrafficTarget{
-								// Use the Revision name from the config.
-								RevisionName: rev("default", "config", 1).Name,
-								Percent:      100,
+								ConfigurationName: "config",
+								RevisionName:      rev("default", "config", 1).Name,
+								Percent:           100,
 							},
 							Active: true,
 						}},
 					},
 				},
 			),
-			simpleK8sService(route("default", "new-latest-ready", WithConfigTarget("config"))),
+			simpleK8sService(route("default", "gateway-pending", WithConfigTarget("config"))),
+			gatewayService(),
 		},
-		// A new LatestReadyRevisionName on the Configuration should result in the new Revision being rolled out.
-		WantUpdates: []clientgotesting.UpdateActionImpl{{
-			Object: simpleReadyIngress(
-				route("default", "new-latest-ready", WithConfigTarget("config"), WithDomain),
+		// The Route's status is left alone.
+		Key: "default/gateway-pending",
+	}, {
+		Name: "route opted out of its placeholder service becomes ready",
+		Objects: []runtime.Object{
+			route("default", "no-service", WithConfigTarget("config"),
+				WithRouteAnnotation(serving.CreateServiceAnnotationKey, "false")),
+			cfg("default", "config",
+				WithGeneration(1), WithLatestCreated, WithLatestReady),
+			rev("default", "config", 1, MarkRevisionReady),
+			simpleReadyIngress(
+				route("default", "no-service", WithConfigTarget("config"), WithDomain,
+					WithRouteAnnotation(serving.CreateServiceAnnotationKey, "false")),
 				&traffic.Config{
 					Targets: map[string][]traffic.RevisionTarget{
 						"": {{
 							TrafficTarget: v1alpha1.TrafficTarget{
-								// This is the new config we're making become ready.
-								RevisionName: "config-00002",
-								Percent:      100,
+								ConfigurationName: "config",
+								RevisionName:      rev("default", "config", 1).Name,
+								Percent:           100,
 							},
 							Active: true,
 						}},
 					},
 				},
 			),
-		}},
+		},
+		// No placeholder Service gets created.
 		WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
-			Object: route("default", "new-latest-ready", WithConfigTarget("config"),
+			Object: route("default", "no-service", WithConfigTarget("config"),
+				WithRouteAnnotation(serving.CreateServiceAnnotationKey, "false"),
 				WithDomain, WithDomainInternal, WithAddress, WithInitRouteConditions,
 				MarkTrafficAssigned, MarkIngressReady, WithStatusTraffic(
 					v1alpha1.TrafficTarget{
-						RevisionName: "config-00002",
-						Percent:      100,
-					})),
+						ConfigurationName: "config",
+						RevisionName:      "config-00001",
+						Percent:           100,
+					}),
+				WithStatusActiveTargets(
+					v1alpha1.ActiveTarget{RevisionName: "config-00001", Percent: 100, Active: true})),
 		}},
-		Key:                     "default/new-latest-ready",
-		SkipNamespaceValidation: true,
+		Key: "default/no-service",
 	}, {
-		Name: "failure updating cluster ingress",
-		// Starting from the new latest ready, induce a failure updating the cluster ingress.
-		WantErr: true,
-		WithReactors: []clientgotesting.ReactionFunc{
-			InduceFailure("update", "clusteringresses"),
-		},
+		Name: "route opting out of its placeholder service deletes it",
 		Objects: []runtime.Object{
-			route("default", "update-ci-failure", WithConfigTarget("config"),
+			route("default", "opt-out", WithConfigTarget("config"),
+				WithRouteAnnotation(serving.CreateServiceAnnotationKey, "false"),
 				WithDomain, WithDomainInternal, WithAddress, WithInitRouteConditions,
 				MarkTrafficAssigned, MarkIngressReady, WithStatusTraffic(
 					v1alpha1.TrafficTarget{
-						RevisionName: "config-00001",
-						Percent:      100,
-					})),
+						ConfigurationName: "config",
+						RevisionName:      "config-00001",
+						Percent:           100,
+					}),
+				WithStatusActiveTargets(
+					v1alpha1.ActiveTarget{RevisionName: "config-00001", Percent: 100, Active: true})),
 			cfg("default", "config",
-				WithGeneration(2), WithLatestCreated, WithLatestReady,
-				// The Route controller attaches our label to this Configuration.
-				WithConfigLabel("serving.knative.dev/route", "update-ci-failure"),
+				WithGeneration(1), WithLatestCreated, WithLatestReady,
+				WithConfigLabel("serving.knative.dev/route", "opt-out"),
 			),
 			rev("default", "config", 1, MarkRevisionReady),
-			// This is the name of the new revision we're referencing above.
-			rev("default", "config", 2, MarkRevisionReady),
 			simpleReadyIngress(
-				route("default", "update-ci-failure", WithConfigTarget("config"), WithDomain),
+				route("default", "opt-out", WithConfigTarget("config"), WithDomain,
+					WithRouteAnnotation(serving.CreateServiceAnnotationKey, "false")),
 				&traffic.Config{
 					Targets: map[string][]traffic.RevisionTarget{
 						"": {{
 							TrafficTarget: v1alpha1.TrafficTarget{
-								// Use the Revision name from the config.
-								RevisionName: rev("default", "config", 1).Name,
-								Percent:      100,
+								ConfigurationName: "config",
+								RevisionName:      rev("default", "config", 1).Name,
+								Percent:           100,
 							},
 							Active: true,
 						}},
 					},
 				},
 			),
-			simpleK8sService(route("default", "update-ci-failure", WithConfigTarget("config"))),
+			// Created before the Route opted out.
+			simpleK8sService(route("default", "opt-out", WithConfigTarget("config"))),
 		},
-		WantUpdates: []clientgotesting.UpdateActionImpl{{
-			Object: simpleReadyIngress(
-				route("default", "update-ci-failure", WithConfigTarget("config"), WithDomain),
-				&traffic.Config{
-					Targets: map[string][]traffic.RevisionTarget{
-						"": {{
-							TrafficTarget: v1alpha1.TrafficTarget{
-								// This is the new config we're making become ready.
-								RevisionName: "config-00002",
-								Percent:      100,
-							},
-							Active: true,
-						}},
-					},
-				},
-			),
-		}},
-		WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
-			Object: route("default", "update-ci-failure", WithConfigTarget("config"),
-				WithDomain, WithDomainInternal, WithAddress, WithInitRouteConditions,
-				MarkTrafficAssigned, MarkIngressReady, WithStatusTraffic(
-					v1alpha1.TrafficTarget{
-						RevisionName: "config-00002",
-						Percent:      100,
-					})),
+		WantDeletes: []clientgotesting.DeleteActionImpl{{
+			Name: "opt-out",
 		}},
-		Key:                     "default/update-ci-failure",
-		SkipNamespaceValidation: true,
+		WantEvents: []string{
+			Eventf(corev1.EventTypeNormal, "Deleted", "Deleted service %q", "opt-out"),
+		},
+		Key: "default/opt-out",
 	}, {
-		Name: "reconcile service mutation",
+		Name:    "unhappy about ownership of placeholder service",
+		WantErr: true,
 		Objects: []runtime.Object{
-			route("default", "svc-mutation", WithConfigTarget("config"),
+			route("default", "unhappy-owner", WithConfigTarget("config"),
 				WithDomain, WithDomainInternal, WithAddress, WithInitRouteConditions,
 				MarkTrafficAssigned, MarkIngressReady, WithStatusTraffic(
 					v1alpha1.TrafficTarget{
-						RevisionName: "config-00001",
-						Percent:      100,
-					})),
+						ConfigurationName: "config",
+						RevisionName:      "config-00001",
+						Percent:           100,
+					}),
+				WithStatusActiveTargets(
+					v1alpha1.ActiveTarget{RevisionName: "config-00001", Percent: 100, Active: true})),
 			cfg("default", "config",
 				WithGeneration(1), WithLatestCreated, WithLatestReady,
 				// The Route controller attaches our label to this Configuration.
-				WithConfigLabel("serving.knative.dev/route", "svc-mutation"),
+				WithConfigLabel("serving.knative.dev/route", "unhappy-owner"),
 			),
 			rev("default", "config", 1, MarkRevisionReady),
 			simpleReadyIngress(
-				route("default", "svc-mutation", WithConfigTarget("config"), WithDomain),
+				route("default", "unhappy-owner", WithConfigTarget("config"), WithDomain),
 				&traffic.Config{
 					Targets: map[string][]traffic.RevisionTarget{
 						"": {{
 							TrafficTarget: v1alpha1.TrafficTarget{
+								ConfigurationName: "config",
 								// Use the Revision name from the config.
 								RevisionName: rev("default", "config", 1).Name,
 								Percent:      100,
@@ -635,40 +1128,54 @@ func TestReconcile(t *testing.T) {
 					},
 				},
 			),
-			simpleK8sService(route("default", "svc-mutation",
-				WithConfigTarget("config")), MutateK8sService),
-		},
-		WantUpdates: []clientgotesting.UpdateActionImpl{{
-			Object: simpleK8sService(route("default", "svc-mutation", WithConfigTarget("config"))),
-		}},
-		Key: "default/svc-mutation",
-	}, {
-		Name: "failure updating k8s service",
-		// We start from the service mutation test, but induce a failure updating the service resource.
-		WantErr: true,
-		WithReactors: []clientgotesting.ReactionFunc{
-			InduceFailure("update", "services"),
+			simpleK8sService(route("default", "unhappy-owner", WithConfigTarget("config")),
+				WithK8sSvcOwnersRemoved),
 		},
-		Objects: []runtime.Object{
-			route("default", "svc-mutation", WithConfigTarget("config"),
+		WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
+			Object: route("default", "unhappy-owner", WithConfigTarget("config"),
 				WithDomain, WithDomainInternal, WithAddress, WithInitRouteConditions,
 				MarkTrafficAssigned, MarkIngressReady, WithStatusTraffic(
 					v1alpha1.TrafficTarget{
-						RevisionName: "config-00001",
-						Percent:      100,
-					})),
+						ConfigurationName: "config",
+						RevisionName:      "config-00001",
+						Percent:           100,
+					}),
+				WithStatusActiveTargets(
+					v1alpha1.ActiveTarget{RevisionName: "config-00001", Percent: 100, Active: true}),
+				// The owner is not us, so we are unhappy.
+				MarkServiceNotOwned),
+		}},
+		Key: "default/unhappy-owner",
+	}, {
+		// This tests that when the Route is labelled differently, it is configured with a
+		// different domain from config-domain.yaml.  This is otherwise a copy of the steady
+		// state test above.
+		Name: "different labels, different domain - steady state",
+		Objects: []runtime.Object{
+			route("default", "different-domain", WithConfigTarget("config"),
+				WithAnotherDomain, WithDomainInternal, WithAddress,
+				WithInitRouteConditions, MarkTrafficAssigned, MarkIngressReady,
+				WithStatusTraffic(v1alpha1.TrafficTarget{
+					ConfigurationName: "config",
+					RevisionName:      "config-00001",
+					Percent:           100,
+				}),
+				WithStatusActiveTargets(
+					v1alpha1.ActiveTarget{RevisionName: "config-00001", Percent: 100, Active: true}), WithRouteLabel("app", "prod")),
 			cfg("default", "config",
 				WithGeneration(1), WithLatestCreated, WithLatestReady,
 				// The Route controller attaches our label to this Configuration.
-				WithConfigLabel("serving.knative.dev/route", "svc-mutation"),
+				WithConfigLabel("serving.knative.dev/route", "different-domain"),
 			),
 			rev("default", "config", 1, MarkRevisionReady),
 			simpleReadyIngress(
-				route("default", "svc-mutation", WithConfigTarget("config"), WithDomain),
+				route("default", "different-domain", WithConfigTarget("config"),
+					WithAnotherDomain, WithRouteLabel("app", "prod")),
 				&traffic.Config{
 					Targets: map[string][]traffic.RevisionTarget{
 						"": {{
 							TrafficTarget: v1alpha1.TrafficTarget{
+								ConfigurationName: "config",
 								// Use the Revision name from the config.
 								RevisionName: rev("default", "config", 1).Name,
 								Percent:      100,
@@ -678,38 +1185,38 @@ func TestReconcile(t *testing.T) {
 					},
 				},
 			),
-			simpleK8sService(route("default", "svc-mutation",
-				WithConfigTarget("config")), MutateK8sService),
+			simpleK8sService(route("default", "different-domain", WithConfigTarget("config"))),
 		},
-		WantUpdates: []clientgotesting.UpdateActionImpl{{
-			Object: simpleK8sService(route("default", "svc-mutation", WithConfigTarget("config"))),
-		}},
-		Key: "default/svc-mutation",
+		Key: "default/different-domain",
 	}, {
-		// In #1789 we switched this to an ExternalName Service. Services created in
-		// 0.1 will still have ClusterIP set, which is Forbidden for ExternalName
-		// Services. Ensure that we drop the ClusterIP if it is set in the spec.
-		Name: "drop cluster ip",
+		Name: "label change rebuilds the ingress",
 		Objects: []runtime.Object{
-			route("default", "cluster-ip", WithConfigTarget("config"),
-				WithDomain, WithDomainInternal, WithAddress, WithInitRouteConditions,
-				MarkTrafficAssigned, MarkIngressReady, WithStatusTraffic(
-					v1alpha1.TrafficTarget{
-						RevisionName: "config-00001",
-						Percent:      100,
-					})),
+			route("default", "relabelled", WithConfigTarget("config"),
+				WithDomain, WithDomainInternal, WithAddress,
+				WithInitRouteConditions, MarkTrafficAssigned, MarkIngressReady,
+				WithStatusTraffic(v1alpha1.TrafficTarget{
+					ConfigurationName: "config",
+					RevisionName:      "config-00001",
+					Percent:           100,
+				}),
+				WithStatusActiveTargets(
+					v1alpha1.ActiveTarget{RevisionName: "config-00001", Percent: 100, Active: true}),
+				WithRouteLabel("app", "staging")),
 			cfg("default", "config",
 				WithGeneration(1), WithLatestCreated, WithLatestReady,
 				// The Route controller attaches our label to this Configuration.
-				WithConfigLabel("serving.knative.dev/route", "cluster-ip"),
+				WithConfigLabel("serving.knative.dev/route", "relabelled"),
 			),
 			rev("default", "config", 1, MarkRevisionReady),
+			// The traffic is unchanged, but the ingress was built before the relabel.
 			simpleReadyIngress(
-				route("default", "cluster-ip", WithConfigTarget("config"), WithDomain),
+				route("default", "relabelled", WithConfigTarget("config"),
+					WithDomain, WithRouteLabel("app", "prod")),
 				&traffic.Config{
 					Targets: map[string][]traffic.RevisionTarget{
 						"": {{
 							TrafficTarget: v1alpha1.TrafficTarget{
+								ConfigurationName: "config",
 								// Use the Revision name from the config.
 								RevisionName: rev("default", "config", 1).Name,
 								Percent:      100,
@@ -719,36 +1226,17 @@ func TestReconcile(t *testing.T) {
 					},
 				},
 			),
-			simpleK8sService(route("default", "cluster-ip",
-				WithConfigTarget("config")), WithClusterIP("127.0.0.1")),
+			simpleK8sService(route("default", "relabelled", WithConfigTarget("config"))),
 		},
 		WantUpdates: []clientgotesting.UpdateActionImpl{{
-			Object: simpleK8sService(route("default", "cluster-ip", WithConfigTarget("config"))),
-		}},
-		Key: "default/cluster-ip",
-	}, {
-		// Make sure we fix the external name if something messes with it.
-		Name: "fix external name",
-		Objects: []runtime.Object{
-			route("default", "external-name", WithConfigTarget("config"),
-				WithDomain, WithDomainInternal, WithAddress, WithInitRouteConditions,
-				MarkTrafficAssigned, MarkIngressReady, WithStatusTraffic(
-					v1alpha1.TrafficTarget{
-						RevisionName: "config-00001",
-						Percent:      100,
-					})),
-			cfg("default", "config",
-				WithGeneration(1), WithLatestCreated, WithLatestReady,
-				// The Route controller attaches our label to this Configuration.
-				WithConfigLabel("serving.knative.dev/route", "external-name"),
-			),
-			rev("default", "config", 1, MarkRevisionReady),
-			simpleReadyIngress(
-				route("default", "external-name", WithConfigTarget("config"), WithDomain),
+			Object: simpleReadyIngress(
+				route("default", "relabelled", WithConfigTarget("config"),
+					WithDomain, WithRouteLabel("app", "staging")),
 				&traffic.Config{
 					Targets: map[string][]traffic.RevisionTarget{
 						"": {{
 							TrafficTarget: v1alpha1.TrafficTarget{
+								ConfigurationName: "config",
 								// Use the Revision name from the config.
 								RevisionName: rev("default", "config", 1).Name,
 								Percent:      100,
@@ -758,35 +1246,26 @@ func TestReconcile(t *testing.T) {
 					},
 				},
 			),
-			simpleK8sService(route("default", "external-name",
-				WithConfigTarget("config")), WithExternalName("this-is-the-wrong-name")),
-		},
-		WantUpdates: []clientgotesting.UpdateActionImpl{{
-			Object: simpleK8sService(route("default", "external-name", WithConfigTarget("config"))),
 		}},
-		Key: "default/external-name",
+		Key: "default/relabelled",
 	}, {
-		Name: "reconcile cluster ingress mutation",
+		Name: "custom domain with TLS, ingress unknown",
 		Objects: []runtime.Object{
-			route("default", "ingress-mutation", WithConfigTarget("config"),
-				WithDomain, WithDomainInternal, WithAddress, WithInitRouteConditions,
-				MarkTrafficAssigned, MarkIngressReady, WithStatusTraffic(
-					v1alpha1.TrafficTarget{
-						RevisionName: "config-00001",
-						Percent:      100,
-					})),
+			route("default", "custom-domain", WithConfigTarget("config"),
+				WithCustomDomain("www.example.com", "example-cert")),
 			cfg("default", "config",
-				WithGeneration(1), WithLatestCreated, WithLatestReady,
-				// The Route controller attaches our label to this Configuration.
-				WithConfigLabel("serving.knative.dev/route", "ingress-mutation"),
-			),
+				WithGeneration(1), WithLatestCreated, WithLatestReady),
 			rev("default", "config", 1, MarkRevisionReady),
-			mutateIngress(simpleReadyIngress(
-				route("default", "ingress-mutation", WithConfigTarget("config"), WithDomain),
+		},
+		WantCreates: []metav1.Object{
+			resources.MakeClusterIngress(
+				route("default", "custom-domain", WithConfigTarget("config"),
+					WithCustomDomain("www.example.com", "example-cert"), WithDomain),
 				&traffic.Config{
 					Targets: map[string][]traffic.RevisionTarget{
 						"": {{
 							TrafficTarget: v1alpha1.TrafficTarget{
+								ConfigurationName: "config",
 								// Use the Revision name from the config.
 								RevisionName: rev("default", "config", 1).Name,
 								Percent:      100,
@@ -795,16 +1274,97 @@ func TestReconcile(t *testing.T) {
 						}},
 					},
 				},
-			)),
-			simpleK8sService(route("default", "ingress-mutation", WithConfigTarget("config"))),
+				testRevisionPort,
+			),
+		},
+		WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
+			Object: route("default", "custom-domain", WithConfigTarget("config"),
+				WithCustomDomain("www.example.com", "example-cert"),
+				WithDomain, WithDomainInternal, WithAddress, WithInitRouteConditions,
+				MarkTrafficAssigned, WithStatusTraffic(v1alpha1.TrafficTarget{
+					ConfigurationName: "config",
+					RevisionName:      "config-00001",
+					Percent:           100,
+				}),
+				WithStatusActiveTargets(
+					v1alpha1.ActiveTarget{RevisionName: "config-00001", Percent: 100, Active: true})),
+		}},
+		WantEvents: []string{
+			Eventf(corev1.EventTypeNormal, "Created", "Created ClusterIngress %q", ""),
+		},
+		Key:                     "default/custom-domain",
+		SkipNamespaceValidation: true,
+	}, {
+		Name: "custom domain claimed by an older route",
+		Objects: []runtime.Object{
+			route("default", "claimant", WithConfigTarget("config"),
+				WithRouteCreationTimestamp(fakeCurTime),
+				WithCustomDomain("www.example.com", ""),
+				WithCustomDomain("api.example.com", ""),
+				WithDomain, WithDomainInternal, WithAddress, WithInitRouteConditions,
+				MarkTrafficAssigned, MarkIngressReady, WithStatusTraffic(v1alpha1.TrafficTarget{
+					ConfigurationName: "config",
+					RevisionName:      "config-00001",
+					Percent:           100,
+				}),
+				WithStatusActiveTargets(
+					v1alpha1.ActiveTarget{RevisionName: "config-00001", Percent: 100, Active: true})),
+			route("default", "owner", WithConfigTarget("config"),
+				WithRouteCreationTimestamp(fakeCurTime.Add(-time.Hour)),
+				WithCustomDomain("www.example.com", "")),
+			cfg("default", "config",
+				WithGeneration(1), WithLatestCreated, WithLatestReady,
+				WithConfigLabel("serving.knative.dev/route", "claimant")),
+			rev("default", "config", 1, MarkRevisionReady),
+			namedIngress("claimant-ingress", simpleReadyIngress(
+				route("default", "claimant", WithConfigTarget("config"),
+					WithRouteCreationTimestamp(fakeCurTime),
+					WithCustomDomain("www.example.com", ""),
+					WithCustomDomain("api.example.com", ""), WithDomain),
+				&traffic.Config{
+					Targets: map[string][]traffic.RevisionTarget{
+						"": {{
+							TrafficTarget: v1alpha1.TrafficTarget{
+								ConfigurationName: "config",
+								// Use the Revision name from the config.
+								RevisionName: rev("default", "config", 1).Name,
+								Percent:      100,
+							},
+							Active: true,
+						}},
+					},
+				},
+			)),
+			namedIngress("owner-ingress", simpleReadyIngress(
+				route("default", "owner", WithConfigTarget("config"),
+					WithCustomDomain("www.example.com", ""), WithDomain),
+				&traffic.Config{
+					Targets: map[string][]traffic.RevisionTarget{
+						"": {{
+							TrafficTarget: v1alpha1.TrafficTarget{
+								ConfigurationName: "config",
+								// Use the Revision name from the config.
+								RevisionName: rev("default", "config", 1).Name,
+								Percent:      100,
+							},
+							Active: true,
+						}},
+					},
+				},
+			)),
+			simpleK8sService(route("default", "claimant", WithConfigTarget("config"))),
 		},
 		WantUpdates: []clientgotesting.UpdateActionImpl{{
-			Object: simpleReadyIngress(
-				route("default", "ingress-mutation", WithConfigTarget("config"), WithDomain),
+			// Only the claimed host is left out.
+			Object: namedIngress("claimant-ingress", simpleReadyIngress(
+				route("default", "claimant", WithConfigTarget("config"),
+					WithRouteCreationTimestamp(fakeCurTime),
+					WithCustomDomain("api.example.com", ""), WithDomain),
 				&traffic.Config{
 					Targets: map[string][]traffic.RevisionTarget{
 						"": {{
 							TrafficTarget: v1alpha1.TrafficTarget{
+								ConfigurationName: "config",
 								// Use the Revision name from the config.
 								RevisionName: rev("default", "config", 1).Name,
 								Percent:      100,
@@ -813,128 +1373,182 @@ func TestReconcile(t *testing.T) {
 						}},
 					},
 				},
-			),
+			)),
 		}},
-		Key:                     "default/ingress-mutation",
+		WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
+			Object: route("default", "claimant", WithConfigTarget("config"),
+				WithRouteCreationTimestamp(fakeCurTime),
+				WithCustomDomain("www.example.com", ""),
+				WithCustomDomain("api.example.com", ""),
+				WithDomain, WithDomainInternal, WithAddress, WithInitRouteConditions,
+				MarkTrafficAssigned, MarkIngressReady, WithStatusTraffic(v1alpha1.TrafficTarget{
+					ConfigurationName: "config",
+					RevisionName:      "config-00001",
+					Percent:           100,
+				}),
+				WithStatusActiveTargets(
+					v1alpha1.ActiveTarget{RevisionName: "config-00001", Percent: 100, Active: true}),
+				WithVirtualServiceName("claimant-ingress"),
+				MarkDomainClaimed("www.example.com")),
+		}},
+		Key:                     "default/claimant",
 		SkipNamespaceValidation: true,
 	}, {
-		Name: "switch to a different config",
+		Name: "custom domain claimed at the same time goes to the first name",
 		Objects: []runtime.Object{
-			// The status reflects "oldconfig", but the spec "newconfig".
-			route("default", "change-configs", WithConfigTarget("newconfig"),
+			route("default", "alpha", WithConfigTarget("config"),
+				WithCustomDomain("www.example.com", ""),
 				WithDomain, WithDomainInternal, WithAddress, WithInitRouteConditions,
-				MarkTrafficAssigned, MarkIngressReady, WithStatusTraffic(
-					v1alpha1.TrafficTarget{
-						RevisionName: "oldconfig-00001",
-						Percent:      100,
-					})),
-			// Both configs exist, but only "oldconfig" is labelled.
-			cfg("default", "oldconfig",
+				MarkTrafficAssigned, MarkIngressReady, WithStatusTraffic(v1alpha1.TrafficTarget{
+					ConfigurationName: "config",
+					RevisionName:      "config-00001",
+					Percent:           100,
+				}),
+				WithStatusActiveTargets(
+					v1alpha1.ActiveTarget{RevisionName: "config-00001", Percent: 100, Active: true}), MarkDomainClaimed("www.example.com")),
+			route("default", "beta", WithConfigTarget("config"),
+				WithCustomDomain("www.example.com", "")),
+			cfg("default", "config",
 				WithGeneration(1), WithLatestCreated, WithLatestReady,
-				// The Route controller attaches our label to this Configuration.
-				WithConfigLabel("serving.knative.dev/route", "change-configs"),
-			),
-			cfg("default", "newconfig",
-				WithGeneration(1), WithLatestCreated, WithLatestReady),
-			rev("default", "oldconfig", 1, MarkRevisionReady),
-			rev("default", "newconfig", 1, MarkRevisionReady),
-			simpleReadyIngress(
-				route("default", "change-configs", WithConfigTarget("oldconfig"), WithDomain),
+				WithConfigLabel("serving.knative.dev/route", "alpha")),
+			rev("default", "config", 1, MarkRevisionReady),
+			namedIngress("alpha-ingress", simpleReadyIngress(
+				route("default", "alpha", WithConfigTarget("config"), WithDomain),
 				&traffic.Config{
 					Targets: map[string][]traffic.RevisionTarget{
 						"": {{
 							TrafficTarget: v1alpha1.TrafficTarget{
+								ConfigurationName: "config",
 								// Use the Revision name from the config.
-								RevisionName: rev("default", "oldconfig", 1).Name,
+								RevisionName: rev("default", "config", 1).Name,
 								Percent:      100,
 							},
 							Active: true,
 						}},
 					},
 				},
-			),
-			simpleK8sService(route("default", "change-configs", WithConfigTarget("oldconfig"))),
+			)),
+			namedIngress("beta-ingress", simpleReadyIngress(
+				route("default", "beta", WithConfigTarget("config"),
+					WithCustomDomain("www.example.com", ""), WithDomain),
+				&traffic.Config{
+					Targets: map[string][]traffic.RevisionTarget{
+						"": {{
+							TrafficTarget: v1alpha1.TrafficTarget{
+								ConfigurationName: "config",
+								// Use the Revision name from the config.
+								RevisionName: rev("default", "config", 1).Name,
+								Percent:      100,
+							},
+							Active: true,
+						}},
+					},
+				},
+			)),
+			simpleK8sService(route("default", "alpha", WithConfigTarget("config"))),
 		},
 		WantUpdates: []clientgotesting.UpdateActionImpl{{
-			// Updated to point to "newconfig" things.
-			Object: simpleReadyIngress(
-				route("default", "change-configs", WithConfigTarget("newconfig"), WithDomain),
+			Object: namedIngress("alpha-ingress", simpleReadyIngress(
+				route("default", "alpha", WithConfigTarget("config"),
+					WithCustomDomain("www.example.com", ""), WithDomain),
 				&traffic.Config{
 					Targets: map[string][]traffic.RevisionTarget{
 						"": {{
 							TrafficTarget: v1alpha1.TrafficTarget{
+								ConfigurationName: "config",
 								// Use the Revision name from the config.
-								RevisionName: rev("default", "newconfig", 1).Name,
+								RevisionName: rev("default", "config", 1).Name,
 								Percent:      100,
 							},
 							Active: true,
 						}},
 					},
 				},
-			),
+			)),
 		}},
 		WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
-			// Status updated to "newconfig"
-			Object: route("default", "change-configs", WithConfigTarget("newconfig"),
+			Object: route("default", "alpha", WithConfigTarget("config"),
+				WithCustomDomain("www.example.com", ""), WithVirtualServiceName("alpha-ingress"),
 				WithDomain, WithDomainInternal, WithAddress, WithInitRouteConditions,
-				MarkTrafficAssigned, MarkIngressReady, WithStatusTraffic(
-					v1alpha1.TrafficTarget{
-						RevisionName: "newconfig-00001",
-						Percent:      100,
-					})),
-		}},
-		Key: "default/change-configs",
-	}, {
-		Name: "configuration missing",
-		Objects: []runtime.Object{
-			route("default", "config-missing", WithConfigTarget("not-found")),
-		},
-		WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
-			Object: route("default", "config-missing", WithConfigTarget("not-found"),
-				WithInitRouteConditions, MarkMissingTrafficTarget("Configuration", "not-found")),
-		}},
-		Key: "default/config-missing",
-	}, {
-		Name: "revision missing (direct)",
-		Objects: []runtime.Object{
-			route("default", "missing-revision-direct", WithRevTarget("not-found")),
-			cfg("default", "config",
-				WithGeneration(1), WithLatestCreated, WithLatestReady),
-		},
-		WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
-			Object: route("default", "missing-revision-direct", WithRevTarget("not-found"),
-				WithInitRouteConditions, MarkMissingTrafficTarget("Revision", "not-found")),
+				MarkTrafficAssigned, MarkIngressReady, WithStatusTraffic(v1alpha1.TrafficTarget{
+					ConfigurationName: "config",
+					RevisionName:      "config-00001",
+					Percent:           100,
+				}),
+				WithStatusActiveTargets(
+					v1alpha1.ActiveTarget{RevisionName: "config-00001", Percent: 100, Active: true})),
 		}},
-		Key: "default/missing-revision-direct",
+		Key:                     "default/alpha",
+		SkipNamespaceValidation: true,
 	}, {
-		Name: "revision missing (indirect)",
+		Name: "new latest created revision",
 		Objects: []runtime.Object{
-			route("default", "missing-revision-indirect", WithConfigTarget("config")),
+			route("default", "new-latest-created", WithConfigTarget("config"),
+				WithDomain, WithDomainInternal, WithAddress, WithInitRouteConditions,
+				MarkTrafficAssigned, MarkIngressReady, WithStatusTraffic(
+					v1alpha1.TrafficTarget{
+						ConfigurationName: "config",
+						RevisionName:      "config-00001",
+						Percent:           100,
+					}),
+				WithStatusActiveTargets(
+					v1alpha1.ActiveTarget{RevisionName: "config-00001", Percent: 100, Active: true})),
 			cfg("default", "config",
-				WithGeneration(1), WithLatestCreated, WithLatestReady),
+				WithGeneration(1), WithLatestCreated, WithLatestReady,
+				WithGeneration(2), WithLatestCreated,
+				WithConfigLabel("serving.knative.dev/route", "new-latest-created"),
+			),
+			rev("default", "config", 1, MarkRevisionReady),
+			// This is the name of the new revision we're referencing above.
+			rev("default", "config", 2, WithInitRevConditions),
+			simpleReadyIngress(
+				route("default", "new-latest-created", WithConfigTarget("config"), WithDomain),
+				&traffic.Config{
+					Targets: map[string][]traffic.RevisionTarget{
+						"": {{
+							TrafficTarget: v1alpha1.TrafficTarget{
+								ConfigurationName: "config",
+								// Use the Revision name from the config.
+								RevisionName: rev("default", "config", 1).Name,
+								Percent:      100,
+							},
+							Active: true,
+						}},
+					},
+				},
+			),
+			simpleK8sService(route("default", "new-latest-created", WithConfigTarget("config"))),
 		},
-		WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
-			Object: route("default", "missing-revision-indirect", WithConfigTarget("config"),
-				WithInitRouteConditions, MarkMissingTrafficTarget("Revision", "config-00001")),
-		}},
-		Key: "default/missing-revision-indirect",
+		// A new LatestCreatedRevisionName on the Configuration alone should result in no changes to the Route.
+		Key: "default/new-latest-created",
 	}, {
-		Name: "pinned route becomes ready",
+		Name: "new latest ready revision",
 		Objects: []runtime.Object{
-			route("default", "pinned-becomes-ready", WithRevTarget(
-				// Use the Revision name from the config
-				rev("default", "config", 1).Name)),
+			route("default", "new-latest-ready", WithConfigTarget("config"),
+				WithDomain, WithDomainInternal, WithAddress, WithInitRouteConditions,
+				MarkTrafficAssigned, MarkIngressReady, WithStatusTraffic(
+					v1alpha1.TrafficTarget{
+						ConfigurationName: "config",
+						RevisionName:      "config-00001",
+						Percent:           100,
+					}),
+				WithStatusActiveTargets(
+					v1alpha1.ActiveTarget{RevisionName: "config-00001", Percent: 100, Active: true})),
 			cfg("default", "config",
-				WithGeneration(1), WithLatestCreated, WithLatestReady),
+				WithGeneration(2), WithLatestCreated, WithLatestReady,
+				// The Route controller attaches our label to this Configuration.
+				WithConfigLabel("serving.knative.dev/route", "new-latest-ready"),
+			),
 			rev("default", "config", 1, MarkRevisionReady),
-			simpleK8sService(route("default", "pinned-becomes-ready", WithConfigTarget("config"))),
+			// This is the name of the new revision we're referencing above.
+			rev("default", "config", 2, MarkRevisionReady),
 			simpleReadyIngress(
-				route("default", "pinned-becomes-ready", WithConfigTarget("config"),
-					WithDomain),
+				route("default", "new-latest-ready", WithConfigTarget("config"), WithDomain),
 				&traffic.Config{
 					Targets: map[string][]traffic.RevisionTarget{
 						"": {{
 							TrafficTarget: v1alpha1.TrafficTarget{
+								ConfigurationName: "config",
 								// Use the Revision name from the config.
 								RevisionName: rev("default", "config", 1).Name,
 								Percent:      100,
@@ -944,305 +1558,2534 @@ func TestReconcile(t *testing.T) {
 					},
 				},
 			),
+			simpleK8sService(route("default", "new-latest-ready", WithConfigTarget("config"))),
 		},
+		// A new LatestReadyRevisionName on the Configuration should result in the new Revision being rolled out.
+		WantUpdates: []clientgotesting.UpdateActionImpl{{
+			Object: simpleReadyIngress(
+				route("default", "new-latest-ready", WithConfigTarget("config"), WithDomain),
+				&traffic.Config{
+					Targets: map[string][]traffic.RevisionTarget{
+						"": {{
+							TrafficTarget: v1alpha1.TrafficTarget{
+								ConfigurationName: "config",
+								// This is the new config we're making become ready.
+								RevisionName: "config-00002",
+								Percent:      100,
+							},
+							Active: true,
+						}},
+					},
+				},
+			),
+		}},
 		WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
-			Object: route("default", "pinned-becomes-ready",
-				// Use the Revision name from the config
-				WithRevTarget(rev("default", "config", 1).Name),
+			Object: route("default", "new-latest-ready", WithConfigTarget("config"),
 				WithDomain, WithDomainInternal, WithAddress, WithInitRouteConditions,
 				MarkTrafficAssigned, MarkIngressReady, WithStatusTraffic(
 					v1alpha1.TrafficTarget{
-						RevisionName: rev("default", "config", 1).Name,
-						Percent:      100,
-					})),
+						ConfigurationName: "config",
+						RevisionName:      "config-00002",
+						Percent:           100,
+					}),
+				WithStatusActiveTargets(
+					v1alpha1.ActiveTarget{RevisionName: "config-00002", Percent: 100, Active: true})),
 		}},
-		Key:                     "default/pinned-becomes-ready",
+		Key:                     "default/new-latest-ready",
 		SkipNamespaceValidation: true,
 	}, {
-		Name: "traffic split becomes ready",
+		Name: "new latest ready revision with gradual rollout",
 		Objects: []runtime.Object{
-			route("default", "named-traffic-split", WithSpecTraffic(
-				v1alpha1.TrafficTarget{
-					ConfigurationName: "blue",
-					Percent:           50,
-				}, v1alpha1.TrafficTarget{
-					ConfigurationName: "green",
-					Percent:           50,
-				})),
-			cfg("default", "blue",
-				WithGeneration(1), WithLatestCreated, WithLatestReady),
-			cfg("default", "green",
-				WithGeneration(1), WithLatestCreated, WithLatestReady),
-			rev("default", "blue", 1, MarkRevisionReady),
-			rev("default", "green", 1, MarkRevisionReady),
-		},
-		WantCreates: []metav1.Object{
-			resources.MakeClusterIngress(
-				route("default", "named-traffic-split", WithDomain, WithSpecTraffic(
+			route("default", "gradual-rollout", WithConfigTarget("config"), WithRollout(9*time.Minute, 25),
+				WithDomain, WithDomainInternal, WithAddress, WithInitRouteConditions,
+				MarkTrafficAssigned, MarkIngressReady, WithStatusTraffic(
 					v1alpha1.TrafficTarget{
-						ConfigurationName: "blue",
-						Percent:           50,
-					}, v1alpha1.TrafficTarget{
-						ConfigurationName: "green",
-						Percent:           50,
-					})),
+						ConfigurationName: "config",
+						RevisionName:      "config-00001",
+						Percent:           100,
+					}),
+				WithStatusActiveTargets(
+					v1alpha1.ActiveTarget{RevisionName: "config-00001", Percent: 100, Active: true})),
+			cfg("default", "config",
+				WithGeneration(2), WithLatestCreated, WithLatestReady,
+				// The Route controller attaches our label to this Configuration.
+				WithConfigLabel("serving.knative.dev/route", "gradual-rollout"),
+			),
+			rev("default", "config", 1, MarkRevisionReady,
+				WithRevisionLabel(serving.ConfigurationLabelKey, "config")),
+			// This is the name of the new revision we're referencing above.
+			rev("default", "config", 2, MarkRevisionReady,
+				WithRevisionLabel(serving.ConfigurationLabelKey, "config")),
+			simpleReadyIngress(
+				route("default", "gradual-rollout", WithConfigTarget("config"), WithDomain),
 				&traffic.Config{
 					Targets: map[string][]traffic.RevisionTarget{
 						"": {{
 							TrafficTarget: v1alpha1.TrafficTarget{
-								// Use the Revision name from the config.
-								RevisionName: rev("default", "blue", 1).Name,
-								Percent:      50,
-							},
-							Active: true,
-						}, {
-							TrafficTarget: v1alpha1.TrafficTarget{
-								// Use the Revision name from the config.
-								RevisionName: rev("default", "green", 1).Name,
-								Percent:      50,
+								ConfigurationName: "config",
+								RevisionName:      "config-00001",
+								Percent:           100,
 							},
 							Active: true,
 						}},
 					},
 				},
 			),
+			simpleK8sService(route("default", "gradual-rollout", WithConfigTarget("config"))),
+		},
+		// Only the first step of the traffic moves to the new Revision.
+		WantUpdates: []clientgotesting.UpdateActionImpl{{
+			Object: simpleReadyIngress(
+				route("default", "gradual-rollout", WithConfigTarget("config"), WithDomain),
+				&traffic.Config{
+					Targets: map[string][]traffic.RevisionTarget{
+						"": {{
+							TrafficTarget: v1alpha1.TrafficTarget{
+								ConfigurationName: "config",
+								RevisionName:      "config-00001",
+								Percent:           75,
+							},
+							Active: true,
+						}, {
+							TrafficTarget: v1alpha1.TrafficTarget{
+								ConfigurationName: "config",
+								RevisionName:      "config-00002",
+								Percent:           25,
+							},
+							Active: true,
+						}},
+					},
+				},
+			),
+		}},
+		WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
+			Object: route("default", "gradual-rollout", WithConfigTarget("config"), WithRollout(9*time.Minute, 25),
+				WithDomain, WithDomainInternal, WithAddress, WithInitRouteConditions,
+				MarkTrafficAssigned, MarkIngressReady, WithStatusTraffic(
+					v1alpha1.TrafficTarget{
+						ConfigurationName: "config",
+						RevisionName:      "config-00001",
+						Percent:           75,
+					}, v1alpha1.TrafficTarget{
+						ConfigurationName: "config",
+						RevisionName:      "config-00002",
+						Percent:           25,
+					}),
+				WithStatusActiveTargets(
+					v1alpha1.ActiveTarget{RevisionName: "config-00001", Percent: 75, Active: true},
+					v1alpha1.ActiveTarget{RevisionName: "config-00002", Percent: 25, Active: true}), WithStatusRollouts(v1alpha1.RolloutStatus{
+					ConfigurationName:    "config",
+					PreviousRevisionName: "config-00001",
+					RevisionName:         "config-00002",
+					StartTime:            metav1.NewTime(fakeCurTime),
+				})),
+		}},
+		Key:                     "default/gradual-rollout",
+		SkipNamespaceValidation: true,
+	}, {
+		Name: "failure updating cluster ingress",
+		// Starting from the new latest ready, induce a failure updating the cluster ingress.
+		WantErr: true,
+		WithReactors: []clientgotesting.ReactionFunc{
+			InduceFailure("update", "clusteringresses"),
+		},
+		Objects: []runtime.Object{
+			route("default", "update-ci-failure", WithConfigTarget("config"),
+				WithDomain, WithDomainInternal, WithAddress, WithInitRouteConditions,
+				MarkTrafficAssigned, MarkIngressReady, WithStatusTraffic(
+					v1alpha1.TrafficTarget{
+						ConfigurationName: "config",
+						RevisionName:      "config-00001",
+						Percent:           100,
+					}),
+				WithStatusActiveTargets(
+					v1alpha1.ActiveTarget{RevisionName: "config-00001", Percent: 100, Active: true})),
+			cfg("default", "config",
+				WithGeneration(2), WithLatestCreated, WithLatestReady,
+				// The Route controller attaches our label to this Configuration.
+				WithConfigLabel("serving.knative.dev/route", "update-ci-failure"),
+			),
+			rev("default", "config", 1, MarkRevisionReady),
+			// This is the name of the new revision we're referencing above.
+			rev("default", "config", 2, MarkRevisionReady),
+			simpleReadyIngress(
+				route("default", "update-ci-failure", WithConfigTarget("config"), WithDomain),
+				&traffic.Config{
+					Targets: map[string][]traffic.RevisionTarget{
+						"": {{
+							TrafficTarget: v1alpha1.TrafficTarget{
+								ConfigurationName: "config",
+								// Use the Revision name from the config.
+								RevisionName: rev("default", "config", 1).Name,
+								Percent:      100,
+							},
+							Active: true,
+						}},
+					},
+				},
+			),
+			simpleK8sService(route("default", "update-ci-failure", WithConfigTarget("config"))),
+		},
+		WantUpdates: []clientgotesting.UpdateActionImpl{{
+			Object: simpleReadyIngress(
+				route("default", "update-ci-failure", WithConfigTarget("config"), WithDomain),
+				&traffic.Config{
+					Targets: map[string][]traffic.RevisionTarget{
+						"": {{
+							TrafficTarget: v1alpha1.TrafficTarget{
+								ConfigurationName: "config",
+								// This is the new config we're making become ready.
+								RevisionName: "config-00002",
+								Percent:      100,
+							},
+							Active: true,
+						}},
+					},
+				},
+			),
+		}},
+		WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
+			Object: route("default", "update-ci-failure", WithConfigTarget("config"),
+				WithDomain, WithDomainInternal, WithAddress, WithInitRouteConditions,
+				MarkTrafficAssigned, MarkIngressReady, WithStatusTraffic(
+					v1alpha1.TrafficTarget{
+						ConfigurationName: "config",
+						RevisionName:      "config-00002",
+						Percent:           100,
+					}),
+				WithStatusActiveTargets(
+					v1alpha1.ActiveTarget{RevisionName: "config-00002", Percent: 100, Active: true}),
+				MarkIngressNotReady("inducing failure for update clusteringresses")),
+		}},
+		Key:                     "default/update-ci-failure",
+		SkipNamespaceValidation: true,
+	}, {
+		Name: "reconcile service mutation",
+		Objects: []runtime.Object{
+			route("default", "svc-mutation", WithConfigTarget("config"),
+				WithDomain, WithDomainInternal, WithAddress, WithInitRouteConditions,
+				MarkTrafficAssigned, MarkIngressReady, WithStatusTraffic(
+					v1alpha1.TrafficTarget{
+						ConfigurationName: "config",
+						RevisionName:      "config-00001",
+						Percent:           100,
+					}),
+				WithStatusActiveTargets(
+					v1alpha1.ActiveTarget{RevisionName: "config-00001", Percent: 100, Active: true})),
+			cfg("default", "config",
+				WithGeneration(1), WithLatestCreated, WithLatestReady,
+				// The Route controller attaches our label to this Configuration.
+				WithConfigLabel("serving.knative.dev/route", "svc-mutation"),
+			),
+			rev("default", "config", 1, MarkRevisionReady),
+			simpleReadyIngress(
+				route("default", "svc-mutation", WithConfigTarget("config"), WithDomain),
+				&traffic.Config{
+					Targets: map[string][]traffic.RevisionTarget{
+						"": {{
+							TrafficTarget: v1alpha1.TrafficTarget{
+								ConfigurationName: "config",
+								// Use the Revision name from the config.
+								RevisionName: rev("default", "config", 1).Name,
+								Percent:      100,
+							},
+							Active: true,
+						}},
+					},
+				},
+			),
+			simpleK8sService(route("default", "svc-mutation",
+				WithConfigTarget("config")), MutateK8sService),
+		},
+		WantUpdates: []clientgotesting.UpdateActionImpl{{
+			Object: simpleK8sService(route("default", "svc-mutation", WithConfigTarget("config"))),
+		}},
+		Key: "default/svc-mutation",
+	}, {
+		Name: "failure updating k8s service",
+		// We start from the service mutation test, but induce a failure updating the service resource.
+		WantErr: true,
+		WithReactors: []clientgotesting.ReactionFunc{
+			InduceFailure("update", "services"),
+		},
+		Objects: []runtime.Object{
+			route("default", "svc-mutation", WithConfigTarget("config"),
+				WithDomain, WithDomainInternal, WithAddress, WithInitRouteConditions,
+				MarkTrafficAssigned, MarkIngressReady, WithStatusTraffic(
+					v1alpha1.TrafficTarget{
+						ConfigurationName: "config",
+						RevisionName:      "config-00001",
+						Percent:           100,
+					}),
+				WithStatusActiveTargets(
+					v1alpha1.ActiveTarget{RevisionName: "config-00001", Percent: 100, Active: true})),
+			cfg("default", "config",
+				WithGeneration(1), WithLatestCreated, WithLatestReady,
+				// The Route controller attaches our label to this Configuration.
+				WithConfigLabel("serving.knative.dev/route", "svc-mutation"),
+			),
+			rev("default", "config", 1, MarkRevisionReady),
+			simpleReadyIngress(
+				route("default", "svc-mutation", WithConfigTarget("config"), WithDomain),
+				&traffic.Config{
+					Targets: map[string][]traffic.RevisionTarget{
+						"": {{
+							TrafficTarget: v1alpha1.TrafficTarget{
+								ConfigurationName: "config",
+								// Use the Revision name from the config.
+								RevisionName: rev("default", "config", 1).Name,
+								Percent:      100,
+							},
+							Active: true,
+						}},
+					},
+				},
+			),
+			simpleK8sService(route("default", "svc-mutation",
+				WithConfigTarget("config")), MutateK8sService),
+		},
+		WantUpdates: []clientgotesting.UpdateActionImpl{{
+			Object: simpleK8sService(route("default", "svc-mutation", WithConfigTarget("config"))),
+		}},
+		WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
+			Object: route("default", "svc-mutation", WithConfigTarget("config"),
+				WithDomain, WithDomainInternal, WithAddress, WithInitRouteConditions,
+				MarkTrafficAssigned, MarkIngressReady, WithStatusTraffic(
+					v1alpha1.TrafficTarget{
+						ConfigurationName: "config",
+						RevisionName:      "config-00001",
+						Percent:           100,
+					}),
+				WithStatusActiveTargets(
+					v1alpha1.ActiveTarget{RevisionName: "config-00001", Percent: 100, Active: true}),
+				MarkServiceNotReady("inducing failure for update services")),
+		}},
+		Key: "default/svc-mutation",
+	}, {
+		// In #1789 we switched this to an ExternalName Service. Services created in
+		// 0.1 will still have ClusterIP set, which is Forbidden for ExternalName
+		// Services. Ensure that we drop the ClusterIP if it is set in the spec.
+		Name: "drop cluster ip",
+		Objects: []runtime.Object{
+			route("default", "cluster-ip", WithConfigTarget("config"),
+				WithDomain, WithDomainInternal, WithAddress, WithInitRouteConditions,
+				MarkTrafficAssigned, MarkIngressReady, WithStatusTraffic(
+					v1alpha1.TrafficTarget{
+						ConfigurationName: "config",
+						RevisionName:      "config-00001",
+						Percent:           100,
+					}),
+				WithStatusActiveTargets(
+					v1alpha1.ActiveTarget{RevisionName: "config-00001", Percent: 100, Active: true})),
+			cfg("default", "config",
+				WithGeneration(1), WithLatestCreated, WithLatestReady,
+				// The Route controller attaches our label to this Configuration.
+				WithConfigLabel("serving.knative.dev/route", "cluster-ip"),
+			),
+			rev("default", "config", 1, MarkRevisionReady),
+			simpleReadyIngress(
+				route("default", "cluster-ip", WithConfigTarget("config"), WithDomain),
+				&traffic.Config{
+					Targets: map[string][]traffic.RevisionTarget{
+						"": {{
+							TrafficTarget: v1alpha1.TrafficTarget{
+								ConfigurationName: "config",
+								// Use the Revision name from the config.
+								RevisionName: rev("default", "config", 1).Name,
+								Percent:      100,
+							},
+							Active: true,
+						}},
+					},
+				},
+			),
+			simpleK8sService(route("default", "cluster-ip",
+				WithConfigTarget("config")), WithClusterIP("127.0.0.1")),
+		},
+		WantUpdates: []clientgotesting.UpdateActionImpl{{
+			Object: simpleK8sService(route("default", "cluster-ip", WithConfigTarget("config"))),
+		}},
+		Key: "default/cluster-ip",
+	}, {
+		// Make sure we fix the external name if something messes with it.
+		Name: "fix external name",
+		Objects: []runtime.Object{
+			route("default", "external-name", WithConfigTarget("config"),
+				WithDomain, WithDomainInternal, WithAddress, WithInitRouteConditions,
+				MarkTrafficAssigned, MarkIngressReady, WithStatusTraffic(
+					v1alpha1.TrafficTarget{
+						ConfigurationName: "config",
+						RevisionName:      "config-00001",
+						Percent:           100,
+					}),
+				WithStatusActiveTargets(
+					v1alpha1.ActiveTarget{RevisionName: "config-00001", Percent: 100, Active: true})),
+			cfg("default", "config",
+				WithGeneration(1), WithLatestCreated, WithLatestReady,
+				// The Route controller attaches our label to this Configuration.
+				WithConfigLabel("serving.knative.dev/route", "external-name"),
+			),
+			rev("default", "config", 1, MarkRevisionReady),
+			simpleReadyIngress(
+				route("default", "external-name", WithConfigTarget("config"), WithDomain),
+				&traffic.Config{
+					Targets: map[string][]traffic.RevisionTarget{
+						"": {{
+							TrafficTarget: v1alpha1.TrafficTarget{
+								ConfigurationName: "config",
+								// Use the Revision name from the config.
+								RevisionName: rev("default", "config", 1).Name,
+								Percent:      100,
+							},
+							Active: true,
+						}},
+					},
+				},
+			),
+			simpleK8sService(route("default", "external-name",
+				WithConfigTarget("config")), WithExternalName("this-is-the-wrong-name")),
+		},
+		WantUpdates: []clientgotesting.UpdateActionImpl{{
+			Object: simpleK8sService(route("default", "external-name", WithConfigTarget("config"))),
+		}},
+		Key: "default/external-name",
+	}, {
+		Name: "reconcile cluster ingress mutation",
+		Objects: []runtime.Object{
+			route("default", "ingress-mutation", WithConfigTarget("config"),
+				WithDomain, WithDomainInternal, WithAddress, WithInitRouteConditions,
+				MarkTrafficAssigned, MarkIngressReady, WithStatusTraffic(
+					v1alpha1.TrafficTarget{
+						ConfigurationName: "config",
+						RevisionName:      "config-00001",
+						Percent:           100,
+					}),
+				WithStatusActiveTargets(
+					v1alpha1.ActiveTarget{RevisionName: "config-00001", Percent: 100, Active: true})),
+			cfg("default", "config",
+				WithGeneration(1), WithLatestCreated, WithLatestReady,
+				// The Route controller attaches our label to this Configuration.
+				WithConfigLabel("serving.knative.dev/route", "ingress-mutation"),
+			),
+			rev("default", "config", 1, MarkRevisionReady),
+			mutateIngress(simpleReadyIngress(
+				route("default", "ingress-mutation", WithConfigTarget("config"), WithDomain),
+				&traffic.Config{
+					Targets: map[string][]traffic.RevisionTarget{
+						"": {{
+							TrafficTarget: v1alpha1.TrafficTarget{
+								ConfigurationName: "config",
+								// Use the Revision name from the config.
+								RevisionName: rev("default", "config", 1).Name,
+								Percent:      100,
+							},
+							Active: true,
+						}},
+					},
+				},
+			)),
+			simpleK8sService(route("default", "ingress-mutation", WithConfigTarget("config"))),
+		},
+		WantUpdates: []clientgotesting.UpdateActionImpl{{
+			Object: simpleReadyIngress(
+				route("default", "ingress-mutation", WithConfigTarget("config"), WithDomain),
+				&traffic.Config{
+					Targets: map[string][]traffic.RevisionTarget{
+						"": {{
+							TrafficTarget: v1alpha1.TrafficTarget{
+								ConfigurationName: "config",
+								// Use the Revision name from the config.
+								RevisionName: rev("default", "config", 1).Name,
+								Percent:      100,
+							},
+							Active: true,
+						}},
+					},
+				},
+			),
+		}},
+		Key:                     "default/ingress-mutation",
+		SkipNamespaceValidation: true,
+	}, {
+		Name: "switch to a different config",
+		Objects: []runtime.Object{
+			// The status reflects "oldconfig", but the spec "newconfig".
+			route("default", "change-configs", WithConfigTarget("newconfig"),
+				WithDomain, WithDomainInternal, WithAddress, WithInitRouteConditions,
+				MarkTrafficAssigned, MarkIngressReady, WithStatusTraffic(
+					v1alpha1.TrafficTarget{
+						ConfigurationName: "oldconfig",
+						RevisionName:      "oldconfig-00001",
+						Percent:           100,
+					}),
+				WithStatusActiveTargets(
+					v1alpha1.ActiveTarget{RevisionName: "oldconfig-00001", Percent: 100, Active: true})),
+			// Both configs exist, but only "oldconfig" is labelled.
+			cfg("default", "oldconfig",
+				WithGeneration(1), WithLatestCreated, WithLatestReady,
+				// The Route controller attaches our label to this Configuration.
+				WithConfigLabel("serving.knative.dev/route", "change-configs"),
+			),
+			cfg("default", "newconfig",
+				WithGeneration(1), WithLatestCreated, WithLatestReady),
+			rev("default", "oldconfig", 1, MarkRevisionReady),
+			rev("default", "newconfig", 1, MarkRevisionReady),
+			simpleReadyIngress(
+				route("default", "change-configs", WithConfigTarget("oldconfig"), WithDomain),
+				&traffic.Config{
+					Targets: map[string][]traffic.RevisionTarget{
+						"": {{
+							TrafficTarget: v1alpha1.TrafficTarget{
+								ConfigurationName: "oldconfig",
+								// Use the Revision name from the config.
+								RevisionName: rev("default", "oldconfig", 1).Name,
+								Percent:      100,
+							},
+							Active: true,
+						}},
+					},
+				},
+			),
+			simpleK8sService(route("default", "change-configs", WithConfigTarget("oldconfig"))),
+		},
+		WantUpdates: []clientgotesting.UpdateActionImpl{{
+			// Updated to point to "newconfig" things.
+			Object: simpleReadyIngress(
+				route("default", "change-configs", WithConfigTarget("newconfig"), WithDomain),
+				&traffic.Config{
+					Targets: map[string][]traffic.RevisionTarget{
+						"": {{
+							TrafficTarget: v1alpha1.TrafficTarget{
+								ConfigurationName: "newconfig",
+								// Use the Revision name from the config.
+								RevisionName: rev("default", "newconfig", 1).Name,
+								Percent:      100,
+							},
+							Active: true,
+						}},
+					},
+				},
+			),
+		}},
+		WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
+			// Status updated to "newconfig"
+			Object: route("default", "change-configs", WithConfigTarget("newconfig"),
+				WithDomain, WithDomainInternal, WithAddress, WithInitRouteConditions,
+				MarkTrafficAssigned, MarkIngressReady, WithStatusTraffic(
+					v1alpha1.TrafficTarget{
+						ConfigurationName: "newconfig",
+						RevisionName:      "newconfig-00001",
+						Percent:           100,
+					}),
+				WithStatusActiveTargets(
+					v1alpha1.ActiveTarget{RevisionName: "newconfig-00001", Percent: 100, Active: true})),
+		}},
+		Key: "default/change-configs",
+	}, {
+		Name: "configuration missing",
+		Objects: []runtime.Object{
+			route("default", "config-missing", WithConfigTarget("not-found")),
+		},
+		WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
+			Object: route("default", "config-missing", WithConfigTarget("not-found"),
+				WithInitRouteConditions, MarkMissingTrafficTarget("Configuration", "not-found"),
+				WithTargetStatuses(v1alpha1.TargetStatus{
+					Reason:  "ConfigurationMissing",
+					Message: `Configuration "not-found" referenced in traffic not found.`,
+				})),
+		}},
+		Key: "default/config-missing",
+	}, {
+		Name: "revision missing (direct)",
+		Objects: []runtime.Object{
+			route("default", "missing-revision-direct", WithRevTarget("not-found")),
+			cfg("default", "config",
+				WithGeneration(1), WithLatestCreated, WithLatestReady),
+		},
+		WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
+			Object: route("default", "missing-revision-direct", WithRevTarget("not-found"),
+				WithInitRouteConditions, MarkMissingTrafficTarget("Revision", "not-found"),
+				WithTargetStatuses(v1alpha1.TargetStatus{
+					RevisionName: "not-found",
+					Reason:       "RevisionMissing",
+					Message:      `Revision "not-found" referenced in traffic not found.`,
+				})),
+		}},
+		Key: "default/missing-revision-direct",
+	}, {
+		Name: "revision missing (indirect)",
+		Objects: []runtime.Object{
+			route("default", "missing-revision-indirect", WithConfigTarget("config")),
+			cfg("default", "config",
+				WithGeneration(1), WithLatestCreated, WithLatestReady),
+		},
+		WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
+			Object: route("default", "missing-revision-indirect", WithConfigTarget("config"),
+				WithInitRouteConditions, MarkMissingTrafficTarget("Revision", "config-00001"),
+				WithTargetStatuses(v1alpha1.TargetStatus{
+					Reason:  "RevisionMissing",
+					Message: `Revision "config-00001" referenced in traffic not found.`,
+				})),
+		}},
+		Key: "default/missing-revision-indirect",
+	}, {
+		Name:    "target names both configuration and revision",
+		WantErr: true,
+		Objects: []runtime.Object{
+			route("default", "both-set", WithSpecTraffic(
+				v1alpha1.TrafficTarget{
+					ConfigurationName: "blue",
+					RevisionName:      "blue-00001",
+					Percent:           100,
+				})),
+			cfg("default", "blue",
+				WithGeneration(1), WithLatestCreated, WithLatestReady),
+			rev("default", "blue", 1, MarkRevisionReady),
+		},
+		WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
+			Object: route("default", "both-set", WithSpecTraffic(
+				v1alpha1.TrafficTarget{
+					ConfigurationName: "blue",
+					RevisionName:      "blue-00001",
+					Percent:           100,
+				}),
+				WithInitRouteConditions,
+				MarkInvalidTrafficTarget("expected exactly one, got both: spec.traffic[0].configurationName, spec.traffic[0].revisionName"),
+				WithTargetStatuses(v1alpha1.TargetStatus{
+					RevisionName: "blue-00001",
+					Reason:       "InvalidTrafficTarget",
+					Message:      "Traffic target is invalid: expected exactly one, got both: spec.traffic[0].configurationName, spec.traffic[0].revisionName.",
+				})),
+		}},
+		WantEvents: []string{
+			// Update validation rejects the malformed Route as the webhook does.
+			Eventf(corev1.EventTypeWarning, "UpdateFailed", "Failed to update status for Route %q: %v",
+				"both-set", "expected exactly one, got both: spec.traffic[0].configurationName, spec.traffic[0].revisionName"),
+		},
+		Key: "default/both-set",
+	}, {
+		Name:    "target names neither configuration nor revision",
+		WantErr: true,
+		Objects: []runtime.Object{
+			route("default", "neither-set", WithSpecTraffic(
+				v1alpha1.TrafficTarget{
+					ConfigurationName: "blue",
+					Percent:           50,
+				}, v1alpha1.TrafficTarget{
+					Name:    "nothing",
+					Percent: 50,
+				})),
+			cfg("default", "blue",
+				WithGeneration(1), WithLatestCreated, WithLatestReady),
+			rev("default", "blue", 1, MarkRevisionReady),
+		},
+		WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
+			Object: route("default", "neither-set", WithSpecTraffic(
+				v1alpha1.TrafficTarget{
+					ConfigurationName: "blue",
+					Percent:           50,
+				}, v1alpha1.TrafficTarget{
+					Name:    "nothing",
+					Percent: 50,
+				}),
+				WithInitRouteConditions,
+				MarkInvalidTrafficTarget("expected exactly one, got neither: spec.traffic[1].configurationName, spec.traffic[1].configurationSelector, spec.traffic[1].revisionName"),
+				MarkTrafficTargetsNotReady(
+					"1 of 2 targets ready; Traffic target is invalid: expected exactly one, got neither: spec.traffic[1].configurationName, spec.traffic[1].configurationSelector, spec.traffic[1].revisionName"),
+				WithTargetStatuses(v1alpha1.TargetStatus{
+					RevisionName: "blue-00001",
+					Ready:        true,
+				}, v1alpha1.TargetStatus{
+					Name:    "nothing",
+					Reason:  "InvalidTrafficTarget",
+					Message: "Traffic target is invalid: expected exactly one, got neither: spec.traffic[1].configurationName, spec.traffic[1].configurationSelector, spec.traffic[1].revisionName.",
+				})),
+		}},
+		WantEvents: []string{
+			// Update validation rejects the malformed Route as the webhook does.
+			Eventf(corev1.EventTypeWarning, "UpdateFailed", "Failed to update status for Route %q: %v",
+				"neither-set", "expected exactly one, got neither: spec.traffic[1].configurationName, spec.traffic[1].configurationSelector, spec.traffic[1].revisionName"),
+		},
+		Key: "default/neither-set",
+	}, {
+		Name: "one of several targets missing",
+		Objects: []runtime.Object{
+			route("default", "partially-missing", WithSpecTraffic(
+				v1alpha1.TrafficTarget{
+					ConfigurationName: "blue",
+					Percent:           50,
+				}, v1alpha1.TrafficTarget{
+					RevisionName: "green-00002",
+					Percent:      50,
+				})),
+			cfg("default", "blue",
+				WithGeneration(1), WithLatestCreated, WithLatestReady),
+			rev("default", "blue", 1, MarkRevisionReady),
+		},
+		WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
+			Object: route("default", "partially-missing", WithSpecTraffic(
+				v1alpha1.TrafficTarget{
+					ConfigurationName: "blue",
+					Percent:           50,
+				}, v1alpha1.TrafficTarget{
+					RevisionName: "green-00002",
+					Percent:      50,
+				}),
+				WithInitRouteConditions, MarkMissingTrafficTarget("Revision", "green-00002"),
+				MarkTrafficTargetsNotReady(
+					`1 of 2 targets ready; Revision "green-00002" referenced in traffic not found`),
+				WithTargetStatuses(v1alpha1.TargetStatus{
+					RevisionName: "blue-00001",
+					Ready:        true,
+				}, v1alpha1.TargetStatus{
+					RevisionName: "green-00002",
+					Reason:       "RevisionMissing",
+					Message:      `Revision "green-00002" referenced in traffic not found.`,
+				})),
+		}},
+		Key: "default/partially-missing",
+	}, {
+		Name: "named targets report their own status",
+		Objects: []runtime.Object{
+			route("default", "mixed", WithSpecTraffic(
+				v1alpha1.TrafficTarget{
+					Name:              "current",
+					ConfigurationName: "blue",
+					Percent:           50,
+				}, v1alpha1.TrafficTarget{
+					Name:         "candidate",
+					RevisionName: "green-00001",
+					Percent:      50,
+				}, v1alpha1.TrafficTarget{
+					Name:              "next",
+					ConfigurationName: "red",
+				})),
+			cfg("default", "blue",
+				WithGeneration(1), WithLatestCreated, WithLatestReady),
+			rev("default", "blue", 1, MarkRevisionReady),
+			cfg("default", "green",
+				WithGeneration(1), WithLatestCreated),
+			rev("default", "green", 1, MarkContainerMissing),
+			cfg("default", "red", WithGeneration(1), WithLatestCreated),
+			rev("default", "red", 1, WithInitRevConditions),
+		},
+		WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
+			Object: route("default", "mixed", WithSpecTraffic(
+				v1alpha1.TrafficTarget{
+					Name:              "current",
+					ConfigurationName: "blue",
+					Percent:           50,
+				}, v1alpha1.TrafficTarget{
+					Name:         "candidate",
+					RevisionName: "green-00001",
+					Percent:      50,
+				}, v1alpha1.TrafficTarget{
+					Name:              "next",
+					ConfigurationName: "red",
+				}),
+				WithInitRouteConditions, MarkRevisionFailed("green-00001"),
+				MarkTrafficTargetsNotReady(`1 of 3 targets ready; `+
+					`Revision "green-00001" failed to become ready; `+
+					`Configuration "red" is waiting for a Revision to become ready`),
+				WithTargetStatuses(v1alpha1.TargetStatus{
+					Name:         "current",
+					RevisionName: "blue-00001",
+					Ready:        true,
+				}, v1alpha1.TargetStatus{
+					Name:         "candidate",
+					RevisionName: "green-00001",
+					Reason:       "RevisionMissing",
+					Message:      `Revision "green-00001" failed to become ready.`,
+				}, v1alpha1.TargetStatus{
+					Name:    "next",
+					Reason:  "RevisionMissing",
+					Message: `Configuration "red" is waiting for a Revision to become ready.`,
+				})),
+		}},
+		Key: "default/mixed",
+	}, {
+		Name: "configuration selector matches one",
+		Objects: []runtime.Object{
+			route("default", "selects-one", WithConfigSelectorTarget("app", "blue")),
+			cfg("default", "blue",
+				WithGeneration(1), WithLatestCreated, WithLatestReady,
+				WithConfigLabel("app", "blue")),
+			rev("default", "blue", 1, MarkRevisionReady),
+			cfg("default", "green",
+				WithGeneration(1), WithLatestCreated, WithLatestReady,
+				WithConfigLabel("app", "green")),
+		},
+		WantCreates: []metav1.Object{
+			resources.MakeClusterIngress(
+				route("default", "selects-one", WithConfigSelectorTarget("app", "blue"), WithDomain),
+				&traffic.Config{
+					Targets: map[string][]traffic.RevisionTarget{
+						"": {{
+							TrafficTarget: v1alpha1.TrafficTarget{
+								// The selector is resolved to the Configuration it matches.
+								ConfigurationName: "blue",
+								RevisionName:      rev("default", "blue", 1).Name,
+								Percent:           100,
+							},
+							Active: true,
+						}},
+					},
+				},
+				testRevisionPort,
+			),
+		},
+		WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
+			Object: route("default", "selects-one", WithConfigSelectorTarget("app", "blue"),
+				WithDomain, WithDomainInternal, WithAddress, WithInitRouteConditions,
+				MarkTrafficAssigned, WithStatusTraffic(v1alpha1.TrafficTarget{
+					ConfigurationName: "blue",
+					RevisionName:      "blue-00001",
+					Percent:           100,
+				}),
+				WithStatusActiveTargets(
+					v1alpha1.ActiveTarget{RevisionName: "blue-00001", Percent: 100, Active: true})),
+		}},
+		WantEvents: []string{
+			Eventf(corev1.EventTypeNormal, "Created", "Created ClusterIngress %q", ""),
+		},
+		Key:                     "default/selects-one",
+		SkipNamespaceValidation: true,
+	}, {
+		Name: "configuration selector split evenly",
+		Objects: []runtime.Object{
+			route("default", "previews", WithSpecTraffic(previews)),
+			cfg("default", "blue",
+				WithGeneration(1), WithLatestCreated, WithLatestReady,
+				WithConfigLabel("env", "preview")),
+			rev("default", "blue", 1, MarkRevisionReady),
+			cfg("default", "green",
+				WithGeneration(1), WithLatestCreated, WithLatestReady,
+				WithConfigLabel("env", "preview")),
+			rev("default", "green", 1, MarkRevisionReady),
+			cfg("default", "red",
+				WithGeneration(1), WithLatestCreated, WithLatestReady,
+				WithConfigLabel("env", "preview")),
+			rev("default", "red", 1, MarkRevisionReady),
+			cfg("default", "prod",
+				WithGeneration(1), WithLatestCreated, WithLatestReady,
+				WithConfigLabel("env", "prod")),
+		},
+		WantCreates: []metav1.Object{
+			resources.MakeClusterIngress(
+				route("default", "previews", WithSpecTraffic(previews), WithDomain),
+				&traffic.Config{
+					Targets: map[string][]traffic.RevisionTarget{
+						"": {{
+							TrafficTarget: v1alpha1.TrafficTarget{
+								ConfigurationName: "blue",
+								RevisionName:      "blue-00001",
+								Percent:           33,
+							},
+							Active: true,
+						}, {
+							TrafficTarget: v1alpha1.TrafficTarget{
+								ConfigurationName: "green",
+								RevisionName:      "green-00001",
+								Percent:           33,
+							},
+							Active: true,
+						}, {
+							TrafficTarget: v1alpha1.TrafficTarget{
+								ConfigurationName: "red",
+								RevisionName:      "red-00001",
+								Percent:           34,
+							},
+							Active: true,
+						}},
+					},
+				},
+				testRevisionPort,
+			),
+		},
+		WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
+			Object: route("default", "previews", WithSpecTraffic(previews),
+				WithDomain, WithDomainInternal, WithAddress, WithInitRouteConditions,
+				MarkTrafficAssigned, WithStatusTraffic(v1alpha1.TrafficTarget{
+					ConfigurationName: "blue",
+					RevisionName:      "blue-00001",
+					Percent:           33,
+				}, v1alpha1.TrafficTarget{
+					ConfigurationName: "green",
+					RevisionName:      "green-00001",
+					Percent:           33,
+				}, v1alpha1.TrafficTarget{
+					ConfigurationName: "red",
+					RevisionName:      "red-00001",
+					Percent:           34,
+				}),
+				WithStatusActiveTargets(
+					v1alpha1.ActiveTarget{RevisionName: "blue-00001", Percent: 33, Active: true},
+					v1alpha1.ActiveTarget{RevisionName: "green-00001", Percent: 33, Active: true},
+					v1alpha1.ActiveTarget{RevisionName: "red-00001", Percent: 34, Active: true})),
+		}},
+		WantEvents: []string{
+			Eventf(corev1.EventTypeNormal, "Created", "Created ClusterIngress %q", ""),
+		},
+		Key:                     "default/previews",
+		SkipNamespaceValidation: true,
+	}, {
+		Name: "configuration selector matches none",
+		Objects: []runtime.Object{
+			route("default", "selects-none", WithConfigSelectorTarget("app", "red")),
+			cfg("default", "blue",
+				WithGeneration(1), WithLatestCreated, WithLatestReady,
+				WithConfigLabel("app", "blue")),
+			rev("default", "blue", 1, MarkRevisionReady),
+		},
+		WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
+			Object: route("default", "selects-none", WithConfigSelectorTarget("app", "red"),
+				WithInitRouteConditions, MarkUnresolvedConfigurationSelector("app=red"),
+				WithTargetStatuses(v1alpha1.TargetStatus{
+					Reason:  "ConfigurationMissing",
+					Message: `No Configuration matches selector "app=red" referenced in traffic.`,
+				})),
+		}},
+		Key: "default/selects-none",
+	}, {
+		Name: "configuration selector matches several",
+		Objects: []runtime.Object{
+			route("default", "selects-many", WithConfigSelectorTarget("team", "colors")),
+			cfg("default", "green",
+				WithGeneration(1), WithLatestCreated, WithLatestReady,
+				WithConfigLabel("team", "colors")),
+			rev("default", "green", 1, MarkRevisionReady),
+			cfg("default", "blue",
+				WithGeneration(1), WithLatestCreated, WithLatestReady,
+				WithConfigLabel("team", "colors")),
+			rev("default", "blue", 1, MarkRevisionReady),
+		},
+		WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
+			Object: route("default", "selects-many", WithConfigSelectorTarget("team", "colors"),
+				WithInitRouteConditions, MarkUnresolvedConfigurationSelector("team=colors", "blue", "green"),
+				WithTargetStatuses(v1alpha1.TargetStatus{
+					Reason:  "ConfigurationAmbiguous",
+					Message: `Configurations "blue", "green" all match selector "team=colors" referenced in traffic.`,
+				})),
+		}},
+		Key: "default/selects-many",
+	}, {
+		Name: "cross-namespace traffic is disabled",
+		Objects: []runtime.Object{
+			route("default", "cross-namespace", WithSpecTraffic(v1alpha1.TrafficTarget{
+				RevisionName: "stable-00001",
+				Namespace:    "stable",
+				Percent:      100,
+			})),
+			cfg("stable", "stable",
+				WithGeneration(1), WithLatestCreated, WithLatestReady),
+			rev("stable", "stable", 1, MarkRevisionReady),
+		},
+		WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
+			Object: route("default", "cross-namespace", WithSpecTraffic(v1alpha1.TrafficTarget{
+				RevisionName: "stable-00001",
+				Namespace:    "stable",
+				Percent:      100,
+			}), WithInitRouteConditions, MarkCrossNamespaceTrafficDisabled("stable")),
+		}},
+		Key: "default/cross-namespace",
+	}, {
+		Name: "domain too long",
+		Objects: []runtime.Object{
+			route("default", longRouteName, WithConfigTarget("config"),
+				WithRouteLabel("app", "long")),
+			cfg("default", "config",
+				WithGeneration(1), WithLatestCreated, WithLatestReady),
+			rev("default", "config", 1, MarkRevisionReady),
+		},
+		WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
+			Object: route("default", longRouteName, WithConfigTarget("config"),
+				WithRouteLabel("app", "long"), WithInitRouteConditions,
+				MarkInvalidDomain(longRouteName+".default."+longDomainSuffix,
+					"must be no more than 253 characters")),
+		}},
+		Key: "default/" + longRouteName,
+	}, {
+		Name: "pinned route becomes ready",
+		Objects: []runtime.Object{
+			route("default", "pinned-becomes-ready", WithRevTarget(
+				// Use the Revision name from the config
+				rev("default", "config", 1).Name)),
+			cfg("default", "config",
+				WithGeneration(1), WithLatestCreated, WithLatestReady),
+			rev("default", "config", 1, MarkRevisionReady),
+			simpleK8sService(route("default", "pinned-becomes-ready", WithConfigTarget("config"))),
+			simpleReadyIngress(
+				route("default", "pinned-becomes-ready", WithConfigTarget("config"),
+					WithDomain),
+				&traffic.Config{
+					Targets: map[string][]traffic.RevisionTarget{
+						"": {{
+							TrafficTarget: v1alpha1.TrafficTarget{
+								ConfigurationName: "config",
+								// Use the Revision name from the config.
+								RevisionName: rev("default", "config", 1).Name,
+								Percent:      100,
+							},
+							Active: true,
+						}},
+					},
+				},
+			),
+		},
+		WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
+			Object: route("default", "pinned-becomes-ready",
+				// Use the Revision name from the config
+				WithRevTarget(rev("default", "config", 1).Name),
+				WithDomain, WithDomainInternal, WithAddress, WithInitRouteConditions,
+				MarkTrafficAssigned, MarkIngressReady, WithStatusTraffic(
+					v1alpha1.TrafficTarget{
+						ConfigurationName: "config",
+						RevisionName:      rev("default", "config", 1).Name,
+						Percent:           100,
+					}),
+				WithStatusActiveTargets(
+					v1alpha1.ActiveTarget{RevisionName: rev("default", "config", 1).Name, Percent: 100, Active: true})),
+		}},
+		Key:                     "default/pinned-becomes-ready",
+		SkipNamespaceValidation: true,
+	}, {
+		Name: "pinned route reports the ancestry of its revision",
+		Objects: []runtime.Object{
+			route("default", "pinned-ancestry", WithRevTarget(rev("default", "config", 1).Name)),
+			// The Configuration belongs to a Service.
+			cfg("default", "config",
+				WithGeneration(1), WithLatestCreated, WithLatestReady,
+				WithConfigLabel(serving.ServiceLabelKey, "svc")),
+			rev("default", "config", 1, MarkRevisionReady),
+			simpleK8sService(route("default", "pinned-ancestry", WithConfigTarget("config"))),
+			simpleReadyIngress(
+				route("default", "pinned-ancestry", WithConfigTarget("config"),
+					WithDomain),
+				&traffic.Config{
+					Targets: map[string][]traffic.RevisionTarget{
+						"": {{
+							TrafficTarget: v1alpha1.TrafficTarget{
+								ConfigurationName: "config",
+								RevisionName:      rev("default", "config", 1).Name,
+								Percent:           100,
+							},
+							Active: true,
+						}},
+					},
+				},
+			),
+		},
+		// The same Configuration and Service a run-latest target would
+		// report are reported for the pinned Revision.
+		WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
+			Object: route("default", "pinned-ancestry",
+				WithRevTarget(rev("default", "config", 1).Name),
+				WithDomain, WithDomainInternal, WithAddress, WithInitRouteConditions,
+				MarkTrafficAssigned, MarkIngressReady, WithStatusTraffic(
+					v1alpha1.TrafficTarget{
+						ConfigurationName: "config",
+						ServiceName:       "svc",
+						RevisionName:      rev("default", "config", 1).Name,
+						Percent:           100,
+					}),
+				WithStatusActiveTargets(
+					v1alpha1.ActiveTarget{RevisionName: rev("default", "config", 1).Name, Percent: 100, Active: true})),
+		}},
+		Key:                     "default/pinned-ancestry",
+		SkipNamespaceValidation: true,
+	}, {
+		Name: "route pinned to a configuration generation stays put",
+		Objects: []runtime.Object{
+			route("default", "pinned-generation", WithSpecTraffic(v1alpha1.TrafficTarget{
+				ConfigurationName:       "config",
+				ConfigurationGeneration: apitesting.Int64Ptr(1),
+				Percent:                 100,
+			})),
+			// A newer Revision of the Configuration has become ready.
+			cfg("default", "config",
+				WithGeneration(2), WithLatestCreated, WithLatestReady),
+			rev("default", "config", 1, MarkRevisionReady,
+				WithRevisionLabel(serving.ConfigurationLabelKey, "config"),
+				WithRevisionLabel(serving.ConfigurationMetadataGenerationLabelKey, "1")),
+			rev("default", "config", 2, MarkRevisionReady,
+				WithRevisionLabel(serving.ConfigurationLabelKey, "config"),
+				WithRevisionLabel(serving.ConfigurationMetadataGenerationLabelKey, "2")),
+			simpleK8sService(route("default", "pinned-generation")),
+			simpleReadyIngress(
+				route("default", "pinned-generation", WithDomain),
+				&traffic.Config{
+					Targets: map[string][]traffic.RevisionTarget{
+						"": {{
+							TrafficTarget: v1alpha1.TrafficTarget{
+								ConfigurationName:       "config",
+								ConfigurationGeneration: apitesting.Int64Ptr(1),
+								RevisionName:            rev("default", "config", 1).Name,
+								Percent:                 100,
+							},
+							Active: true,
+						}},
+					},
+				},
+			),
+		},
+		WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
+			Object: route("default", "pinned-generation",
+				WithSpecTraffic(v1alpha1.TrafficTarget{
+					ConfigurationName:       "config",
+					ConfigurationGeneration: apitesting.Int64Ptr(1),
+					Percent:                 100,
+				}),
+				WithDomain, WithDomainInternal, WithAddress, WithInitRouteConditions,
+				MarkTrafficAssigned, MarkIngressReady, WithStatusTraffic(
+					v1alpha1.TrafficTarget{
+						ConfigurationName: "config",
+						RevisionName:      rev("default", "config", 1).Name,
+						Percent:           100,
+					}),
+				WithStatusActiveTargets(
+					v1alpha1.ActiveTarget{RevisionName: rev("default", "config", 1).Name, Percent: 100, Active: true})),
+		}},
+		Key:                     "default/pinned-generation",
+		SkipNamespaceValidation: true,
+	}, {
+		Name: "traffic split becomes ready",
+		Objects: []runtime.Object{
+			route("default", "named-traffic-split", WithSpecTraffic(
+				v1alpha1.TrafficTarget{
+					ConfigurationName: "blue",
+					Percent:           50,
+				}, v1alpha1.TrafficTarget{
+					ConfigurationName: "green",
+					Percent:           50,
+				})),
+			cfg("default", "blue",
+				WithGeneration(1), WithLatestCreated, WithLatestReady),
+			cfg("default", "green",
+				WithGeneration(1), WithLatestCreated, WithLatestReady),
+			rev("default", "blue", 1, MarkRevisionReady),
+			rev("default", "green", 1, MarkRevisionReady),
+		},
+		WantCreates: []metav1.Object{
+			resources.MakeClusterIngress(
+				route("default", "named-traffic-split", WithDomain, WithSpecTraffic(
+					v1alpha1.TrafficTarget{
+						ConfigurationName: "blue",
+						Percent:           50,
+					}, v1alpha1.TrafficTarget{
+						ConfigurationName: "green",
+						Percent:           50,
+					})),
+				&traffic.Config{
+					Targets: map[string][]traffic.RevisionTarget{
+						"": {{
+							TrafficTarget: v1alpha1.TrafficTarget{
+								ConfigurationName: "blue",
+								// Use the Revision name from the config.
+								RevisionName: rev("default", "blue", 1).Name,
+								Percent:      50,
+							},
+							Active: true,
+						}, {
+							TrafficTarget: v1alpha1.TrafficTarget{
+								ConfigurationName: "green",
+								// Use the Revision name from the config.
+								RevisionName: rev("default", "green", 1).Name,
+								Percent:      50,
+							},
+							Active: true,
+						}},
+					},
+				},
+				testRevisionPort,
+			),
+		},
+		WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
+			Object: route("default", "named-traffic-split",
+				WithSpecTraffic(v1alpha1.TrafficTarget{
+					ConfigurationName: "blue",
+					Percent:           50,
+				}, v1alpha1.TrafficTarget{
+					ConfigurationName: "green",
+					Percent:           50,
+				}),
+				WithDomain, WithDomainInternal, WithAddress, WithInitRouteConditions,
+				MarkTrafficAssigned, WithStatusTraffic(
+					v1alpha1.TrafficTarget{
+						ConfigurationName: "blue",
+						RevisionName:      "blue-00001",
+						Percent:           50,
+					}, v1alpha1.TrafficTarget{
+						ConfigurationName: "green",
+						RevisionName:      "green-00001",
+						Percent:           50,
+					}),
+				WithStatusActiveTargets(
+					v1alpha1.ActiveTarget{RevisionName: "blue-00001", Percent: 50, Active: true},
+					v1alpha1.ActiveTarget{RevisionName: "green-00001", Percent: 50, Active: true})),
+		}},
+		WantEvents: []string{
+			Eventf(corev1.EventTypeNormal, "Created", "Created ClusterIngress %q", ""),
+		},
+		Key:                     "default/named-traffic-split",
+		SkipNamespaceValidation: true,
+	}, {
+		Name: "mirror target becomes ready",
+		Objects: []runtime.Object{
+			route("default", "mirrored", WithSpecTraffic(
+				v1alpha1.TrafficTarget{
+					ConfigurationName: "blue",
+					Percent:           100,
+				}, v1alpha1.TrafficTarget{
+					ConfigurationName: "green",
+					Mirror:            true,
+				})),
+			cfg("default", "blue",
+				WithGeneration(1), WithLatestCreated, WithLatestReady),
+			cfg("default", "green",
+				WithGeneration(1), WithLatestCreated, WithLatestReady),
+			rev("default", "blue", 1, MarkRevisionReady),
+			rev("default", "green", 1, MarkRevisionReady),
+		},
+		WantCreates: []metav1.Object{
+			resources.MakeClusterIngress(
+				route("default", "mirrored", WithDomain, WithSpecTraffic(
+					v1alpha1.TrafficTarget{
+						ConfigurationName: "blue",
+						Percent:           100,
+					}, v1alpha1.TrafficTarget{
+						ConfigurationName: "green",
+						Mirror:            true,
+					})),
+				&traffic.Config{
+					Targets: map[string][]traffic.RevisionTarget{
+						"": {{
+							TrafficTarget: v1alpha1.TrafficTarget{
+								ConfigurationName: "blue",
+								RevisionName:      rev("default", "blue", 1).Name,
+								Percent:           100,
+							},
+							Active: true,
+						}, {
+							TrafficTarget: v1alpha1.TrafficTarget{
+								ConfigurationName: "green",
+								RevisionName:      rev("default", "green", 1).Name,
+								Mirror:            true,
+							},
+							Active: true,
+						}},
+					},
+				},
+				testRevisionPort,
+			),
+		},
+		WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
+			Object: route("default", "mirrored",
+				WithSpecTraffic(v1alpha1.TrafficTarget{
+					ConfigurationName: "blue",
+					Percent:           100,
+				}, v1alpha1.TrafficTarget{
+					ConfigurationName: "green",
+					Mirror:            true,
+				}),
+				WithDomain, WithDomainInternal, WithAddress, WithInitRouteConditions,
+				MarkTrafficAssigned, WithStatusTraffic(
+					v1alpha1.TrafficTarget{
+						ConfigurationName: "blue",
+						RevisionName:      "blue-00001",
+						Percent:           100,
+					}, v1alpha1.TrafficTarget{
+						ConfigurationName: "green",
+						RevisionName:      "green-00001",
+						Mirror:            true,
+					}),
+				// The mirror takes no part in the split.
+				WithStatusActiveTargets(
+					v1alpha1.ActiveTarget{RevisionName: "blue-00001", Percent: 100, Active: true})),
+		}},
+		WantEvents: []string{
+			Eventf(corev1.EventTypeNormal, "Created", "Created ClusterIngress %q", ""),
+		},
+		Key:                     "default/mirrored",
+		SkipNamespaceValidation: true,
+	}, {
+		Name: "traffic split by path prefix",
+		Objects: []runtime.Object{
+			route("default", "versioned", WithSpecTraffic(
+				v1alpha1.TrafficTarget{
+					ConfigurationName: "blue",
+					Percent:           100,
+				}, v1alpha1.TrafficTarget{
+					Name:              "v2",
+					ConfigurationName: "green",
+					PathPrefix:        "/v2",
+				})),
+			cfg("default", "blue",
+				WithGeneration(1), WithLatestCreated, WithLatestReady),
+			cfg("default", "green",
+				WithGeneration(1), WithLatestCreated, WithLatestReady),
+			rev("default", "blue", 1, MarkRevisionReady),
+			rev("default", "green", 1, MarkRevisionReady),
+		},
+		WantCreates: []metav1.Object{
+			resources.MakeClusterIngress(
+				route("default", "versioned", WithDomain, WithSpecTraffic(
+					v1alpha1.TrafficTarget{
+						ConfigurationName: "blue",
+						Percent:           100,
+					}, v1alpha1.TrafficTarget{
+						Name:              "v2",
+						ConfigurationName: "green",
+						PathPrefix:        "/v2",
+					})),
+				&traffic.Config{
+					Targets: map[string][]traffic.RevisionTarget{
+						"": {{
+							TrafficTarget: v1alpha1.TrafficTarget{
+								ConfigurationName: "blue",
+								RevisionName:      rev("default", "blue", 1).Name,
+								Percent:           100,
+							},
+							Active: true,
+						}, {
+							TrafficTarget: v1alpha1.TrafficTarget{
+								Name:              "v2",
+								ConfigurationName: "green",
+								RevisionName:      rev("default", "green", 1).Name,
+								PathPrefix:        "/v2",
+							},
+							Active: true,
+						}},
+						"v2": {{
+							TrafficTarget: v1alpha1.TrafficTarget{
+								Name:              "v2",
+								ConfigurationName: "green",
+								RevisionName:      rev("default", "green", 1).Name,
+								PathPrefix:        "/v2",
+								Percent:           100,
+							},
+							Active: true,
+						}},
+					},
+				},
+				testRevisionPort,
+			),
+		},
+		WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
+			Object: route("default", "versioned",
+				WithSpecTraffic(v1alpha1.TrafficTarget{
+					ConfigurationName: "blue",
+					Percent:           100,
+				}, v1alpha1.TrafficTarget{
+					Name:              "v2",
+					ConfigurationName: "green",
+					PathPrefix:        "/v2",
+				}),
+				WithDomain, WithDomainInternal, WithAddress, WithInitRouteConditions,
+				MarkTrafficAssigned, WithStatusTraffic(
+					v1alpha1.TrafficTarget{
+						ConfigurationName: "blue",
+						RevisionName:      "blue-00001",
+						Percent:           100,
+					}, v1alpha1.TrafficTarget{
+						Name:              "v2",
+						ConfigurationName: "green",
+						RevisionName:      "green-00001",
+					}),
+				WithStatusActiveTargets(
+					v1alpha1.ActiveTarget{RevisionName: "blue-00001", Percent: 100, Active: true},
+					v1alpha1.ActiveTarget{RevisionName: "green-00001", Active: true})),
+		}},
+		WantEvents: []string{
+			Eventf(corev1.EventTypeNormal, "Created", "Created ClusterIngress %q", ""),
+		},
+		Key:                     "default/versioned",
+		SkipNamespaceValidation: true,
+	}, {
+		Name: "traffic split by request header",
+		Objects: []runtime.Object{
+			route("default", "canary", WithSpecTraffic(
+				v1alpha1.TrafficTarget{
+					ConfigurationName: "blue",
+					Percent:           100,
+				}, v1alpha1.TrafficTarget{
+					Name:              "canary",
+					ConfigurationName: "green",
+					Match: []v1alpha1.HeaderMatch{{
+						Name:  "x-canary",
+						Exact: "true",
+					}},
+				})),
+			cfg("default", "blue",
+				WithGeneration(1), WithLatestCreated, WithLatestReady),
+			cfg("default", "green",
+				WithGeneration(1), WithLatestCreated, WithLatestReady),
+			rev("default", "blue", 1, MarkRevisionReady),
+			rev("default", "green", 1, MarkRevisionReady),
+		},
+		WantCreates: []metav1.Object{
+			resources.MakeClusterIngress(
+				route("default", "canary", WithDomain, WithSpecTraffic(
+					v1alpha1.TrafficTarget{
+						ConfigurationName: "blue",
+						Percent:           100,
+					}, v1alpha1.TrafficTarget{
+						Name:              "canary",
+						ConfigurationName: "green",
+						Match: []v1alpha1.HeaderMatch{{
+							Name:  "x-canary",
+							Exact: "true",
+						}},
+					})),
+				&traffic.Config{
+					Targets: map[string][]traffic.RevisionTarget{
+						"": {{
+							TrafficTarget: v1alpha1.TrafficTarget{
+								ConfigurationName: "blue",
+								RevisionName:      rev("default", "blue", 1).Name,
+								Percent:           100,
+							},
+							Active: true,
+						}, {
+							TrafficTarget: v1alpha1.TrafficTarget{
+								Name:              "canary",
+								ConfigurationName: "green",
+								RevisionName:      rev("default", "green", 1).Name,
+								Match: []v1alpha1.HeaderMatch{{
+									Name:  "x-canary",
+									Exact: "true",
+								}},
+							},
+							Active: true,
+						}},
+						"canary": {{
+							TrafficTarget: v1alpha1.TrafficTarget{
+								Name:              "canary",
+								ConfigurationName: "green",
+								RevisionName:      rev("default", "green", 1).Name,
+								Match: []v1alpha1.HeaderMatch{{
+									Name:  "x-canary",
+									Exact: "true",
+								}},
+								Percent: 100,
+							},
+							Active: true,
+						}},
+					},
+				},
+				testRevisionPort,
+			),
+		},
+		WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
+			Object: route("default", "canary",
+				WithSpecTraffic(v1alpha1.TrafficTarget{
+					ConfigurationName: "blue",
+					Percent:           100,
+				}, v1alpha1.TrafficTarget{
+					Name:              "canary",
+					ConfigurationName: "green",
+					Match: []v1alpha1.HeaderMatch{{
+						Name:  "x-canary",
+						Exact: "true",
+					}},
+				}),
+				WithDomain, WithDomainInternal, WithAddress, WithInitRouteConditions,
+				MarkTrafficAssigned, WithStatusTraffic(
+					v1alpha1.TrafficTarget{
+						ConfigurationName: "blue",
+						RevisionName:      "blue-00001",
+						Percent:           100,
+					}, v1alpha1.TrafficTarget{
+						Name:              "canary",
+						ConfigurationName: "green",
+						RevisionName:      "green-00001",
+					}),
+				WithStatusActiveTargets(
+					v1alpha1.ActiveTarget{RevisionName: "blue-00001", Percent: 100, Active: true},
+					v1alpha1.ActiveTarget{RevisionName: "green-00001", Active: true})),
+		}},
+		WantEvents: []string{
+			Eventf(corev1.EventTypeNormal, "Created", "Created ClusterIngress %q", ""),
+		},
+		Key:                     "default/canary",
+		SkipNamespaceValidation: true,
+	}, {
+		Name: "same revision targets",
+		Objects: []runtime.Object{
+			route("default", "same-revision-targets", WithSpecTraffic(
+				v1alpha1.TrafficTarget{
+					Name:              "gray",
+					ConfigurationName: "gray",
+					Percent:           50,
+				}, v1alpha1.TrafficTarget{
+					Name:         "also-gray",
+					RevisionName: "gray-00001",
+					Percent:      50,
+				})),
+			cfg("default", "gray",
+				WithGeneration(1), WithLatestCreated, WithLatestReady),
+			rev("default", "gray", 1, MarkRevisionReady),
+		},
+		WantCreates: []metav1.Object{
+			resources.MakeClusterIngress(
+				route("default", "same-revision-targets", WithDomain, WithSpecTraffic(
+					v1alpha1.TrafficTarget{
+						Name:              "gray",
+						ConfigurationName: "gray",
+						Percent:           50,
+					}, v1alpha1.TrafficTarget{
+						Name:         "also-gray",
+						RevisionName: "gray-00001",
+						Percent:      50,
+					})),
+				&traffic.Config{
+					Targets: map[string][]traffic.RevisionTarget{
+						"": {{
+							TrafficTarget: v1alpha1.TrafficTarget{
+								Name:              "gray",
+								ConfigurationName: "gray",
+								// Use the Revision name from the config.
+								RevisionName: rev("default", "gray", 1).Name,
+								Percent:      100,
+							},
+							Active: true,
+						}},
+						"gray": {{
+							TrafficTarget: v1alpha1.TrafficTarget{
+								Name:              "gray",
+								ConfigurationName: "gray",
+								// Use the Revision name from the config.
+								RevisionName: rev("default", "gray", 1).Name,
+								Percent:      100,
+							},
+							Active: true,
+						}},
+						"also-gray": {{
+							TrafficTarget: v1alpha1.TrafficTarget{
+								Name:              "also-gray",
+								ConfigurationName: "gray",
+								// Use the Revision name from the config.
+								RevisionName: rev("default", "gray", 1).Name,
+								Percent:      100,
+							},
+							Active: true,
+						}},
+					},
+				},
+				testRevisionPort,
+			),
+		},
+		WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
+			Object: route("default", "same-revision-targets",
+				WithSpecTraffic(v1alpha1.TrafficTarget{
+					Name:              "gray",
+					ConfigurationName: "gray",
+					Percent:           50,
+				}, v1alpha1.TrafficTarget{
+					Name:         "also-gray",
+					RevisionName: "gray-00001",
+					Percent:      50,
+				}),
+				WithDomain, WithDomainInternal, WithAddress, WithInitRouteConditions,
+				MarkTrafficAssigned, WithStatusTraffic(
+					v1alpha1.TrafficTarget{
+						Name:              "gray",
+						ConfigurationName: "gray",
+						RevisionName:      "gray-00001",
+						Percent:           50,
+					}, v1alpha1.TrafficTarget{
+						Name:              "also-gray",
+						ConfigurationName: "gray",
+						RevisionName:      "gray-00001",
+						Percent:           50,
+					}),
+				WithStatusActiveTargets(
+					v1alpha1.ActiveTarget{RevisionName: "gray-00001", Percent: 100, Active: true})),
+		}},
+		WantEvents: []string{
+			Eventf(corev1.EventTypeNormal, "Created", "Created ClusterIngress %q", ""),
+		},
+		Key:                     "default/same-revision-targets",
+		SkipNamespaceValidation: true,
+	}, {
+		Name: "change route configuration",
+		// Start from a steady state referencing "blue", and modify the route spec to point to "green" instead.
+		Objects: []runtime.Object{
+			route("default", "switch-configs", WithConfigTarget("green"),
+				WithDomain, WithDomainInternal, WithAddress, WithInitRouteConditions,
+				MarkTrafficAssigned, MarkIngressReady, WithStatusTraffic(
+					v1alpha1.TrafficTarget{
+						Name:              "blue",
+						ConfigurationName: "blue",
+						RevisionName:      "blue-00001",
+						Percent:           100,
+					}),
+				WithStatusActiveTargets(
+					v1alpha1.ActiveTarget{RevisionName: "blue-00001", Percent: 100, Active: true})),
+			cfg("default", "blue",
+				WithGeneration(1), WithLatestCreated, WithLatestReady,
+				// The Route controller attaches our label to this Configuration.
+				WithConfigLabel("serving.knative.dev/route", "switch-configs"),
+			),
+			cfg("default", "green",
+				WithGeneration(1), WithLatestCreated, WithLatestReady),
+			rev("default", "blue", 1, MarkRevisionReady),
+			rev("default", "green", 1, MarkRevisionReady),
+			simpleReadyIngress(
+				route("default", "switch-configs", WithConfigTarget("blue"), WithDomain),
+				&traffic.Config{
+					Targets: map[string][]traffic.RevisionTarget{
+						"": {{
+							TrafficTarget: v1alpha1.TrafficTarget{
+								ConfigurationName: "blue",
+								// Use the Revision name from the config.
+								RevisionName: rev("default", "blue", 1).Name,
+								Percent:      100,
+							},
+							Active: true,
+						}},
+					},
+				},
+			),
+			simpleK8sService(route("default", "switch-configs", WithConfigTarget("blue"))),
+		},
+		WantUpdates: []clientgotesting.UpdateActionImpl{{
+			Object: simpleReadyIngress(
+				route("default", "switch-configs", WithConfigTarget("green"), WithDomain),
+				&traffic.Config{
+					Targets: map[string][]traffic.RevisionTarget{
+						"": {{
+							TrafficTarget: v1alpha1.TrafficTarget{
+								ConfigurationName: "green",
+								// Use the Revision name from the config.
+								RevisionName: rev("default", "green", 1).Name,
+								Percent:      100,
+							},
+							Active: true,
+						}},
+					},
+				},
+			),
+		}},
+		WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
+			Object: route("default", "switch-configs", WithConfigTarget("green"),
+				WithDomain, WithDomainInternal, WithAddress, WithInitRouteConditions,
+				MarkTrafficAssigned, MarkIngressReady, WithStatusTraffic(
+					v1alpha1.TrafficTarget{
+						ConfigurationName: "green",
+						RevisionName:      "green-00001",
+						Percent:           100,
+					}),
+				WithStatusActiveTargets(
+					v1alpha1.ActiveTarget{RevisionName: "green-00001", Percent: 100, Active: true})),
+		}},
+		Key:                     "default/switch-configs",
+		SkipNamespaceValidation: true,
+	}, {
+		Name: "Update stale lastPinned",
+		Objects: []runtime.Object{
+			route("default", "stale-lastpinned", WithConfigTarget("config"),
+				WithDomain, WithDomainInternal, WithAddress, WithInitRouteConditions,
+				MarkTrafficAssigned, MarkIngressReady, WithStatusTraffic(
+					v1alpha1.TrafficTarget{
+						ConfigurationName: "config",
+						RevisionName:      "config-00001",
+						Percent:           100,
+					}),
+				WithStatusActiveTargets(
+					v1alpha1.ActiveTarget{RevisionName: "config-00001", Percent: 100, Active: true})),
+			cfg("default", "config",
+				WithGeneration(1), WithLatestCreated, WithLatestReady,
+				// The Route controller attaches our label to this Configuration.
+				WithConfigLabel("serving.knative.dev/route", "stale-lastpinned"),
+			),
+			rev("default", "config", 1, MarkRevisionReady,
+				WithLastPinned(fakeCurTime.Add(-10*time.Minute))),
+			simpleReadyIngress(
+				route("default", "stale-lastpinned", WithConfigTarget("config"), WithDomain),
+				&traffic.Config{
+					Targets: map[string][]traffic.RevisionTarget{
+						"": {{
+							TrafficTarget: v1alpha1.TrafficTarget{
+								ConfigurationName: "config",
+								// Use the Revision name from the config.
+								RevisionName: rev("default", "config", 1).Name,
+								Percent:      100,
+							},
+							Active: true,
+						}},
+					},
+				},
+			),
+			simpleK8sService(route("default", "stale-lastpinned", WithConfigTarget("config"))),
+		},
+		WantPatches: []clientgotesting.PatchActionImpl{
+			patchLastPinned("default", "config-00001"),
+		},
+		Key: "default/stale-lastpinned",
+	}, {
+		Name: "route to an inactive revision requires activation",
+		Objects: []runtime.Object{
+			route("default", "inactive", WithConfigTarget("config")),
+			cfg("default", "config",
+				WithGeneration(1), WithLatestCreated, WithLatestReady),
+			rev("default", "config", 1, MarkRevisionReady, MarkInactive("NoTraffic", "no traffic")),
+		},
+		WantCreates: []metav1.Object{
+			resources.MakeClusterIngress(
+				route("default", "inactive", WithConfigTarget("config"), WithDomain),
+				&traffic.Config{
+					Targets: map[string][]traffic.RevisionTarget{
+						"": {{
+							TrafficTarget: v1alpha1.TrafficTarget{
+								ConfigurationName: "config",
+								RevisionName:      "config-00001",
+								Percent:           100,
+							},
+							Active: false,
+						}},
+					},
+				},
+				testRevisionPort,
+			),
 		},
 		WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
-			Object: route("default", "named-traffic-split",
-				WithSpecTraffic(v1alpha1.TrafficTarget{
-					ConfigurationName: "blue",
-					Percent:           50,
-				}, v1alpha1.TrafficTarget{
-					ConfigurationName: "green",
-					Percent:           50,
+			Object: route("default", "inactive", WithConfigTarget("config"),
+				WithDomain, WithDomainInternal, WithAddress, WithInitRouteConditions,
+				MarkTrafficAssigned, WithStatusTraffic(v1alpha1.TrafficTarget{
+					ConfigurationName: "config",
+					RevisionName:      "config-00001",
+					Percent:           100,
 				}),
+				WithStatusActiveTargets(
+					v1alpha1.ActiveTarget{RevisionName: "config-00001", Percent: 100, Active: false}),
+				WithRequiresActivation),
+		}},
+		WantEvents: []string{
+			Eventf(corev1.EventTypeNormal, "Created", "Created ClusterIngress %q", ""),
+		},
+		Key:                     "default/inactive",
+		SkipNamespaceValidation: true,
+	}, {
+		Name: "steady state after the revision is activated",
+		Objects: []runtime.Object{
+			route("default", "reactivated", WithConfigTarget("config"),
 				WithDomain, WithDomainInternal, WithAddress, WithInitRouteConditions,
-				MarkTrafficAssigned, WithStatusTraffic(
+				MarkTrafficAssigned, MarkIngressReady, WithStatusTraffic(
 					v1alpha1.TrafficTarget{
-						RevisionName: "blue-00001",
-						Percent:      50,
-					}, v1alpha1.TrafficTarget{
-						RevisionName: "green-00001",
-						Percent:      50,
+						ConfigurationName: "config",
+						RevisionName:      "config-00001",
+						Percent:           100,
+					}),
+				WithStatusActiveTargets(
+					v1alpha1.ActiveTarget{RevisionName: "config-00001", Percent: 100, Active: false}),
+				WithRequiresActivation),
+			cfg("default", "config",
+				WithGeneration(1), WithLatestCreated, WithLatestReady,
+				WithConfigLabel("serving.knative.dev/route", "reactivated"),
+			),
+			rev("default", "config", 1, MarkRevisionReady),
+			simpleReadyIngress(
+				route("default", "reactivated", WithConfigTarget("config"), WithDomain),
+				&traffic.Config{
+					Targets: map[string][]traffic.RevisionTarget{
+						"": {{
+							TrafficTarget: v1alpha1.TrafficTarget{
+								ConfigurationName: "config",
+								RevisionName:      "config-00001",
+								Percent:           100,
+							},
+							Active: true,
+						}},
+					},
+				},
+			),
+			simpleK8sService(route("default", "reactivated", WithConfigTarget("config"))),
+		},
+		WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
+			// No traffic goes through the activator anymore.
+			Object: route("default", "reactivated", WithConfigTarget("config"),
+				WithDomain, WithDomainInternal, WithAddress, WithInitRouteConditions,
+				MarkTrafficAssigned, MarkIngressReady, WithStatusTraffic(
+					v1alpha1.TrafficTarget{
+						ConfigurationName: "config",
+						RevisionName:      "config-00001",
+						Percent:           100,
+					}),
+				WithStatusActiveTargets(
+					v1alpha1.ActiveTarget{RevisionName: "config-00001", Percent: 100, Active: true})),
+		}},
+		Key: "default/reactivated",
+	}}
+
+	// TODO(mattmoor): Revision inactive (direct reference)
+	// TODO(mattmoor): Multiple inactive Revisions
+
+	table.Test(t, MakeFactory(newTableReconciler))
+}
+
+func newTableReconciler(listers *Listers, opt reconciler.Options) controller.Reconciler {
+	return &Reconciler{
+		Base:                 reconciler.NewBase(opt, controllerAgentName),
+		routeLister:          listers.GetRouteLister(),
+		configurationLister:  listers.GetConfigurationLister(),
+		revisionLister:       listers.GetRevisionLister(),
+		serviceLister:        listers.GetK8sServiceLister(),
+		clusterIngressLister: listers.GetClusterIngressLister(),
+		tracker:              &rtesting.NullTracker{},
+		configStore: &testConfigStore{
+			config: ReconcilerTestConfig(),
+		},
+		clock:          FakeClock{Time: fakeCurTime},
+		domainResolver: DefaultDomainResolver,
+		nameGenerator:  names.SameName,
+		enqueueAfter:   func(interface{}, time.Duration) {},
+
+		propagatedMetadataPrefix: "telemetry.knative.dev/",
+	}
+}
+
+func TestReconcileCustomRevisionPort(t *testing.T) {
+	const port = 8080
+	tc := &traffic.Config{
+		Targets: map[string][]traffic.RevisionTarget{
+			"": {{
+				TrafficTarget: v1alpha1.TrafficTarget{
+					ConfigurationName: "config",
+					RevisionName:      rev("default", "config", 1).Name,
+					Percent:           100,
+				},
+				Active: true,
+			}},
+		},
+	}
+	ingress := func(port int32) *netv1alpha1.ClusterIngress {
+		ci := resources.MakeClusterIngress(
+			route("default", "custom-port", WithConfigTarget("config"), WithDomain), tc, port)
+		ci.Status = meshOnlyIngressStatus()
+		return ci
+	}
+	svc, _ := resources.MakeK8sService(route("default", "custom-port", WithConfigTarget("config")),
+		&netv1alpha1.ClusterIngress{Status: meshOnlyIngressStatus()}, v1alpha1.RevisionProtocolHTTP1, port)
+
+	table := TableTest{{
+		Name: "revisions served on a custom port",
+		Objects: []runtime.Object{
+			route("default", "custom-port", WithConfigTarget("config")),
+			cfg("default", "config",
+				WithGeneration(1), WithLatestCreated, WithLatestReady),
+			rev("default", "config", 1, MarkRevisionReady),
+			// Built while Revisions were served on the default port.
+			ingress(testRevisionPort),
+		},
+		WantUpdates: []clientgotesting.UpdateActionImpl{{
+			Object: ingress(port),
+		}},
+		WantCreates: []metav1.Object{
+			svc,
+		},
+		WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
+			Object: route("default", "custom-port", WithConfigTarget("config"),
+				WithDomain, WithDomainInternal, WithAddress, WithInitRouteConditions,
+				MarkTrafficAssigned, MarkIngressReady, WithStatusTraffic(
+					v1alpha1.TrafficTarget{
+						ConfigurationName: "config",
+						RevisionName:      "config-00001",
+						Percent:           100,
+					}),
+				WithStatusActiveTargets(
+					v1alpha1.ActiveTarget{RevisionName: "config-00001", Percent: 100, Active: true})),
+		}},
+		WantEvents: []string{
+			Eventf(corev1.EventTypeNormal, "Created", "Created service %q", "custom-port"),
+		},
+		Key:                     "default/custom-port",
+		SkipNamespaceValidation: true,
+	}}
+
+	table.Test(t, MakeFactory(func(listers *Listers, opt reconciler.Options) controller.Reconciler {
+		r := newTableReconciler(listers, opt).(*Reconciler)
+		rc := ReconcilerTestConfig()
+		rc.Network.DefaultRevisionPort = port
+		r.configStore = &testConfigStore{config: rc}
+		return r
+	}))
+}
+
+// stubDomainResolver resolves the domains of Routes from a fixed map.
+type stubDomainResolver map[string]string
+
+func (r stubDomainResolver) Resolve(_ context.Context, route *v1alpha1.Route) (string, error) {
+	domain, ok := r[route.Name]
+	if !ok {
+		return "", fmt.Errorf("no domain for %q", route.Name)
+	}
+	return domain, nil
+}
+
+func TestReconcileDomainResolver(t *testing.T) {
+	withResolvedDomain := func(r *v1alpha1.Route) {
+		r.Status.Domain = "resolved.example.net"
+	}
+	table := TableTest{{
+		Name: "route served at the resolved domain",
+		Objects: []runtime.Object{
+			route("default", "resolved", WithConfigTarget("config")),
+			cfg("default", "config",
+				WithGeneration(1), WithLatestCreated, WithLatestReady),
+			rev("default", "config", 1, MarkRevisionReady),
+		},
+		WantCreates: []metav1.Object{
+			resources.MakeClusterIngress(
+				route("default", "resolved", WithConfigTarget("config"), withResolvedDomain),
+				&traffic.Config{
+					Targets: map[string][]traffic.RevisionTarget{
+						"": {{
+							TrafficTarget: v1alpha1.TrafficTarget{
+								ConfigurationName: "config",
+								RevisionName:      rev("default", "config", 1).Name,
+								Percent:           100,
+							},
+							Active: true,
+						}},
+					},
+				},
+				testRevisionPort,
+			),
+		},
+		WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
+			Object: route("default", "resolved", WithConfigTarget("config"),
+				withResolvedDomain, WithDomainInternal, WithAddress, WithInitRouteConditions,
+				MarkTrafficAssigned, WithStatusTraffic(v1alpha1.TrafficTarget{
+					ConfigurationName: "config",
+					RevisionName:      "config-00001",
+					Percent:           100,
+				}),
+				WithStatusActiveTargets(
+					v1alpha1.ActiveTarget{RevisionName: "config-00001", Percent: 100, Active: true})),
+		}},
+		WantEvents: []string{
+			Eventf(corev1.EventTypeNormal, "Created", "Created ClusterIngress %q", ""),
+		},
+		Key:                     "default/resolved",
+		SkipNamespaceValidation: true,
+	}, {
+		Name: "failure resolving the domain",
+		Objects: []runtime.Object{
+			route("default", "unresolved", WithConfigTarget("config")),
+			cfg("default", "config",
+				WithGeneration(1), WithLatestCreated, WithLatestReady),
+			rev("default", "config", 1, MarkRevisionReady),
+		},
+		WantErr: true,
+		WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
+			Object: route("default", "unresolved", WithConfigTarget("config"),
+				WithInitRouteConditions),
+		}},
+		Key: "default/unresolved",
+	}}
+
+	table.Test(t, MakeFactory(func(listers *Listers, opt reconciler.Options) controller.Reconciler {
+		r := newTableReconciler(listers, opt).(*Reconciler)
+		r.domainResolver = stubDomainResolver{"resolved": "resolved.example.net"}
+		return r
+	}))
+}
+
+func TestReconcileNameGenerator(t *testing.T) {
+	// The longest name a Route may have.
+	longName := "long-" + strings.Repeat("x", 58)
+	hashed := names.HashSuffix.K8sService(route("default", longName))
+	withHashedAddress := func(r *v1alpha1.Route) {
+		r.Status.PlaceholderServiceName = hashed
+		r.Status.DomainInternal = hashed + ".default.svc.cluster.local"
+		r.Status.Address = &duckv1alpha1.Addressable{
+			Hostname: hashed + ".default.svc.cluster.local",
+		}
+	}
+	withoutRecordedName := func(r *v1alpha1.Route) {
+		r.Status.PlaceholderServiceName = ""
+	}
+	tc := &traffic.Config{
+		Targets: map[string][]traffic.RevisionTarget{
+			"": {{
+				TrafficTarget: v1alpha1.TrafficTarget{
+					ConfigurationName: "config",
+					RevisionName:      rev("default", "config", 1).Name,
+					Percent:           100,
+				},
+				Active: true,
+			}},
+		},
+	}
+	statusTraffic := WithStatusTraffic(v1alpha1.TrafficTarget{
+		ConfigurationName: "config",
+		RevisionName:      "config-00001",
+		Percent:           100,
+	})
+	activeTargets := WithStatusActiveTargets(
+		v1alpha1.ActiveTarget{RevisionName: "config-00001", Percent: 100, Active: true})
+
+	table := TableTest{{
+		Name: "long route gets a hashed placeholder service name",
+		Objects: []runtime.Object{
+			route("default", longName, WithConfigTarget("config")),
+			cfg("default", "config",
+				WithGeneration(1), WithLatestCreated, WithLatestReady),
+			rev("default", "config", 1, MarkRevisionReady),
+		},
+		WantCreates: []metav1.Object{
+			resources.MakeClusterIngress(
+				route("default", longName, WithConfigTarget("config"), WithDomain, withHashedAddress),
+				tc, testRevisionPort),
+		},
+		WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
+			Object: route("default", longName, WithConfigTarget("config"),
+				WithDomain, withHashedAddress, WithInitRouteConditions,
+				MarkTrafficAssigned, statusTraffic, activeTargets),
+		}},
+		WantEvents: []string{
+			Eventf(corev1.EventTypeNormal, "Created", "Created ClusterIngress %q", ""),
+		},
+		Key:                     "default/" + longName,
+		SkipNamespaceValidation: true,
+	}, {
+		Name: "steady state finds the hashed placeholder service",
+		Objects: []runtime.Object{
+			route("default", longName, WithConfigTarget("config"),
+				WithDomain, withHashedAddress, WithInitRouteConditions,
+				MarkTrafficAssigned, MarkIngressReady, statusTraffic, activeTargets),
+			cfg("default", "config",
+				WithGeneration(1), WithLatestCreated, WithLatestReady),
+			rev("default", "config", 1, MarkRevisionReady),
+			simpleReadyIngress(
+				route("default", longName, WithConfigTarget("config"), WithDomain, withHashedAddress),
+				tc),
+			simpleK8sService(route("default", longName, WithConfigTarget("config"), withHashedAddress)),
+		},
+		Key:                     "default/" + longName,
+		SkipNamespaceValidation: true,
+	}, {
+		Name: "route reconciled before names were recorded keeps its service",
+		Objects: []runtime.Object{
+			route("default", "unrecorded", WithConfigTarget("config"),
+				WithDomain, WithDomainInternal, WithAddress, withoutRecordedName, WithInitRouteConditions,
+				MarkTrafficAssigned, MarkIngressReady, statusTraffic, activeTargets),
+			cfg("default", "config",
+				WithGeneration(1), WithLatestCreated, WithLatestReady),
+			rev("default", "config", 1, MarkRevisionReady),
+			simpleReadyIngress(
+				route("default", "unrecorded", WithConfigTarget("config"), WithDomain),
+				tc),
+			simpleK8sService(route("default", "unrecorded", WithConfigTarget("config"))),
+		},
+		WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
+			Object: route("default", "unrecorded", WithConfigTarget("config"),
+				WithDomain, WithDomainInternal, WithAddress, WithInitRouteConditions,
+				MarkTrafficAssigned, MarkIngressReady, statusTraffic, activeTargets),
+		}},
+		Key:                     "default/unrecorded",
+		SkipNamespaceValidation: true,
+	}}
+
+	table.Test(t, MakeFactory(func(listers *Listers, opt reconciler.Options) controller.Reconciler {
+		r := newTableReconciler(listers, opt).(*Reconciler)
+		r.nameGenerator = names.HashSuffix
+		return r
+	}))
+}
+
+func TestReconcileRequeues(t *testing.T) {
+	tests := []struct {
+		row  TableRow
+		want []time.Duration
+	}{{
+		row: TableRow{
+			Name: "configuration building",
+			Objects: []runtime.Object{
+				route("default", "building", WithConfigTarget("config")),
+				cfg("default", "config", WithGeneration(1), WithLatestCreated),
+				rev("default", "config", 1, WithInitRevConditions,
+					WithBuildRef("config-00001"), MarkBuilding),
+			},
+			WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
+				Object: route("default", "building", WithConfigTarget("config"),
+					WithInitRouteConditions, MarkConfigurationNotReady("config"),
+					WithTargetStatuses(v1alpha1.TargetStatus{
+						Reason:  "RevisionMissing",
+						Message: `Configuration "config" is waiting for a Revision to become ready.`,
+					})),
+			}},
+			Key: "default/building",
+		},
+		// The build completing doesn't enqueue the Route, so it looks
+		// at the Configuration again later.
+		want: []time.Duration{time.Minute},
+	}, {
+		row: TableRow{
+			Name: "configuration failed",
+			Objects: []runtime.Object{
+				route("default", "failed", WithConfigTarget("config")),
+				cfg("default", "config",
+					WithGeneration(1), WithLatestCreated, MarkLatestCreatedFailed("blah")),
+				rev("default", "config", 1, WithInitRevConditions, MarkContainerMissing),
+			},
+			WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
+				Object: route("default", "failed", WithConfigTarget("config"),
+					WithInitRouteConditions, MarkConfigurationFailed("config"),
+					WithTargetStatuses(v1alpha1.TargetStatus{
+						Reason:  "RevisionMissing",
+						Message: `Configuration "config" does not have any ready Revision.`,
 					})),
+			}},
+			Key: "default/failed",
+		},
+		// Nothing to wait for.
+		want: nil,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.row.Name, func(t *testing.T) {
+			var got []time.Duration
+			test.row.Test(t, MakeFactory(func(listers *Listers, opt reconciler.Options) controller.Reconciler {
+				r := newTableReconciler(listers, opt).(*Reconciler)
+				cfg := ReconcilerTestConfig()
+				cfg.Route.RevisionReadyPollInterval = time.Minute
+				r.configStore = &testConfigStore{config: cfg}
+				r.enqueueAfter = func(_ interface{}, after time.Duration) {
+					got = append(got, after)
+				}
+				return r
+			}))
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("Unexpected requeues (-want +got): %s", diff)
+			}
+		})
+	}
+}
+
+func TestReconcileDraining(t *testing.T) {
+	const window = time.Minute
+	newTarget := traffic.RevisionTarget{
+		TrafficTarget: v1alpha1.TrafficTarget{
+			ConfigurationName: "config",
+			RevisionName:      "config-00002",
+			Percent:           100,
+		},
+		Active: true,
+	}
+	drainingTarget := traffic.RevisionTarget{
+		TrafficTarget: v1alpha1.TrafficTarget{RevisionName: "config-00001"},
+		Active:        true,
+		Draining:      true,
+	}
+	oldIngress := simpleReadyIngress(
+		route("default", "draining", WithConfigTarget("config"), WithDomain),
+		&traffic.Config{
+			Targets: map[string][]traffic.RevisionTarget{
+				"": {{
+					TrafficTarget: v1alpha1.TrafficTarget{
+						ConfigurationName: "config",
+						RevisionName:      "config-00001",
+						Percent:           100,
+					},
+					Active: true,
+				}},
+			},
+		},
+	)
+	drainingIngress := simpleReadyIngress(
+		route("default", "draining", WithConfigTarget("config"), WithDomain),
+		&traffic.Config{
+			Targets: map[string][]traffic.RevisionTarget{
+				"": {newTarget, drainingTarget},
+			},
+		},
+	)
+	drainedIngress := simpleReadyIngress(
+		route("default", "draining", WithConfigTarget("config"), WithDomain),
+		&traffic.Config{
+			Targets: map[string][]traffic.RevisionTarget{
+				"": {newTarget},
+			},
+		},
+	)
+	routeWithTraffic := func(revisionName string, opts ...RouteOption) *v1alpha1.Route {
+		return route("default", "draining", append([]RouteOption{WithConfigTarget("config"),
+			WithDomain, WithDomainInternal, WithAddress, WithInitRouteConditions,
+			MarkTrafficAssigned, MarkIngressReady, WithStatusTraffic(
+				v1alpha1.TrafficTarget{
+					ConfigurationName: "config",
+					RevisionName:      revisionName,
+					Percent:           100,
+				}),
+			WithStatusActiveTargets(
+				v1alpha1.ActiveTarget{RevisionName: revisionName, Percent: 100, Active: true}),
+		}, opts...)...)
+	}
+	objects := func(r *v1alpha1.Route, ci *netv1alpha1.ClusterIngress) []runtime.Object {
+		return []runtime.Object{
+			r,
+			cfg("default", "config",
+				WithGeneration(2), WithLatestCreated, WithLatestReady,
+				WithConfigLabel("serving.knative.dev/route", "draining"),
+			),
+			rev("default", "config", 1, MarkRevisionReady),
+			rev("default", "config", 2, MarkRevisionReady),
+			ci,
+			simpleK8sService(route("default", "draining", WithConfigTarget("config"))),
+		}
+	}
+
+	tests := []struct {
+		row  TableRow
+		want []time.Duration
+	}{{
+		row: TableRow{
+			Name:    "new latest ready revision drains the previous one",
+			Objects: objects(routeWithTraffic("config-00001"), oldIngress),
+			// The previous Revision lingers at 0% while it drains.
+			WantUpdates: []clientgotesting.UpdateActionImpl{{
+				Object: drainingIngress,
+			}},
+			WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
+				Object: routeWithTraffic("config-00002", WithStatusDraining(v1alpha1.DrainingRevision{
+					RevisionName: "config-00001",
+					Since:        metav1.NewTime(fakeCurTime),
+				})),
+			}},
+			Key:                     "default/draining",
+			SkipNamespaceValidation: true,
+		},
+		want: []time.Duration{window},
+	}, {
+		row: TableRow{
+			Name: "previous revision still draining",
+			Objects: objects(routeWithTraffic("config-00002", WithStatusDraining(v1alpha1.DrainingRevision{
+				RevisionName: "config-00001",
+				Since:        metav1.NewTime(fakeCurTime.Add(-window / 2)),
+			})), drainingIngress),
+			Key:                     "default/draining",
+			SkipNamespaceValidation: true,
+		},
+		want: []time.Duration{window / 2},
+	}, {
+		row: TableRow{
+			Name: "drain window passed",
+			Objects: objects(routeWithTraffic("config-00002", WithStatusDraining(v1alpha1.DrainingRevision{
+				RevisionName: "config-00001",
+				Since:        metav1.NewTime(fakeCurTime.Add(-window)),
+			})), drainingIngress),
+			WantUpdates: []clientgotesting.UpdateActionImpl{{
+				Object: drainedIngress,
+			}},
+			WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
+				Object: routeWithTraffic("config-00002"),
+			}},
+			Key:                     "default/draining",
+			SkipNamespaceValidation: true,
+		},
+		want: nil,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.row.Name, func(t *testing.T) {
+			var got []time.Duration
+			test.row.Test(t, MakeFactory(func(listers *Listers, opt reconciler.Options) controller.Reconciler {
+				r := newTableReconciler(listers, opt).(*Reconciler)
+				cfg := ReconcilerTestConfig()
+				cfg.Route.RevisionDrainWindow = window
+				r.configStore = &testConfigStore{config: cfg}
+				r.enqueueAfter = func(_ interface{}, after time.Duration) {
+					got = append(got, after)
+				}
+				return r
+			}))
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("Unexpected requeues (-want +got): %s", diff)
+			}
+		})
+	}
+}
+
+func TestReconcileTrafficHistory(t *testing.T) {
+	settled := v1alpha1.TrafficRecord{
+		Traffic: []v1alpha1.TrafficTarget{{
+			ConfigurationName: "config",
+			RevisionName:      "config-00001",
+			Percent:           100,
+		}},
+		Time: metav1.NewTime(fakeCurTime.Add(-time.Hour)),
+	}
+	routeWithTraffic := func(name string, assigned []v1alpha1.TrafficTarget, opts ...RouteOption) *v1alpha1.Route {
+		var active []v1alpha1.ActiveTarget
+		for _, tt := range assigned {
+			active = append(active, v1alpha1.ActiveTarget{RevisionName: tt.RevisionName, Percent: tt.Percent, Active: true})
+		}
+		return route("default", name, append([]RouteOption{WithConfigTarget("config"),
+			WithDomain, WithDomainInternal, WithAddress, WithInitRouteConditions,
+			MarkTrafficAssigned, MarkIngressReady, WithStatusTraffic(assigned...),
+			WithStatusActiveTargets(active...), WithTrafficHistory(settled),
+		}, opts...)...)
+	}
+	ingress := func(name string, assigned []v1alpha1.TrafficTarget) *netv1alpha1.ClusterIngress {
+		var targets []traffic.RevisionTarget
+		for _, tt := range assigned {
+			targets = append(targets, traffic.RevisionTarget{TrafficTarget: tt, Active: true})
+		}
+		return simpleReadyIngress(
+			route("default", name, WithConfigTarget("config"), WithDomain),
+			&traffic.Config{Targets: map[string][]traffic.RevisionTarget{"": targets}},
+		)
+	}
+	objects := func(r *v1alpha1.Route) []runtime.Object {
+		return []runtime.Object{
+			r,
+			cfg("default", "config",
+				WithGeneration(2), WithLatestCreated, WithLatestReady,
+				WithConfigLabel("serving.knative.dev/route", r.Name),
+			),
+			rev("default", "config", 1, MarkRevisionReady,
+				WithRevisionLabel(serving.ConfigurationLabelKey, "config")),
+			rev("default", "config", 2, MarkRevisionReady,
+				WithRevisionLabel(serving.ConfigurationLabelKey, "config")),
+			ingress(r.Name, settled.Traffic),
+			simpleK8sService(route("default", r.Name, WithConfigTarget("config"))),
+		}
+	}
+	newTraffic := []v1alpha1.TrafficTarget{{
+		ConfigurationName: "config",
+		RevisionName:      "config-00002",
+		Percent:           100,
+	}}
+	rolloutTraffic := []v1alpha1.TrafficTarget{{
+		ConfigurationName: "config",
+		RevisionName:      "config-00001",
+		Percent:           75,
+	}, {
+		ConfigurationName: "config",
+		RevisionName:      "config-00002",
+		Percent:           25,
+	}}
+
+	table := TableTest{{
+		Name:    "settled traffic assignment is recorded",
+		Objects: objects(routeWithTraffic("history", settled.Traffic)),
+		WantUpdates: []clientgotesting.UpdateActionImpl{{
+			Object: ingress("history", newTraffic),
 		}},
-		WantEvents: []string{
-			Eventf(corev1.EventTypeNormal, "Created", "Created ClusterIngress %q", ""),
-		},
-		Key:                     "default/named-traffic-split",
+		WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
+			Object: routeWithTraffic("history", newTraffic, WithTrafficHistory(settled, v1alpha1.TrafficRecord{
+				Traffic: newTraffic,
+				Time:    metav1.NewTime(fakeCurTime),
+			})),
+		}},
+		Key:                     "default/history",
 		SkipNamespaceValidation: true,
 	}, {
-		Name: "same revision targets",
-		Objects: []runtime.Object{
-			route("default", "same-revision-targets", WithSpecTraffic(
-				v1alpha1.TrafficTarget{
-					Name:              "gray",
-					ConfigurationName: "gray",
-					Percent:           50,
-				}, v1alpha1.TrafficTarget{
-					Name:         "also-gray",
-					RevisionName: "gray-00001",
-					Percent:      50,
+		Name: "steps of a rollout are not recorded",
+		Objects: objects(routeWithTraffic("rollout-history", settled.Traffic,
+			WithRollout(9*time.Minute, 25))),
+		WantUpdates: []clientgotesting.UpdateActionImpl{{
+			Object: ingress("rollout-history", rolloutTraffic),
+		}},
+		WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
+			Object: routeWithTraffic("rollout-history", rolloutTraffic,
+				WithRollout(9*time.Minute, 25), WithStatusRollouts(v1alpha1.RolloutStatus{
+					ConfigurationName:    "config",
+					PreviousRevisionName: "config-00001",
+					RevisionName:         "config-00002",
+					StartTime:            metav1.NewTime(fakeCurTime),
 				})),
-			cfg("default", "gray",
+		}},
+		Key:                     "default/rollout-history",
+		SkipNamespaceValidation: true,
+	}}
+
+	table.Test(t, MakeFactory(func(listers *Listers, opt reconciler.Options) controller.Reconciler {
+		r := newTableReconciler(listers, opt).(*Reconciler)
+		cfg := ReconcilerTestConfig()
+		cfg.Route.TrafficHistoryLimit = 5
+		r.configStore = &testConfigStore{config: cfg}
+		return r
+	}))
+}
+
+func TestReconcileCrossNamespace(t *testing.T) {
+	target := v1alpha1.TrafficTarget{
+		RevisionName: "stable-00001",
+		Namespace:    "stable",
+		Percent:      100,
+	}
+	table := TableTest{{
+		Name: "revision in another namespace",
+		Objects: []runtime.Object{
+			route("default", "cross-namespace", WithSpecTraffic(target)),
+			cfg("stable", "stable",
 				WithGeneration(1), WithLatestCreated, WithLatestReady),
-			rev("default", "gray", 1, MarkRevisionReady),
+			rev("stable", "stable", 1, MarkRevisionReady),
 		},
 		WantCreates: []metav1.Object{
 			resources.MakeClusterIngress(
-				route("default", "same-revision-targets", WithDomain, WithSpecTraffic(
-					v1alpha1.TrafficTarget{
-						Name:              "gray",
-						ConfigurationName: "gray",
-						Percent:           50,
-					}, v1alpha1.TrafficTarget{
-						Name:         "also-gray",
-						RevisionName: "gray-00001",
-						Percent:      50,
-					})),
+				route("default", "cross-namespace", WithDomain, WithSpecTraffic(target)),
 				&traffic.Config{
 					Targets: map[string][]traffic.RevisionTarget{
 						"": {{
 							TrafficTarget: v1alpha1.TrafficTarget{
-								// Use the Revision name from the config.
-								RevisionName: rev("default", "gray", 1).Name,
-								Percent:      100,
-							},
-							Active: true,
-						}},
-						"gray": {{
-							TrafficTarget: v1alpha1.TrafficTarget{
-								// Use the Revision name from the config.
-								RevisionName: rev("default", "gray", 1).Name,
-								Percent:      100,
-							},
-							Active: true,
-						}},
-						"also-gray": {{
-							TrafficTarget: v1alpha1.TrafficTarget{
-								// Use the Revision name from the config.
-								RevisionName: rev("default", "gray", 1).Name,
-								Percent:      100,
+								ConfigurationName: "stable",
+								RevisionName:      "stable-00001",
+								Namespace:         "stable",
+								Percent:           100,
 							},
 							Active: true,
 						}},
 					},
 				},
+				testRevisionPort,
 			),
 		},
 		WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
-			Object: route("default", "same-revision-targets",
-				WithSpecTraffic(v1alpha1.TrafficTarget{
-					Name:              "gray",
-					ConfigurationName: "gray",
-					Percent:           50,
-				}, v1alpha1.TrafficTarget{
-					Name:         "also-gray",
-					RevisionName: "gray-00001",
-					Percent:      50,
-				}),
+			Object: route("default", "cross-namespace", WithSpecTraffic(target),
 				WithDomain, WithDomainInternal, WithAddress, WithInitRouteConditions,
-				MarkTrafficAssigned, WithStatusTraffic(
-					v1alpha1.TrafficTarget{
-						Name:         "gray",
-						RevisionName: "gray-00001",
-						Percent:      50,
-					}, v1alpha1.TrafficTarget{
-						Name:         "also-gray",
-						RevisionName: "gray-00001",
-						Percent:      50,
-					})),
+				MarkTrafficAssigned, WithStatusTraffic(v1alpha1.TrafficTarget{
+					ConfigurationName: "stable",
+					RevisionName:      "stable-00001",
+					Namespace:         "stable",
+					Percent:           100,
+				}),
+				WithStatusActiveTargets(
+					v1alpha1.ActiveTarget{RevisionName: "stable-00001", Percent: 100, Active: true})),
 		}},
 		WantEvents: []string{
 			Eventf(corev1.EventTypeNormal, "Created", "Created ClusterIngress %q", ""),
 		},
-		Key:                     "default/same-revision-targets",
+		Key:                     "default/cross-namespace",
 		SkipNamespaceValidation: true,
 	}, {
-		Name: "change route configuration",
-		// Start from a steady state referencing "blue", and modify the route spec to point to "green" instead.
+		Name: "missing revision in another namespace",
 		Objects: []runtime.Object{
-			route("default", "switch-configs", WithConfigTarget("green"),
-				WithDomain, WithDomainInternal, WithAddress, WithInitRouteConditions,
-				MarkTrafficAssigned, MarkIngressReady, WithStatusTraffic(
-					v1alpha1.TrafficTarget{
-						Name:         "blue",
-						RevisionName: "blue-00001",
-						Percent:      100,
-					})),
-			cfg("default", "blue",
-				WithGeneration(1), WithLatestCreated, WithLatestReady,
-				// The Route controller attaches our label to this Configuration.
-				WithConfigLabel("serving.knative.dev/route", "switch-configs"),
-			),
-			cfg("default", "green",
-				WithGeneration(1), WithLatestCreated, WithLatestReady),
-			rev("default", "blue", 1, MarkRevisionReady),
-			rev("default", "green", 1, MarkRevisionReady),
-			simpleReadyIngress(
-				route("default", "switch-configs", WithConfigTarget("blue"), WithDomain),
-				&traffic.Config{
-					Targets: map[string][]traffic.RevisionTarget{
-						"": {{
-							TrafficTarget: v1alpha1.TrafficTarget{
-								// Use the Revision name from the config.
-								RevisionName: rev("default", "blue", 1).Name,
-								Percent:      100,
-							},
-							Active: true,
-						}},
-					},
-				},
-			),
-			simpleK8sService(route("default", "switch-configs", WithConfigTarget("blue"))),
+			route("default", "cross-namespace", WithSpecTraffic(target)),
 		},
-		WantUpdates: []clientgotesting.UpdateActionImpl{{
-			Object: simpleReadyIngress(
-				route("default", "switch-configs", WithConfigTarget("green"), WithDomain),
-				&traffic.Config{
-					Targets: map[string][]traffic.RevisionTarget{
-						"": {{
-							TrafficTarget: v1alpha1.TrafficTarget{
-								// Use the Revision name from the config.
-								RevisionName: rev("default", "green", 1).Name,
-								Percent:      100,
-							},
-							Active: true,
-						}},
-					},
-				},
-			),
-		}},
 		WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
-			Object: route("default", "switch-configs", WithConfigTarget("green"),
-				WithDomain, WithDomainInternal, WithAddress, WithInitRouteConditions,
-				MarkTrafficAssigned, MarkIngressReady, WithStatusTraffic(
-					v1alpha1.TrafficTarget{
-						RevisionName: "green-00001",
-						Percent:      100,
-					})),
+			Object: route("default", "cross-namespace", WithSpecTraffic(target),
+				WithInitRouteConditions, MarkMissingTrafficTarget("Revision", "stable/stable-00001"),
+				WithTargetStatuses(v1alpha1.TargetStatus{
+					RevisionName: "stable-00001",
+					Reason:       "RevisionMissing",
+					Message:      `Revision "stable/stable-00001" referenced in traffic not found.`,
+				})),
 		}},
-		Key:                     "default/switch-configs",
-		SkipNamespaceValidation: true,
+		Key: "default/cross-namespace",
+	}}
+
+	table.Test(t, MakeFactory(func(listers *Listers, opt reconciler.Options) controller.Reconciler {
+		r := newTableReconciler(listers, opt).(*Reconciler)
+		cfg := ReconcilerTestConfig()
+		cfg.Route.AllowCrossNamespaceTraffic = true
+		r.configStore = &testConfigStore{config: cfg}
+		return r
+	}))
+}
+
+func TestReconcileReportsResult(t *testing.T) {
+	tests := []struct {
+		name string
+		row  TableRow
+		want map[string]int
+	}{{
+		name: "success",
+		row: TableRow{
+			Objects: []runtime.Object{
+				route("default", "first-reconcile", WithConfigTarget("not-ready")),
+				cfg("default", "not-ready", WithGeneration(1), WithLatestCreated),
+				rev("default", "not-ready", 1, WithInitRevConditions),
+			},
+			Key: "default/first-reconcile",
+		},
+		want: map[string]int{"success": 1},
 	}, {
-		Name: "Update stale lastPinned",
-		Objects: []runtime.Object{
-			route("default", "stale-lastpinned", WithConfigTarget("config"),
-				WithDomain, WithDomainInternal, WithAddress, WithInitRouteConditions,
-				MarkTrafficAssigned, MarkIngressReady, WithStatusTraffic(
-					v1alpha1.TrafficTarget{
-						RevisionName: "config-00001",
-						Percent:      100,
-					})),
-			cfg("default", "config",
-				WithGeneration(1), WithLatestCreated, WithLatestReady,
-				// The Route controller attaches our label to this Configuration.
-				WithConfigLabel("serving.knative.dev/route", "stale-lastpinned"),
-			),
-			rev("default", "config", 1, MarkRevisionReady,
-				WithLastPinned(fakeCurTime.Add(-10*time.Minute))),
-			simpleReadyIngress(
-				route("default", "stale-lastpinned", WithConfigTarget("config"), WithDomain),
-				&traffic.Config{
-					Targets: map[string][]traffic.RevisionTarget{
-						"": {{
-							TrafficTarget: v1alpha1.TrafficTarget{
-								// Use the Revision name from the config.
-								RevisionName: rev("default", "config", 1).Name,
-								Percent:      100,
-							},
-							Active: true,
-						}},
-					},
-				},
-			),
-			simpleK8sService(route("default", "stale-lastpinned", WithConfigTarget("config"))),
+		name: "error",
+		row: TableRow{
+			WithReactors: []clientgotesting.ReactionFunc{
+				InduceFailure("update", "routes"),
+			},
+			Objects: []runtime.Object{
+				route("default", "first-reconcile", WithConfigTarget("not-ready")),
+				cfg("default", "not-ready", WithGeneration(1), WithLatestCreated),
+				rev("default", "not-ready", 1, WithInitRevConditions),
+			},
+			Key: "default/first-reconcile",
 		},
-		WantPatches: []clientgotesting.PatchActionImpl{
-			patchLastPinned("default", "config-00001"),
+		want: map[string]int{"error": 1},
+	}, {
+		name: "requeue",
+		row: TableRow{
+			Objects: []runtime.Object{
+				route("default", "rolling", WithConfigTarget("config"), WithRollout(10*time.Minute, 20),
+					WithStatusTraffic(v1alpha1.TrafficTarget{
+						ConfigurationName: "config",
+						RevisionName:      "config-00001",
+						Percent:           100,
+					})),
+				cfg("default", "config",
+					WithGeneration(2), WithLatestCreated, WithLatestReady),
+				rev("default", "config", 1, MarkRevisionReady),
+				rev("default", "config", 2, MarkRevisionReady),
+			},
+			Key: "default/rolling",
 		},
-		Key: "default/stale-lastpinned",
+		want: map[string]int{"requeue": 1},
 	}}
 
-	// TODO(mattmoor): Revision inactive (direct reference)
-	// TODO(mattmoor): Revision inactive (indirect reference)
-	// TODO(mattmoor): Multiple inactive Revisions
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c, _, _, stats := MakeFactory(newTableReconciler)(t, &test.row)
+			c.Reconcile(context.TODO(), test.row.Key)
+			if diff := cmp.Diff(test.want, stats.GetRouteReconcileStats()); diff != "" {
+				t.Errorf("Unexpected route reconcile stats (-want +got): %s", diff)
+			}
+		})
+	}
+}
 
-	table.Test(t, MakeFactory(func(listers *Listers, opt reconciler.Options) controller.Reconciler {
-		return &Reconciler{
-			Base:                 reconciler.NewBase(opt, controllerAgentName),
-			routeLister:          listers.GetRouteLister(),
-			configurationLister:  listers.GetConfigurationLister(),
-			revisionLister:       listers.GetRevisionLister(),
-			serviceLister:        listers.GetK8sServiceLister(),
-			clusterIngressLister: listers.GetClusterIngressLister(),
-			tracker:              &rtesting.NullTracker{},
-			configStore: &testConfigStore{
-				config: ReconcilerTestConfig(),
-			},
-			clock: FakeClock{Time: fakeCurTime},
+func TestReconcileLogsKey(t *testing.T) {
+	row := TableRow{
+		Objects: []runtime.Object{
+			route("default", "logged", WithConfigTarget("config")),
+			cfg("default", "config",
+				WithGeneration(1), WithLatestCreated, WithLatestReady),
+			rev("default", "config", 1, MarkRevisionReady),
+		},
+		Key: "default/logged",
+	}
+	var buf bytes.Buffer
+	base := zap.New(zapcore.NewCore(
+		zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig()),
+		zapcore.AddSync(&buf), zap.DebugLevel)).Sugar()
+
+	c, _, _, _ := MakeFactory(func(listers *Listers, opt reconciler.Options) controller.Reconciler {
+		// Lines logged through the base logger lack the key.
+		opt.Logger = base
+		return newTableReconciler(listers, opt)
+	})(t, &row)
+	// Like controller.Impl, hand the reconcile a logger carrying the key.
+	ctx := logging.WithLogger(context.TODO(), base.With(zap.String(logkey.Key, row.Key)))
+	if err := c.Reconcile(ctx, row.Key); err != nil {
+		t.Fatalf("Reconcile() = %v", err)
+	}
+
+	lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+	if len(lines) == 0 || len(lines[0]) == 0 {
+		t.Fatal("Reconcile() logged nothing")
+	}
+	for _, line := range lines {
+		var entry map[string]interface{}
+		if err := json.Unmarshal(line, &entry); err != nil {
+			t.Fatalf("Failed to parse log line %s: %v", line, err)
 		}
-	}))
+		if got, want := entry[logkey.Key], row.Key; got != want {
+			t.Errorf("%s = %v, want %q in %s", logkey.Key, got, want, line)
+		}
+	}
 }
 
 func route(namespace, name string, ro ...RouteOption) *v1alpha1.Route {
@@ -1285,7 +4128,19 @@ func cfg(namespace, name string, co ...ConfigOption) *v1alpha1.Configuration {
 func simpleK8sService(r *v1alpha1.Route, so ...K8sServiceOption) *corev1.Service {
 	// omit the error here, as we are sure the loadbalancer info is porvided.
 	// return the service instance only, so that the result can be used in TableRow.
-	svc, _ := resources.MakeK8sService(r, &netv1alpha1.ClusterIngress{Status: readyIngressStatus()})
+	svc, _ := resources.MakeK8sService(r, &netv1alpha1.ClusterIngress{Status: readyIngressStatus()},
+		v1alpha1.RevisionProtocolHTTP1, testRevisionPort)
+
+	for _, opt := range so {
+		opt(svc)
+	}
+
+	return svc
+}
+
+func meshOnlyK8sService(r *v1alpha1.Route, so ...K8sServiceOption) *corev1.Service {
+	svc, _ := resources.MakeK8sService(r, &netv1alpha1.ClusterIngress{Status: meshOnlyIngressStatus()},
+		v1alpha1.RevisionProtocolHTTP1, testRevisionPort)
 
 	for _, opt := range so {
 		opt(svc)
@@ -1309,13 +4164,87 @@ func readyIngressStatus() netv1alpha1.IngressStatus {
 	return status
 }
 
+// gatewayService is the ingress gateway Service readyIngressStatus points at,
+// with the given load balancer ingress points.
+func gatewayService(lb ...corev1.LoadBalancerIngress) *corev1.Service {
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "istio-ingressgateway",
+			Namespace: "istio-system",
+		},
+		Spec: corev1.ServiceSpec{
+			Type: corev1.ServiceTypeLoadBalancer,
+		},
+		Status: corev1.ServiceStatus{
+			LoadBalancer: corev1.LoadBalancerStatus{Ingress: lb},
+		},
+	}
+}
+
+func meshOnlyIngressStatus() netv1alpha1.IngressStatus {
+	status := netv1alpha1.IngressStatus{}
+	status.InitializeConditions()
+	status.MarkNetworkConfigured()
+	status.MarkLoadBalancerReady([]netv1alpha1.LoadBalancerIngressStatus{{MeshOnly: true}})
+
+	return status
+}
+
+// namedIngress names the given ClusterIngress, which the fake client would
+// leave unnamed as it ignores GenerateName.
+func namedIngress(name string, ci *netv1alpha1.ClusterIngress) *netv1alpha1.ClusterIngress {
+	ci.Name = name
+	return ci
+}
+
 func ingressWithStatus(r *v1alpha1.Route, tc *traffic.Config, status netv1alpha1.IngressStatus) *netv1alpha1.ClusterIngress {
-	ci := resources.MakeClusterIngress(r, tc)
+	ci := resources.MakeClusterIngress(r, tc, testRevisionPort)
 	ci.Status = status
 
 	return ci
 }
 
+// repeatStatusUpdate returns the status update of obj, n times over.
+func repeatStatusUpdate(n int, obj runtime.Object) []clientgotesting.UpdateActionImpl {
+	updates := make([]clientgotesting.UpdateActionImpl, n)
+	for i := range updates {
+		updates[i].Object = obj
+	}
+	return updates
+}
+
+// repeatGet returns the get of the named Route, n times over.
+func repeatGet(n int, namespace, name string) []clientgotesting.GetActionImpl {
+	gets := make([]clientgotesting.GetActionImpl, n)
+	for i := range gets {
+		gets[i] = clientgotesting.NewGetAction(v1alpha1.SchemeGroupVersion.WithResource("routes"), namespace, name)
+	}
+	return gets
+}
+
+// induceStatusConflicts makes the first n status updates of Routes fail as
+// if the Route had been modified concurrently.
+func induceStatusConflicts(n int) clientgotesting.ReactionFunc {
+	return func(action clientgotesting.Action) (bool, runtime.Object, error) {
+		if n == 0 || !action.Matches("update", "routes") || action.GetSubresource() != "status" {
+			return false, nil, nil
+		}
+		n--
+		name := action.(clientgotesting.UpdateAction).GetObject().(*v1alpha1.Route).Name
+		return true, nil, apierrs.NewConflict(v1alpha1.Resource("routes"), name, errors.New("modified concurrently"))
+	}
+}
+
+func withIngressName(ci *netv1alpha1.ClusterIngress, name string) *netv1alpha1.ClusterIngress {
+	ci.Name = name
+	return ci
+}
+
+func withIngressLabel(ci *netv1alpha1.ClusterIngress, key, value string) *netv1alpha1.ClusterIngress {
+	ci.Labels[key] = value
+	return ci
+}
+
 func mutateIngress(ci *netv1alpha1.ClusterIngress) *netv1alpha1.ClusterIngress {
 	// Thor's Hammer
 	ci.Spec = netv1alpha1.IngressSpec{}
@@ -1400,10 +4329,19 @@ func ReconcilerTestConfig() *config.Config {
 				"another-example.com": {
 					Selector: map[string]string{"app": "prod"},
 				},
+				longDomainSuffix: {
+					Selector: map[string]string{"app": "long"},
+				},
 			},
 		},
 		GC: &gc.Config{
 			StaleRevisionLastpinnedDebounce: time.Duration(1 * time.Minute),
 		},
+		Network: &revisionconfig.Network{
+			DefaultRevisionPort: testRevisionPort,
+		},
+		Route: &config.Route{
+			RevisionReadyTimeout: 10 * time.Minute,
+		},
 	}
 }
@@ -18,7 +18,9 @@ package traffic
 
 import (
 	"fmt"
+	"strings"
 
+	"github.com/knative/pkg/apis"
 	"github.com/knative/serving/pkg/apis/serving/v1alpha1"
 	corev1 "k8s.io/api/core/v1"
 )
@@ -58,6 +60,52 @@ func (e *missingTargetError) IsFailure() bool {
 	return true
 }
 
+type unresolvedSelectorError struct {
+	selector string   // Selector of the traffic target.
+	matches  []string // Names of the Configurations it matches.
+}
+
+var _ TargetError = (*unresolvedSelectorError)(nil)
+
+// Error implements error.
+func (e *unresolvedSelectorError) Error() string {
+	if len(e.matches) == 0 {
+		return fmt.Sprintf("No Configuration matches selector %q referenced in traffic", e.selector)
+	}
+	return fmt.Sprintf("%d Configurations match selector %q referenced in traffic", len(e.matches), e.selector)
+}
+
+// MarkBadTrafficTarget implements TargetError.
+func (e *unresolvedSelectorError) MarkBadTrafficTarget(rs *v1alpha1.RouteStatus) {
+	rs.MarkUnresolvedConfigurationSelector(e.selector, e.matches)
+}
+
+// IsFailure implements TargetError.
+func (e *unresolvedSelectorError) IsFailure() bool {
+	return true
+}
+
+type invalidTargetError struct {
+	err *apis.FieldError // Why the traffic target is invalid.
+}
+
+var _ TargetError = (*invalidTargetError)(nil)
+
+// Error implements error.
+func (e *invalidTargetError) Error() string {
+	return e.err.Error()
+}
+
+// MarkBadTrafficTarget implements TargetError.
+func (e *invalidTargetError) MarkBadTrafficTarget(rs *v1alpha1.RouteStatus) {
+	rs.MarkInvalidTrafficTarget(e.err.Error())
+}
+
+// IsFailure implements TargetError.
+func (e *invalidTargetError) IsFailure() bool {
+	return true
+}
+
 type unreadyConfigError struct {
 	name      string // Name of the config that isn't ready.
 	isFailure bool   // True iff target fails to get ready.
@@ -108,6 +156,58 @@ func (e *unreadyRevisionError) IsFailure() bool {
 	return e.isFailure
 }
 
+// multiTargetError collects the TargetErrors of a Route with several traffic
+// targets, so that all of them are reported and not only the worst one.
+type multiTargetError struct {
+	primary TargetError   // The error that decides how the Route is marked.
+	errs    []TargetError // All errors, in the order of the traffic targets.
+	total   int           // Number of traffic targets of the Route.
+}
+
+var _ TargetError = (*multiTargetError)(nil)
+
+// Error implements error.
+func (e *multiTargetError) Error() string {
+	msgs := []string{fmt.Sprintf("%d of %d targets ready", e.total-len(e.errs), e.total)}
+	seen := make(map[string]bool, len(e.errs))
+	for _, err := range e.errs {
+		if msg := targetMessage(err); !seen[msg] {
+			seen[msg] = true
+			msgs = append(msgs, msg)
+		}
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// MarkBadTrafficTarget implements TargetError.
+func (e *multiTargetError) MarkBadTrafficTarget(rs *v1alpha1.RouteStatus) {
+	e.primary.MarkBadTrafficTarget(rs)
+	rs.MarkTrafficTargetsNotReady(e.Error())
+}
+
+// IsFailure implements TargetError.
+func (e *multiTargetError) IsFailure() bool {
+	return e.primary.IsFailure()
+}
+
+// targetMessage returns the message err puts on a Route's AllTrafficAssigned
+// condition, so that it reads the same within a summary.
+func targetMessage(err TargetError) string {
+	_, message := targetCondition(err)
+	return strings.TrimSuffix(message, ".")
+}
+
+// targetCondition returns the reason and message err puts on a Route's
+// AllTrafficAssigned condition.
+func targetCondition(err TargetError) (string, string) {
+	rs := &v1alpha1.RouteStatus{}
+	err.MarkBadTrafficTarget(rs)
+	if cond := rs.GetCondition(v1alpha1.RouteConditionAllTrafficAssigned); cond != nil {
+		return cond.Reason, cond.Message
+	}
+	return "", err.Error()
+}
+
 // errUnreadyConfiguration returns a TargetError for a Configuration that is not ready.
 func errUnreadyConfiguration(config *v1alpha1.Configuration) TargetError {
 	status := corev1.ConditionUnknown
@@ -140,6 +240,30 @@ func errMissingConfiguration(name string) TargetError {
 	}
 }
 
+// errMissingConfigurationGeneration returns a TargetError for a generation of
+// a Configuration that has no Revision.
+func errMissingConfigurationGeneration(name string, generation int64) TargetError {
+	return &missingTargetError{
+		kind: "Revision",
+		name: fmt.Sprintf("%s (generation %d)", name, generation),
+	}
+}
+
+// errUnresolvedConfigurationSelector returns a TargetError for a selector
+// that doesn't match exactly one Configuration.
+func errUnresolvedConfigurationSelector(selector string, matches []string) TargetError {
+	return &unresolvedSelectorError{
+		selector: selector,
+		matches:  matches,
+	}
+}
+
+// errInvalidTarget returns a TargetError for a traffic target that doesn't
+// name exactly one of a Revision, a Configuration or a Configuration selector.
+func errInvalidTarget(err *apis.FieldError) TargetError {
+	return &invalidTargetError{err: err}
+}
+
 // errMissingRevision returns a TargetError for a Revision that does not exist.
 func errMissingRevision(name string) TargetError {
 	return &missingTargetError{
@@ -175,3 +175,80 @@ func TestMarkBadTrafficTarget_RevisionNotYetReady(t *testing.T) {
 		}
 	}
 }
+
+func TestMarkBadTrafficTarget_MultipleTargets(t *testing.T) {
+	err := &multiTargetError{
+		primary: errUnreadyConfiguration(failedConfig),
+		errs: []TargetError{
+			errUnreadyRevision(unreadyRev),
+			errUnreadyConfiguration(failedConfig),
+			errUnreadyRevision(unreadyRev),
+		},
+		total: 4,
+	}
+	r := getTestRouteWithTrafficTargets([]v1alpha1.TrafficTarget{})
+
+	if !err.IsFailure() {
+		t.Error("IsFailure() = false, wanted the failure of the primary error")
+	}
+	err.MarkBadTrafficTarget(&r.Status)
+	for _, condType := range []duckv1alpha1.ConditionType{
+		v1alpha1.RouteConditionAllTrafficAssigned,
+		v1alpha1.RouteConditionReady,
+	} {
+		got := r.Status.GetCondition(condType)
+		want := &duckv1alpha1.Condition{
+			Type:   condType,
+			Status: corev1.ConditionFalse,
+			Reason: "RevisionMissing",
+			Message: `1 of 4 targets ready; Revision "unready-revision" is not yet ready; ` +
+				`Configuration "failed-config" does not have any ready Revision`,
+			LastTransitionTime: got.LastTransitionTime,
+			Severity:           "Error",
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("Unexpected condition diff (-want +got): %v", diff)
+		}
+	}
+}
+
+func TestMarkBadTrafficTarget_UnresolvedSelector(t *testing.T) {
+	tests := []struct {
+		name        string
+		err         TargetError
+		wantReason  string
+		wantMessage string
+	}{{
+		name:        "no match",
+		err:         errUnresolvedConfigurationSelector("app=red", nil),
+		wantReason:  "ConfigurationMissing",
+		wantMessage: `No Configuration matches selector "app=red" referenced in traffic.`,
+	}, {
+		name:        "several matches",
+		err:         errUnresolvedConfigurationSelector("team=colors", []string{"blue", "green"}),
+		wantReason:  "ConfigurationAmbiguous",
+		wantMessage: `Configurations "blue", "green" all match selector "team=colors" referenced in traffic.`,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if !test.err.IsFailure() {
+				t.Error("IsFailure() = false, wanted true")
+			}
+			r := getTestRouteWithTrafficTargets([]v1alpha1.TrafficTarget{})
+			test.err.MarkBadTrafficTarget(&r.Status)
+			got := r.Status.GetCondition(v1alpha1.RouteConditionAllTrafficAssigned)
+			want := &duckv1alpha1.Condition{
+				Type:               v1alpha1.RouteConditionAllTrafficAssigned,
+				Status:             corev1.ConditionFalse,
+				Reason:             test.wantReason,
+				Message:            test.wantMessage,
+				LastTransitionTime: got.LastTransitionTime,
+				Severity:           "Error",
+			}
+			if diff := cmp.Diff(want, got); diff != "" {
+				t.Errorf("Unexpected condition diff (-want +got): %v", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,184 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package traffic
+
+import (
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/knative/serving/pkg/apis/serving/v1alpha1"
+)
+
+// rollout carries what's needed to move the traffic of Configuration targets
+// to their latest ready Revision gradually.
+type rollout struct {
+	duration    time.Duration
+	stepPercent int
+	now         time.Time
+
+	// previous is the Route's status from before this reconciliation.
+	previous v1alpha1.RouteStatus
+
+	// inProgress are the rollouts that haven't completed yet, and nextStep
+	// is how long until the earliest of them should advance.
+	inProgress []v1alpha1.RolloutStatus
+	nextStep   time.Duration
+}
+
+func newRollout(u *v1alpha1.Route, now time.Time) *rollout {
+	if u.Spec.RolloutDuration == nil || u.Spec.RolloutDuration.Duration <= 0 {
+		return nil
+	}
+	return &rollout{
+		duration:    u.Spec.RolloutDuration.Duration,
+		stepPercent: u.Spec.RolloutStepPercent,
+		now:         now,
+		previous:    u.Status,
+	}
+}
+
+// progress returns the percentage of traffic moved to the new Revision of a
+// rollout started at the given time, and how long until its next step.  The
+// first step happens right away and the last one once the rollout duration
+// has elapsed, at which point progress returns 100.
+func (r *rollout) progress(start time.Time) (int, time.Duration) {
+	if r.stepPercent <= 0 || r.stepPercent >= 100 {
+		return 100, 0
+	}
+	steps := (100 + r.stepPercent - 1) / r.stepPercent
+	interval := r.duration / time.Duration(steps-1)
+	if interval <= 0 {
+		return 100, 0
+	}
+	elapsed := r.now.Sub(start)
+	if elapsed < 0 {
+		elapsed = 0
+	}
+	done := int(elapsed/interval) + 1
+	if done >= steps {
+		return 100, 0
+	}
+	return done * r.stepPercent, interval*time.Duration(done) - elapsed
+}
+
+func (r *rollout) track(rs v1alpha1.RolloutStatus, next time.Duration) {
+	r.inProgress = append(r.inProgress, rs)
+	if r.nextStep == 0 || next < r.nextStep {
+		r.nextStep = next
+	}
+}
+
+// findRollout returns the rollout of the given Configuration to the given
+// Revision, carrying on from the Route's status or starting a new one when
+// its traffic last went to another Revision of that Configuration.  It
+// returns nil when there's nothing to roll out from.
+func (t *configBuilder) findRollout(configName, revName string) (*v1alpha1.RolloutStatus, *v1alpha1.Revision, error) {
+	for _, rs := range t.rollout.previous.Rollouts {
+		if rs.ConfigurationName != configName || rs.RevisionName != revName {
+			continue
+		}
+		prev, err := t.getRoutableRevision(rs.PreviousRevisionName)
+		if prev == nil || err != nil {
+			return nil, nil, err
+		}
+		return rs.DeepCopy(), prev, nil
+	}
+
+	// Start from where most of the Configuration's traffic went before.
+	var from *v1alpha1.Revision
+	percent := -1
+	for _, tt := range t.rollout.previous.Traffic {
+		if tt.RevisionName == revName || tt.Percent <= percent {
+			continue
+		}
+		rev, err := t.getRoutableRevision(tt.RevisionName)
+		if err != nil {
+			return nil, nil, err
+		}
+		if rev == nil {
+			continue
+		}
+		if name, ok := configurationNameOf(rev); ok && name == configName {
+			from, percent = rev, tt.Percent
+		}
+	}
+	if from == nil {
+		return nil, nil, nil
+	}
+	return &v1alpha1.RolloutStatus{
+		ConfigurationName:    configName,
+		PreviousRevisionName: from.Name,
+		RevisionName:         revName,
+		StartTime:            metav1.NewTime(t.rollout.now),
+	}, from, nil
+}
+
+// getRoutableRevision returns the named Revision, or nil if it no longer
+// exists or can't receive traffic.
+func (t *configBuilder) getRoutableRevision(name string) (*v1alpha1.Revision, error) {
+	rev, err := t.revLister.Revisions(t.namespace).Get(name)
+	if errors.IsNotFound(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if !rev.Status.IsRoutable() {
+		return nil, nil
+	}
+	return rev, nil
+}
+
+// addRolloutTarget adds a target of the given Configuration, splitting its
+// traffic between the previous and the latest ready Revision while a rollout
+// between them is in progress.
+func (t *configBuilder) addRolloutTarget(target RevisionTarget, configName string) error {
+	rs, prev, err := t.findRollout(configName, target.RevisionName)
+	if err != nil {
+		return err
+	}
+	if rs == nil {
+		t.addFlattenedTarget(target)
+		return nil
+	}
+	share, next := t.rollout.progress(rs.StartTime.Time)
+	if share >= 100 {
+		t.addFlattenedTarget(target)
+		return nil
+	}
+	t.rollout.track(*rs, next)
+	t.revisions[prev.Name] = prev
+
+	previous := target
+	previous.TrafficTarget.RevisionName = prev.Name
+	previous.Active = !prev.Status.IsActivationRequired()
+	percent := target.TrafficTarget.Percent
+	target.TrafficTarget.Percent = percent * share / 100
+	previous.TrafficTarget.Percent = percent - target.TrafficTarget.Percent
+
+	t.revisionTargets = append(t.revisionTargets, previous, target)
+	t.targets[""] = append(t.targets[""], previous, target)
+	if name := target.TrafficTarget.Name; name != "" {
+		// Named targets get all of their traffic, so split that
+		// traffic rather than their share of the Route's.
+		previous.TrafficTarget.Percent = 100 - share
+		target.TrafficTarget.Percent = share
+		t.targets[name] = append(t.targets[name], previous, target)
+	}
+	return nil
+}
@@ -0,0 +1,262 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package traffic
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/knative/serving/pkg/apis/serving/v1alpha1"
+	. "github.com/knative/serving/pkg/reconciler/v1alpha1/testing"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var rolloutNow = time.Date(2018, 11, 1, 12, 0, 0, 0, time.UTC)
+
+func getTestRolloutRoute(tts []v1alpha1.TrafficTarget, status v1alpha1.RouteStatus) *v1alpha1.Route {
+	r := getTestRouteWithTrafficTargets(tts)
+	// Four steps of 25%, three minutes apart.
+	r.Spec.RolloutDuration = &metav1.Duration{Duration: 9 * time.Minute}
+	r.Spec.RolloutStepPercent = 25
+	r.Status = status
+	return r
+}
+
+func TestRolloutProgress(t *testing.T) {
+	tests := []struct {
+		name     string
+		step     int
+		elapsed  time.Duration
+		want     int
+		wantNext time.Duration
+	}{{
+		name:     "first step",
+		step:     25,
+		want:     25,
+		wantNext: 3 * time.Minute,
+	}, {
+		name:     "between steps",
+		step:     25,
+		elapsed:  4 * time.Minute,
+		want:     50,
+		wantNext: 2 * time.Minute,
+	}, {
+		name:     "last step",
+		step:     25,
+		elapsed:  6 * time.Minute,
+		want:     75,
+		wantNext: 3 * time.Minute,
+	}, {
+		name:    "complete",
+		step:    25,
+		elapsed: 9 * time.Minute,
+		want:    100,
+	}, {
+		name:     "uneven steps",
+		step:     40,
+		elapsed:  5 * time.Minute,
+		want:     80,
+		wantNext: 4 * time.Minute,
+	}, {
+		name: "single step",
+		step: 100,
+		want: 100,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := &rollout{
+				duration:    9 * time.Minute,
+				stepPercent: test.step,
+				now:         rolloutNow,
+			}
+			got, gotNext := r.progress(rolloutNow.Add(-test.elapsed))
+			if got != test.want || gotNext != test.wantNext {
+				t.Errorf("progress() = %d, %v, wanted %d, %v", got, gotNext, test.want, test.wantNext)
+			}
+		})
+	}
+}
+
+func TestBuildTrafficConfiguration_RolloutStart(t *testing.T) {
+	tts := []v1alpha1.TrafficTarget{{
+		ConfigurationName: goodConfig.Name,
+		Percent:           100,
+	}}
+	r := getTestRolloutRoute(tts, v1alpha1.RouteStatus{
+		Traffic: []v1alpha1.TrafficTarget{{
+			RevisionName: goodOldRev.Name,
+			Percent:      100,
+		}},
+	})
+	oldTarget := RevisionTarget{
+		TrafficTarget: v1alpha1.TrafficTarget{
+			ConfigurationName: goodConfig.Name,
+			RevisionName:      goodOldRev.Name,
+			Percent:           75,
+		},
+		Active: true,
+	}
+	newTarget := RevisionTarget{
+		TrafficTarget: v1alpha1.TrafficTarget{
+			ConfigurationName: goodConfig.Name,
+			RevisionName:      goodNewRev.Name,
+			Percent:           25,
+		},
+		Active: true,
+	}
+	expected := &Config{
+		Targets: map[string][]RevisionTarget{
+			"": {oldTarget, newTarget},
+		},
+		revisionTargets: []RevisionTarget{oldTarget, newTarget},
+		Configurations: map[string]*v1alpha1.Configuration{
+			goodConfig.Name: goodConfig,
+		},
+		Revisions: map[string]*v1alpha1.Revision{
+			goodOldRev.Name: goodOldRev,
+			goodNewRev.Name: goodNewRev,
+		},
+		Rollouts: []v1alpha1.RolloutStatus{{
+			ConfigurationName:    goodConfig.Name,
+			PreviousRevisionName: goodOldRev.Name,
+			RevisionName:         goodNewRev.Name,
+			StartTime:            metav1.NewTime(rolloutNow),
+		}},
+		NextRolloutStep: 3 * time.Minute,
+	}
+	tc, err := BuildTrafficConfigurationWithClock(configLister, revLister, r, FakeClock{Time: rolloutNow})
+	if err != nil {
+		t.Errorf("Unexpected error %v", err)
+	} else if diff := cmp.Diff(expected, tc, cmpOpts...); diff != "" {
+		t.Errorf("Unexpected traffic diff (-want +got): %v", diff)
+	}
+}
+
+func TestBuildTrafficConfiguration_RolloutNamedTarget(t *testing.T) {
+	tts := []v1alpha1.TrafficTarget{{
+		Name:              "beta",
+		ConfigurationName: goodConfig.Name,
+		Percent:           100,
+	}}
+	r := getTestRolloutRoute(tts, v1alpha1.RouteStatus{
+		Traffic: []v1alpha1.TrafficTarget{{
+			Name:         "beta",
+			RevisionName: goodOldRev.Name,
+			Percent:      50,
+		}, {
+			Name:         "beta",
+			RevisionName: goodNewRev.Name,
+			Percent:      50,
+		}},
+		Rollouts: []v1alpha1.RolloutStatus{{
+			ConfigurationName:    goodConfig.Name,
+			PreviousRevisionName: goodOldRev.Name,
+			RevisionName:         goodNewRev.Name,
+			StartTime:            metav1.NewTime(rolloutNow.Add(-7 * time.Minute)),
+		}},
+	})
+	tc, err := BuildTrafficConfigurationWithClock(configLister, revLister, r, FakeClock{Time: rolloutNow})
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	want := []v1alpha1.TrafficTarget{{
+		Name:              "beta",
+		ConfigurationName: goodConfig.Name,
+		RevisionName:      goodOldRev.Name,
+		Percent:           25,
+	}, {
+		Name:              "beta",
+		ConfigurationName: goodConfig.Name,
+		RevisionName:      goodNewRev.Name,
+		Percent:           75,
+	}}
+	if diff := cmp.Diff(want, tc.GetRevisionTrafficTargets()); diff != "" {
+		t.Errorf("Unexpected revision targets (-want +got): %v", diff)
+	}
+	for _, tt := range tc.Targets["beta"] {
+		if got, want := tt.Percent, map[string]int{goodOldRev.Name: 25, goodNewRev.Name: 75}[tt.RevisionName]; got != want {
+			t.Errorf("Named target %s percent = %d, wanted %d", tt.RevisionName, got, want)
+		}
+	}
+	if got, want := tc.NextRolloutStep, 2*time.Minute; got != want {
+		t.Errorf("NextRolloutStep = %v, wanted %v", got, want)
+	}
+}
+
+func TestBuildTrafficConfiguration_RolloutComplete(t *testing.T) {
+	tts := []v1alpha1.TrafficTarget{{
+		ConfigurationName: goodConfig.Name,
+		Percent:           100,
+	}}
+	r := getTestRolloutRoute(tts, v1alpha1.RouteStatus{
+		Traffic: []v1alpha1.TrafficTarget{{
+			RevisionName: goodOldRev.Name,
+			Percent:      25,
+		}, {
+			RevisionName: goodNewRev.Name,
+			Percent:      75,
+		}},
+		Rollouts: []v1alpha1.RolloutStatus{{
+			ConfigurationName:    goodConfig.Name,
+			PreviousRevisionName: goodOldRev.Name,
+			RevisionName:         goodNewRev.Name,
+			StartTime:            metav1.NewTime(rolloutNow.Add(-9 * time.Minute)),
+		}},
+	})
+	tc, err := BuildTrafficConfigurationWithClock(configLister, revLister, r, FakeClock{Time: rolloutNow})
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	want := []v1alpha1.TrafficTarget{{
+		ConfigurationName: goodConfig.Name,
+		RevisionName:      goodNewRev.Name,
+		Percent:           100,
+	}}
+	if diff := cmp.Diff(want, tc.GetRevisionTrafficTargets()); diff != "" {
+		t.Errorf("Unexpected revision targets (-want +got): %v", diff)
+	}
+	if len(tc.Rollouts) != 0 || tc.NextRolloutStep != 0 {
+		t.Errorf("Rollouts = %v, %v, wanted none", tc.Rollouts, tc.NextRolloutStep)
+	}
+}
+
+func TestBuildTrafficConfiguration_RolloutFromUnroutableRevision(t *testing.T) {
+	tts := []v1alpha1.TrafficTarget{{
+		ConfigurationName: goodConfig.Name,
+		Percent:           100,
+	}}
+	r := getTestRolloutRoute(tts, v1alpha1.RouteStatus{
+		Traffic: []v1alpha1.TrafficTarget{{
+			RevisionName: missingRev.Name,
+			Percent:      100,
+		}},
+	})
+	tc, err := BuildTrafficConfigurationWithClock(configLister, revLister, r, FakeClock{Time: rolloutNow})
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	want := []v1alpha1.TrafficTarget{{
+		ConfigurationName: goodConfig.Name,
+		RevisionName:      goodNewRev.Name,
+		Percent:           100,
+	}}
+	if diff := cmp.Diff(want, tc.GetRevisionTrafficTargets()); diff != "" {
+		t.Errorf("Unexpected revision targets (-want +got): %v", diff)
+	}
+}
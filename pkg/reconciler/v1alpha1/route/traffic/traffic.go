@@ -17,17 +17,28 @@ limitations under the License.
 package traffic
 
 import (
+	"sort"
+	"strconv"
+	"time"
+
 	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 
+	"github.com/knative/pkg/apis"
 	"github.com/knative/serving/pkg/apis/serving"
 	"github.com/knative/serving/pkg/apis/serving/v1alpha1"
 	listers "github.com/knative/serving/pkg/client/listers/serving/v1alpha1"
+	"github.com/knative/serving/pkg/system"
 )
 
 // A RevisionTarget adds the Active/Inactive state of a Revision to a flattened TrafficTarget.
 type RevisionTarget struct {
 	v1alpha1.TrafficTarget
 	Active bool
+	// Draining is true for a Revision that no longer receives traffic, but
+	// keeps a 0% destination for the requests it is still serving.
+	Draining bool
 }
 
 // Config encapsulates details of our traffic so that we don't need to make API calls, or use details of the
@@ -43,9 +54,18 @@ type Config struct {
 	// is used to populate the Route.Status.TrafficTarget field.
 	revisionTargets []RevisionTarget
 
-	// The referred `Configuration`s and `Revision`s.
+	// The referred `Configuration`s and `Revision`s, keyed by ObjectKey.
 	Configurations map[string]*v1alpha1.Configuration
 	Revisions      map[string]*v1alpha1.Revision
+
+	// TargetStatuses reports on each traffic target of the Route, in
+	// order, whether it resolved to a ready Revision.
+	TargetStatuses []v1alpha1.TargetStatus
+
+	// Rollouts lists the gradual rollouts still in progress, and
+	// NextRolloutStep is how long until the earliest of them advances.
+	Rollouts        []v1alpha1.RolloutStatus
+	NextRolloutStep time.Duration
 }
 
 // BuildTrafficConfiguration consolidates and flattens the Route.Spec.Traffic to the Revision-level. It also provides a
@@ -55,9 +75,17 @@ type Config struct {
 // In the case that some target is missing, an error of type TargetError will be returned.
 func BuildTrafficConfiguration(configLister listers.ConfigurationLister, revLister listers.RevisionLister,
 	u *v1alpha1.Route) (*Config, error) {
+	return BuildTrafficConfigurationWithClock(configLister, revLister, u, system.RealClock{})
+}
+
+// BuildTrafficConfigurationWithClock is BuildTrafficConfiguration, using the
+// given clock to advance gradual rollouts requested by the Route.
+func BuildTrafficConfigurationWithClock(configLister listers.ConfigurationLister, revLister listers.RevisionLister,
+	u *v1alpha1.Route, clock system.Clock) (*Config, error) {
 	builder := newBuilder(configLister, revLister, u.Namespace)
-	for _, tt := range u.Spec.Traffic {
-		if err := builder.addTrafficTarget(&tt); err != nil {
+	builder.rollout = newRollout(u, clock.Now())
+	for i, tt := range u.Spec.Traffic {
+		if err := builder.addTrafficTarget(i, &tt); err != nil {
 			// Other non-traffic target errors shouldn't be ignored.
 			return nil, err
 		}
@@ -65,15 +93,130 @@ func BuildTrafficConfiguration(configLister listers.ConfigurationLister, revList
 	return builder.build()
 }
 
-// GetRevisionTrafficTargets return a list of TrafficTarget flattened to the RevisionName, and having ConfigurationName cleared out.
+// ObjectKey returns the key a Configuration or Revision referred by a traffic
+// target is kept under: its name, qualified by its namespace when the target
+// names a namespace other than the Route's.
+func ObjectKey(namespace, name string) string {
+	if namespace == "" {
+		return name
+	}
+	return namespace + "/" + name
+}
+
+// GetRevisionTrafficTargets return a list of TrafficTarget flattened to the RevisionName.
+// Each carries the Configuration and Service the Revision comes from, and the
+// image digest resolved by the Revision, if any.
 func (t *Config) GetRevisionTrafficTargets() []v1alpha1.TrafficTarget {
 	results := make([]v1alpha1.TrafficTarget, len(t.revisionTargets))
 	for i, tt := range t.revisionTargets {
-		results[i] = v1alpha1.TrafficTarget{RevisionName: tt.RevisionName, Name: tt.Name, Percent: tt.Percent}
+		results[i] = v1alpha1.TrafficTarget{RevisionName: tt.RevisionName, ConfigurationName: tt.ConfigurationName,
+			Namespace: tt.Namespace, Name: tt.Name, Percent: tt.Percent, Mirror: tt.Mirror, Subset: tt.Subset}
+		if config, ok := t.Configurations[ObjectKey(tt.Namespace, tt.ConfigurationName)]; ok {
+			results[i].ServiceName = config.Labels[serving.ServiceLabelKey]
+		}
+		if rev, ok := t.Revisions[ObjectKey(tt.Namespace, tt.RevisionName)]; ok {
+			results[i].ImageDigest = rev.Status.ImageDigest
+		}
 	}
 	return results
 }
 
+// GetActiveTargets returns the traffic split over Revisions that the Route
+// serves by default, and whether each Revision is active.
+func (t *Config) GetActiveTargets() []v1alpha1.ActiveTarget {
+	targets := t.Targets[""]
+	if len(targets) == 0 {
+		return nil
+	}
+	results := make([]v1alpha1.ActiveTarget, 0, len(targets))
+	for _, tt := range targets {
+		if tt.Mirror || tt.Draining {
+			// The mirror doesn't take part in the split, and draining
+			// Revisions left it.
+			continue
+		}
+		results = append(results, v1alpha1.ActiveTarget{RevisionName: tt.RevisionName, Percent: tt.Percent, Active: tt.Active})
+	}
+	return results
+}
+
+// RequiresActivation returns whether some of the traffic of the Route goes
+// through the activator, i.e. to Revisions that aren't active.
+func (t *Config) RequiresActivation() bool {
+	for _, tts := range t.Targets {
+		for _, tt := range tts {
+			// Mirrors get their copy directly, and targets without
+			// any traffic aren't routed to.
+			if !tt.Active && !tt.Mirror && tt.Percent > 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Protocol returns the protocol served by the Revisions receiving the traffic
+// of the Route.  It's HTTP/1 unless they all serve the same other protocol.
+func (t *Config) Protocol() v1alpha1.RevisionProtocolType {
+	var protocol v1alpha1.RevisionProtocolType
+	for _, tt := range t.Targets[""] {
+		if tt.Draining {
+			continue
+		}
+		rev, ok := t.Revisions[ObjectKey(tt.Namespace, tt.RevisionName)]
+		if !ok {
+			continue
+		}
+		switch p := rev.GetProtocol(); {
+		case protocol == "":
+			protocol = p
+		case protocol != p:
+			return v1alpha1.RevisionProtocolHTTP1
+		}
+	}
+	if protocol == "" {
+		return v1alpha1.RevisionProtocolHTTP1
+	}
+	return protocol
+}
+
+// ReadinessPath returns the path of the HTTP readiness probe of the Revisions
+// receiving the traffic of the Route, if they all probe the same one.
+func (t *Config) ReadinessPath() string {
+	path := ""
+	for _, tt := range t.Targets[""] {
+		if tt.Mirror || tt.Percent == 0 {
+			continue
+		}
+		rev, ok := t.Revisions[ObjectKey(tt.Namespace, tt.RevisionName)]
+		if !ok {
+			return ""
+		}
+		probe := rev.Spec.Container.ReadinessProbe
+		if probe == nil || probe.HTTPGet == nil || probe.HTTPGet.Path == "" {
+			return ""
+		}
+		switch p := probe.HTTPGet.Path; {
+		case path == "":
+			path = p
+		case path != p:
+			return ""
+		}
+	}
+	return path
+}
+
+// Drain keeps a 0% destination for the given Revision of the Route's default
+// traffic, which it no longer receives, so the requests it is still serving
+// complete.
+func (t *Config) Drain(revisionName string) {
+	t.Targets[""] = append(t.Targets[""], RevisionTarget{
+		TrafficTarget: v1alpha1.TrafficTarget{RevisionName: revisionName},
+		Active:        true,
+		Draining:      true,
+	})
+}
+
 type configBuilder struct {
 	configLister listers.ConfigurationLister
 	revLister    listers.RevisionLister
@@ -85,13 +228,24 @@ type configBuilder struct {
 	// revisionTargets is the original list of targets, at the Revision level.
 	revisionTargets []RevisionTarget
 
-	// configurations contains all the referred Configuration, keyed by their name.
+	// configurations contains all the referred Configuration, keyed by ObjectKey.
 	configurations map[string]*v1alpha1.Configuration
-	// revisions contains all the referred Revision, keyed by their name.
+	// revisions contains all the referred Revision, keyed by ObjectKey.
 	revisions map[string]*v1alpha1.Revision
 
+	// rollout, when set, moves Configuration targets to their latest
+	// ready Revision gradually.
+	rollout *rollout
+
 	// TargetError are deferred until we got a complete list of all referred targets.
 	deferredTargetErr TargetError
+	// targetErrs holds every deferred TargetError, and targetCount the number of
+	// traffic targets they are out of.
+	targetErrs  []TargetError
+	targetCount int
+
+	// targetStatuses holds the outcome of each traffic target.
+	targetStatuses []v1alpha1.TargetStatus
 }
 
 func newBuilder(configLister listers.ConfigurationLister, revLister listers.RevisionLister, namespace string) *configBuilder {
@@ -106,30 +260,41 @@ func newBuilder(configLister listers.ConfigurationLister, revLister listers.Revi
 	}
 }
 
-func (t *configBuilder) getConfiguration(name string) (*v1alpha1.Configuration, error) {
-	if _, ok := t.configurations[name]; !ok {
-		config, err := t.configLister.Configurations(t.namespace).Get(name)
+// namespaceOf returns the namespace the objects referred by a traffic target
+// live in.  Targets leave it empty for the namespace of the Route.
+func (t *configBuilder) namespaceOf(ns string) string {
+	if ns == "" {
+		return t.namespace
+	}
+	return ns
+}
+
+func (t *configBuilder) getConfiguration(ns, name string) (*v1alpha1.Configuration, error) {
+	key := ObjectKey(ns, name)
+	if _, ok := t.configurations[key]; !ok {
+		config, err := t.configLister.Configurations(t.namespaceOf(ns)).Get(name)
 		if errors.IsNotFound(err) {
-			return nil, errMissingConfiguration(name)
+			return nil, errMissingConfiguration(key)
 		} else if err != nil {
 			return nil, err
 		}
-		t.configurations[name] = config
+		t.configurations[key] = config
 	}
-	return t.configurations[name], nil
+	return t.configurations[key], nil
 }
 
-func (t *configBuilder) getRevision(name string) (*v1alpha1.Revision, error) {
-	if _, ok := t.revisions[name]; !ok {
-		rev, err := t.revLister.Revisions(t.namespace).Get(name)
+func (t *configBuilder) getRevision(ns, name string) (*v1alpha1.Revision, error) {
+	key := ObjectKey(ns, name)
+	if _, ok := t.revisions[key]; !ok {
+		rev, err := t.revLister.Revisions(t.namespaceOf(ns)).Get(name)
 		if errors.IsNotFound(err) {
-			return nil, errMissingRevision(name)
+			return nil, errMissingRevision(key)
 		} else if err != nil {
 			return nil, err
 		}
-		t.revisions[name] = rev
+		t.revisions[key] = rev
 	}
-	return t.revisions[name], nil
+	return t.revisions[key], nil
 }
 
 // deferTargetError will record a TargetError.  A TargetError with
@@ -138,35 +303,86 @@ func (t *configBuilder) deferTargetError(err TargetError) {
 	if t.deferredTargetErr == nil || err.IsFailure() {
 		t.deferredTargetErr = err
 	}
+	t.targetErrs = append(t.targetErrs, err)
 }
 
-func (t *configBuilder) addTrafficTarget(tt *v1alpha1.TrafficTarget) error {
+// validateTarget checks that the traffic target at the given index names
+// exactly one of a Revision and a Configuration, or a Configuration selector.
+// The webhook rejects such targets too, but Routes stored before it did
+// still reach the reconciler.
+func validateTarget(index int, tt *v1alpha1.TrafficTarget) *apis.FieldError {
+	var err *apis.FieldError
+	switch {
+	case tt.RevisionName != "" && tt.ConfigurationName != "":
+		err = apis.ErrMultipleOneOf("revisionName", "configurationName")
+	case tt.RevisionName == "" && tt.ConfigurationName == "" && tt.ConfigurationSelector == nil:
+		err = apis.ErrMissingOneOf("revisionName", "configurationName", "configurationSelector")
+	default:
+		return nil
+	}
+	return err.ViaFieldIndex("traffic", index).ViaField("spec")
+}
+
+func (t *configBuilder) addTrafficTarget(index int, tt *v1alpha1.TrafficTarget) error {
+	if tt.Namespace == t.namespace {
+		// Naming the namespace of the Route is the same as leaving it out.
+		local := *tt
+		local.Namespace = ""
+		tt = &local
+	}
+	t.targetCount++
+	added := len(t.revisionTargets)
 	var err error
-	if tt.RevisionName != "" {
+	if ferr := validateTarget(index, tt); ferr != nil {
+		err = errInvalidTarget(ferr)
+	} else if tt.RevisionName != "" {
 		err = t.addRevisionTarget(tt)
 	} else if tt.ConfigurationName != "" {
 		err = t.addConfigurationTarget(tt)
+	} else if tt.ConfigurationSelector != nil {
+		err = t.addConfigurationSelectorTarget(tt)
+	}
+	status := v1alpha1.TargetStatus{
+		Name:         tt.Name,
+		RevisionName: tt.RevisionName,
 	}
 	if err, ok := err.(TargetError); err != nil && ok {
 		// Defer target errors, as we still want to compile a list of
 		// all referred targets, including missing ones.
 		t.deferTargetError(err)
+		if rerr, ok := err.(*unreadyRevisionError); ok {
+			status.RevisionName = rerr.name
+		}
+		status.Reason, status.Message = targetCondition(err)
+		t.targetStatuses = append(t.targetStatuses, status)
 		return nil
+	} else if err != nil {
+		return err
+	}
+	if len(t.revisionTargets) > added {
+		// The latest of the Revisions the target resolved to, e.g. the
+		// one a gradual rollout moves its traffic to.
+		status.RevisionName = t.revisionTargets[len(t.revisionTargets)-1].RevisionName
 	}
-	return err
+	status.Ready = true
+	t.targetStatuses = append(t.targetStatuses, status)
+	return nil
 }
 
 // addConfigurationTarget flattens a traffic target to the Revision level, by looking up for the LatestReadyRevisionName
 // on the referred Configuration.  It adds both to the lists of directly referred targets.
 func (t *configBuilder) addConfigurationTarget(tt *v1alpha1.TrafficTarget) error {
-	config, err := t.getConfiguration(tt.ConfigurationName)
+	config, err := t.getConfiguration(tt.Namespace, tt.ConfigurationName)
 	if err != nil {
 		return err
 	}
+	if tt.ConfigurationGeneration != nil {
+		return t.addConfigurationGenerationTarget(tt, config)
+	}
 	if config.Status.LatestReadyRevisionName == "" {
 		return errUnreadyConfiguration(config)
 	}
-	rev, err := t.getRevision(config.Status.LatestReadyRevisionName)
+	rev, err := t.getRevision(tt.Namespace, config.Status.LatestReadyRevisionName)
 	if err != nil {
 		return err
 	}
@@ -175,12 +391,103 @@ func (t *configBuilder) addConfigurationTarget(tt *v1alpha1.TrafficTarget) error
 		Active:        !rev.Status.IsActivationRequired(),
 	}
 	target.TrafficTarget.RevisionName = rev.Name
+	// Rollouts only track the Configurations of the Route's namespace.
+	if t.rollout != nil && !tt.Mirror && tt.Namespace == "" {
+		return t.addRolloutTarget(target, config.Name)
+	}
+	t.addFlattenedTarget(target)
+	return nil
+}
+
+// addConfigurationSelectorTarget resolves the selector of a traffic target
+// to the single Configuration it matches, and adds that as the target.
+func (t *configBuilder) addConfigurationSelectorTarget(tt *v1alpha1.TrafficTarget) error {
+	selector, err := metav1.LabelSelectorAsSelector(tt.ConfigurationSelector)
+	if err != nil {
+		return err
+	}
+	configs, err := t.configLister.Configurations(t.namespace).List(selector)
+	if err != nil {
+		return err
+	}
+	if tt.SplitEvenly && len(configs) > 0 {
+		return t.addSplitEvenlyTargets(tt, configs)
+	}
+	if len(configs) != 1 {
+		names := make([]string, 0, len(configs))
+		for _, config := range configs {
+			// Report on, and track, all of the candidates.
+			t.configurations[config.Name] = config
+			names = append(names, config.Name)
+		}
+		sort.Strings(names)
+		return errUnresolvedConfigurationSelector(selector.String(), names)
+	}
+	t.configurations[configs[0].Name] = configs[0]
+	resolved := *tt
+	resolved.ConfigurationName = configs[0].Name
+	resolved.ConfigurationSelector = nil
+	return t.addConfigurationTarget(&resolved)
+}
+
+// addSplitEvenlyTargets spreads the percentage of a traffic target over the
+// given Configurations, in the order of their names.  What's left over from
+// rounding goes one percent at a time to the last of them.
+func (t *configBuilder) addSplitEvenlyTargets(tt *v1alpha1.TrafficTarget, configs []*v1alpha1.Configuration) error {
+	sort.Slice(configs, func(i, j int) bool { return configs[i].Name < configs[j].Name })
+	for _, config := range configs {
+		// Track all of them, even if one isn't ready.
+		t.configurations[config.Name] = config
+	}
+	n := len(configs)
+	for i, config := range configs {
+		resolved := *tt
+		resolved.ConfigurationName = config.Name
+		resolved.ConfigurationSelector = nil
+		resolved.SplitEvenly = false
+		resolved.Percent = tt.Percent / n
+		if i >= n-tt.Percent%n {
+			resolved.Percent++
+		}
+		if err := t.addConfigurationTarget(&resolved); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addConfigurationGenerationTarget flattens a traffic target pinned to a
+// generation of the given Configuration to the Revision created for it.
+func (t *configBuilder) addConfigurationGenerationTarget(tt *v1alpha1.TrafficTarget, config *v1alpha1.Configuration) error {
+	generation := *tt.ConfigurationGeneration
+	revs, err := t.revLister.Revisions(t.namespaceOf(tt.Namespace)).List(labels.SelectorFromSet(labels.Set{
+		serving.ConfigurationLabelKey:                   config.Name,
+		serving.ConfigurationMetadataGenerationLabelKey: strconv.FormatInt(generation, 10),
+	}))
+	if err != nil {
+		return err
+	}
+	if len(revs) == 0 {
+		return errMissingConfigurationGeneration(config.Name, generation)
+	}
+	// There is only ever one Revision per generation, barring manual edits.
+	sort.Slice(revs, func(i, j int) bool { return revs[i].Name < revs[j].Name })
+	rev := revs[0]
+	if !rev.Status.IsRoutable() {
+		return errUnreadyRevision(rev)
+	}
+	t.revisions[ObjectKey(tt.Namespace, rev.Name)] = rev
+	target := RevisionTarget{
+		TrafficTarget: *tt,
+		Active:        !rev.Status.IsActivationRequired(),
+	}
+	target.TrafficTarget.RevisionName = rev.Name
 	t.addFlattenedTarget(target)
 	return nil
 }
 
 func (t *configBuilder) addRevisionTarget(tt *v1alpha1.TrafficTarget) error {
-	rev, err := t.getRevision(tt.RevisionName)
+	rev, err := t.getRevision(tt.Namespace, tt.RevisionName)
 	if err != nil {
 		return err
 	}
@@ -191,10 +498,9 @@ func (t *configBuilder) addRevisionTarget(tt *v1alpha1.TrafficTarget) error {
 		TrafficTarget: *tt,
 		Active:        !rev.Status.IsActivationRequired(),
 	}
-	t.revisions[tt.RevisionName] = rev
-	if configName, ok := rev.Labels[serving.ConfigurationLabelKey]; ok {
+	if configName, ok := configurationNameOf(rev); ok {
 		target.TrafficTarget.ConfigurationName = configName
-		if _, err := t.getConfiguration(configName); err != nil {
+		if _, err := t.getConfiguration(tt.Namespace, configName); err != nil {
 			return err
 		}
 	}
@@ -202,6 +508,19 @@ func (t *configBuilder) addRevisionTarget(tt *v1alpha1.TrafficTarget) error {
 	return nil
 }
 
+// configurationNameOf returns the name of the Configuration that created
+// the Revision.  Revisions are labelled with it, but may only be linked to
+// it through their owner reference, e.g. when created by older releases.
+func configurationNameOf(rev *v1alpha1.Revision) (string, bool) {
+	if name, ok := rev.Labels[serving.ConfigurationLabelKey]; ok {
+		return name, true
+	}
+	if owner := metav1.GetControllerOf(rev); owner != nil && owner.Kind == "Configuration" {
+		return owner.Name, true
+	}
+	return "", false
+}
+
 func (t *configBuilder) addFlattenedTarget(target RevisionTarget) {
 	name := target.TrafficTarget.Name
 	t.revisionTargets = append(t.revisionTargets, target)
@@ -211,11 +530,22 @@ func (t *configBuilder) addFlattenedTarget(target RevisionTarget) {
 	}
 }
 
+// consolidate coalesces targets pointing at the same Revision into a single
+// target carrying the sum of their percentages, so that each Revision only
+// shows up once as a destination.  A mirror target is kept apart, as it isn't
+// a destination of the traffic split, and so are targets of distinct subsets
+// of a Revision.
 func consolidate(targets []RevisionTarget) []RevisionTarget {
 	byName := make(map[string]RevisionTarget)
 	names := []string{}
 	for _, tt := range targets {
-		name := tt.TrafficTarget.RevisionName
+		name := ObjectKey(tt.TrafficTarget.Namespace, tt.TrafficTarget.RevisionName)
+		if tt.TrafficTarget.Subset != "" {
+			name += "@" + tt.TrafficTarget.Subset
+		}
+		if tt.TrafficTarget.Mirror {
+			name = "mirror:" + name
+		}
 		cur, ok := byName[name]
 		if !ok {
 			byName[name] = tt
@@ -229,10 +559,49 @@ func consolidate(targets []RevisionTarget) []RevisionTarget {
 	for i, name := range names {
 		consolidated[i] = byName[name]
 	}
-	if len(consolidated) == 1 {
-		consolidated[0].TrafficTarget.Percent = 100
+	return normalize(consolidated)
+}
+
+// normalize scales the percentages of the given targets so that they sum to
+// exactly 100, as required of the weights of a route.  Each target gets the
+// floor of its share, and what's left over from rounding goes one percent at
+// a time to the largest targets, earlier targets first among equals, so the
+// outcome is deterministic.  A lone target takes all of the traffic, but
+// targets none of which were given any traffic are left without it.
+func normalize(targets []RevisionTarget) []RevisionTarget {
+	if len(targets) == 1 {
+		targets[0].TrafficTarget.Percent = 100
+		return targets
+	}
+	total := 0
+	for _, tt := range targets {
+		total += tt.TrafficTarget.Percent
 	}
-	return consolidated
+	if total == 0 || total == 100 {
+		return targets
+	}
+	weights := make([]int, len(targets))
+	for i, tt := range targets {
+		weights[i] = tt.TrafficTarget.Percent
+	}
+
+	remainder := 100
+	for i := range targets {
+		targets[i].TrafficTarget.Percent = weights[i] * 100 / total
+		remainder -= targets[i].TrafficTarget.Percent
+	}
+	order := make([]int, len(targets))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return weights[order[i]] > weights[order[j]]
+	})
+	// Rounding down loses less than a percent per target.
+	for _, i := range order[:remainder] {
+		targets[i].TrafficTarget.Percent++
+	}
+	return targets
 }
 
 func consolidateAll(targets map[string][]RevisionTarget) map[string][]RevisionTarget {
@@ -247,11 +616,25 @@ func (t *configBuilder) build() (*Config, error) {
 	if t.deferredTargetErr != nil {
 		t.targets = nil
 		t.revisionTargets = nil
+		t.rollout = nil
 	}
-	return &Config{
+	c := &Config{
 		Targets:         consolidateAll(t.targets),
 		revisionTargets: t.revisionTargets,
 		Configurations:  t.configurations,
 		Revisions:       t.revisions,
-	}, t.deferredTargetErr
+		TargetStatuses:  t.targetStatuses,
+	}
+	if t.rollout != nil {
+		c.Rollouts = t.rollout.inProgress
+		c.NextRolloutStep = t.rollout.nextStep
+	}
+	if t.deferredTargetErr != nil && t.targetCount > 1 {
+		return c, &multiTargetError{
+			primary: t.deferredTargetErr,
+			errs:    t.targetErrs,
+			total:   t.targetCount,
+		}
+	}
+	return c, t.deferredTargetErr
 }
@@ -16,10 +16,15 @@ limitations under the License.
 package traffic
 
 import (
+	"fmt"
 	"os"
+	"strings"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/knative/pkg/apis"
+	"github.com/knative/pkg/kmeta"
 	duckv1alpha1 "github.com/knative/pkg/apis/duck/v1alpha1"
 	"github.com/knative/serving/pkg/apis/serving"
 	"github.com/knative/serving/pkg/apis/serving/v1alpha1"
@@ -72,10 +77,18 @@ var (
 	niceOldRev *v1alpha1.Revision
 	niceNewRev *v1alpha1.Revision
 
+	// ownedRev is a good revision of niceConfig that is only linked to it
+	// through its owner reference, not its label.
+	ownedRev *v1alpha1.Revision
+
 	configLister listers.ConfigurationLister
 	revLister    listers.RevisionLister
 
-	cmpOpts = []cmp.Option{cmp.AllowUnexported(Config{})}
+	// TargetStatuses are covered by TestBuildTrafficConfiguration_TargetStatuses.
+	cmpOpts = []cmp.Option{
+		cmp.AllowUnexported(Config{}),
+		cmpopts.IgnoreFields(Config{}, "TargetStatuses"),
+	}
 )
 
 func setUp() {
@@ -86,6 +99,10 @@ func setUp() {
 	inactiveConfig, inactiveRev = getTestInactiveConfig("inactive")
 	goodConfig, goodOldRev, goodNewRev = getTestReadyConfig("good")
 	niceConfig, niceOldRev, niceNewRev = getTestReadyConfig("nice")
+	ownedRev = niceOldRev.DeepCopy()
+	ownedRev.Name = "nice-revision-owned"
+	ownedRev.Labels = nil
+	ownedRev.OwnerReferences = []metav1.OwnerReference{*kmeta.NewControllerRef(niceConfig)}
 	servingClient := fakeclientset.NewSimpleClientset()
 
 	servingInformer := informers.NewSharedInformerFactory(servingClient, 0)
@@ -103,6 +120,7 @@ func setUp() {
 		emptyConfig,
 		goodConfig, goodOldRev, goodNewRev,
 		niceConfig, niceOldRev, niceNewRev,
+		ownedRev,
 	}
 
 	for _, obj := range objs {
@@ -152,6 +170,89 @@ func TestBuildTrafficConfiguration_Vanilla(t *testing.T) {
 	}
 }
 
+func TestBuildTrafficConfiguration_CrossNamespace(t *testing.T) {
+	tts := []v1alpha1.TrafficTarget{{
+		ConfigurationName: goodConfig.Name,
+		Namespace:         testNamespace,
+		Percent:           100,
+	}}
+	target := RevisionTarget{
+		TrafficTarget: v1alpha1.TrafficTarget{
+			ConfigurationName: goodConfig.Name,
+			RevisionName:      goodNewRev.Name,
+			Namespace:         testNamespace,
+			Percent:           100,
+		},
+		Active: true,
+	}
+	expected := &Config{
+		Targets:         map[string][]RevisionTarget{"": {target}},
+		revisionTargets: []RevisionTarget{target},
+		// Objects of another namespace than the Route's are keyed by both.
+		Configurations: map[string]*v1alpha1.Configuration{
+			ObjectKey(testNamespace, goodConfig.Name): goodConfig,
+		},
+		Revisions: map[string]*v1alpha1.Revision{
+			ObjectKey(testNamespace, goodNewRev.Name): goodNewRev,
+		},
+	}
+	r := getTestRouteWithTrafficTargets(tts)
+	r.Namespace = "elsewhere"
+	if tc, err := BuildTrafficConfiguration(configLister, revLister, r); err != nil {
+		t.Errorf("Unexpected error %v", err)
+	} else if got, want := expected, tc; !cmp.Equal(got, want, cmpOpts...) {
+		t.Errorf("Unexpected traffic diff (-want +got): %v", cmp.Diff(got, want, cmpOpts...))
+	} else if got, want := tc.GetRevisionTrafficTargets()[0].Namespace, testNamespace; got != want {
+		t.Errorf("Status traffic namespace = %q, want %q", got, want)
+	}
+
+	// Naming the Route's own namespace is the same as leaving it out.
+	r.Namespace = testNamespace
+	tc, err := BuildTrafficConfiguration(configLister, revLister, r)
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if _, ok := tc.Revisions[goodNewRev.Name]; !ok {
+		t.Errorf("Revisions = %v, want one keyed %q", tc.Revisions, goodNewRev.Name)
+	}
+}
+
+// A Revision may only be linked to its Configuration through its owner,
+// which is then reported as the ConfigurationName of its target.
+func TestBuildTrafficConfiguration_OwnedRevision(t *testing.T) {
+	tts := []v1alpha1.TrafficTarget{{
+		RevisionName: ownedRev.Name,
+		Percent:      100,
+	}}
+	expected := &Config{
+		Targets: map[string][]RevisionTarget{
+			"": {{
+				TrafficTarget: v1alpha1.TrafficTarget{
+					RevisionName:      ownedRev.Name,
+					ConfigurationName: niceConfig.Name,
+					Percent:           100,
+				},
+				Active: true,
+			}},
+		},
+		revisionTargets: []RevisionTarget{{
+			TrafficTarget: v1alpha1.TrafficTarget{
+				ConfigurationName: niceConfig.Name,
+				RevisionName:      ownedRev.Name,
+				Percent:           100,
+			},
+			Active: true,
+		}},
+		Configurations: map[string]*v1alpha1.Configuration{niceConfig.Name: niceConfig},
+		Revisions:      map[string]*v1alpha1.Revision{ownedRev.Name: ownedRev},
+	}
+	if tc, err := BuildTrafficConfiguration(configLister, revLister, getTestRouteWithTrafficTargets(tts)); err != nil {
+		t.Errorf("Unexpected error %v", err)
+	} else if got, want := expected, tc; !cmp.Equal(got, want, cmpOpts...) {
+		t.Errorf("Unexpected traffic diff (-want +got): %v", cmp.Diff(got, want, cmpOpts...))
+	}
+}
+
 func TestBuildTrafficConfiguration_NoNameRevision(t *testing.T) {
 	tts := []v1alpha1.TrafficTarget{{
 		RevisionName: goodNewRev.Name,
@@ -422,6 +523,74 @@ func TestBuildTrafficConfiguration_Consolidated(t *testing.T) {
 	}
 }
 
+// Duplicate references to the same revision, through both configuration and
+// revision targets, are coalesced into a single destination for the default host,
+// while named targets keep their own hosts.
+func TestBuildTrafficConfiguration_DuplicateTargets(t *testing.T) {
+	tts := []v1alpha1.TrafficTarget{{
+		ConfigurationName: goodConfig.Name,
+		Percent:           30,
+	}, {
+		RevisionName: goodNewRev.Name,
+		Percent:      20,
+	}, {
+		RevisionName: goodOldRev.Name,
+		Percent:      50,
+	}, {
+		Name:         "a",
+		RevisionName: goodNewRev.Name,
+	}, {
+		Name:         "b",
+		RevisionName: goodNewRev.Name,
+	}}
+	tc, err := BuildTrafficConfiguration(configLister, revLister, getTestRouteWithTrafficTargets(tts))
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	expected := map[string][]RevisionTarget{
+		"": {{
+			TrafficTarget: v1alpha1.TrafficTarget{
+				ConfigurationName: goodConfig.Name,
+				RevisionName:      goodNewRev.Name,
+				Percent:           50,
+			},
+			Active: true,
+		}, {
+			TrafficTarget: v1alpha1.TrafficTarget{
+				ConfigurationName: goodConfig.Name,
+				RevisionName:      goodOldRev.Name,
+				Percent:           50,
+			},
+			Active: true,
+		}},
+		"a": {{
+			TrafficTarget: v1alpha1.TrafficTarget{
+				Name:              "a",
+				ConfigurationName: goodConfig.Name,
+				RevisionName:      goodNewRev.Name,
+				Percent:           100,
+			},
+			Active: true,
+		}},
+		"b": {{
+			TrafficTarget: v1alpha1.TrafficTarget{
+				Name:              "b",
+				ConfigurationName: goodConfig.Name,
+				RevisionName:      goodNewRev.Name,
+				Percent:           100,
+			},
+			Active: true,
+		}},
+	}
+	if diff := cmp.Diff(expected, tc.Targets); diff != "" {
+		t.Errorf("Unexpected targets diff (-want +got): %v", diff)
+	}
+	// The status still reflects every target as specified.
+	if got, want := len(tc.GetRevisionTrafficTargets()), len(tts); got != want {
+		t.Errorf("len(GetRevisionTrafficTargets()) = %d, want %d", got, want)
+	}
+}
+
 // Splitting traffic between a two fixed revisions.
 func TestBuildTrafficConfiguration_TwoFixedRevisions(t *testing.T) {
 	tts := []v1alpha1.TrafficTarget{{
@@ -474,6 +643,42 @@ func TestBuildTrafficConfiguration_TwoFixedRevisions(t *testing.T) {
 	}
 }
 
+// Subsets of the same revision are kept as separate destinations.
+func TestBuildTrafficConfiguration_Subsets(t *testing.T) {
+	tts := []v1alpha1.TrafficTarget{{
+		RevisionName: goodNewRev.Name,
+		Percent:      90,
+	}, {
+		RevisionName: goodNewRev.Name,
+		Percent:      10,
+		Subset:       "canary",
+	}}
+	tc, err := BuildTrafficConfiguration(configLister, revLister, getTestRouteWithTrafficTargets(tts))
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	want := []v1alpha1.TrafficTarget{{
+		ConfigurationName: goodConfig.Name,
+		RevisionName:      goodNewRev.Name,
+		Percent:           90,
+	}, {
+		ConfigurationName: goodConfig.Name,
+		RevisionName:      goodNewRev.Name,
+		Percent:           10,
+		Subset:            "canary",
+	}}
+	var got []v1alpha1.TrafficTarget
+	for _, rt := range tc.Targets[""] {
+		got = append(got, rt.TrafficTarget)
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Unexpected targets (-want +got): %v", diff)
+	}
+	if diff := cmp.Diff(want, tc.GetRevisionTrafficTargets()); diff != "" {
+		t.Errorf("Unexpected revision traffic targets (-want +got): %v", diff)
+	}
+}
+
 // Splitting traffic between a two fixed revisions of two configurations.
 func TestBuildTrafficConfiguration_TwoFixedRevisionsFromTwoConfigurations(t *testing.T) {
 	tts := []v1alpha1.TrafficTarget{{
@@ -625,7 +830,11 @@ func TestBuildTrafficConfiguration_MissingConfig(t *testing.T) {
 		Configurations: map[string]*v1alpha1.Configuration{goodConfig.Name: goodConfig},
 		Revisions:      map[string]*v1alpha1.Revision{goodOldRev.Name: goodOldRev, goodNewRev.Name: goodNewRev},
 	}
-	expectedErr := errMissingConfiguration(missingConfig.Name)
+	expectedErr := &multiTargetError{
+		primary: errMissingConfiguration(missingConfig.Name),
+		errs:    []TargetError{errMissingConfiguration(missingConfig.Name)},
+		total:   3,
+	}
 	r := getTestRouteWithTrafficTargets(tts)
 	if tc, err := BuildTrafficConfiguration(configLister, revLister, r); expectedErr.Error() != err.Error() {
 		t.Errorf("Expected %v, saw %v", expectedErr, err)
@@ -708,7 +917,11 @@ func TestBuildTrafficConfiguration_EmptyAndFailedConfigurations(t *testing.T) {
 		},
 		Revisions: map[string]*v1alpha1.Revision{},
 	}
-	expectedErr := errUnreadyConfiguration(failedConfig)
+	expectedErr := &multiTargetError{
+		primary: errUnreadyConfiguration(failedConfig),
+		errs:    []TargetError{errUnreadyConfiguration(emptyConfig), errUnreadyConfiguration(failedConfig)},
+		total:   2,
+	}
 	r := getTestRouteWithTrafficTargets(tts)
 	if tc, err := BuildTrafficConfiguration(configLister, revLister, r); expectedErr.Error() != err.Error() {
 		t.Errorf("Expected error %v, saw %v", expectedErr, err)
@@ -733,7 +946,11 @@ func TestBuildTrafficConfiguration_FailedAndEmptyConfigurations(t *testing.T) {
 		},
 		Revisions: map[string]*v1alpha1.Revision{},
 	}
-	expectedErr := errUnreadyConfiguration(failedConfig)
+	expectedErr := &multiTargetError{
+		primary: errUnreadyConfiguration(failedConfig),
+		errs:    []TargetError{errUnreadyConfiguration(failedConfig), errUnreadyConfiguration(emptyConfig)},
+		total:   2,
+	}
 	r := getTestRouteWithTrafficTargets(tts)
 	if tc, err := BuildTrafficConfiguration(configLister, revLister, r); expectedErr.Error() != err.Error() {
 		t.Errorf("Expected error %v, saw %v", expectedErr, err)
@@ -755,7 +972,11 @@ func TestBuildTrafficConfiguration_MissingRevision(t *testing.T) {
 		Configurations: map[string]*v1alpha1.Configuration{goodConfig.Name: goodConfig},
 		Revisions:      map[string]*v1alpha1.Revision{goodNewRev.Name: goodNewRev},
 	}
-	expectedErr := errMissingRevision(missingRev.Name)
+	expectedErr := &multiTargetError{
+		primary: errMissingRevision(missingRev.Name),
+		errs:    []TargetError{errMissingRevision(missingRev.Name)},
+		total:   2,
+	}
 	r := getTestRouteWithTrafficTargets(tts)
 	if tc, err := BuildTrafficConfiguration(configLister, revLister, r); expectedErr.Error() != err.Error() {
 		t.Errorf("Expected %s, saw %s", expectedErr.Error(), err.Error())
@@ -764,6 +985,388 @@ func TestBuildTrafficConfiguration_MissingRevision(t *testing.T) {
 	}
 }
 
+func TestBuildTrafficConfiguration_InvalidTargets(t *testing.T) {
+	tests := []struct {
+		name    string
+		targets []v1alpha1.TrafficTarget
+		want    *apis.FieldError
+	}{{
+		name: "both configuration and revision",
+		targets: []v1alpha1.TrafficTarget{{
+			ConfigurationName: goodConfig.Name,
+			RevisionName:      goodNewRev.Name,
+			Percent:           100,
+		}},
+		want: apis.ErrMultipleOneOf("spec.traffic[0].configurationName", "spec.traffic[0].revisionName"),
+	}, {
+		name: "neither configuration nor revision",
+		targets: []v1alpha1.TrafficTarget{{
+			RevisionName: goodNewRev.Name,
+			Percent:      50,
+		}, {
+			Name:    "empty",
+			Percent: 50,
+		}},
+		want: apis.ErrMissingOneOf("spec.traffic[1].configurationName",
+			"spec.traffic[1].configurationSelector", "spec.traffic[1].revisionName"),
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := getTestRouteWithTrafficTargets(test.targets)
+			_, err := BuildTrafficConfiguration(configLister, revLister, r)
+			if _, ok := err.(TargetError); !ok {
+				t.Fatalf("BuildTrafficConfiguration() = %v, wanted a TargetError", err)
+			}
+			if !strings.Contains(err.Error(), test.want.Error()) {
+				t.Errorf("BuildTrafficConfiguration() = %v, wanted it to contain %v", err, test.want)
+			}
+			rs := &v1alpha1.RouteStatus{}
+			err.(TargetError).MarkBadTrafficTarget(rs)
+			if cond := rs.GetCondition(v1alpha1.RouteConditionReady); cond == nil || !cond.IsFalse() || cond.Reason != "InvalidTrafficTarget" {
+				t.Errorf("Ready = %v, wanted False with reason InvalidTrafficTarget", cond)
+			}
+		})
+	}
+}
+
+func TestBuildTrafficConfiguration_MissingConfigurationGeneration(t *testing.T) {
+	generation := int64(42)
+	tts := []v1alpha1.TrafficTarget{{
+		ConfigurationName:       goodConfig.Name,
+		ConfigurationGeneration: &generation,
+		Percent:                 100,
+	}}
+	expectedErr := errMissingConfigurationGeneration(goodConfig.Name, generation)
+	r := getTestRouteWithTrafficTargets(tts)
+	if _, err := BuildTrafficConfiguration(configLister, revLister, r); err == nil || expectedErr.Error() != err.Error() {
+		t.Errorf("Expected %v, saw %v", expectedErr, err)
+	}
+}
+
+func TestBuildTrafficConfiguration_TargetStatuses(t *testing.T) {
+	tts := []v1alpha1.TrafficTarget{{
+		Name:              "current",
+		ConfigurationName: goodConfig.Name,
+		Percent:           50,
+	}, {
+		Name:         "candidate",
+		RevisionName: failedRev.Name,
+		Percent:      50,
+	}, {
+		RevisionName: missingRev.Name,
+	}}
+	expected := []v1alpha1.TargetStatus{{
+		Name:         "current",
+		RevisionName: goodNewRev.Name,
+		Ready:        true,
+	}, {
+		Name:         "candidate",
+		RevisionName: failedRev.Name,
+		Reason:       "RevisionMissing",
+		Message:      fmt.Sprintf("Revision %q failed to become ready.", failedRev.Name),
+	}, {
+		RevisionName: missingRev.Name,
+		Reason:       "RevisionMissing",
+		Message:      fmt.Sprintf("Revision %q referenced in traffic not found.", missingRev.Name),
+	}}
+	r := getTestRouteWithTrafficTargets(tts)
+	tc, err := BuildTrafficConfiguration(configLister, revLister, r)
+	if err == nil {
+		t.Fatal("BuildTrafficConfiguration() = nil, wanted an error")
+	}
+	if diff := cmp.Diff(expected, tc.TargetStatuses); diff != "" {
+		t.Errorf("Unexpected target statuses (-want +got): %v", diff)
+	}
+}
+
+// A target kept at 0%, e.g. for a quick rollback, still shows up in status.
+func TestBuildTrafficConfiguration_ZeroPercentTarget(t *testing.T) {
+	tts := []v1alpha1.TrafficTarget{{
+		ConfigurationName: goodConfig.Name,
+		Percent:           90,
+	}, {
+		ConfigurationName: niceConfig.Name,
+		Percent:           10,
+	}, {
+		RevisionName: goodOldRev.Name,
+		Percent:      0,
+	}}
+	tc, err := BuildTrafficConfiguration(configLister, revLister, getTestRouteWithTrafficTargets(tts))
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	want := []v1alpha1.TrafficTarget{{
+		ConfigurationName: goodConfig.Name,
+		RevisionName:      goodNewRev.Name,
+		Percent:           90,
+	}, {
+		ConfigurationName: niceConfig.Name,
+		RevisionName:      niceNewRev.Name,
+		Percent:           10,
+	}, {
+		ConfigurationName: goodConfig.Name,
+		RevisionName:      goodOldRev.Name,
+		Percent:           0,
+	}}
+	if diff := cmp.Diff(want, tc.GetRevisionTrafficTargets()); diff != "" {
+		t.Errorf("Unexpected status traffic (-want +got): %v", diff)
+	}
+}
+
+func TestGetActiveTargets(t *testing.T) {
+	tts := []v1alpha1.TrafficTarget{{
+		ConfigurationName: inactiveConfig.Name,
+		Percent:           30,
+	}, {
+		RevisionName: goodNewRev.Name,
+		Percent:      20,
+	}, {
+		Name:              "beta",
+		ConfigurationName: goodConfig.Name,
+		Percent:           50,
+	}}
+	tc, err := BuildTrafficConfiguration(configLister, revLister, getTestRouteWithTrafficTargets(tts))
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	// Both targets of goodNewRev are coalesced.
+	want := []v1alpha1.ActiveTarget{{
+		RevisionName: inactiveRev.Name,
+		Percent:      30,
+		Active:       false,
+	}, {
+		RevisionName: goodNewRev.Name,
+		Percent:      70,
+		Active:       true,
+	}}
+	if diff := cmp.Diff(want, tc.GetActiveTargets()); diff != "" {
+		t.Errorf("Unexpected active targets (-want +got): %v", diff)
+	}
+}
+
+func TestRequiresActivation(t *testing.T) {
+	tests := []struct {
+		name string
+		tts  []v1alpha1.TrafficTarget
+		want bool
+	}{{
+		name: "all active",
+		tts: []v1alpha1.TrafficTarget{{
+			ConfigurationName: goodConfig.Name,
+			Percent:           100,
+		}},
+		want: false,
+	}, {
+		name: "inactive in the split",
+		tts: []v1alpha1.TrafficTarget{{
+			ConfigurationName: goodConfig.Name,
+			Percent:           90,
+		}, {
+			ConfigurationName: inactiveConfig.Name,
+			Percent:           10,
+		}},
+		want: true,
+	}, {
+		name: "inactive mirror",
+		tts: []v1alpha1.TrafficTarget{{
+			ConfigurationName: goodConfig.Name,
+			Percent:           100,
+		}, {
+			ConfigurationName: inactiveConfig.Name,
+			Mirror:            true,
+		}},
+		want: false,
+	}, {
+		name: "inactive named target",
+		tts: []v1alpha1.TrafficTarget{{
+			ConfigurationName: goodConfig.Name,
+			Percent:           100,
+		}, {
+			Name:              "beta",
+			ConfigurationName: inactiveConfig.Name,
+		}},
+		// Requests to its own host reach it through the activator.
+		want: true,
+	}}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tc, err := BuildTrafficConfiguration(configLister, revLister, getTestRouteWithTrafficTargets(test.tts))
+			if err != nil {
+				t.Fatalf("Unexpected error %v", err)
+			}
+			if got := tc.RequiresActivation(); got != test.want {
+				t.Errorf("RequiresActivation() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name     string
+		percents []int
+		want     []int
+	}{{
+		name:     "already sums to 100",
+		percents: []int{33, 33, 34},
+		want:     []int{33, 33, 34},
+	}, {
+		name:     "seven equal targets",
+		percents: []int{100 / 7, 100 / 7, 100 / 7, 100 / 7, 100 / 7, 100 / 7, 100 / 7},
+		want:     []int{15, 15, 14, 14, 14, 14, 14},
+	}, {
+		name:     "remainder goes to the largest targets",
+		percents: []int{10, 30, 10, 30},
+		want:     []int{12, 38, 12, 38},
+	}, {
+		name:     "single target",
+		percents: []int{0},
+		want:     []int{100},
+	}, {
+		name:     "targets without traffic stay without it",
+		percents: []int{0, 50, 0},
+		want:     []int{0, 100, 0},
+	}, {
+		name:     "no traffic",
+		percents: []int{0, 0, 0},
+		want:     []int{0, 0, 0},
+	}}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			targets := make([]RevisionTarget, len(test.percents))
+			for i, p := range test.percents {
+				targets[i].TrafficTarget.RevisionName = fmt.Sprintf("rev-%d", i)
+				targets[i].TrafficTarget.Percent = p
+			}
+			got := []int{}
+			sum := 0
+			for _, tt := range normalize(targets) {
+				got = append(got, tt.TrafficTarget.Percent)
+				sum += tt.TrafficTarget.Percent
+			}
+			if sum != 0 && sum != 100 {
+				t.Errorf("normalize() = %v, which sums to %d", got, sum)
+			}
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("Unexpected percents (-want +got): %v", diff)
+			}
+		})
+	}
+}
+
+func TestProtocol(t *testing.T) {
+	h1 := &v1alpha1.Revision{ObjectMeta: metav1.ObjectMeta{Name: "h1"}}
+	h2c := &v1alpha1.Revision{
+		ObjectMeta: metav1.ObjectMeta{Name: "h2c"},
+		Spec: v1alpha1.RevisionSpec{
+			Container: corev1.Container{
+				Ports: []corev1.ContainerPort{{Name: "h2c"}},
+			},
+		},
+	}
+	revs := map[string]*v1alpha1.Revision{h1.Name: h1, h2c.Name: h2c}
+	target := func(name string) RevisionTarget {
+		return RevisionTarget{TrafficTarget: v1alpha1.TrafficTarget{RevisionName: name}}
+	}
+
+	tests := []struct {
+		name    string
+		targets []RevisionTarget
+		want    v1alpha1.RevisionProtocolType
+	}{{
+		name: "no targets",
+		want: v1alpha1.RevisionProtocolHTTP1,
+	}, {
+		name:    "http1 only",
+		targets: []RevisionTarget{target(h1.Name)},
+		want:    v1alpha1.RevisionProtocolHTTP1,
+	}, {
+		name:    "h2c only",
+		targets: []RevisionTarget{target(h2c.Name), target(h2c.Name)},
+		want:    v1alpha1.RevisionProtocolH2C,
+	}, {
+		name:    "mixed",
+		targets: []RevisionTarget{target(h2c.Name), target(h1.Name)},
+		want:    v1alpha1.RevisionProtocolHTTP1,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tc := &Config{
+				Targets:   map[string][]RevisionTarget{"": test.targets},
+				Revisions: revs,
+			}
+			if got := tc.Protocol(); got != test.want {
+				t.Errorf("Protocol() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestReadinessPath(t *testing.T) {
+	probed := func(name, path string) *v1alpha1.Revision {
+		return &v1alpha1.Revision{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec: v1alpha1.RevisionSpec{
+				Container: corev1.Container{
+					ReadinessProbe: &corev1.Probe{
+						Handler: corev1.Handler{
+							HTTPGet: &corev1.HTTPGetAction{Path: path},
+						},
+					},
+				},
+			},
+		}
+	}
+	revs := map[string]*v1alpha1.Revision{
+		"unprobed": {ObjectMeta: metav1.ObjectMeta{Name: "unprobed"}},
+		"healthz":  probed("healthz", "/healthz"),
+		"healthz2": probed("healthz2", "/healthz"),
+		"ready":    probed("ready", "/ready"),
+	}
+	target := func(name string, percent int) RevisionTarget {
+		return RevisionTarget{TrafficTarget: v1alpha1.TrafficTarget{RevisionName: name, Percent: percent}}
+	}
+
+	tests := []struct {
+		name    string
+		targets []RevisionTarget
+		want    string
+	}{{
+		name: "no targets",
+		want: "",
+	}, {
+		name:    "not probed",
+		targets: []RevisionTarget{target("unprobed", 100)},
+		want:    "",
+	}, {
+		name:    "same path",
+		targets: []RevisionTarget{target("healthz", 50), target("healthz2", 50)},
+		want:    "/healthz",
+	}, {
+		name:    "different paths",
+		targets: []RevisionTarget{target("healthz", 50), target("ready", 50)},
+		want:    "",
+	}, {
+		name:    "different path without traffic",
+		targets: []RevisionTarget{target("healthz", 100), target("ready", 0)},
+		want:    "/healthz",
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tc := &Config{
+				Targets:   map[string][]RevisionTarget{"": test.targets},
+				Revisions: revs,
+			}
+			if got := tc.ReadinessPath(); got != test.want {
+				t.Errorf("ReadinessPath() = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
 func TestRoundTripping(t *testing.T) {
 	tts := []v1alpha1.TrafficTarget{{
 		RevisionName: goodOldRev.Name,
@@ -776,14 +1379,17 @@ func TestRoundTripping(t *testing.T) {
 		ConfigurationName: niceConfig.Name,
 	}}
 	expected := []v1alpha1.TrafficTarget{{
-		RevisionName: goodOldRev.Name,
-		Percent:      100,
+		ConfigurationName: goodConfig.Name,
+		RevisionName:      goodOldRev.Name,
+		Percent:           100,
 	}, {
-		Name:         "beta",
-		RevisionName: goodNewRev.Name,
+		Name:              "beta",
+		ConfigurationName: goodConfig.Name,
+		RevisionName:      goodNewRev.Name,
 	}, {
-		Name:         "alpha",
-		RevisionName: niceNewRev.Name,
+		Name:              "alpha",
+		ConfigurationName: niceConfig.Name,
+		RevisionName:      niceNewRev.Name,
 	}}
 	if tc, err := BuildTrafficConfiguration(configLister, revLister, getTestRouteWithTrafficTargets(tts)); err != nil {
 		t.Errorf("Unexpected error %v", err)
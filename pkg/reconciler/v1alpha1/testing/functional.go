@@ -26,6 +26,7 @@ import (
 	"github.com/knative/serving/pkg/apis/autoscaling"
 	autoscalingv1alpha1 "github.com/knative/serving/pkg/apis/autoscaling/v1alpha1"
 	netv1alpha1 "github.com/knative/serving/pkg/apis/networking/v1alpha1"
+	"github.com/knative/serving/pkg/apis/serving"
 	"github.com/knative/serving/pkg/apis/serving/v1alpha1"
 	confignames "github.com/knative/serving/pkg/reconciler/v1alpha1/configuration/resources/names"
 	routenames "github.com/knative/serving/pkg/reconciler/v1alpha1/route/resources/names"
@@ -300,6 +301,17 @@ func WithRevTarget(revision string) RouteOption {
 	})
 }
 
+// WithConfigSelectorTarget sets the Route's traffic block to point at the
+// Configuration labelled with the given key and value.
+func WithConfigSelectorTarget(key, value string) RouteOption {
+	return WithSpecTraffic(v1alpha1.TrafficTarget{
+		ConfigurationSelector: &metav1.LabelSelector{
+			MatchLabels: map[string]string{key: value},
+		},
+		Percent: 100,
+	})
+}
+
 // WithStatusTraffic sets the Route's status traffic block to the specified traffic targets.
 func WithStatusTraffic(traffic ...v1alpha1.TrafficTarget) RouteOption {
 	return func(r *v1alpha1.Route) {
@@ -307,6 +319,54 @@ func WithStatusTraffic(traffic ...v1alpha1.TrafficTarget) RouteOption {
 	}
 }
 
+// WithStatusActiveTargets sets the resolved traffic of the Route.
+func WithStatusActiveTargets(targets ...v1alpha1.ActiveTarget) RouteOption {
+	return func(r *v1alpha1.Route) {
+		r.Status.ActiveTargets = targets
+	}
+}
+
+// WithStatusDraining sets the Revisions the Route is draining.
+func WithStatusDraining(draining ...v1alpha1.DrainingRevision) RouteOption {
+	return func(r *v1alpha1.Route) {
+		r.Status.Draining = draining
+	}
+}
+
+// WithRequiresActivation flags the Route's traffic as going through the activator.
+func WithRequiresActivation(r *v1alpha1.Route) {
+	r.Status.RequiresActivation = true
+}
+
+// WithTargetStatuses sets the outcome of each traffic target of the Route.
+func WithTargetStatuses(statuses ...v1alpha1.TargetStatus) RouteOption {
+	return func(r *v1alpha1.Route) {
+		r.Status.TargetStatuses = statuses
+	}
+}
+
+// WithRollout sets the Route to roll out new Revisions gradually.
+func WithRollout(duration time.Duration, step int) RouteOption {
+	return func(r *v1alpha1.Route) {
+		r.Spec.RolloutDuration = &metav1.Duration{Duration: duration}
+		r.Spec.RolloutStepPercent = step
+	}
+}
+
+// WithStatusRollouts sets the Route's status rollouts to the provided ones.
+func WithStatusRollouts(rollouts ...v1alpha1.RolloutStatus) RouteOption {
+	return func(r *v1alpha1.Route) {
+		r.Status.Rollouts = rollouts
+	}
+}
+
+// WithTrafficHistory sets the past traffic assignments of the Route.
+func WithTrafficHistory(records ...v1alpha1.TrafficRecord) RouteOption {
+	return func(r *v1alpha1.Route) {
+		r.Status.TrafficHistory = records
+	}
+}
+
 // WithRouteOwnersRemoved clears the owner references of this Route.
 func WithRouteOwnersRemoved(r *v1alpha1.Route) {
 	r.OwnerReferences = nil
@@ -327,13 +387,43 @@ func WithDomainInternal(r *v1alpha1.Route) {
 	r.Status.DomainInternal = fmt.Sprintf("%s.%s.svc.cluster.local", r.Name, r.Namespace)
 }
 
-// WithAddress sets the .Status.Address field to the prototypical internal hostname.
+// WithAddress sets the .Status.Address field to the prototypical internal hostname,
+// of the placeholder Service named after the Route.
 func WithAddress(r *v1alpha1.Route) {
+	r.Status.PlaceholderServiceName = r.Name
 	r.Status.Address = &duckv1alpha1.Addressable{
 		Hostname: fmt.Sprintf("%s.%s.svc.cluster.local", r.Name, r.Namespace),
 	}
 }
 
+// WithVirtualServiceName sets the .Status.VirtualServiceName field.
+func WithVirtualServiceName(name string) RouteOption {
+	return func(r *v1alpha1.Route) {
+		r.Status.VirtualServiceName = name
+	}
+}
+
+// WithIngressAddress sets the .Status.IngressAddress field to the given host.
+func WithIngressAddress(host string) RouteOption {
+	return func(r *v1alpha1.Route) {
+		r.Status.IngressAddress = &v1alpha1.IngressAddress{
+			Host: host,
+			URL:  "http://" + host,
+		}
+	}
+}
+
+// WithSecureIngressAddress sets the .Status.IngressAddress field to the given
+// host, reached over https.
+func WithSecureIngressAddress(host string) RouteOption {
+	return func(r *v1alpha1.Route) {
+		r.Status.IngressAddress = &v1alpha1.IngressAddress{
+			Host: host,
+			URL:  "https://" + host,
+		}
+	}
+}
+
 // WithAnotherDomain sets the .Status.Domain field to an atypical domain.
 func WithAnotherDomain(r *v1alpha1.Route) {
 	r.Status.Domain = fmt.Sprintf("%s.%s.another-example.com", r.Name, r.Namespace)
@@ -371,6 +461,27 @@ func MarkMissingTrafficTarget(kind, revision string) RouteOption {
 	}
 }
 
+// MarkInvalidTrafficTarget calls the method of the same name on .Status
+func MarkInvalidTrafficTarget(message string) RouteOption {
+	return func(r *v1alpha1.Route) {
+		r.Status.MarkInvalidTrafficTarget(message)
+	}
+}
+
+// MarkTrafficTargetsNotReady calls the method of the same name on .Status
+func MarkTrafficTargetsNotReady(summary string) RouteOption {
+	return func(r *v1alpha1.Route) {
+		r.Status.MarkTrafficTargetsNotReady(summary)
+	}
+}
+
+// MarkUnresolvedConfigurationSelector calls the method of the same name on .Status
+func MarkUnresolvedConfigurationSelector(selector string, matches ...string) RouteOption {
+	return func(r *v1alpha1.Route) {
+		r.Status.MarkUnresolvedConfigurationSelector(selector, matches)
+	}
+}
+
 // MarkConfigurationNotReady calls the method of the same name on .Status
 func MarkConfigurationNotReady(name string) RouteOption {
 	return func(r *v1alpha1.Route) {
@@ -385,6 +496,74 @@ func MarkConfigurationFailed(name string) RouteOption {
 	}
 }
 
+// MarkRevisionFailed calls the method of the same name on .Status
+func MarkRevisionFailed(name string) RouteOption {
+	return func(r *v1alpha1.Route) {
+		r.Status.MarkRevisionFailed(name)
+	}
+}
+
+// MarkCrossNamespaceTrafficDisabled calls the method of the same name on .Status
+func MarkCrossNamespaceTrafficDisabled(namespace string) RouteOption {
+	return func(r *v1alpha1.Route) {
+		r.Status.MarkCrossNamespaceTrafficDisabled(namespace)
+	}
+}
+
+// MarkRevisionReadyTimeout calls the method of the same name on .Status
+func MarkRevisionReadyTimeout(name, message string) RouteOption {
+	return func(r *v1alpha1.Route) {
+		r.Status.MarkRevisionReadyTimeout(name, message)
+	}
+}
+
+// MarkIngressNotReady calls the method of the same name on .Status
+func MarkIngressNotReady(message string) RouteOption {
+	return func(r *v1alpha1.Route) {
+		r.Status.MarkIngressNotReady(message)
+	}
+}
+
+// MarkServiceNotReady calls the method of the same name on .Status
+func MarkServiceNotReady(message string) RouteOption {
+	return func(r *v1alpha1.Route) {
+		r.Status.MarkServiceNotReady(routenames.K8sService(r), message)
+	}
+}
+
+// MarkInvalidDomain calls the method of the same name on .Status
+func MarkInvalidDomain(domain, message string) RouteOption {
+	return func(r *v1alpha1.Route) {
+		r.Status.MarkInvalidDomain(domain, message)
+	}
+}
+
+// WithRouteCreationTimestamp sets the Route's timestamp to the provided time.
+func WithRouteCreationTimestamp(t time.Time) RouteOption {
+	return func(r *v1alpha1.Route) {
+		r.ObjectMeta.CreationTimestamp = metav1.NewTime(t)
+	}
+}
+
+// MarkDomainClaimed calls the method of the same name on .Status
+func MarkDomainClaimed(host string) RouteOption {
+	return func(r *v1alpha1.Route) {
+		r.Status.MarkDomainClaimed(host)
+	}
+}
+
+// WithCustomDomain adds a custom domain, with the given TLS Secret if it
+// isn't empty, to the Route's spec.
+func WithCustomDomain(host, secret string) RouteOption {
+	return func(r *v1alpha1.Route) {
+		d := v1alpha1.CustomDomain{Host: host}
+		if secret != "" {
+			d.TLSSecret = &corev1.LocalObjectReference{Name: secret}
+		}
+		r.Spec.Domains = append(r.Spec.Domains, d)
+	}
+}
+
 // WithRouteLabel sets the specified label on the Route.
 func WithRouteLabel(key, value string) RouteOption {
 	return func(r *v1alpha1.Route) {
@@ -395,6 +574,16 @@ func WithRouteLabel(key, value string) RouteOption {
 	}
 }
 
+// WithRouteAnnotation sets the specified annotation on the Route.
+func WithRouteAnnotation(key, value string) RouteOption {
+	return func(r *v1alpha1.Route) {
+		if r.Annotations == nil {
+			r.Annotations = make(map[string]string)
+		}
+		r.Annotations[key] = value
+	}
+}
+
 // ConfigOption enables further configuration of a Configuration.
 type ConfigOption func(*v1alpha1.Configuration)
 
@@ -502,6 +691,16 @@ func WithBuildRef(name string) RevisionOption {
 	}
 }
 
+// MarkBuilding has the Revision wait for its Build to complete.
+func MarkBuilding(rev *v1alpha1.Revision) {
+	rev.Status.PropagateBuildStatus(duckv1alpha1.KResourceStatus{
+		Conditions: []duckv1alpha1.Condition{{
+			Type:   duckv1alpha1.ConditionSucceeded,
+			Status: corev1.ConditionUnknown,
+		}},
+	})
+}
+
 // MarkResourceNotOwned calls the function of the same name on the Revision's status.
 func MarkResourceNotOwned(kind, name string) RevisionOption {
 	return func(rev *v1alpha1.Revision) {
@@ -596,6 +795,36 @@ func WithLastPinned(t time.Time) RevisionOption {
 	}
 }
 
+// WithRevisionLabel attaches a particular label to the Revision.
+// WithReadinessProbePath probes the readiness of the Revision with an HTTP GET
+// of the given path.
+func WithReadinessProbePath(path string) RevisionOption {
+	return func(rev *v1alpha1.Revision) {
+		rev.Spec.Container.ReadinessProbe = &corev1.Probe{
+			Handler: corev1.Handler{
+				HTTPGet: &corev1.HTTPGetAction{Path: path},
+			},
+		}
+	}
+}
+
+func WithRevisionLabel(key, value string) RevisionOption {
+	return func(rev *v1alpha1.Revision) {
+		if rev.Labels == nil {
+			rev.Labels = make(map[string]string)
+		}
+		rev.Labels[key] = value
+	}
+}
+
+// WithRevContainerPortName sets the name of the port the Revision's
+// container listens on, which selects the protocol it serves.
+func WithRevContainerPortName(name string) RevisionOption {
+	return func(rev *v1alpha1.Revision) {
+		rev.Spec.Container.Ports = []corev1.ContainerPort{{Name: name}}
+	}
+}
+
 // WithRevStatus is a generic escape hatch for creating hard-to-craft
 // status orientations.
 func WithRevStatus(st v1alpha1.RevisionStatus) RevisionOption {
@@ -604,6 +833,13 @@ func WithRevStatus(st v1alpha1.RevisionStatus) RevisionOption {
 	}
 }
 
+// WithImageDigest sets the resolved image digest of the Revision.
+func WithImageDigest(digest string) RevisionOption {
+	return func(rev *v1alpha1.Revision) {
+		rev.Status.ImageDigest = digest
+	}
+}
+
 // MarkActive calls .Status.MarkActive on the Revision.
 func MarkActive(r *v1alpha1.Revision) {
 	r.Status.MarkActive()
@@ -757,6 +993,23 @@ func WithExternalName(name string) K8sServiceOption {
 	}
 }
 
+// WithServicePortName renames the Service's first port.
+func WithServicePortName(name string) K8sServiceOption {
+	return func(svc *corev1.Service) {
+		svc.Spec.Ports[0].Name = name
+	}
+}
+
+// WithReadinessPathAnnotation advertises the given readiness path on the Service.
+func WithReadinessPathAnnotation(path string) K8sServiceOption {
+	return func(svc *corev1.Service) {
+		if svc.Annotations == nil {
+			svc.Annotations = make(map[string]string)
+		}
+		svc.Annotations[serving.ReadinessPathAnnotationKey] = path
+	}
+}
+
 // WithK8sSvcOwnersRemoved clears the owner references of this Route.
 func WithK8sSvcOwnersRemoved(svc *corev1.Service) {
 	svc.OwnerReferences = nil